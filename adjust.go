@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// 手動調整のイベント名が指定されていない場合に使用する名前
+const defaultAdjustmentName = "手動調整"
+
+// Adjustment はカレンダーにない作業時間を加えたり、差し引いたりする手動調整を表す
+// 調整ファイル（YAML）には、次のどちらの形式でも記述できる
+//
+//   - "+2h 2024-06-03 カレンダーにないクライアントとの通話"
+//   - {date: 2024-06-03, duration: -30m, name: 開発, note: 実際には早く終わった}
+type Adjustment struct {
+	Date     string `yaml:"date"`
+	Duration string `yaml:"duration"`
+	Name     string `yaml:"name"`
+	Note     string `yaml:"note"`
+
+	// 調整ファイル内の行番号（エラーの表示とイベントのIDに使用する）
+	line     int
+	date     time.Time
+	duration time.Duration
+}
+
+// parseCompact は「+2h 2024-06-03 メモ」形式（時間と日付は順不同）の1行を解析する
+func (a *Adjustment) parseCompact(s string) error {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return errors.New(printer.Sprintf("「+2h 2024-06-03 メモ」の形式で時間と日付を指定してください"))
+	}
+	a.Duration, a.Date = fields[0], fields[1]
+	if _, err := time.Parse("2006-01-02", fields[0]); err == nil {
+		a.Date, a.Duration = fields[0], fields[1]
+	}
+	a.Note = strings.Join(fields[2:], " ")
+	return nil
+}
+
+// parse は日付と時間の指定を解析する
+func (a *Adjustment) parse(location *time.Location) error {
+	var err error
+	if a.date, err = time.ParseInLocation("2006-01-02", a.Date, location); err != nil {
+		return errors.New(printer.Sprintf("日付 '%s' が不正です（YYYY-MM-DD形式で指定してください）", a.Date))
+	}
+	if a.duration, err = time.ParseDuration(a.Duration); err != nil || a.duration == 0 {
+		return errors.New(printer.Sprintf("時間 '%s' が不正です（+2h、-30m などの形式で指定してください）", a.Duration))
+	}
+	return nil
+}
+
+// loadAdjustments は調整ファイルを読み込む
+func loadAdjustments(path string, location *time.Location) ([]Adjustment, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	// 空のファイルは調整なしとして扱う
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	list := doc.Content[0]
+	// 「adjustments:」の下にリストを書いた場合にも対応する
+	if list.Kind == yaml.MappingNode {
+		var found *yaml.Node
+		for i := 0; i+1 < len(list.Content); i += 2 {
+			if list.Content[i].Value == "adjustments" {
+				found = list.Content[i+1]
+			}
+		}
+		if found == nil {
+			return nil, errors.New(printer.Sprintf("%s: 調整のリスト（adjustments）がありません", path))
+		}
+		list = found
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, errors.New(printer.Sprintf("%s:%d: 調整はリストで指定してください", path, list.Line))
+	}
+
+	var adjustments []Adjustment
+	for _, item := range list.Content {
+		a := Adjustment{line: item.Line}
+		switch item.Kind {
+		case yaml.ScalarNode:
+			err = a.parseCompact(item.Value)
+		case yaml.MappingNode:
+			err = item.Decode(&a)
+		default:
+			err = errors.New(printer.Sprintf("文字列またはマッピングで指定してください"))
+		}
+		if err == nil {
+			err = a.parse(location)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, item.Line, err)
+		}
+		adjustments = append(adjustments, a)
+	}
+	return adjustments, nil
+}
+
+// event は手動調整を集計に加えるための、カレンダーのイベントの形式に変換する
+// 開始日時はその日の0時、終了日時は調整する時間の長さだけ後にする
+func (a *Adjustment) event() *calendar.Event {
+	name := a.Name
+	if name == "" {
+		name = a.Note
+	}
+	if name == "" {
+		name = printer.Sprintf(defaultAdjustmentName)
+	}
+	return &calendar.Event{
+		Id:          fmt.Sprintf("manual-%d", a.line),
+		Summary:     name,
+		Description: a.Note,
+		Status:      "confirmed",
+		Start:       &calendar.EventDateTime{DateTime: a.date.Format(time.RFC3339)},
+		End:         &calendar.EventDateTime{DateTime: a.date.Add(a.duration.Abs()).Format(time.RFC3339)},
+	}
+}
+
+// applyAdjustments は期間内の手動調整を、手動調整であることを示すイベントとして集計結果に加える
+// イベント名（name）を指定した調整は、集計の条件に一致する場合だけ加える
+func applyAdjustments(r *Report, adjustments []Adjustment, o *queryOptions) {
+	added := false
+	for _, a := range adjustments {
+		if a.date.Before(r.StartDate) || a.date.After(r.EndDate) {
+			continue
+		}
+		item := a.event()
+		if a.Name != "" && !o.matches(item) {
+			continue
+		}
+		r.Events = append(r.Events, MatchedEvent{
+			Event:    item,
+			Start:    a.date,
+			End:      a.date.Add(a.duration.Abs()),
+			Duration: a.duration,
+			Manual:   true,
+			Project:  projectFor(o.projects, item.Summary),
+		})
+		added = true
+	}
+	if added {
+		r.Events = sortedEvents(r.Events, "start", false)
+		r.recompute()
+	}
+}
+
+// manualDuration は手動調整で加えた（負の場合は差し引いた）時間の合計を返す
+func (r *Report) manualDuration() (time.Duration, int) {
+	var total time.Duration
+	count := 0
+	for _, e := range r.Events {
+		if e.Manual {
+			total += e.Duration
+			count++
+		}
+	}
+	return total, count
+}
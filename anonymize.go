@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// anonymizer はイベント名や参加者を仮名に置き換え、説明や場所を取り除く（-anonymize）
+// 同じ値は同じ仮名になるため、時間やグループごとの集計は変わらない
+// 仮名から元の値を推測できないように、実行ごとに生成した鍵でハッシュ化する（仮名は実行ごとに変わる）
+type anonymizer struct {
+	key []byte
+}
+
+// newAnonymizer は仮名の生成に使用する鍵を生成する
+func newAnonymizer() (*anonymizer, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &anonymizer{key: key}, nil
+}
+
+// pseudonym は値を「event-1a2b3c4d」のような種類ごとの仮名に置き換える（空文字はそのまま返す）
+func (a *anonymizer) pseudonym(kind, value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(kind + "\x00" + value))
+	return kind + "-" + hex.EncodeToString(mac.Sum(nil))[:8]
+}
+
+// person は参加者・主催者のメールアドレスを仮名に置き換える（グループのキーと同じく大文字小文字を区別しない）
+func (a *anonymizer) person(email string) string {
+	return a.pseudonym("person", strings.ToLower(email))
+}
+
+// anonymizeReport は集計結果に含まれるイベント名・参加者・説明などを仮名に置き換えるか取り除く
+// 比較期間の集計結果も同じ鍵で置き換えるため、グループの対応は保たれる
+func (o *queryOptions) anonymizeReport(r *Report) {
+	a := o.anonymizer
+	events := make(map[*calendar.Event]*calendar.Event)
+	event := func(e *calendar.Event) *calendar.Event {
+		if e == nil {
+			return nil
+		}
+		if c, ok := events[e]; ok {
+			return c
+		}
+		c := a.event(e)
+		events[e] = c
+		return c
+	}
+
+	// 集計の条件に含まれるイベント名なども、イベントと同じ仮名にする
+	label := *o
+	label.name = a.pseudonym("event", o.name)
+	label.attendee = a.person(o.attendee)
+	label.organizer = a.person(o.organizer)
+	if o.descriptionContains != "" || o.descriptionRegex != "" {
+		label.descriptionContains, label.descriptionRegex = "***", ""
+	}
+	if o.location != "" {
+		label.location = "***"
+	}
+
+	var anonymize func(r *Report)
+	anonymize = func(r *Report) {
+		r.Name = label.label()
+		for i := range r.Events {
+			r.Events[i].Event = event(r.Events[i].Event)
+		}
+		for i := range r.Excluded {
+			r.Excluded[i].Event = event(r.Excluded[i].Event)
+		}
+		for i := range r.Outliers {
+			r.Outliers[i].Event.Event = event(r.Outliers[i].Event.Event)
+		}
+		for i := range r.Overlaps {
+			r.Overlaps[i].A = event(r.Overlaps[i].A)
+			r.Overlaps[i].B = event(r.Overlaps[i].B)
+		}
+		for i := range r.Groups {
+			r.Groups[i].Key = a.groupKey(r.GroupBy, r.Groups[i].Key)
+		}
+		if r.PersonHours != nil {
+			for i := range r.PersonHours.Organizers {
+				r.PersonHours.Organizers[i].Organizer = a.groupKey("organizer", r.PersonHours.Organizers[i].Organizer)
+			}
+		}
+		if r.Comparison != nil {
+			anonymize(r.Comparison)
+		}
+	}
+	anonymize(r)
+}
+
+// groupKey はグループのキーのうち、イベント名や参加者・主催者を仮名に置き換える
+// 日付・タグ・プロジェクトのグループと、該当なしを表すキーはそのまま返す
+func (a *anonymizer) groupKey(groupBy, key string) string {
+	switch groupBy {
+	case "name", "recurrence":
+		return a.pseudonym("event", key)
+	case "attendee":
+		if key != noAttendeeLabel {
+			return a.person(key)
+		}
+	case "organizer":
+		if key != noOrganizerLabel {
+			return a.person(key)
+		}
+	}
+	return key
+}
+
+// event はイベントの写しを作成し、イベント名と参加者を仮名に置き換え、説明・場所・会議のリンクを取り除く
+// 元のイベントはキャッシュや他の集計と共有しているため、書き換えない
+func (a *anonymizer) event(e *calendar.Event) *calendar.Event {
+	c := *e
+	c.Summary = a.pseudonym("event", e.Summary)
+	c.Description = ""
+	c.Location = ""
+	c.HangoutLink = ""
+	c.HtmlLink = ""
+	c.ConferenceData = nil
+	c.Attachments = nil
+	c.Attendees = nil
+	for _, at := range e.Attendees {
+		ac := *at
+		ac.Email = a.person(at.Email)
+		ac.DisplayName = ""
+		ac.Comment = ""
+		c.Attendees = append(c.Attendees, &ac)
+	}
+	if e.Organizer != nil {
+		c.Organizer = &calendar.EventOrganizer{Email: a.person(e.Organizer.Email), Self: e.Organizer.Self}
+	}
+	if e.Creator != nil {
+		c.Creator = &calendar.EventCreator{Email: a.person(e.Creator.Email), Self: e.Creator.Self}
+	}
+	return &c
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiDuration はAPIのレスポンスで使用する時間（分数と小数の時間数の両方を返す）
+type apiDuration struct {
+	Minutes int     `json:"minutes"`
+	Hours   float64 `json:"hours"`
+}
+
+// newAPIDuration は時間をAPIのレスポンスの形式に変換する
+func newAPIDuration(d time.Duration) apiDuration {
+	return apiDuration{Minutes: int(d.Minutes()), Hours: d.Hours()}
+}
+
+// apiGroup はグループごとの合計時間
+type apiGroup struct {
+	Key      string      `json:"key"`
+	Count    int         `json:"count"`
+	Duration apiDuration `json:"duration"`
+}
+
+// apiEvent は一致したイベント
+type apiEvent struct {
+	ID       string      `json:"id"`
+	Summary  string      `json:"summary"`
+	Start    time.Time   `json:"start"`
+	End      time.Time   `json:"end"`
+	Duration apiDuration `json:"duration"`
+}
+
+// apiReport は /v1/sum のレスポンス
+type apiReport struct {
+	Name       string      `json:"name"`
+	Start      string      `json:"start"`
+	End        string      `json:"end"`
+	Total      apiDuration `json:"total"`
+	Count      int         `json:"count"`
+	ActiveDays int         `json:"active_days"`
+	Average    apiDuration `json:"average"`
+	Median     apiDuration `json:"median"`
+	GroupBy    string      `json:"group_by,omitempty"`
+	Groups     []apiGroup  `json:"groups,omitempty"`
+	Events     []apiEvent  `json:"events"`
+
+	// 1日あたりの上限を適用する前の合計時間（-daily-cap を指定した場合のみ）
+	RawTotal *apiDuration `json:"raw_total,omitempty"`
+}
+
+// newAPIReport は集計結果をAPIのレスポンスの形式に変換する
+func newAPIReport(r *Report) apiReport {
+	v := apiReport{
+		Name:       r.Name,
+		Start:      r.StartDate.Format("2006-01-02"),
+		End:        r.EndDate.Format("2006-01-02"),
+		Total:      newAPIDuration(r.Total),
+		Count:      r.count(),
+		ActiveDays: r.Stats.ActiveDays,
+		Average:    newAPIDuration(r.Stats.Average),
+		Median:     newAPIDuration(r.Stats.Median),
+		GroupBy:    r.GroupBy,
+		Events:     []apiEvent{},
+	}
+	// 1日あたりの上限を指定した場合は、上限を適用する前の合計時間も返す
+	if r.DailyCap > 0 {
+		raw := newAPIDuration(r.Total + r.dailyCapped())
+		v.RawTotal = &raw
+	}
+	for _, g := range r.Groups {
+		v.Groups = append(v.Groups, apiGroup{Key: g.Key, Count: g.Count, Duration: newAPIDuration(g.Duration)})
+	}
+	for _, e := range r.Events {
+		v.Events = append(v.Events, apiEvent{
+			ID:       e.Event.Id,
+			Summary:  e.Event.Summary,
+			Start:    e.Start.In(r.Location),
+			End:      e.End.In(r.Location),
+			Duration: newAPIDuration(e.Duration),
+		})
+	}
+	return v
+}
+
+// writeJSON はレスポンスをJSON形式で書き込む
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Warn("レスポンスの書き込みに失敗しました", "error", err)
+	}
+}
+
+// authorized はAPIトークンが設定されている場合に、リクエストのAuthorizationヘッダーを確認する
+func (s *server) authorized(r *http.Request) bool {
+	if s.apiToken == "" {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+s.apiToken
+}
+
+// handleSum は集計結果をJSON形式で返す（GET /v1/sum?name=...&start=...&end=...）
+func (s *server) handleSum(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	// 時間の表示形式などの共有の状態を設定する解析から、JSONへの変換までを他のリクエストと同時に実行しない
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opts, err := s.options(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": strings.TrimSpace(err.Error())})
+		return
+	}
+	report, err := s.query(r.Context(), opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if fe, ok := err.(*fatalError); ok && fe.code == exitUsage {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPIReport(report))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// 自分以外の参加者がいないイベントのグループ名
+const noAttendeeLabel = "(参加者なし)"
+
+// 主催者が設定されていないイベントのグループ名
+const noOrganizerLabel = "(主催者なし)"
+
+// hasAttendee はイベントの参加者に指定されたメールアドレスが含まれているかを判定する
+func hasAttendee(e *calendar.Event, email string) bool {
+	for _, a := range e.Attendees {
+		if strings.EqualFold(a.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// attendeeCount は会議室などのリソースを除いた参加者の人数を返す（自分を含む）
+func attendeeCount(e *calendar.Event) int {
+	n := 0
+	for _, a := range e.Attendees {
+		if !a.Resource {
+			n++
+		}
+	}
+	return n
+}
+
+// attendeeKeys はイベントの参加者のうち、自分と会議室などのリソースを除いたメールアドレスを返す
+func attendeeKeys(e *calendar.Event) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, a := range e.Attendees {
+		if a.Self || a.Resource || a.Email == "" {
+			continue
+		}
+		key := strings.ToLower(a.Email)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// isOrganizer はイベントの主催者が指定されたメールアドレスかどうかを判定する
+func isOrganizer(e *calendar.Event, email string) bool {
+	return e.Organizer != nil && strings.EqualFold(e.Organizer.Email, email)
+}
+
+// organizerKey はイベントの主催者のメールアドレスを返す
+func organizerKey(e *calendar.Event) string {
+	if e.Organizer == nil || e.Organizer.Email == "" {
+		return noOrganizerLabel
+	}
+	return strings.ToLower(e.Organizer.Email)
+}
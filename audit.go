@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// auditRecord は合計時間の根拠となったイベントを特定するための監査記録を表す
+type auditRecord struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// イベントを取得した日時（監査記録を書き出す場合はキャッシュを使用せず、この時点のAPIの内容を記録する）
+	FetchedAt time.Time `json:"fetched_at"`
+
+	// 集計に使用したコマンドライン引数
+	Args []string `json:"args"`
+
+	Name         string  `json:"name"`
+	Source       string  `json:"source"`
+	StartDate    string  `json:"start_date"`
+	EndDate      string  `json:"end_date"`
+	Timezone     string  `json:"timezone"`
+	TotalMinutes float64 `json:"total_minutes"`
+	Count        int     `json:"count"`
+
+	Events []auditEvent `json:"events"`
+}
+
+// auditEvent は監査記録に含まれる1件のイベントを表す
+type auditEvent struct {
+	ID               string       `json:"id,omitempty"`
+	ICalUID          string       `json:"ical_uid,omitempty"`
+	RecurringEventID string       `json:"recurring_event_id,omitempty"`
+	ETag             string       `json:"etag,omitempty"`
+	Updated          string       `json:"updated,omitempty"`
+	Summary          string       `json:"summary"`
+	Start            time.Time    `json:"start"`
+	End              time.Time    `json:"end"`
+	Minutes          float64      `json:"minutes"`
+	DeductedMinutes  float64      `json:"deducted_minutes,omitempty"`
+	CappedMinutes    float64      `json:"capped_minutes,omitempty"`
+	DailyCapped      float64      `json:"daily_capped_minutes,omitempty"`
+	Project          string       `json:"project,omitempty"`
+	Splits           []auditSplit `json:"splits,omitempty"`
+	Manual           bool         `json:"manual,omitempty"`
+
+	// イベントが一致した集計の条件
+	MatchedRules []string `json:"matched_rules,omitempty"`
+}
+
+// auditSplit は説明の記述に従ってプロジェクトごとに分割した時間を表す
+type auditSplit struct {
+	Project string  `json:"project"`
+	Minutes float64 `json:"minutes"`
+}
+
+// newAuditRecord は集計結果から監査記録を作成する
+func newAuditRecord(r *Report, o *queryOptions, fetchedAt time.Time, args []string) *auditRecord {
+	a := &auditRecord{
+		CreatedAt:    time.Now(),
+		FetchedAt:    fetchedAt,
+		Args:         args,
+		Name:         r.Name,
+		Source:       o.sourceName(),
+		StartDate:    r.StartDate.Format("2006-01-02"),
+		EndDate:      r.EndDate.Format("2006-01-02"),
+		Timezone:     r.Location.String(),
+		TotalMinutes: r.Total.Minutes(),
+		Count:        r.count(),
+		Events:       []auditEvent{},
+	}
+	for _, e := range r.Events {
+		ae := auditEvent{
+			Summary:         e.Event.Summary,
+			Start:           e.Start,
+			End:             e.End,
+			Minutes:         e.Duration.Minutes(),
+			DeductedMinutes: e.Deducted.Minutes(),
+			CappedMinutes:   e.Capped.Minutes(),
+			DailyCapped:     e.DailyCapped.Minutes(),
+			Project:         e.Project,
+			Manual:          e.Manual,
+		}
+		for _, sp := range e.Splits {
+			ae.Splits = append(ae.Splits, auditSplit{Project: sp.Project, Minutes: sp.Duration.Minutes()})
+		}
+		// 手動調整はカレンダーのイベントではないため、IDや一致した条件は記録しない
+		if !e.Manual {
+			ae.ID = e.Event.Id
+			ae.ICalUID = e.Event.ICalUID
+			ae.RecurringEventID = e.Event.RecurringEventId
+			ae.ETag = e.Event.Etag
+			ae.Updated = e.Event.Updated
+			ae.MatchedRules = o.matchedRules(e.Event)
+		}
+		a.Events = append(a.Events, ae)
+	}
+	return a
+}
+
+// writeAuditRecord は監査記録をファイルに書き出す
+// 後から書き換えられないように、既存のファイルは上書きせず、読み取り専用の権限で作成する
+func writeAuditRecord(path string, a *auditRecord) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0400)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return errors.New(printer.Sprintf("監査記録 %s はすでに存在します（監査記録は上書きしません）", path))
+		}
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(a); err != nil {
+		f.Close()
+		return err
+	}
+	// 書き出した内容が確実に保存されてから完了とする
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
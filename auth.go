@@ -0,0 +1,532 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/term"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+// authScope は機能ごとに必要なOAuthのスコープを表す
+type authScope struct {
+	name  string
+	scope string
+	help  string
+}
+
+// authScopes は gcal-sum が使用するスコープの一覧
+// 読み取り以外のスコープは、その機能を初めて使用した時に追加で許可を求める
+var authScopes = []authScope{
+	{name: "calendar-read", scope: calendar.CalendarReadonlyScope, help: "カレンダーの読み取り（集計）"},
+	{name: "calendar-write", scope: calendar.CalendarEventsScope, help: "カレンダーへのイベントの書き込み（export calendar）"},
+	{name: "sheets", scope: sheets.SpreadsheetsScope, help: "Google スプレッドシートへの書き込み（export sheets）"},
+}
+
+// authSubcommands は auth で指定できるサブコマンド
+var authSubcommands = []string{"scopes", "key", "revoke"}
+
+// Googleのトークンを取り消すエンドポイント
+const tokenRevokeURL = "https://oauth2.googleapis.com/revoke"
+
+// savedToken はトークンファイルの形式
+// 追加の権限が必要かどうかを判定できるように、トークンと合わせて付与されたスコープを保存する
+type savedToken struct {
+	*oauth2.Token
+	Scope string `json:"scope,omitempty"`
+}
+
+// tokenScope はトークンの応答に含まれる、付与されたスコープ（スペース区切り）を返す
+func tokenScope(tok *oauth2.Token) string {
+	s, _ := tok.Extra("scope").(string)
+	return s
+}
+
+// readTokenScope はトークンファイルに保存した、付与されたスコープ（スペース区切り）を返す
+func readTokenScope(path string) (string, error) {
+	b, err := readTokenFile(path)
+	if err != nil {
+		return "", err
+	}
+	var saved struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return "", err
+	}
+	return saved.Scope, nil
+}
+
+// grantedScopes はトークンファイルのトークンに付与されたスコープを返す
+// スコープを保存していない以前のトークンファイルは、カレンダーの読み取りだけを許可したものとみなす
+func grantedScopes(path string) ([]string, error) {
+	scope, err := readTokenScope(path)
+	if err != nil {
+		return nil, err
+	}
+	if scope == "" {
+		return []string{calendar.CalendarReadonlyScope}, nil
+	}
+	return strings.Fields(scope), nil
+}
+
+// hasScope は付与されたスコープに指定されたスコープが含まれるかを判定する
+// カレンダーのすべての操作を許可するスコープは、読み取りとイベントの書き込みのスコープも含むものとみなす
+func hasScope(granted []string, scope string) bool {
+	if containsString(granted, scope) {
+		return true
+	}
+	return (scope == calendar.CalendarReadonlyScope || scope == calendar.CalendarEventsScope) && containsString(granted, calendar.CalendarScope)
+}
+
+// missingScopes は要求するスコープのうち、付与されていないスコープを返す
+func missingScopes(granted, requested []string) []string {
+	var missing []string
+	for _, s := range requested {
+		if !hasScope(granted, s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// mergeScopes は2つのスコープの一覧を重複を除いてまとめる
+func mergeScopes(a, b []string) []string {
+	merged := append([]string{}, a...)
+	for _, s := range b {
+		if !containsString(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// ensureScopes は保存したトークンに要求するスコープがない場合に、ブラウザで追加の権限を許可してトークンを取得し直す
+// トークンファイルがない場合は、getClient での認証で要求するスコープをまとめて許可するため、何もしない
+func ensureScopes(ctx context.Context, config *oauth2.Config, tokenPath string) {
+	granted, err := grantedScopes(tokenPath)
+	if err != nil || len(missingScopes(granted, config.Scopes)) == 0 {
+		return
+	}
+
+	unlock := lockTokenFile(ctx, tokenPath)
+	defer unlock()
+	// ロックを待つ間に別のプロセスが許可した場合は、そのトークンを使用する
+	if granted, err = grantedScopes(tokenPath); err != nil {
+		return
+	}
+	missing := missingScopes(granted, config.Scopes)
+	if len(missing) == 0 {
+		return
+	}
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		fatalCode(exitAuth, "この機能には追加の権限が必要です（端末から gcal-sum auth scopes -grant で許可してください）", "scopes", strings.Join(missing, " "))
+	}
+	slog.Info("この機能には追加の権限が必要なため、ブラウザで許可してください", "scopes", strings.Join(missing, " "))
+	consentScopes(ctx, config, tokenPath, mergeScopes(granted, config.Scopes))
+}
+
+// consentScopes はブラウザで指定されたスコープを許可してトークンを取得し、付与されたスコープと合わせて保存する
+// トークンファイルのロックを取得した状態で呼び出す
+func consentScopes(ctx context.Context, config *oauth2.Config, tokenPath string, scopes []string) {
+	consentConfig := *config
+	consentConfig.Scopes = scopes
+	tok := getTokenFromWeb(ctx, &consentConfig)
+	// 応答にスコープが含まれない場合は、要求したスコープが付与されたものとみなす
+	if tokenScope(tok) == "" {
+		tok = tok.WithExtra(map[string]interface{}{"scope": strings.Join(scopes, " ")})
+	}
+	saveToken(tokenPath, tok)
+}
+
+// fetchGrantedScopes はGoogleのトークン情報のエンドポイントから、アクセストークンに付与されているスコープを取得する
+func fetchGrantedScopes(ctx context.Context, accessToken string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenInfoURL+"?access_token="+url.QueryEscape(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(printer.Sprintf("HTTP %d", resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return strings.Fields(info.Scope), nil
+}
+
+// printAuthUsage はauthコマンドの使用方法を表示する
+func printAuthUsage() {
+	printer.Printf("使用方法: gcal-sum auth <サブコマンド> [オプション]\n")
+	printer.Printf("サブコマンド:\n")
+	printer.Printf("  scopes   トークンに付与されている権限の表示と、追加の権限の許可\n")
+	printer.Printf("  key      トークンファイルを暗号化する鍵の生成\n")
+	printer.Printf("  revoke   トークンをGoogleで取り消し、トークンファイルを削除する\n")
+}
+
+// registerAuthFlags はサブコマンドごとの auth のフラグを登録する（補完の候補に使用する）
+func registerAuthFlags(fs *flag.FlagSet, cfg *Config, sub string) {
+	switch sub {
+	case "scopes":
+		registerAuthScopesFlags(fs, cfg)
+	case "key":
+		registerAuthKeyFlags(fs)
+	case "revoke":
+		registerAuthRevokeFlags(fs, cfg)
+	}
+}
+
+// runAuthCommand は認証に関するサブコマンドを実行する
+func runAuthCommand(cfg *Config, args []string) {
+	if len(args) == 0 {
+		printer.Printf("エラー: サブコマンドを指定してください。\n")
+		printAuthUsage()
+		os.Exit(exitUsage)
+	}
+
+	switch args[0] {
+	case "scopes":
+		runAuthScopes(cfg, args[1:])
+	case "key":
+		runAuthKey(args[1:])
+	case "revoke":
+		runAuthRevoke(cfg, args[1:])
+	default:
+		printer.Printf("エラー: サブコマンド '%s' はサポートされていません。\n", args[0])
+		printAuthUsage()
+		os.Exit(exitUsage)
+	}
+}
+
+// scopesByName はカンマ区切りの機能の名前を、対応するスコープに変換する
+func scopesByName(names string) ([]string, error) {
+	var scopes []string
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, s := range authScopes {
+			if s.name == name {
+				scopes = append(scopes, s.scope)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var valid []string
+			for _, s := range authScopes {
+				valid = append(valid, s.name)
+			}
+			return nil, errors.New(printer.Sprintf("権限 '%s' はサポートされていません（%s）。", name, strings.Join(valid, ", ")))
+		}
+	}
+	return scopes, nil
+}
+
+// authScopesFlags は auth scopes のフラグ
+type authScopesFlags struct {
+	grant     string
+	reconsent bool
+	timeout   time.Duration
+}
+
+// registerAuthScopesFlags は auth scopes のフラグを登録する（補完の候補にも使用する）
+func registerAuthScopesFlags(fs *flag.FlagSet, cfg *Config) *authScopesFlags {
+	f := &authScopesFlags{}
+	fs.StringVar(&f.grant, "grant", "", "追加で許可する権限（calendar-write, sheets）、カンマ区切りで複数指定可能")
+	fs.BoolVar(&f.reconsent, "reconsent", false, "許可済みの権限について、ブラウザで改めて許可する")
+	fs.DurationVar(&f.timeout, "timeout", 0, "処理全体のタイムアウト（例: 5m）。0の場合はタイムアウトしない")
+	return f
+}
+
+// runAuthScopes はトークンに付与されている権限を表示し、指定された場合は追加の権限や再度の許可をブラウザで求める
+func runAuthScopes(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("auth scopes", flag.ExitOnError)
+	flags := registerAuthScopesFlags(fs, cfg)
+	parseFlags(fs, args)
+
+	requested, err := scopesByName(flags.grant)
+	if err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	config := mustOAuthConfig(cfg.CredentialsPath, calendar.CalendarReadonlyScope)
+
+	ctx, cancel := newCommandContext(flags.timeout)
+	defer cancel()
+	granted, err := grantedScopes(cfg.TokenPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if len(requested) == 0 && !flags.reconsent {
+			printer.Printf("%s がありません（まだ認証していません）。gcal-sum を実行するか、-grant を指定して認証してください。\n", cfg.TokenPath)
+			exit(exitAuth)
+		}
+		granted = []string{calendar.CalendarReadonlyScope}
+	case err != nil:
+		fatalCode(exitAuth, "トークンファイルの読み込みに失敗しました", "error", err, "path", cfg.TokenPath)
+	}
+
+	// 追加の権限か再度の許可が指定された場合は、許可済みの権限も含めてブラウザで許可する
+	if len(missingScopes(granted, requested)) > 0 || flags.reconsent {
+		unlock := lockTokenFile(ctx, cfg.TokenPath)
+		consentScopes(ctx, config, cfg.TokenPath, mergeScopes(granted, requested))
+		unlock()
+		if granted, err = grantedScopes(cfg.TokenPath); err != nil {
+			fatalCode(exitAuth, "トークンファイルの読み込みに失敗しました", "error", err, "path", cfg.TokenPath)
+		}
+	}
+
+	// Googleの画面で権限を取り消した場合にも正しく表示できるように、実際に付与されているスコープを確認する
+	if tok, err := tokenFromFile(cfg.TokenPath); err == nil {
+		if tok, err = config.TokenSource(ctx, tok).Token(); err != nil {
+			slog.Warn("アクセストークンを取得できないため、保存したスコープを表示します", "error", contextError(ctx, err))
+		} else if scopes, err := fetchGrantedScopes(ctx, tok.AccessToken); err != nil {
+			slog.Warn("付与されているスコープを確認できないため、保存したスコープを表示します", "error", contextError(ctx, err))
+		} else {
+			if strings.Join(scopes, " ") != strings.Join(granted, " ") {
+				saveToken(cfg.TokenPath, tok.WithExtra(map[string]interface{}{"scope": strings.Join(scopes, " ")}))
+			}
+			granted = scopes
+		}
+	}
+
+	printer.Printf("トークン: %s\n", cfg.TokenPath)
+	rows := [][]string{{"", printer.Sprintf("権限"), printer.Sprintf("用途")}}
+	for _, s := range authScopes {
+		mark := "✗"
+		if hasScope(granted, s.scope) {
+			mark = "✓"
+		}
+		rows = append(rows, []string{mark, s.name, translate(s.help)})
+	}
+	widths := columnWidths(rows)
+	for _, row := range rows {
+		fmt.Println(strings.TrimRight(formatRow(row, widths), " "))
+	}
+	// gcal-sum が使用しない権限も付与されている場合は、スコープのURLをそのまま表示する
+	for _, scope := range granted {
+		known := false
+		for _, s := range authScopes {
+			if s.scope == scope {
+				known = true
+			}
+		}
+		if !known {
+			printer.Printf("その他のスコープ: %s\n", scope)
+		}
+	}
+}
+
+// authKeyFlags は auth key のフラグ
+type authKeyFlags struct {
+	keyring bool
+	force   bool
+}
+
+// registerAuthKeyFlags は auth key のフラグを登録する（補完の候補にも使用する）
+func registerAuthKeyFlags(fs *flag.FlagSet) *authKeyFlags {
+	f := &authKeyFlags{}
+	fs.BoolVar(&f.keyring, "keyring", false, "生成した鍵を表示せずにOSのキーリングに保存する（設定ファイルの token_encryption に keyring を指定して使用する）")
+	fs.BoolVar(&f.force, "force", false, "OSのキーリングに保存済みの鍵を置き換える（置き換える前の鍵で暗号化したトークンファイルは読み込めなくなる）")
+	return f
+}
+
+// runAuthKey はトークンファイルを暗号化する鍵を生成し、表示するかOSのキーリングに保存する
+func runAuthKey(args []string) {
+	fs := flag.NewFlagSet("auth key", flag.ExitOnError)
+	flags := registerAuthKeyFlags(fs)
+	parseFlags(fs, args)
+
+	if flags.keyring && !flags.force {
+		if _, err := keyringTokenKey(); err == nil {
+			printer.Printf("エラー: OSのキーリングにはすでに鍵が保存されています（置き換える場合は -force を指定してください）。\n")
+			os.Exit(exitUsage)
+		}
+	}
+
+	key, err := newTokenKey()
+	if err != nil {
+		fatal("鍵の生成に失敗しました", "error", err)
+	}
+	if !flags.keyring {
+		// 鍵だけを標準出力に出力し、説明は標準エラー出力に出力する（パスワードマネージャーなどに保存しやすくするため）
+		fmt.Println(key)
+		printer.Fprintf(os.Stderr, "この鍵を環境変数 %s に指定すると、トークンファイルを暗号化して保存します。\n", tokenKeyEnv)
+		return
+	}
+	if err := storeKeyringTokenKey(key); err != nil {
+		fatal("OSのキーリングへの鍵の保存に失敗しました", "error", err)
+	}
+	printer.Printf("鍵をOSのキーリングに保存しました（サービス: %s、アカウント: %s）。設定ファイルの token_encryption に keyring を指定してください。\n", tokenKeyringService, tokenKeyringAccount)
+}
+
+// legacyTokenPaths は以前のバージョンで書き込みの権限ごとに作成していたトークンファイルのパスを返す
+func legacyTokenPaths(tokenPath string) []string {
+	ext := filepath.Ext(tokenPath)
+	base := strings.TrimSuffix(tokenPath, ext)
+	return []string{base + "_sheets" + ext, base + "_calendar_write" + ext}
+}
+
+// revokeToken はGoogleのエンドポイントでトークンを取り消す（リフレッシュトークンを取り消すと、許可したすべての権限が取り消される）
+// トークンがすでに無効な場合（Googleのアカウントの画面で取り消し済みなど）は、エラーにせずに revoked を false で返す
+func revokeToken(ctx context.Context, tok *oauth2.Token) (revoked bool, err error) {
+	token := tok.RefreshToken
+	if token == "" {
+		token = tok.AccessToken
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenRevokeURL, strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return true, nil
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode == http.StatusBadRequest && body.Error == "invalid_token" {
+		return false, nil
+	}
+	return false, errors.New(printer.Sprintf("HTTP %d %s", resp.StatusCode, body.Error))
+}
+
+// authRevokeFlags は auth revoke のフラグ
+type authRevokeFlags struct {
+	yes     bool
+	force   bool
+	timeout time.Duration
+}
+
+// registerAuthRevokeFlags は auth revoke のフラグを登録する（補完の候補にも使用する）
+func registerAuthRevokeFlags(fs *flag.FlagSet, cfg *Config) *authRevokeFlags {
+	f := &authRevokeFlags{}
+	fs.BoolVar(&f.yes, "yes", false, "確認せずに取り消す")
+	fs.BoolVar(&f.force, "force", false, "トークンを取り消せなかった場合もトークンファイルを削除する")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "トークンの取り消しのタイムアウト")
+	return f
+}
+
+// runAuthRevoke はトークンをGoogleで取り消してから、トークンファイル（以前のバージョンで作成したものを含む）を削除する
+// 取り消しに失敗した場合は、後からやり直せるように -force を指定しない限りトークンファイルを削除しない
+func runAuthRevoke(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("auth revoke", flag.ExitOnError)
+	flags := registerAuthRevokeFlags(fs, cfg)
+	parseFlags(fs, args)
+
+	var paths []string
+	for _, path := range append([]string{cfg.TokenPath}, legacyTokenPaths(cfg.TokenPath)...) {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		printer.Printf("%s がありません（まだ認証していないか、すでに削除されています）。\n", cfg.TokenPath)
+		return
+	}
+
+	if !flags.yes {
+		if !canConfirm() {
+			printer.Printf("エラー: 端末から実行するか、-yes を指定してください。\n")
+			os.Exit(exitUsage)
+		}
+		// 確認の入力を待つ時間はタイムアウトに含めない
+		confirmCtx, cancel := newCommandContext(0)
+		ok := askYesNo(confirmCtx, printer.Sprintf("gcal-sum に許可したカレンダーなどへのアクセスを取り消し、%s を削除します。よろしいですか？ [y/N]: ", strings.Join(paths, ", ")))
+		cancel()
+		if !ok {
+			printer.Printf("取り消しを中止しました。\n")
+			return
+		}
+	}
+
+	ctx, cancel := newCommandContext(flags.timeout)
+	defer cancel()
+
+	failed := false
+	for _, path := range paths {
+		tok, err := tokenFromFile(path)
+		if err != nil {
+			slog.Error("トークンファイルを読み込めないため、トークンを取り消せません", "path", path, "error", err)
+			failed = true
+			continue
+		}
+		revoked, err := revokeToken(ctx, tok)
+		switch {
+		case err != nil:
+			slog.Error("トークンの取り消しに失敗しました", "path", path, "error", contextError(ctx, err))
+			failed = true
+		case revoked:
+			slog.Info("トークンを取り消しました", "path", path)
+		default:
+			slog.Info("トークンはすでに無効になっています", "path", path)
+		}
+	}
+	if failed {
+		if !flags.force {
+			fatalCode(exitAuth, "トークンを取り消せなかったため、トークンファイルを削除していません（削除する場合は -force を指定してください）")
+		}
+		slog.Warn("取り消せなかったトークンは、Googleアカウントの「サードパーティ製のアプリとサービス」の画面から取り消してください", "url", "https://myaccount.google.com/connections")
+	}
+
+	for _, path := range paths {
+		// 取り消しと同時に別のプロセスがトークンを更新して保存し直さないように、ロックを取得してから削除する
+		unlock := lockTokenFile(ctx, path)
+		err := os.Remove(path)
+		unlock()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fatal("トークンファイルの削除に失敗しました", "error", err, "path", path)
+		}
+		printer.Printf("%s を削除しました\n", path)
+	}
+}
+
+// askYesNo は y または n が入力されるまで確認を繰り返し、y の場合に true を返す（空入力は n として扱う）
+func askYesNo(ctx context.Context, prompt string) bool {
+	lines := stdinLines()
+	for {
+		fmt.Fprint(os.Stderr, prompt)
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return false
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				return true
+			case "", "n", "no":
+				return false
+			}
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr)
+			fatalCode(exitCodeFor(context.Cause(ctx)), "確認を中断しました", "reason", context.Cause(ctx))
+		}
+	}
+}
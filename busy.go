@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// busyPeriod は予定が入っている時間帯を表す
+type busyPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// fetchBusyPeriods はFreeBusy APIを使用して、カレンダーごとの予定が入っている時間帯を取得する
+func fetchBusyPeriods(ctx context.Context, srv *calendar.Service, calendarIDs []string, location *time.Location, rangeStart, rangeEnd time.Time) (map[string][]busyPeriod, error) {
+	req := &calendar.FreeBusyRequest{
+		TimeMin:  rangeStart.Format(time.RFC3339),
+		TimeMax:  rangeEnd.Format(time.RFC3339),
+		TimeZone: location.String(),
+	}
+	for _, id := range calendarIDs {
+		req.Items = append(req.Items, &calendar.FreeBusyRequestItem{Id: id})
+	}
+	var resp *calendar.FreeBusyResponse
+	err := withRetry(ctx, func() error {
+		var err error
+		resp, err = srv.Freebusy.Query(req).Fields("calendars").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make(map[string][]busyPeriod)
+	for _, id := range calendarIDs {
+		cal, ok := resp.Calendars[id]
+		if !ok {
+			continue
+		}
+		if len(cal.Errors) > 0 {
+			return nil, errors.New(printer.Sprintf("カレンダー %s の空き時間を取得できませんでした: %s", id, cal.Errors[0].Reason))
+		}
+		for _, p := range cal.Busy {
+			start, err := time.Parse(time.RFC3339, p.Start)
+			if err != nil {
+				return nil, err
+			}
+			end, err := time.Parse(time.RFC3339, p.End)
+			if err != nil {
+				return nil, err
+			}
+			busy[id] = append(busy[id], busyPeriod{Start: start, End: end})
+		}
+	}
+	return busy, nil
+}
+
+// mergeBusyPeriods は重なっている時間帯を1つにまとめ、開始日時順に返す
+func mergeBusyPeriods(periods []busyPeriod) []busyPeriod {
+	sorted := append([]busyPeriod(nil), periods...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var merged []busyPeriod
+	for _, p := range sorted {
+		if n := len(merged); n > 0 && !p.Start.After(merged[n-1].End) {
+			merged[n-1].End = later(merged[n-1].End, p.End)
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// freeBlocks は時間帯の中で予定が入っていない時間帯を返す。periodsはmergeBusyPeriodsでまとめたものを渡す
+func freeBlocks(periods []busyPeriod, windowStart, windowEnd time.Time) []busyPeriod {
+	var blocks []busyPeriod
+	cursor := windowStart
+	for _, p := range periods {
+		start := later(p.Start, windowStart)
+		end := earlier(p.End, windowEnd)
+		if !start.Before(end) {
+			continue
+		}
+		if cursor.Before(start) {
+			blocks = append(blocks, busyPeriod{Start: cursor, End: start})
+		}
+		cursor = later(cursor, end)
+	}
+	if cursor.Before(windowEnd) {
+		blocks = append(blocks, busyPeriod{Start: cursor, End: windowEnd})
+	}
+	return blocks
+}
+
+// busyDuration は期間内（勤務時間帯が指定されている場合はその時間帯内）で予定が入っている時間の合計を返す
+func busyDuration(periods []busyPeriod, schedule *workSchedule, location *time.Location, rangeStart, rangeEnd time.Time) time.Duration {
+	var total time.Duration
+	for _, p := range mergeBusyPeriods(periods) {
+		start := later(p.Start, rangeStart)
+		end := earlier(p.End, rangeEnd)
+		if !start.Before(end) {
+			continue
+		}
+		if schedule != nil {
+			total += schedule.overlap(start, end, location)
+		} else {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// registerBusyFlags は busy のフラグを登録する（補完の候補にも使用する）
+func registerBusyFlags(fs *flag.FlagSet, cfg *Config) *queryOptions {
+	opts := &queryOptions{}
+	opts.registerDateRange(fs, cfg)
+	fs.StringVar(&opts.calendarID, "calendar", cfg.Calendar, "カレンダーID、カンマ区切りで複数指定可能")
+	fs.StringVar(&opts.workHours, "work-hours", "", "勤務時間帯内の時間だけを集計（HH:MM-HH:MM形式、例: 09:00-18:00）")
+	fs.StringVar(&opts.workdays, "workdays", "", "勤務日の曜日だけを集計（例: mon-fri, mon,wed,fri）")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "処理全体のタイムアウト（例: 5m）。0の場合はタイムアウトしない")
+	return opts
+}
+
+// runBusyCommand はFreeBusy APIで予定が入っている時間と空き時間を集計するサブコマンドを実行する
+func runBusyCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("busy", flag.ExitOnError)
+	opts := registerBusyFlags(fs, cfg)
+	parseFlags(fs, args)
+
+	if _, err := opts.checkDateRange(); err != nil {
+		printer.Printf("エラー: %v\n", err)
+		printer.Printf("使用方法: gcal-sum busy -month=YYYY-MM [-calendar=カレンダーID] [-work-hours=09:00-18:00] [-workdays=mon-fri]\n")
+		os.Exit(exitUsage)
+	}
+	schedule, err := newWorkSchedule(opts.workHours, opts.workdays)
+	if err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	startDate, endDate := opts.dateRange(location)
+	rangeEnd := endDate.AddDate(0, 0, 1)
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	srv := newCalendarService(ctx, cfg.CredentialsPath, cfg.TokenPath)
+	ids := opts.calendarIDs()
+	busy, err := fetchBusyPeriods(ctx, srv, ids, location, startDate, rangeEnd)
+	if err != nil {
+		fatalAPI("空き時間情報の取得に失敗しました", contextError(ctx, err))
+	}
+
+	// 対象となる時間（勤務時間帯が指定されている場合はその時間帯の合計）
+	available := rangeEnd.Sub(startDate)
+	if schedule != nil {
+		available = schedule.overlap(startDate, rangeEnd, location)
+	}
+
+	printer.Printf("検索期間: %s から %s\n", startDate.Format("2006/01/02"), endDate.Format("2006/01/02"))
+	var all []busyPeriod
+	if len(ids) > 1 {
+		printer.Printf("カレンダー別の予定あり時間:\n")
+	}
+	for _, id := range ids {
+		all = append(all, busy[id]...)
+		if len(ids) > 1 {
+			fmt.Printf("- %s: %s\n", id, formatDuration(busyDuration(busy[id], schedule, location, startDate, rangeEnd)))
+		}
+	}
+
+	// 複数のカレンダーで同じ時間帯に予定がある場合は1回分として数える
+	total := busyDuration(all, schedule, location, startDate, rangeEnd)
+	printer.Printf("対象時間: %s\n", formatDuration(available))
+	printer.Printf("予定あり: %s\n", formatDuration(total))
+	printer.Printf("空き時間: %s\n", formatDuration(available-total))
+	if available > 0 {
+		printer.Printf("予定の割合: %.1f%%\n", float64(total)/float64(available)*100)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// デフォルトのキャッシュ有効期間
+const defaultCacheTTL = time.Hour
+
+// cachedEvents はキャッシュファイルに保存するイベント一覧
+type cachedEvents struct {
+	CalendarID string            `json:"calendar_id"`
+	Query      string            `json:"query,omitempty"`
+	TimeMin    string            `json:"time_min"`
+	TimeMax    string            `json:"time_max"`
+	FetchedAt  time.Time         `json:"fetched_at"`
+	Items      []*calendar.Event `json:"items"`
+}
+
+// eventCache は取得したイベントをディスクにキャッシュする
+type eventCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cachePath はカレンダーID・検索語・期間からキャッシュファイルのパスを求める
+func (c *eventCache) cachePath(calendarID, query, timeMin, timeMax string) string {
+	key := calendarID + "\n" + timeMin + "\n" + timeMax
+	if query != "" {
+		key += "\n" + query
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load はキャッシュからイベント一覧を読み込む。キャッシュが存在しないか期限切れの場合はfalseを返す
+func (c *eventCache) load(calendarID, query, timeMin, timeMax string) ([]*calendar.Event, bool) {
+	f, err := os.Open(c.cachePath(calendarID, query, timeMin, timeMax))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var cached cachedEvents
+	if err := json.NewDecoder(f).Decode(&cached); err != nil {
+		return nil, false
+	}
+	if time.Since(cached.FetchedAt) > c.ttl {
+		return nil, false
+	}
+	return cached.Items, true
+}
+
+// save はイベント一覧をキャッシュに保存する
+func (c *eventCache) save(calendarID, query, timeMin, timeMax string, items []*calendar.Event) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(c.cachePath(calendarID, query, timeMin, timeMax), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(cachedEvents{
+		CalendarID: calendarID,
+		Query:      query,
+		TimeMin:    timeMin,
+		TimeMax:    timeMax,
+		FetchedAt:  time.Now(),
+		Items:      items,
+	})
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// timeZoneSource はカレンダーのタイムゾーンの設定を取得できる取得元
+type timeZoneSource interface {
+	// TimeZone はカレンダーのタイムゾーン（IANAのタイムゾーン名）を返す。設定がない場合は空文字を返す
+	TimeZone(ctx context.Context, calendarID string) (string, error)
+}
+
+// TimeZone はCalendar APIからカレンダーのタイムゾーンの設定を取得する
+func (s *googleSource) TimeZone(ctx context.Context, calendarID string) (string, error) {
+	var tz string
+	err := withRetry(ctx, func() error {
+		c, err := s.srv.Calendars.Get(calendarID).Fields("timeZone").Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+		tz = c.TimeZone
+		return nil
+	})
+	return tz, err
+}
+
+// TimeZone はICSファイルの X-WR-TIMEZONE をカレンダーのタイムゾーンとして返す
+func (s *icsSource) TimeZone(ctx context.Context, calendarID string) (string, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	lines, err := unfoldICSLines(f)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if prop := parseICSProperty(line); prop.Name == "X-WR-TIMEZONE" {
+			return prop.Value, nil
+		}
+		if line == "BEGIN:VEVENT" {
+			break
+		}
+	}
+	return "", nil
+}
+
+// TimeZone はフィクスチャの timeZone（Events.list の応答と同じ項目）をカレンダーのタイムゾーンとして返す
+func (s *mockSource) TimeZone(ctx context.Context, calendarID string) (string, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", err
+	}
+	var events calendar.Events
+	if json.Unmarshal(b, &events) != nil {
+		// イベントの配列の形式にはタイムゾーンがない
+		return "", nil
+	}
+	return events.TimeZone, nil
+}
+
+// eventZones は -calendar-timezone で、イベントごとに取得元のカレンダーのタイムゾーンを記録する
+// 複数の集計（team や run）から並行に記録されることがあるため、ロックして更新する
+type eventZones struct {
+	mu        sync.Mutex
+	calendars map[string]*time.Location
+	events    map[*calendar.Event]*time.Location
+}
+
+// newEventZones はタイムゾーンの記録を作成する
+func newEventZones() *eventZones {
+	return &eventZones{
+		calendars: make(map[string]*time.Location),
+		events:    make(map[*calendar.Event]*time.Location),
+	}
+}
+
+// load はカレンダーのタイムゾーンの設定を読み込む（読み込み済みのカレンダーは取得し直さない）
+// 取得に失敗した場合や設定がない場合は、表示のタイムゾーンを使用する
+func (z *eventZones) load(ctx context.Context, src CalendarSource, ids []string) {
+	if z == nil {
+		return
+	}
+	ts, ok := src.(timeZoneSource)
+	if !ok {
+		return
+	}
+	for _, id := range ids {
+		z.mu.Lock()
+		_, loaded := z.calendars[id]
+		z.mu.Unlock()
+		if loaded {
+			continue
+		}
+		var loc *time.Location
+		tz, err := ts.TimeZone(ctx, id)
+		switch {
+		case err != nil:
+			slog.Warn("カレンダーのタイムゾーンの取得に失敗したため、表示のタイムゾーンを使用します", "calendar", id, "error", contextError(ctx, err))
+		case tz != "":
+			if loc, err = time.LoadLocation(tz); err != nil {
+				slog.Warn("カレンダーのタイムゾーンの読み込みに失敗したため、表示のタイムゾーンを使用します", "calendar", id, "timezone", tz, "error", err)
+			} else {
+				slog.Debug("カレンダーのタイムゾーンを読み込みました", "calendar", id, "timezone", tz)
+			}
+		}
+		z.mu.Lock()
+		z.calendars[id] = loc
+		z.mu.Unlock()
+	}
+}
+
+// record はイベントを取得したカレンダーのタイムゾーンを記録する
+func (z *eventZones) record(calendarID string, items ...*calendar.Event) {
+	if z == nil {
+		return
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	loc := z.calendars[calendarID]
+	if loc == nil {
+		return
+	}
+	for _, item := range items {
+		if _, ok := z.events[item]; !ok {
+			z.events[item] = loc
+		}
+	}
+}
+
+// forget はイベントのタイムゾーンの記録を取り除く
+func (z *eventZones) forget(item *calendar.Event) {
+	if z == nil {
+		return
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	delete(z.events, item)
+}
+
+// calendar はカレンダーのタイムゾーンを返す（設定がない場合は fallback を返す）
+func (z *eventZones) calendar(calendarID string, fallback *time.Location) *time.Location {
+	if z == nil {
+		return fallback
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if loc := z.calendars[calendarID]; loc != nil {
+		return loc
+	}
+	return fallback
+}
+
+// of はイベントを取得したカレンダーのタイムゾーンを返す（記録がない場合はnil）
+func (z *eventZones) of(item *calendar.Event) *time.Location {
+	if z == nil {
+		return nil
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.events[item]
+}
+
+// location はイベントの終日の日付や日ごとの振り分けに使用するタイムゾーンを返す（記録がない場合は fallback を返す）
+func (z *eventZones) location(item *calendar.Event, fallback *time.Location) *time.Location {
+	if loc := z.of(item); loc != nil {
+		return loc
+	}
+	return fallback
+}
+
+// localStart はイベントの開始日時を、日ごとに振り分けるタイムゾーンで返す
+// -calendar-timezone の場合は取得元のカレンダーのタイムゾーン、それ以外は表示のタイムゾーンを使用する
+func (e MatchedEvent) localStart(location *time.Location) time.Time {
+	if e.Location != nil {
+		return e.Start.In(e.Location)
+	}
+	return e.Start.In(location)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+	"golang.org/x/text/width"
+)
+
+// デフォルトの端末幅（幅を取得できない場合に使用）
+const defaultTerminalWidth = 80
+
+// terminalWidth は出力先の端末の幅を返す
+func terminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return defaultTerminalWidth
+}
+
+// displayWidth は文字列の端末上での表示幅を返す（全角文字は2として数える）
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// padRight は文字列の表示幅が指定の幅になるよう末尾に空白を追加する
+func padRight(s string, w int) string {
+	if pad := w - displayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// printChart はグループごとの合計時間を端末幅に合わせた横棒グラフで出力する
+func printChart(w io.Writer, groups []GroupTotal, label string, termWidth int) {
+	if len(groups) == 0 {
+		return
+	}
+
+	labelWidth, valueWidth := 0, 0
+	var maxDuration time.Duration
+	for _, g := range groups {
+		labelWidth = max(labelWidth, displayWidth(g.Key))
+		valueWidth = max(valueWidth, displayWidth(formatDuration(g.Duration)))
+		maxDuration = max(maxDuration, g.Duration)
+	}
+
+	// ラベル、区切り、値の表示に必要な幅を除いた残りを棒の最大長にする
+	barWidth := max(termWidth-labelWidth-valueWidth-4, 10)
+
+	printer.Fprintf(w, "%s別の合計時間グラフ:\n", label)
+	for _, g := range groups {
+		n := 0
+		if maxDuration > 0 {
+			n = int(float64(g.Duration) / float64(maxDuration) * float64(barWidth))
+		}
+		// 0より大きい値は最低1マス表示する
+		if n == 0 && g.Duration > 0 {
+			n = 1
+		}
+		fmt.Fprintf(w, "%s │%s %s\n", padRight(g.Key, labelWidth), colorize(w, colorCyan, strings.Repeat("█", n)), formatDuration(g.Duration))
+	}
+	fmt.Fprintln(w)
+}
+
+// 割合の棒の幅（文字数）
+const shareBarWidth = 10
+
+// 1文字を8段階に分けて棒の端を表示するための文字
+var shareBarEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// shareBar は割合（%）を、100%で shareBarWidth 文字になる棒で表す
+func shareBar(percent float64) string {
+	eighths := int(min(max(percent, 0), 100) / 100 * shareBarWidth * 8)
+	// 0より大きい値は最低限の長さで表示する
+	if eighths == 0 && percent > 0 {
+		eighths = 1
+	}
+	return strings.Repeat("█", eighths/8) + shareBarEighths[eighths%8]
+}
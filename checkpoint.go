@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// fetchCheckpoint はAPIの割り当て上限に達した時点までに取得が完了したイベントを保持する
+type fetchCheckpoint struct {
+	SavedAt   time.Time                    `json:"saved_at"`
+	Completed map[string][]*calendar.Event `json:"completed"`
+
+	path string
+}
+
+// isQuotaError はAPIの割り当て上限（1日の上限やレート制限）に達したことによるエラーかを判定する
+func isQuotaError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, e := range apiErr.Errors {
+		switch e.Reason {
+		case "quotaExceeded", "dailyLimitExceeded", "rateLimitExceeded", "userRateLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
+
+// key は取得処理を識別するキーを返す
+func (t fetchTask) key() string {
+	return t.calendarID + "\n" + t.start.Format(time.RFC3339) + "\n" + t.end.Format(time.RFC3339)
+}
+
+// checkpointPath は取得条件ごとのチェックポイントファイルのパスを求める
+// 取得結果が変わる条件（カレンダー・期間・検索語など）が異なる場合は別のファイルにする
+func (o *queryOptions) checkpointPath(dir string, startDate, searchEndDate time.Time) string {
+	key := strings.Join([]string{
+		o.calendarID,
+		startDate.Format(time.RFC3339),
+		searchEndDate.Format(time.RFC3339),
+		o.serverQuery(),
+		fmt.Sprint(o.sync, o.includeCancelled),
+	}, "\n")
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, "checkpoint-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadCheckpoint はチェックポイントを読み込む。存在しない場合は空のチェックポイントを返す
+func loadCheckpoint(path string) (*fetchCheckpoint, error) {
+	cp := &fetchCheckpoint{Completed: make(map[string][]*calendar.Event), path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cp, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(cp); err != nil {
+		return nil, err
+	}
+	if cp.Completed == nil {
+		cp.Completed = make(map[string][]*calendar.Event)
+	}
+	return cp, nil
+}
+
+// save はチェックポイントをファイルに保存する
+func (cp *fetchCheckpoint) save() error {
+	if err := os.MkdirAll(filepath.Dir(cp.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(cp.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cp.SavedAt = time.Now()
+	return json.NewEncoder(f).Encode(cp)
+}
+
+// remove はチェックポイントファイルを削除する
+func (cp *fetchCheckpoint) remove() error {
+	if err := os.Remove(cp.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
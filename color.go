@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// 出力の色付けに使用するANSIエスケープシーケンスの属性
+const (
+	colorBold   = "1"
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+	colorCyan   = "36"
+)
+
+// colorDisabled は -no-color が指定された場合に true にする
+var colorDisabled bool
+
+// useColor は出力先に色を付けるかどうかを判定する
+// 出力先が端末の場合だけ色を付け、-no-color、環境変数 NO_COLOR（https://no-color.org/）、TERM=dumb の場合は付けない
+func useColor(w io.Writer) bool {
+	if colorDisabled || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// colorize は出力先に色を付ける場合に、文字列を指定の属性で色付けする（属性が空の場合は色を付けない。末尾の改行は色付けの外に出す）
+func colorize(w io.Writer, attr, s string) string {
+	if s == "" || attr == "" || !useColor(w) {
+		return s
+	}
+	body := strings.TrimSuffix(s, "\n")
+	return "\x1b[" + attr + "m" + body + "\x1b[0m" + s[len(body):]
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// comparisonRange は比較対象の期間を求める。比較が指定されていない場合はfalseを返す
+func (o *queryOptions) comparisonRange(startDate, endDate time.Time, location *time.Location) (time.Time, time.Time, bool, error) {
+	if o.compareTo != "" {
+		// 期間指定（YYYY-MM-DD..YYYY-MM-DD）
+		if from, to, ok := strings.Cut(o.compareTo, ".."); ok {
+			start, err := time.ParseInLocation("2006-01-02", from, location)
+			if err != nil {
+				return time.Time{}, time.Time{}, false, err
+			}
+			end, err := time.ParseInLocation("2006-01-02", to, location)
+			if err != nil {
+				return time.Time{}, time.Time{}, false, err
+			}
+			return start, end, true, nil
+		}
+
+		// 月指定（YYYY-MM）
+		start, end, err := getMonthDates(o.compareTo, location)
+		return start, end, err == nil, err
+	}
+
+	if o.vsPrevious {
+		// 月指定の場合は前月、それ以外は同じ日数だけ前の期間と比較する
+		if o.month != "" {
+			start := startDate.AddDate(0, -1, 0)
+			return start, startDate.AddDate(0, 0, -1), true, nil
+		}
+		days := daysBetween(startDate, endDate) + 1
+		end := startDate.AddDate(0, 0, -1)
+		return end.AddDate(0, 0, -(days - 1)), end, true, nil
+	}
+	return time.Time{}, time.Time{}, false, nil
+}
+
+// formatSignedDuration は時間を符号付きの「+X時間Y分」形式の文字列に変換する
+func formatSignedDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	return "+" + formatDuration(d)
+}
+
+// comparisonSummary は比較期間との差分を表示用の文字列に変換する
+func comparisonSummary(r *Report) string {
+	c := r.Comparison
+	return printer.Sprintf("%s（差分 %s、%s）",
+		formatDuration(c.Total),
+		formatSignedDuration(r.Total-c.Total),
+		formatChange(c.Total.Minutes(), r.Total.Minutes()))
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// 補完スクリプトを出力できるシェル
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionCommand は補完の候補にするサブコマンド
+type completionCommand struct {
+	name  string
+	help  string
+	flags func(fs *flag.FlagSet, cfg *Config, target string)
+	targs []string
+}
+
+// completionCommands は補完の候補にするサブコマンドの一覧
+// init と completion はフラグがなく、doctor は設定ファイルの読み込み前に実行するため、フラグの登録関数を持たない
+// フラグは各サブコマンドと同じ登録関数で登録するため、サブコマンドを実行せずに候補を集められる
+var completionCommands = []completionCommand{
+	{name: "init", help: "対話形式の初期設定"},
+	{name: "doctor", help: "設定と認証の診断"},
+	{name: "report", help: "HTMLレポートの作成", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerReportFlags(fs, cfg) }},
+	{name: "export", help: "集計結果を外部サービスに出力", flags: registerExportFlags, targs: exportTargets},
+	{name: "auth", help: "認証と権限の管理", flags: registerAuthFlags, targs: authSubcommands},
+	{name: "sync", help: "ローカルストアへの差分同期", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerSyncFlags(fs, cfg) }},
+	{name: "history", help: "集計結果の履歴の表示", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerHistoryFlags(fs, cfg) }},
+	{name: "snapshot", help: "集計結果のスナップショットの保存", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerSnapshotFlags(fs, cfg) }},
+	{name: "diff", help: "スナップショットとの差分の表示", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerDiffFlags(fs, cfg) }},
+	{name: "heatmap", help: "曜日×時間帯のヒートマップ", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerHeatmapFlags(fs, cfg) }},
+	{name: "busy", help: "予定あり・空き時間の集計", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerBusyFlags(fs, cfg) }},
+	{name: "meetings", help: "会議の負荷のレポート", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerMeetingsFlags(fs, cfg) }},
+	{name: "focus", help: "集中時間の分析", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerFocusFlags(fs, cfg) }},
+	{name: "rooms", help: "会議室の利用率のレポート", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerRoomsFlags(fs, cfg) }},
+	{name: "team", help: "チームの利用者ごとの集計", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerTeamFlags(fs, cfg) }},
+	{name: "serve", help: "Web画面での集計", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerServeFlags(fs, cfg) }},
+	{name: "watch", help: "定期的な集計と配信", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerWatchFlags(fs, cfg) }},
+	{name: "run", help: "設定ファイルの複数の集計をまとめて実行", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerRunFlags(fs, cfg) }},
+	{name: "exporter", help: "Prometheusへのメトリクスの公開", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerExporterFlags(fs, cfg) }},
+	{name: "tui", help: "対話的な集計", flags: func(fs *flag.FlagSet, cfg *Config, _ string) { registerTUIFlags(fs, cfg) }},
+	{name: "completion", help: "シェルの補完スクリプトの出力", targs: completionShells},
+}
+
+// calendarListCachePath はカレンダー一覧のキャッシュの保存先を返す
+func calendarListCachePath(cacheDir string) string {
+	return filepath.Join(cacheDir, "calendars.json")
+}
+
+// saveCalendarList はカレンダーIDの補完に使用するため、カレンダー一覧をキャッシュに保存する
+func saveCalendarList(cacheDir string, items []*calendar.CalendarListEntry) error {
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(calendarListCachePath(cacheDir), b, 0600)
+}
+
+// loadCalendarList はキャッシュしたカレンダー一覧を読み込む（キャッシュがない場合は空）
+func loadCalendarList(cacheDir string) []*calendar.CalendarListEntry {
+	b, err := os.ReadFile(calendarListCachePath(cacheDir))
+	if err != nil {
+		return nil
+	}
+	var items []*calendar.CalendarListEntry
+	json.Unmarshal(b, &items)
+	return items
+}
+
+// flagValues はフラグの値の補完候補を返す（説明がない場合は値と同じ）
+func flagValues(cfg *Config, name string) [][2]string {
+	var values []string
+	switch name {
+	case "calendar", "holiday-calendar", "to-calendar":
+		var cands [][2]string
+		if name == "calendar" {
+			cands = append(cands, [2]string{"primary", printer.Sprintf("メインのカレンダー")})
+		}
+		for _, item := range loadCalendarList(cfg.CacheDir) {
+			cands = append(cands, [2]string{item.Id, item.Summary})
+		}
+		return cands
+	case "group-by":
+		values = groupByOptions
+	case "output":
+		values = outputFormats
+	case "duration-format":
+		values = durationFormats
+	case "sort":
+		values = eventSortKeys
+	case "outliers":
+		values = outlierModes
+	case "week-start":
+		values = []string{"monday", "sunday", "saturday"}
+	case "match":
+		values = []string{"exact", "contains", "regex"}
+	case "lang":
+		values = languages
+	case "log-format":
+		values = logFormats
+	}
+	cands := make([][2]string, len(values))
+	for i, v := range values {
+		cands[i] = [2]string{v, v}
+	}
+	return cands
+}
+
+// isBoolFlag は値を取らないフラグかどうかを判定する
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// completeWords は入力中のコマンドラインに対する補完候補を返す
+// words はプログラム名を除いた単語の列で、最後の要素が入力中の単語
+func completeWords(cfg *Config, words []string) [][2]string {
+	cur, prev := words[len(words)-1], words[:len(words)-1]
+
+	if len(prev) == 0 && !strings.HasPrefix(cur, "-") {
+		var cands [][2]string
+		for _, c := range completionCommands {
+			cands = append(cands, [2]string{c.name, translate(c.help)})
+		}
+		return cands
+	}
+
+	// サブコマンドと、フラグの登録に使用する関数を決める
+	name := "gcal-sum"
+	register := func(fs *flag.FlagSet, cfg *Config, _ string) { registerSumFlags(fs, cfg) }
+	var target string
+	for _, c := range completionCommands {
+		if len(prev) == 0 || c.name != prev[0] {
+			continue
+		}
+		name, register, prev = c.name, c.flags, prev[1:]
+		if c.targs == nil {
+			break
+		}
+		if len(prev) == 0 {
+			cands := make([][2]string, len(c.targs))
+			for i, t := range c.targs {
+				cands[i] = [2]string{t, t}
+			}
+			return cands
+		}
+		// export と auth は出力先・サブコマンドごとにフラグが異なるため、登録関数に渡す
+		target, prev = prev[0], prev[1:]
+		break
+	}
+	if register == nil {
+		return nil
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	register(fs, cfg, target)
+	registerCommonFlags(fs)
+
+	// 「-flag 値」の値を入力中の場合
+	if len(prev) > 0 && strings.HasPrefix(prev[len(prev)-1], "-") && !strings.Contains(prev[len(prev)-1], "=") {
+		if f := fs.Lookup(strings.TrimLeft(prev[len(prev)-1], "-")); f != nil && !isBoolFlag(f) {
+			return valueCandidates(cfg, f.Name, "", cur)
+		}
+	}
+	if !strings.HasPrefix(cur, "-") {
+		return nil
+	}
+
+	// 「-flag=値」の値を入力中の場合
+	if name, value, ok := strings.Cut(strings.TrimLeft(cur, "-"), "="); ok {
+		return valueCandidates(cfg, name, cur[:len(cur)-len(value)], value)
+	}
+
+	var cands [][2]string
+	fs.VisitAll(func(f *flag.Flag) {
+		cands = append(cands, [2]string{"-" + f.Name, translate(f.Usage)})
+	})
+	return cands
+}
+
+// valueCandidates はフラグの値の補完候補に、入力済みの部分（「-flag=」や、カンマ区切りの前の値）を付けて返す
+func valueCandidates(cfg *Config, name, prefix, value string) [][2]string {
+	if i := strings.LastIndex(value, ","); i >= 0 && name == "calendar" {
+		prefix, value = prefix+value[:i+1], value[i+1:]
+	}
+	var cands [][2]string
+	for _, c := range flagValues(cfg, name) {
+		if strings.HasPrefix(c[0], value) {
+			cands = append(cands, [2]string{prefix + c[0], c[1]})
+		}
+	}
+	return cands
+}
+
+// runCompleteCommand はシェルの補完スクリプトから呼び出され、補完候補を「値<TAB>説明」の形式で1行ずつ出力する
+func runCompleteCommand(cfg *Config, args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	// PowerShellから空の単語を渡すために使用する「""」は、空の単語として扱う
+	if args[len(args)-1] == `""` {
+		args[len(args)-1] = ""
+	}
+	cur := args[len(args)-1]
+	for _, c := range completeWords(cfg, args) {
+		if !strings.HasPrefix(c[0], cur) {
+			continue
+		}
+		fmt.Printf("%s\t%s\n", c[0], strings.ReplaceAll(c[1], "\n", " "))
+	}
+}
+
+// completionScripts はシェルごとの補完スクリプト
+// 補完候補はスクリプトから「gcal-sum __complete 単語…」を呼び出して取得する
+var completionScripts = map[string]string{
+	"bash": `# gcal-sum の bash 補完（~/.bashrc に「source <(gcal-sum completion bash)」を追加）
+_gcal_sum() {
+    local line=${COMP_LINE:0:COMP_POINT}
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *' ' ]] && words+=("")
+    local word=${words[${#words[@]}-1]}
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    local prefix=${word:0:${#word}-${#cur}}
+    local IFS=$'\n' cand
+    COMPREPLY=()
+    for cand in $(gcal-sum __complete "${words[@]:1}" 2>/dev/null | cut -f1); do
+        COMPREPLY+=("${cand#"$prefix"}")
+    done
+}
+complete -o default -F _gcal_sum gcal-sum
+`,
+	"zsh": `#compdef gcal-sum
+# gcal-sum の zsh 補完（~/.zshrc に「source <(gcal-sum completion zsh)」を追加）
+_gcal_sum() {
+    local -a cands
+    local line
+    for line in "${(@f)$(gcal-sum __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -z $line ]] && continue
+        cands+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+    done
+    if (( ${#cands} )); then
+        _describe 'gcal-sum' cands
+    else
+        _files
+    fi
+}
+compdef _gcal_sum gcal-sum
+`,
+	"fish": `# gcal-sum の fish 補完（「gcal-sum completion fish > ~/.config/fish/completions/gcal-sum.fish」で保存）
+function __gcal_sum_complete
+    set -l out (gcal-sum __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)
+    if test (count $out) -eq 0
+        __fish_complete_path (commandline -ct)
+    else
+        printf '%s\n' $out
+    end
+end
+complete -c gcal-sum -f -a '(__gcal_sum_complete)'
+`,
+	"powershell": `# gcal-sum の PowerShell 補完（$PROFILE に「gcal-sum completion powershell | Out-String | Invoke-Expression」を追加）
+Register-ArgumentCompleter -Native -CommandName gcal-sum -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | Where-Object { $_.Extent.EndOffset -le $cursorPosition } | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    gcal-sum __complete @words 2>$null | ForEach-Object {
+        $value, $desc = $_ -split "` + "`" + `t", 2
+        if (-not $desc) { $desc = $value }
+        [System.Management.Automation.CompletionResult]::new($value, $value, 'ParameterValue', $desc)
+    }
+}
+`,
+}
+
+// runCompletionCommand はシェルの補完スクリプトを出力する
+func runCompletionCommand(args []string) {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		printer.Printf("エラー: シェルを指定してください（%s）。\n", strings.Join(completionShells, ", "))
+		printer.Printf("使用方法: gcal-sum completion <シェル>\n")
+		os.Exit(exitUsage)
+	}
+	fmt.Print(completionScripts[args[0]])
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompleteWordsFlags(t *testing.T) {
+	cfg := &Config{}
+	cfg.applyDefaults(t.TempDir())
+
+	tests := []struct {
+		name    string
+		words   []string
+		want    []string
+		notWant []string
+	}{
+		{"サブコマンドなし", []string{"-"}, []string{"-name", "-group-by", "-lang"}, []string{"-o"}},
+		{"report", []string{"report", "-"}, []string{"-name", "-html", "-no-color"}, nil},
+		{"export toggl", []string{"export", "toggl", "-"}, []string{"-email", "-billable", "-client"}, []string{"-first-name"}},
+		{"export harvest", []string{"export", "harvest", "-"}, []string{"-first-name", "-last-name"}, []string{"-email"}},
+		{"auth key", []string{"auth", "key", "-"}, []string{"-keyring", "-lang"}, []string{"-name"}},
+		{"meetings", []string{"meetings", "-"}, []string{"-min-attendees"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range completeWords(cfg, tt.words) {
+				got = append(got, c[0])
+			}
+			for _, w := range tt.want {
+				if !slices.Contains(got, w) {
+					t.Errorf("候補に %s がありません: %v", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if slices.Contains(got, w) {
+					t.Errorf("候補に %s があります", w)
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteWordsValues(t *testing.T) {
+	cfg := &Config{}
+	cfg.applyDefaults(t.TempDir())
+
+	got := completeWords(cfg, []string{"report", "-group-by", ""})
+	if len(got) != len(groupByOptions) || got[0][0] != groupByOptions[0] {
+		t.Errorf("-group-by の候補 = %v; want %v", got, groupByOptions)
+	}
+	got = completeWords(cfg, []string{"-match=re"})
+	if len(got) != 1 || got[0][0] != "-match=regex" {
+		t.Errorf("-match= の候補 = %v; want -match=regex", got)
+	}
+	// 補完の候補を集めるだけで、サブコマンドを実行しないこと
+	if got := completeWords(cfg, []string{"export", "unknown", "-"}); len(got) == 0 {
+		t.Error("サポートしていない出力先でも共通のフラグを候補にすること")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// デフォルトのタイムゾーン
+const defaultTimezone = "Asia/Tokyo"
+
+// Config は設定ファイル（config.json）の内容を表す
+type Config struct {
+	CredentialsPath string `json:"credentials,omitempty"`
+	TokenPath       string `json:"token,omitempty"`
+	Calendar        string `json:"calendar,omitempty"`
+	Timezone        string `json:"timezone,omitempty"`
+	CacheDir        string `json:"cache_dir,omitempty"`
+	StoreDir        string `json:"store_dir,omitempty"`
+	HistoryPath     string `json:"history,omitempty"`
+	SnapshotDir     string `json:"snapshot_dir,omitempty"`
+
+	// トークンファイルを暗号化する鍵の取得元（env: 環境変数 GCAL_SUM_TOKEN_KEY, keyring: OSのキーリング）
+	TokenEncryption string `json:"token_encryption,omitempty"`
+
+	// credentials.json の代わりに使用するOAuthクライアントのIDとシークレット
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+
+	// 休憩などを合計時間から差し引くルール
+	Deductions []DeductionRule `json:"deductions,omitempty"`
+
+	// イベント名のパターンとプロジェクト（クライアント）の対応付け
+	Projects []ProjectRule `json:"projects,omitempty"`
+
+	// 説明の「clientA: 30m / clientB: 60m」形式の行に従って、プロジェクト別の集計でイベントの時間を分割するかどうか
+	SplitMarkers bool `json:"split_markers,omitempty"`
+
+	// イベント名の別名（例: {"gym": ["Gym", "ジム", "Workout"]}）
+	// -name に別名を指定すると、いずれかのイベント名に一致するイベントを集計する
+	Aliases map[string][]string `json:"aliases,omitempty"`
+
+	// イベント名を比較する際に、絵文字と前後の記号を取り除くかどうか（例: 「🏃 Run」を「Run」として比較する）
+	IgnoreEmoji bool `json:"ignore_emoji,omitempty"`
+
+	// 日ごとの振り分けと終日イベントの日付に、表示のタイムゾーンの代わりに各カレンダーのタイムゾーンの設定を使用するかどうか
+	CalendarTimezone bool `json:"calendar_timezone,omitempty"`
+
+	// イベント名ごとの目標時間（例: {"Gym": "12h"}）
+	Targets map[string]string `json:"targets,omitempty"`
+
+	// 稼働率の計算に使用する1日あたりの稼働時間（例: "8h"）と祝日カレンダーのID
+	HoursPerDay     string `json:"hours_per_day,omitempty"`
+	HolidayCalendar string `json:"holiday_calendar,omitempty"`
+
+	// 残業時間の計算に使用する所定労働時間と時間外労働の上限（-overtime）
+	Overtime OvertimeConfig `json:"overtime,omitempty"`
+
+	// 集計から常に除外するイベント
+	ExcludeEvents []ExcludedEvent `json:"exclude_events,omitempty"`
+
+	// 週の初日（例: "monday", "sunday"）。-week や週ごとの集計で使用する
+	WeekStart string `json:"week_start,omitempty"`
+
+	// 集計結果を投稿するSlackのIncoming WebhookのURL
+	SlackWebhook string `json:"slack_webhook,omitempty"`
+
+	// 集計結果の配信先（Webhook、メール）
+	Delivery DeliveryConfig `json:"delivery,omitempty"`
+
+	// watch で定期的に実行する集計
+	Watch []WatchJob `json:"watch,omitempty"`
+
+	// run でまとめて実行する集計
+	Queries []RunQuery `json:"queries,omitempty"`
+
+	// export jira で作業ログを登録するJiraの設定
+	Jira JiraConfig `json:"jira,omitempty"`
+
+	// rooms で集計する会議室のリソースカレンダーのID
+	Rooms []string `json:"rooms,omitempty"`
+
+	// team で集計する利用者とサービスアカウントの設定
+	Team TeamConfig `json:"team,omitempty"`
+
+	// exporter で定期的に集計するクエリ
+	Metrics []MetricQuery `json:"metrics,omitempty"`
+}
+
+// MetricQuery は exporter で定期的に集計し、Prometheusのメトリクスとして公開するクエリ
+type MetricQuery struct {
+	// メトリクスの name ラベルの値
+	Name string `json:"name"`
+
+	// 集計の条件（serve のクエリパラメータと同じ名前で指定する。例: {"name": "On-call", "match": "contains"}）
+	Query map[string]string `json:"query"`
+
+	// 集計する期間（day: 今日, week: 今週, month: 今月）。省略した場合は month
+	Period string `json:"period,omitempty"`
+}
+
+// loadConfig は設定ファイルを読み込む。ファイルが存在しない場合は空の設定を返す
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// saveConfig は設定ファイルを保存する
+func saveConfig(path string, cfg *Config) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// 設定を上書きする環境変数
+var configEnvVars = []struct {
+	name  string
+	field func(c *Config) *string
+}{
+	{"GCAL_SUM_CREDENTIALS", func(c *Config) *string { return &c.CredentialsPath }},
+	{"GCAL_SUM_CLIENT_ID", func(c *Config) *string { return &c.ClientID }},
+	{"GCAL_SUM_CLIENT_SECRET", func(c *Config) *string { return &c.ClientSecret }},
+	{"GCAL_SUM_TOKEN", func(c *Config) *string { return &c.TokenPath }},
+	{"GCAL_SUM_TOKEN_ENCRYPTION", func(c *Config) *string { return &c.TokenEncryption }},
+	{"GCAL_SUM_CALENDAR", func(c *Config) *string { return &c.Calendar }},
+	{"GCAL_SUM_TIMEZONE", func(c *Config) *string { return &c.Timezone }},
+	{"GCAL_SUM_CACHE_DIR", func(c *Config) *string { return &c.CacheDir }},
+	{"GCAL_SUM_STORE_DIR", func(c *Config) *string { return &c.StoreDir }},
+	{"GCAL_SUM_HISTORY", func(c *Config) *string { return &c.HistoryPath }},
+	{"GCAL_SUM_SNAPSHOT_DIR", func(c *Config) *string { return &c.SnapshotDir }},
+	{"GCAL_SUM_HOLIDAY_CALENDAR", func(c *Config) *string { return &c.HolidayCalendar }},
+	{"GCAL_SUM_SLACK_WEBHOOK", func(c *Config) *string { return &c.SlackWebhook }},
+	{"GCAL_SUM_JIRA_TOKEN", func(c *Config) *string { return &c.Jira.Token }},
+	{"GCAL_SUM_SERVICE_ACCOUNT", func(c *Config) *string { return &c.Team.ServiceAccount }},
+}
+
+// applyEnv は環境変数で設定を上書きする（優先順位: フラグ > 環境変数 > 設定ファイル）
+func (c *Config) applyEnv() {
+	for _, env := range configEnvVars {
+		if v := os.Getenv(env.name); v != "" {
+			*env.field(c) = v
+		}
+	}
+	// SMTPのパスワードは設定ファイルに書かずに環境変数で渡せるようにする
+	if v := os.Getenv("GCAL_SUM_SMTP_PASSWORD"); v != "" && c.Delivery.Email != nil {
+		c.Delivery.Email.Password = v
+	}
+}
+
+// applyDefaults は未設定の項目にデフォルト値を設定する
+func (c *Config) applyDefaults(appDir string) {
+	if c.CredentialsPath == "" {
+		c.CredentialsPath = filepath.Join(appDir, "credentials.json")
+	}
+	if c.TokenPath == "" {
+		c.TokenPath = filepath.Join(appDir, "token.json")
+	}
+	if c.Calendar == "" {
+		c.Calendar = "primary"
+	}
+	if c.Timezone == "" {
+		c.Timezone = defaultTimezone
+	}
+	if c.CacheDir == "" {
+		c.CacheDir = filepath.Join(appDir, "cache")
+	}
+	if c.StoreDir == "" {
+		c.StoreDir = filepath.Join(appDir, "store")
+	}
+	if c.HistoryPath == "" {
+		c.HistoryPath = filepath.Join(appDir, "history.jsonl")
+	}
+	if c.SnapshotDir == "" {
+		c.SnapshotDir = filepath.Join(appDir, "snapshots")
+	}
+	if c.HolidayCalendar == "" {
+		c.HolidayCalendar = japaneseHolidayCalendar
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// stdinLines は標準入力を1行ずつ読み込むチャネル（入力が終わった場合は閉じる）
+// 入力を待つ間もCtrl-Cやタイムアウトで中断できるように、読み込みは別のゴルーチンで行う
+// -months で複数回確認する場合も同じ読み込みを使うように、最初に使用する時に1回だけ開始する
+var stdinLines = sync.OnceValue(func() <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+})
+
+// confirmEvents は一致したイベントを1件ずつ表示し、集計に含めるかどうかを確認する
+// 確認の表示は、集計結果を出力する標準出力と混ざらないように out に出力する
+func confirmEvents(ctx context.Context, r *Report, lines <-chan string, out io.Writer) {
+	if len(r.Events) == 0 {
+		return
+	}
+	printer.Fprintf(out, "一致したイベントを集計に含めるかを確認します（y: 含める, n: 除外する, a: 残りをすべて含める, q: 中止する）\n")
+	kept := make([]MatchedEvent, 0, len(r.Events))
+events:
+	for i, e := range r.Events {
+		printer.Fprintf(out, "[%d/%d] %s (%s～%s) [%s]",
+			i+1, len(r.Events),
+			e.Event.Summary,
+			e.Start.In(r.Location).Format("2006/01/02 15:04"),
+			e.End.In(r.Location).Format("2006/01/02 15:04"),
+			formatDuration(e.Duration))
+		if r.isOutlier(e) {
+			fmt.Fprint(out, colorize(out, colorYellow, printer.Sprintf(" (外れ値)")))
+		}
+		fmt.Fprintln(out)
+		switch askConfirm(ctx, lines, out) {
+		case "y":
+			kept = append(kept, e)
+		case "n":
+			r.Excluded = append(r.Excluded, e)
+		case "a":
+			kept = append(kept, r.Events[i:]...)
+			break events
+		case "q":
+			fatalCode(exitInterrupted, "イベントの確認を中止しました")
+		}
+	}
+	r.Events = kept
+	r.recompute()
+	fmt.Fprintln(out)
+}
+
+// askConfirm は y、n、a、q のいずれかが入力されるまで確認を繰り返し、入力された値を返す（空入力は y として扱う）
+func askConfirm(ctx context.Context, lines <-chan string, out io.Writer) string {
+	for {
+		printer.Fprintf(out, "集計に含めますか？ [Y/n/a/q]: ")
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				fatalCode(exitUsage, "イベントの確認中に入力が終了しました")
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "", "y", "yes":
+				return "y"
+			case "n", "no":
+				return "n"
+			case "a", "all":
+				return "a"
+			case "q", "quit":
+				return "q"
+			}
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			fatalCode(exitCodeFor(context.Cause(ctx)), "イベントの確認を中断しました", "reason", context.Cause(ctx))
+		}
+	}
+}
+
+// excludedDuration は確認により除外したイベントの時間の合計を返す
+func excludedDuration(events []MatchedEvent) time.Duration {
+	var total time.Duration
+	for _, e := range events {
+		total += e.Duration
+	}
+	return total
+}
+
+// canConfirm は対話的な確認を行える（標準入力と標準エラー出力が端末である）かどうかを判定する
+func canConfirm() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
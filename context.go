@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// causeError は処理が中断された理由を表す
+// 表示言語はフラグの解析後に決まるため、メッセージは表示する時点で翻訳する
+type causeError string
+
+func (e causeError) Error() string {
+	return printer.Sprintf(string(e))
+}
+
+// 処理が中断された理由
+var (
+	errInterrupted error = causeError("中断されました")
+	errTimeout     error = causeError("タイムアウトしました")
+)
+
+// newCommandContext はCtrl-C（SIGINT）またはSIGTERMでキャンセルされるコンテキストを返す
+// timeoutが0より大きい場合は、その時間が経過した時点でもキャンセルされる
+// 1回目のシグナルで処理を中断し、2回目のシグナルでは通常どおり強制終了する
+func newCommandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigCh:
+			signal.Stop(sigCh)
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+
+	stop := func() {
+		signal.Stop(sigCh)
+		cancel(nil)
+	}
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	timeoutCtx, cancelTimeout := context.WithTimeoutCause(ctx, timeout, errTimeout)
+	return timeoutCtx, func() {
+		cancelTimeout()
+		stop()
+	}
+}
+
+// contextError はコンテキストがキャンセルされている場合はその理由を、そうでない場合はerrをそのまま返す
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return err
+}
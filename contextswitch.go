@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// デフォルトの連続した予定とみなす間隔（前の予定の終了から次の予定の開始まで）
+const defaultBackToBackGap = 10 * time.Minute
+
+// ContextSwitches は一致したイベントの間の切り替えの回数と、予定の間隔を表す（-context-switches）
+type ContextSwitches struct {
+	// 連続した予定とみなす間隔
+	Gap time.Duration
+
+	// 期間全体の切り替えの回数、連続した予定の回数、予定の間隔の平均
+	Switches   int
+	BackToBack int
+	AverageGap time.Duration
+
+	// 一致したイベントがある日ごとの集計（日付順）
+	Days []SwitchDay
+}
+
+// SwitchDay は1日の予定の件数、切り替えの回数、連続した予定の回数、予定の間隔の平均を表す
+type SwitchDay struct {
+	Date       time.Time
+	Events     int
+	Switches   int
+	BackToBack int
+	AverageGap time.Duration
+}
+
+// computeContextSwitches は一致したイベントを日ごとに開始時刻の順に並べ、前の予定の終了から次の予定の開始までの間隔を求める
+// 間隔が gap より短い場合（重なっている場合を含む）は、休憩なしの連続した予定として数える（手動調整は含めない）
+func computeContextSwitches(r *Report, gap time.Duration) *ContextSwitches {
+	var events []MatchedEvent
+	for _, e := range r.Events {
+		if !e.Manual {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	cs := &ContextSwitches{Gap: gap}
+	var totalGap time.Duration
+	var day *SwitchDay
+	var dayGap time.Duration
+	var coveredUntil time.Time
+	finishDay := func() {
+		if day != nil && day.Switches > 0 {
+			day.AverageGap = dayGap / time.Duration(day.Switches)
+		}
+	}
+	for _, e := range events {
+		start := e.localStart(r.Location)
+		date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, r.Location)
+		if day == nil || !day.Date.Equal(date) {
+			finishDay()
+			cs.Days = append(cs.Days, SwitchDay{Date: date})
+			day = &cs.Days[len(cs.Days)-1]
+			dayGap = 0
+			coveredUntil = e.End
+			day.Events++
+			continue
+		}
+		// 重なっている予定は間隔を0とする
+		g := max(e.Start.Sub(coveredUntil), 0)
+		day.Events++
+		day.Switches++
+		dayGap += g
+		totalGap += g
+		if g < gap {
+			day.BackToBack++
+		}
+		coveredUntil = later(coveredUntil, e.End)
+	}
+	finishDay()
+
+	for _, d := range cs.Days {
+		cs.Switches += d.Switches
+		cs.BackToBack += d.BackToBack
+	}
+	if cs.Switches > 0 {
+		cs.AverageGap = totalGap / time.Duration(cs.Switches)
+	}
+	return cs
+}
+
+// averageGap は予定の間隔の平均を表示用の文字列に変換する（予定が1件だけの日は「-」を返す）
+func (d SwitchDay) averageGap() string {
+	if d.Switches == 0 {
+		return "-"
+	}
+	return formatDuration(d.AverageGap)
+}
+
+// printContextSwitches は予定の切り替えの回数と、日ごとの内訳をテキスト形式で出力する
+func printContextSwitches(w io.Writer, r *Report) {
+	cs := r.ContextSwitches
+	if cs == nil {
+		return
+	}
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("予定の切り替え（%s 未満の間隔は連続した予定として数える）:\n", formatDuration(cs.Gap))))
+	printer.Fprintf(w, "- 切り替え: %d回 / 連続した予定: %d回 / 予定の間隔の平均: %s\n", cs.Switches, cs.BackToBack, formatDuration(cs.AverageGap))
+	if len(cs.Days) > 0 {
+		printer.Fprintf(w, "- 1日あたりの切り替え: %.1f回 / 連続した予定: %.1f回\n",
+			float64(cs.Switches)/float64(len(cs.Days)), float64(cs.BackToBack)/float64(len(cs.Days)))
+	}
+	if r.NoList {
+		fmt.Fprintln(w)
+		return
+	}
+	for _, d := range cs.Days {
+		printer.Fprintf(w, "- %s: %d件, 切り替え %d回, 連続 %d回, 間隔の平均 %s\n",
+			d.Date.Format("2006/01/02"), d.Events, d.Switches, d.BackToBack, d.averageGap())
+	}
+	fmt.Fprintln(w)
+}
+
+// printContextSwitchesMarkdown は予定の切り替えの回数と、日ごとの内訳をMarkdown形式の表で出力する
+func printContextSwitchesMarkdown(w io.Writer, r *Report) {
+	cs := r.ContextSwitches
+	if cs == nil {
+		return
+	}
+	printer.Fprintf(w, "### 予定の切り替え（%s 未満の間隔は連続した予定として数える）\n\n", formatDuration(cs.Gap))
+	printer.Fprintf(w, "| 日付 | 件数 | 切り替え | 連続 | 間隔の平均 |\n")
+	printer.Fprintf(w, "|---|---:|---:|---:|---:|\n")
+	events := 0
+	for _, d := range cs.Days {
+		events += d.Events
+		fmt.Fprintf(w, "| %s | %d | %d | %d | %s |\n", d.Date.Format("2006/01/02"), d.Events, d.Switches, d.BackToBack, d.averageGap())
+	}
+	printer.Fprintf(w, "| **合計** | **%d** | **%d** | **%d** | **%s** |\n\n", events, cs.Switches, cs.BackToBack, formatDuration(cs.AverageGap))
+}
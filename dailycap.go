@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// CappedDay は -daily-cap の上限を超えた日の、上限を適用する前と後の時間を表す
+type CappedDay struct {
+	Date    time.Time
+	Raw     time.Duration
+	Counted time.Duration
+}
+
+// applyDailyCap は1日に集計する時間を上限までに切り詰める
+// イベントは開始日の時間として数え、開始時刻の順に上限に達するまで加え、上限を超えた分は後から始まるイベントの時間から差し引く
+// 上限を超えた日を日付順に返す
+func applyDailyCap(events []MatchedEvent, limit time.Duration, location *time.Location) []CappedDay {
+	order := make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return events[order[i]].Start.Before(events[order[j]].Start)
+	})
+
+	var days []CappedDay
+	index := make(map[string]int)
+	for _, i := range order {
+		e := &events[i]
+		start := e.localStart(location)
+		key := start.Format("2006-01-02")
+		d, ok := index[key]
+		if !ok {
+			d = len(days)
+			index[key] = d
+			days = append(days, CappedDay{Date: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location)})
+		}
+		day := &days[d]
+		day.Raw += e.Duration
+
+		counted := min(e.Duration, max(limit-day.Counted, 0))
+		if counted < e.Duration {
+			e.DailyCapped = e.Duration - counted
+			e.Duration = counted
+			// プロジェクトごとに分割した時間も、切り詰めた後の時間に合わせて分割し直す
+			if len(e.Splits) > 0 {
+				e.Splits = splitDuration(e.Splits, counted, nil)
+			}
+		}
+		day.Counted += counted
+	}
+
+	var capped []CappedDay
+	for _, day := range days {
+		if day.Raw > day.Counted {
+			capped = append(capped, day)
+		}
+	}
+	sort.Slice(capped, func(i, j int) bool {
+		return capped[i].Date.Before(capped[j].Date)
+	})
+	return capped
+}
+
+// dailyCapped は -daily-cap によって切り詰めた時間の合計を返す
+func (r *Report) dailyCapped() time.Duration {
+	var d time.Duration
+	for _, day := range r.CappedDays {
+		d += day.Raw - day.Counted
+	}
+	return d
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日付の表現で使用できる曜日の名前（略称は weekdayNames を使用する）
+var weekdayFullNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// 日付の表現で使用できる相対的な日の名前と、今日からの日数
+var relativeDays = map[string]int{
+	"today": 0, "yesterday": -1, "tomorrow": 1,
+	"今日": 0, "昨日": -1, "明日": 1, "一昨日": -2,
+}
+
+// normalizeDateExpr は日付の表現を小文字にし、連続する空白を1つにする
+func normalizeDateExpr(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// lookupWeekday は曜日の名前（monday または mon）を解析する
+func lookupWeekday(s string) (time.Weekday, bool) {
+	if d, ok := weekdayFullNames[s]; ok {
+		return d, true
+	}
+	d, ok := weekdayNames[s]
+	return d, ok
+}
+
+// dateUnit は「3 days」などの単位（day, week, month, year）を解析する
+func dateUnit(s string) (string, bool) {
+	s = strings.TrimSuffix(s, "s")
+	switch s {
+	case "day", "week", "month", "year":
+		return s, true
+	}
+	return "", false
+}
+
+// addDateUnit は日付に単位ごとの数を加える
+func addDateUnit(t time.Time, unit string, n int) time.Time {
+	switch unit {
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	case "year":
+		return t.AddDate(n, 0, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// startOfDay は日時をその日の0時に切り捨てる
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// weekStart は週の初日（-week-start または設定ファイルの week_start で変更する）
+var weekStart = time.Monday
+
+// setWeekStart は週の初日を設定する。空の場合は月曜日にする
+func setWeekStart(s string) error {
+	if s == "" {
+		weekStart = time.Monday
+		return nil
+	}
+	day, ok := lookupWeekday(strings.ToLower(s))
+	if !ok {
+		return errors.New(printer.Sprintf("週の初日 '%s' はサポートされていません（monday, sunday など）。", s))
+	}
+	weekStart = day
+	return nil
+}
+
+// startOfWeek は日付を含む週の初日を返す
+func startOfWeek(t time.Time) time.Time {
+	return t.AddDate(0, 0, -((int(t.Weekday()) - int(weekStart) + 7) % 7))
+}
+
+// getWeekDates はISO週番号（YYYY-Www形式、例: 2024-W23）の週の初日と末日を返す
+// 週の初日が月曜日以外の場合は、その週の月曜日を含む週を返す
+func getWeekDates(weekStr string, location *time.Location) (time.Time, time.Time, error) {
+	year, week, ok := strings.Cut(strings.ToUpper(weekStr), "-W")
+	y, yErr := strconv.Atoi(year)
+	w, wErr := strconv.Atoi(week)
+	if !ok || yErr != nil || wErr != nil || len(year) != 4 || w < 1 || w > 53 {
+		return time.Time{}, time.Time{}, errors.New(printer.Sprintf("週 '%s' を解析できません（YYYY-Www形式、例: 2024-W23）", weekStr))
+	}
+	// 1月4日を含む週がその年の第1週になる
+	jan4 := time.Date(y, time.January, 4, 0, 0, 0, 0, location)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+7*(w-1))
+	if _, isoWeek := monday.ISOWeek(); isoWeek != w {
+		return time.Time{}, time.Time{}, errors.New(printer.Sprintf("%s年に第%d週はありません", year, w))
+	}
+	start := startOfWeek(monday)
+	return start, start.AddDate(0, 0, 6), nil
+}
+
+// parseDateExpr はYYYY-MM-DD形式の日付、または today、yesterday、3 days ago、last monday などの表現を解析する
+// 相対的な表現は now の日付を基準にする
+func parseDateExpr(s string, now time.Time) (time.Time, error) {
+	today := startOfDay(now)
+	if t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(s), now.Location()); err == nil {
+		return t, nil
+	}
+
+	expr := normalizeDateExpr(s)
+	if n, ok := relativeDays[expr]; ok {
+		return today.AddDate(0, 0, n), nil
+	}
+
+	words := strings.Fields(expr)
+	switch {
+	// 「3 days ago」「2 weeks ago」
+	case len(words) == 3 && words[2] == "ago":
+		n, err := strconv.Atoi(words[0])
+		unit, ok := dateUnit(words[1])
+		if err == nil && ok && n >= 0 {
+			return addDateUnit(today, unit, -n), nil
+		}
+
+	// 「last monday」「this friday」「next tue」
+	case len(words) == 2:
+		day, ok := lookupWeekday(words[1])
+		if !ok {
+			break
+		}
+		diff := int(day) - int(today.Weekday())
+		switch words[0] {
+		case "last":
+			// 今日より前で直近の同じ曜日
+			if diff >= 0 {
+				diff -= 7
+			}
+			return today.AddDate(0, 0, diff), nil
+		case "this":
+			// 今週の同じ曜日
+			return startOfWeek(today).AddDate(0, 0, (int(day)-int(weekStart)+7)%7), nil
+		case "next":
+			// 今日より後で直近の同じ曜日
+			if diff <= 0 {
+				diff += 7
+			}
+			return today.AddDate(0, 0, diff), nil
+		}
+
+	// 「monday」（今日より前で直近の同じ曜日、今日がその曜日の場合は今日）
+	case len(words) == 1:
+		if day, ok := lookupWeekday(words[0]); ok {
+			diff := int(day) - int(today.Weekday())
+			if diff > 0 {
+				diff -= 7
+			}
+			return today.AddDate(0, 0, diff), nil
+		}
+	}
+	return time.Time{}, errors.New(printer.Sprintf("日付 '%s' を解析できません（YYYY-MM-DD、today、yesterday、3 days ago、last monday などの形式で指定してください）", s))
+}
+
+// parsePeriodExpr は past 30 days、last week、this month などの期間の表現を解析し、開始日と終了日を返す
+// 「開始..終了」の形式では、それぞれを parseDateExpr で解析する
+func parsePeriodExpr(s string, now time.Time) (time.Time, time.Time, error) {
+	today := startOfDay(now)
+	if from, to, ok := strings.Cut(s, ".."); ok {
+		start, err := parseDateExpr(from, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end, err := parseDateExpr(to, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return start, end, nil
+	}
+
+	expr := normalizeDateExpr(s)
+	if n, ok := relativeDays[expr]; ok {
+		day := today.AddDate(0, 0, n)
+		return day, day, nil
+	}
+
+	words := strings.Fields(expr)
+	switch {
+	// 「past 30 days」「last 2 weeks」（今日までの期間）
+	case len(words) == 3 && (words[0] == "past" || words[0] == "last"):
+		n, err := strconv.Atoi(words[1])
+		unit, ok := dateUnit(words[2])
+		if err == nil && ok && n > 0 {
+			return addDateUnit(today, unit, -n).AddDate(0, 0, 1), today, nil
+		}
+
+	// 「this week」「last month」「next year」など（暦の上での期間全体）
+	case len(words) == 2 && (words[0] == "this" || words[0] == "last" || words[0] == "next"):
+		unit, ok := dateUnit(words[1])
+		if !ok || unit == "day" {
+			break
+		}
+		var start time.Time
+		switch unit {
+		case "week":
+			start = startOfWeek(today)
+		case "month":
+			start = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+		case "year":
+			start = time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+		}
+		switch words[0] {
+		case "last":
+			start = addDateUnit(start, unit, -1)
+		case "next":
+			start = addDateUnit(start, unit, 1)
+		}
+		return start, addDateUnit(start, unit, 1).AddDate(0, 0, -1), nil
+	}
+	return time.Time{}, time.Time{}, errors.New(printer.Sprintf("期間 '%s' を解析できません（past 30 days、last week、this month、開始..終了 などの形式で指定してください）", s))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// DeductionRule は休憩などを合計時間から差し引くルールを表す
+//
+//	{"type": "long_event", "longer_than": "6h", "deduct": "1h"}  6時間を超えるイベントから1時間を差し引く
+//	{"type": "event", "name": "Lunch"}                           イベント内に含まれる「Lunch」の時間を差し引く
+type DeductionRule struct {
+	Type       string `json:"type"`
+	LongerThan string `json:"longer_than,omitempty"`
+	Deduct     string `json:"deduct,omitempty"`
+	Name       string `json:"name,omitempty"`
+
+	longerThan time.Duration
+	deduct     time.Duration
+}
+
+// parse はルールの内容を検証し、時間の指定を解析する
+func (r *DeductionRule) parse() error {
+	switch r.Type {
+	case "long_event":
+		var err error
+		if r.longerThan, err = time.ParseDuration(r.LongerThan); err != nil {
+			return errors.New(printer.Sprintf("控除ルールの longer_than '%s' が不正です: %v", r.LongerThan, err))
+		}
+		if r.deduct, err = time.ParseDuration(r.Deduct); err != nil {
+			return errors.New(printer.Sprintf("控除ルールの deduct '%s' が不正です: %v", r.Deduct, err))
+		}
+	case "event":
+		if r.Name == "" {
+			return errors.New(printer.Sprintf("控除ルール（type: event）には name を指定してください"))
+		}
+	default:
+		return errors.New(printer.Sprintf("控除ルールの type '%s' はサポートされていません（long_event, event）", r.Type))
+	}
+	return nil
+}
+
+// deductionFor はイベントの集計対象の時間（start～end、長さduration）から差し引く時間を求める
+func deductionFor(rules []DeductionRule, self *calendar.Event, start, end time.Time, duration time.Duration, items []*calendar.Event) time.Duration {
+	var deducted time.Duration
+	for _, rule := range rules {
+		switch rule.Type {
+		case "long_event":
+			if duration > rule.longerThan {
+				deducted += rule.deduct
+			}
+		case "event":
+			// イベントの時間内に含まれる休憩イベントの時間を差し引く
+			for _, item := range items {
+				if item == self || !strings.EqualFold(item.Summary, rule.Name) {
+					continue
+				}
+				breakStart, breakEnd, ok := timedEventTimes(item, time.UTC)
+				if !ok {
+					continue
+				}
+				s := later(start, breakStart)
+				e := earlier(end, breakEnd)
+				if s.Before(e) {
+					deducted += e.Sub(s)
+				}
+			}
+		}
+	}
+	return min(deducted, duration)
+}
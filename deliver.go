@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// デフォルトのSMTPサーバーのポート（STARTTLSで送信する）
+const defaultSMTPPort = 587
+
+// DeliveryConfig は集計結果の配信先の設定
+type DeliveryConfig struct {
+	// 集計結果をJSON形式でPOSTするURL
+	Webhook string `json:"webhook,omitempty"`
+
+	// 集計結果を送信するメールの設定
+	Email *EmailConfig `json:"email,omitempty"`
+}
+
+// EmailConfig はメールによる配信の設定
+type EmailConfig struct {
+	To       []string `json:"to,omitempty"`
+	From     string   `json:"from,omitempty"`
+	SMTPHost string   `json:"smtp_host,omitempty"`
+	SMTPPort int      `json:"smtp_port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+}
+
+// deliverWebhook は集計結果をJSON形式でWebhookにPOSTする
+func deliverWebhook(ctx context.Context, url string, r *Report) error {
+	return postJSON(ctx, url, newAPIReport(r))
+}
+
+// reportEmail は集計結果を本文（テキスト形式）と添付ファイル（JSON形式）にしたメールを作成する
+func reportEmail(from string, to []string, r *Report) ([]byte, error) {
+	var text bytes.Buffer
+	printText(&text, r)
+	data, err := json.MarshalIndent(newAPIReport(r), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(text.Bytes())
+	part, err = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":        {"application/json; charset=utf-8"},
+		"Content-Disposition": {`attachment; filename="report.json"`},
+	})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(data)
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	subject := printer.Sprintf("イベント「%s」の集計（%s から %s）", r.Name, r.StartDate.Format("2006/01/02"), r.EndDate.Format("2006/01/02"))
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// deliverEmail は集計結果をSMTPでメール送信する。一時的なエラーの場合は再試行する
+func deliverEmail(ctx context.Context, c *EmailConfig, to []string, r *Report) error {
+	if c == nil || c.SMTPHost == "" {
+		return errors.New(printer.Sprintf("設定ファイルの delivery.email.smtp_host を指定してください"))
+	}
+	from := c.From
+	if from == "" {
+		from = c.Username
+	}
+	msg, err := reportEmail(from, to, r)
+	if err != nil {
+		return err
+	}
+	port := c.SMTPPort
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.SMTPHost)
+	}
+	addr := net.JoinHostPort(c.SMTPHost, strconv.Itoa(port))
+	return withRetry(ctx, func() error {
+		return smtp.SendMail(addr, auth, from, to, msg)
+	})
+}
+
+// splitList はカンマ区切りの文字列を空白を除いて分割する
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
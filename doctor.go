@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// トークンに付与されたスコープを確認するエンドポイント
+const tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// 診断結果の状態
+const (
+	doctorOK = iota
+	doctorWarn
+	doctorFail
+)
+
+// 診断結果の状態ごとの表示
+var doctorMarks = map[int]string{
+	doctorOK:   "✓",
+	doctorWarn: "!",
+	doctorFail: "✗",
+}
+
+// doctor は設定と認証の診断を行い、結果を1項目ずつ表示する
+type doctor struct {
+	failed int
+	warned int
+}
+
+// report は診断結果を表示する。fix は問題がある場合の対処方法
+func (d *doctor) report(status int, name, detail, fix string) {
+	switch status {
+	case doctorWarn:
+		d.warned++
+	case doctorFail:
+		d.failed++
+	}
+	fmt.Printf("%s %s: %s\n", doctorMarks[status], translate(name), detail)
+	if fix != "" {
+		printer.Printf("    対処方法: %s\n", fix)
+	}
+}
+
+// credentialsFile は credentials.json のクライアントの設定
+type credentialsFile struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	AuthURI      string `json:"auth_uri"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// checkCredentials は credentials.json の構造を確認し、OAuth2の設定を返す（問題がある場合はnil）
+func (d *doctor) checkCredentials(path string) *oauth2.Config {
+	const name = "認証情報ファイル"
+	switch oauthClientSource(path) {
+	case "option":
+		if oauthClient.secret == "" {
+			d.report(doctorWarn, name, printer.Sprintf("指定されたOAuthクライアント（クライアントID: %s）にシークレットがありません", oauthClient.id),
+				printer.Sprintf("-client-secret または環境変数 GCAL_SUM_CLIENT_SECRET でクライアントのシークレットを指定してください"))
+		} else {
+			d.report(doctorOK, name, printer.Sprintf("フラグ・環境変数・設定ファイルで指定したOAuthクライアント（クライアントID: %s）", oauthClient.id), "")
+		}
+		config, _ := oauthConfig(path, calendar.CalendarReadonlyScope)
+		return config
+	case "embedded":
+		d.report(doctorOK, name, printer.Sprintf("組み込みのOAuthクライアント（クライアントID: %s）", defaultClientID), "")
+		config, _ := oauthConfig(path, calendar.CalendarReadonlyScope)
+		return config
+	}
+	downloadFix := printer.Sprintf("Google Cloud Consoleの「APIとサービス」→「認証情報」で種類が「デスクトップアプリ」のOAuthクライアントIDを作成し、JSONを %s に保存してください", path)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		d.report(doctorFail, name, printer.Sprintf("%s を読み込めません: %v", path, err), downloadFix)
+		return nil
+	}
+	var file struct {
+		Installed *credentialsFile `json:"installed"`
+		Web       *credentialsFile `json:"web"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		d.report(doctorFail, name, printer.Sprintf("JSONの形式が正しくありません: %v", err), downloadFix)
+		return nil
+	}
+	c := file.Installed
+	if c == nil {
+		c = file.Web
+	}
+	if c == nil {
+		d.report(doctorFail, name, printer.Sprintf("OAuthクライアントの設定（installed または web）がありません"),
+			printer.Sprintf("サービスアカウントのキーやAPIキーではなく、OAuthクライアントIDのJSONを使用してください"))
+		return nil
+	}
+	var missing []string
+	for _, f := range []struct{ key, value string }{
+		{"client_id", c.ClientID}, {"client_secret", c.ClientSecret}, {"auth_uri", c.AuthURI}, {"token_uri", c.TokenURI},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.key)
+		}
+	}
+	if len(missing) > 0 {
+		d.report(doctorFail, name, printer.Sprintf("%s がありません", strings.Join(missing, ", ")), downloadFix)
+		return nil
+	}
+	config, err := google.ConfigFromJSON(b, calendar.CalendarReadonlyScope)
+	if err != nil {
+		d.report(doctorFail, name, printer.Sprintf("OAuth2の設定に失敗しました: %v", err), downloadFix)
+		return nil
+	}
+	// 認証時は 127.0.0.1 の空いているポートをリダイレクト先にするため、任意のポートを使用できるデスクトップアプリの種類が必要になる
+	if file.Installed == nil {
+		d.report(doctorFail, name, printer.Sprintf("%s（クライアントID: %s、種類: ウェブアプリケーション）", path, c.ClientID),
+			printer.Sprintf("認証時のリダイレクト先のポートは毎回変わるため、種類が「デスクトップアプリ」のOAuthクライアントIDを使用してください"))
+		return nil
+	}
+	d.report(doctorOK, name, printer.Sprintf("%s（クライアントID: %s）", path, c.ClientID), "")
+	return config
+}
+
+// checkPort は認証時にリダイレクトを受け取るローカルサーバーを、ループバックアドレスで起動できるかを確認する
+func (d *doctor) checkPort() {
+	const name = "認証用のポート"
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		d.report(doctorFail, name, printer.Sprintf("127.0.0.1 で待機できません: %v", err),
+			printer.Sprintf("ループバックアドレスでの待機を妨げているファイアウォールなどの設定を確認してください"))
+		return
+	}
+	l.Close()
+	d.report(doctorOK, name, printer.Sprintf("127.0.0.1 の空いているポートで待機できます"), "")
+}
+
+// checkToken はトークンファイルを確認し、読み込んだトークンを返す（問題がある場合はnil）
+func (d *doctor) checkToken(path string) *oauth2.Token {
+	const name = "トークン"
+	authFix := printer.Sprintf("gcal-sum を実行して、ブラウザで認証をやり直してください")
+	tok, err := tokenFromFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		d.report(doctorWarn, name, printer.Sprintf("%s がありません（まだ認証していません）", path), authFix)
+		return nil
+	}
+	if err != nil {
+		d.report(doctorFail, name, printer.Sprintf("%s を読み込めません: %v", path, err),
+			printer.Sprintf("%s を削除してから、gcal-sum を実行して認証をやり直してください", path))
+		return nil
+	}
+	if tok.RefreshToken == "" {
+		d.report(doctorWarn, name, printer.Sprintf("%s にリフレッシュトークンがありません", path),
+			printer.Sprintf("アクセストークンの期限が切れるたびに再認証が必要になります。%s を削除して認証をやり直してください", path))
+		return tok
+	}
+	if tok.Expiry.IsZero() {
+		d.report(doctorOK, name, path, "")
+	} else if tok.Expiry.Before(time.Now()) {
+		d.report(doctorOK, name, printer.Sprintf("%s（アクセストークンは期限切れ、次回の実行時に更新します）", path), "")
+	} else {
+		d.report(doctorOK, name, printer.Sprintf("%s（アクセストークンの有効期限: %s）", path, tok.Expiry.Local().Format("2006-01-02 15:04")), "")
+	}
+	return tok
+}
+
+// refreshToken はトークンを更新できるかを確認し、有効なトークンを返す（問題がある場合はnil）
+// 診断では設定を変更しないため、更新したトークンはファイルに保存しない
+func (d *doctor) refreshToken(ctx context.Context, config *oauth2.Config, tok *oauth2.Token, path string) *oauth2.Token {
+	const name = "トークンの更新"
+	if tok.Valid() && tok.Expiry.After(time.Now().Add(tokenRefreshMargin)) {
+		return tok
+	}
+	newToken, err := config.TokenSource(ctx, tok).Token()
+	if err != nil {
+		var re *oauth2.RetrieveError
+		switch {
+		case isInvalidGrant(err):
+			d.report(doctorFail, name, printer.Sprintf("リフレッシュトークンが失効しています"),
+				printer.Sprintf("%s を削除してから、gcal-sum を実行して認証をやり直してください（テスト中のOAuth同意画面では、トークンは7日で失効します）", path))
+		case errors.As(err, &re) && re.ErrorCode == "invalid_client":
+			d.report(doctorFail, name, printer.Sprintf("クライアントIDまたはクライアントシークレットが正しくありません"),
+				printer.Sprintf("トークンを発行したOAuthクライアントの credentials.json を使用するか、%s を削除して認証をやり直してください", path))
+		default:
+			d.report(doctorFail, name, contextError(ctx, err).Error(), printer.Sprintf("ネットワークの接続を確認してください"))
+		}
+		return nil
+	}
+	d.report(doctorOK, name, printer.Sprintf("リフレッシュトークンでアクセストークンを更新できました"), "")
+	return newToken
+}
+
+// checkScopes はアクセストークンにカレンダーを読み取るスコープが付与されているかを確認する
+func (d *doctor) checkScopes(ctx context.Context, tok *oauth2.Token) {
+	const name = "スコープ"
+	scopes, err := fetchGrantedScopes(ctx, tok.AccessToken)
+	if err != nil {
+		d.report(doctorWarn, name, printer.Sprintf("スコープを確認できません: %v", contextError(ctx, err)), printer.Sprintf("ネットワークの接続を確認してください"))
+		return
+	}
+	if !hasScope(scopes, calendar.CalendarReadonlyScope) {
+		d.report(doctorFail, name, printer.Sprintf("カレンダーを読み取るスコープがありません（%s）", strings.Join(scopes, " ")),
+			printer.Sprintf("gcal-sum auth scopes -reconsent を実行して、ブラウザで許可をやり直してください"))
+		return
+	}
+	d.report(doctorOK, name, strings.Join(scopes, " "), "")
+}
+
+// checkAPI はCalendar APIに接続でき、設定したカレンダーにアクセスできるかを確認する
+func (d *doctor) checkAPI(ctx context.Context, config *oauth2.Config, tok *oauth2.Token, calendarID string) {
+	const name = "Calendar API"
+	srv, err := calendar.NewService(ctx, option.WithHTTPClient(config.Client(ctx, tok)))
+	if err != nil {
+		d.report(doctorFail, name, err.Error(), "")
+		return
+	}
+	list, err := srv.CalendarList.List().MaxResults(1).Context(ctx).Do()
+	if err != nil {
+		fix := printer.Sprintf("ネットワークの接続を確認してください")
+		if strings.Contains(err.Error(), "accessNotConfigured") || strings.Contains(err.Error(), "SERVICE_DISABLED") {
+			fix = printer.Sprintf("Google Cloud ConsoleでGoogle Calendar APIを有効にしてください")
+		}
+		d.report(doctorFail, name, contextError(ctx, err).Error(), fix)
+		return
+	}
+	d.report(doctorOK, name, printer.Sprintf("カレンダー一覧を取得できました（%d件）", len(list.Items)), "")
+
+	if _, err := srv.Calendars.Get(calendarID).Context(ctx).Do(); err != nil {
+		d.report(doctorFail, "カレンダー", printer.Sprintf("'%s' にアクセスできません: %v", calendarID, contextError(ctx, err)),
+			printer.Sprintf("gcal-sum -list で利用可能なカレンダーのIDを確認し、設定ファイルの calendar を修正してください"))
+		return
+	}
+	d.report(doctorOK, "カレンダー", calendarID, "")
+}
+
+// checkDir はディレクトリに書き込めるかを確認する
+func (d *doctor) checkDir(name, dir string) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		d.report(doctorFail, name, printer.Sprintf("%s を作成できません: %v", dir, err), printer.Sprintf("設定ファイルで書き込み可能なディレクトリを指定してください"))
+		return
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		d.report(doctorFail, name, printer.Sprintf("%s に書き込めません: %v", dir, err), printer.Sprintf("設定ファイルで書き込み可能なディレクトリを指定してください"))
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	d.report(doctorOK, name, dir, "")
+}
+
+// runDoctor は設定ファイル、認証情報、トークン、APIへの接続を診断し、問題がある場合は対処方法を表示する
+// 設定ファイルの問題も診断できるように、設定ファイルの読み込み前に実行する
+func runDoctor(appDir, configPath string, args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	offline := fs.Bool("offline", false, "ネットワークを使用する確認（トークンの更新、スコープ、APIへの接続）を行わない")
+	timeout := fs.Duration("timeout", 30*time.Second, "ネットワークを使用する確認のタイムアウト")
+	parseFlags(fs, args)
+
+	d := &doctor{}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		d.report(doctorFail, "設定ファイル", printer.Sprintf("%s を読み込めません: %v", configPath, err),
+			printer.Sprintf("JSONの形式を修正するか、gcal-sum init で設定ファイルを作成し直してください"))
+		cfg = &Config{}
+	} else if _, statErr := os.Stat(configPath); statErr != nil {
+		d.report(doctorOK, "設定ファイル", printer.Sprintf("%s がないため、デフォルトの設定を使用します", configPath), "")
+	} else {
+		d.report(doctorOK, "設定ファイル", configPath, "")
+	}
+	cfg.applyEnv()
+	cfg.applyDefaults(appDir)
+	if err := setTokenEncryption(cfg); err != nil {
+		d.report(doctorFail, "トークンの暗号化", err.Error(),
+			printer.Sprintf("設定ファイルの token_encryption に env または keyring を指定してください"))
+	}
+	setOAuthClient(cfg)
+
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		d.report(doctorFail, "タイムゾーン", printer.Sprintf("'%s' を読み込めません: %v", cfg.Timezone, err),
+			printer.Sprintf("設定ファイルの timezone に Asia/Tokyo のようなIANAのタイムゾーン名を指定してください"))
+	} else {
+		d.report(doctorOK, "タイムゾーン", cfg.Timezone, "")
+	}
+	d.checkDir("キャッシュ", cfg.CacheDir)
+
+	config := d.checkCredentials(cfg.CredentialsPath)
+	d.checkPort()
+	tok := d.checkToken(cfg.TokenPath)
+
+	if *offline {
+		printer.Printf("（-offline のため、ネットワークを使用する確認は行いません）\n")
+	} else if config != nil && tok != nil {
+		ctx, cancel := newCommandContext(*timeout)
+		defer cancel()
+		if tok = d.refreshToken(ctx, config, tok, cfg.TokenPath); tok != nil {
+			d.checkScopes(ctx, tok)
+			d.checkAPI(ctx, config, tok, cfg.Calendar)
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case d.failed > 0:
+		printer.Printf("%d件の問題が見つかりました\n", d.failed)
+		exit(exitError)
+	case d.warned > 0:
+		printer.Printf("問題は見つかりませんでした（注意: %d件）\n", d.warned)
+	default:
+		printer.Printf("問題は見つかりませんでした\n")
+	}
+}
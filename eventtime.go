@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// オフセットのない日時の形式（他のカレンダーから取り込んだイベントなどで、タイムゾーンが timeZone に別に指定される）
+const localDateTimeLayout = "2006-01-02T15:04:05"
+
+// parseEventDateTime はイベントの日時（dateTime）を解析する
+// オフセットがない場合は、日時の timeZone、それもない場合は location のタイムゾーンの日時として扱う
+func parseEventDateTime(dt *calendar.EventDateTime, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+		return t, nil
+	}
+	loc := location
+	if dt.TimeZone != "" {
+		l, err := time.LoadLocation(dt.TimeZone)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = l
+	}
+	return time.ParseInLocation(localDateTimeLayout, dt.DateTime, loc)
+}
+
+// eventStartDateTime は開始日時として使用する日時を返す
+// 開始が日付だけで、originalStartTime に日時がある場合（他のカレンダーから取り込んだイベントなど）は originalStartTime を返す
+func eventStartDateTime(e *calendar.Event) *calendar.EventDateTime {
+	if e.Start.DateTime == "" && e.OriginalStartTime != nil && e.OriginalStartTime.DateTime != "" {
+		return e.OriginalStartTime
+	}
+	return e.Start
+}
+
+// resolveEventTimes はイベントの開始日時と終了日時を求め、終日イベントかどうかを返す
+//   - 開始・終了の dateTime がある場合は、その日時を使用する
+//   - 開始が日付だけで originalStartTime に日時があり、終了の dateTime がある場合は、originalStartTime から終了までとする
+//   - 終了の時刻がわからない場合と、日付だけの場合は終日イベントとして、location のタイムゾーンの0時から終了日の0時までとする
+func resolveEventTimes(e *calendar.Event, location *time.Location) (start, end time.Time, allDay bool, err error) {
+	if e.Start == nil || e.End == nil {
+		return start, end, false, errors.New(printer.Sprintf("start または end がありません"))
+	}
+	startDT := eventStartDateTime(e)
+	if startDT.DateTime != "" && (e.End.DateTime != "" || startDT == e.Start) {
+		if start, err = parseEventDateTime(startDT, location); err != nil {
+			return start, end, false, fmt.Errorf("%s: %w", printer.Sprintf("開始日時の解析に失敗しました"), err)
+		}
+		if e.End.DateTime != "" {
+			end, err = parseEventDateTime(e.End, location)
+		} else {
+			// 終了が日付だけの場合は、終了日の0時までとする
+			end, err = time.ParseInLocation("2006-01-02", e.End.Date, location)
+		}
+		if err != nil {
+			return start, end, false, fmt.Errorf("%s: %w", printer.Sprintf("終了日時の解析に失敗しました"), err)
+		}
+		if end.Before(start) {
+			return start, end, false, errors.New(printer.Sprintf("終了日時が開始日時より前になっています"))
+		}
+		return start, end, false, nil
+	}
+
+	if start, err = time.ParseInLocation("2006-01-02", e.Start.Date, location); err != nil {
+		return start, end, true, fmt.Errorf("%s: %w", printer.Sprintf("開始日の解析に失敗しました"), err)
+	}
+	if e.End.Date == "" {
+		return start, start.AddDate(0, 0, 1), true, nil
+	}
+	if end, err = time.ParseInLocation("2006-01-02", e.End.Date, location); err != nil {
+		return start, end, true, fmt.Errorf("%s: %w", printer.Sprintf("終了日の解析に失敗しました"), err)
+	}
+	return start, end, true, nil
+}
+
+// timedEventTimes は時間指定のイベントの開始日時と終了日時を返す。終日イベントや日時を解析できないイベントの場合は ok を false にする
+func timedEventTimes(e *calendar.Event, location *time.Location) (start, end time.Time, ok bool) {
+	start, end, allDay, err := resolveEventTimes(e, location)
+	return start, end, err == nil && !allDay
+}
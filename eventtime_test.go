@@ -0,0 +1,172 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestResolveEventTimes(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		event     *calendar.Event
+		wantStart time.Time
+		wantEnd   time.Time
+		allDay    bool
+		wantErr   bool
+	}{
+		{
+			name: "オフセットのある日時",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00+09:00"},
+				End:   &calendar.EventDateTime{DateTime: "2024-04-01T11:30:00+09:00"},
+			},
+			wantStart: time.Date(2024, 4, 1, 10, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2024, 4, 1, 11, 30, 0, 0, tokyo),
+		},
+		{
+			name: "オフセットのない日時と timeZone",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00", TimeZone: "America/New_York"},
+				End:   &calendar.EventDateTime{DateTime: "2024-04-01T11:00:00", TimeZone: "America/New_York"},
+			},
+			wantStart: time.Date(2024, 4, 1, 10, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2024, 4, 1, 11, 0, 0, 0, newYork),
+		},
+		{
+			name: "オフセットも timeZone もない日時",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00"},
+				End:   &calendar.EventDateTime{DateTime: "2024-04-01T11:00:00"},
+			},
+			wantStart: time.Date(2024, 4, 1, 10, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2024, 4, 1, 11, 0, 0, 0, tokyo),
+		},
+		{
+			name: "終日イベント",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{Date: "2024-04-01"},
+				End:   &calendar.EventDateTime{Date: "2024-04-03"},
+			},
+			wantStart: time.Date(2024, 4, 1, 0, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2024, 4, 3, 0, 0, 0, 0, tokyo),
+			allDay:    true,
+		},
+		{
+			name: "終了日のない終日イベント",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{Date: "2024-04-01"},
+				End:   &calendar.EventDateTime{},
+			},
+			wantStart: time.Date(2024, 4, 1, 0, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2024, 4, 2, 0, 0, 0, 0, tokyo),
+			allDay:    true,
+		},
+		{
+			name: "開始が日付だけで originalStartTime に日時がある",
+			event: &calendar.Event{
+				Start:             &calendar.EventDateTime{Date: "2024-04-01"},
+				End:               &calendar.EventDateTime{DateTime: "2024-04-01T15:00:00+09:00"},
+				OriginalStartTime: &calendar.EventDateTime{DateTime: "2024-04-01T14:00:00+09:00"},
+			},
+			wantStart: time.Date(2024, 4, 1, 14, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2024, 4, 1, 15, 0, 0, 0, tokyo),
+		},
+		{
+			name: "originalStartTime があっても終了が日付だけの場合は終日イベント",
+			event: &calendar.Event{
+				Start:             &calendar.EventDateTime{Date: "2024-04-01"},
+				End:               &calendar.EventDateTime{Date: "2024-04-02"},
+				OriginalStartTime: &calendar.EventDateTime{DateTime: "2024-04-01T14:00:00+09:00"},
+			},
+			wantStart: time.Date(2024, 4, 1, 0, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2024, 4, 2, 0, 0, 0, 0, tokyo),
+			allDay:    true,
+		},
+		{
+			name: "終了がない",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00+09:00"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "キャンセルされた繰り返しの回",
+			event: &calendar.Event{
+				Status:            "cancelled",
+				RecurringEventId:  "series",
+				OriginalStartTime: &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00+09:00"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "終了が開始より前",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2024-04-01T11:00:00+09:00"},
+				End:   &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00+09:00"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "不明な timeZone",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00", TimeZone: "Invalid/Zone"},
+				End:   &calendar.EventDateTime{DateTime: "2024-04-01T11:00:00", TimeZone: "Invalid/Zone"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, allDay, err := resolveEventTimes(tt.event, tokyo)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("エラーになりませんでした: start=%v end=%v", start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("start, end = %v, %v; want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+			if allDay != tt.allDay {
+				t.Errorf("allDay = %v; want %v", allDay, tt.allDay)
+			}
+		})
+	}
+}
+
+func TestTimedEventTimes(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	allDay := &calendar.Event{
+		Start: &calendar.EventDateTime{Date: "2024-04-01"},
+		End:   &calendar.EventDateTime{Date: "2024-04-02"},
+	}
+	if _, _, ok := timedEventTimes(allDay, tokyo); ok {
+		t.Error("終日イベントで ok が true になりました")
+	}
+	timed := &calendar.Event{
+		Start: &calendar.EventDateTime{DateTime: "2024-04-01T10:00:00+09:00"},
+		End:   &calendar.EventDateTime{DateTime: "2024-04-01T11:00:00+09:00"},
+	}
+	start, end, ok := timedEventTimes(timed, tokyo)
+	if !ok || end.Sub(start) != time.Hour {
+		t.Errorf("timedEventTimes = %v, %v, %v; want 1時間, true", start, end, ok)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// ExcludedEvent は設定ファイルの exclude_events で、集計から常に除外するイベントを表す
+type ExcludedEvent struct {
+	// イベントのID（繰り返しイベントのIDを指定した場合はすべての回を除外する）
+	ID string `json:"id"`
+
+	// 除外する集計の名前（集計結果の「イベント 'X'」の X と一致する場合だけ除外する）。空の場合はすべての集計で除外する
+	Query string `json:"query,omitempty"`
+
+	// 除外する理由などのメモ
+	Note string `json:"note,omitempty"`
+}
+
+// idList は -exclude-id のように、繰り返し指定できるIDのリストのフラグ（カンマ区切りでも指定できる）
+type idList []string
+
+func (l *idList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *idList) Set(s string) error {
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			*l = append(*l, id)
+		}
+	}
+	return nil
+}
+
+// buildExcludeSet は -exclude-id と、設定ファイルの除外リストのうちこの集計に当てはまるものから、除外するIDの集合を作る
+func (o *queryOptions) buildExcludeSet() {
+	o.excludeSet = make(map[string]bool)
+	for _, id := range o.excludeIDs {
+		o.excludeSet[id] = true
+	}
+	if o.noExcludeList {
+		return
+	}
+	label := o.label()
+	for _, e := range o.excludeList {
+		if e.ID != "" && (e.Query == "" || e.Query == label) {
+			o.excludeSet[e.ID] = true
+		}
+	}
+}
+
+// isExcludedID はイベントが（または繰り返しイベントのすべての回が）除外するIDに含まれるかを判定する
+func (o *queryOptions) isExcludedID(item *calendar.Event) bool {
+	if len(o.excludeSet) == 0 {
+		return false
+	}
+	return o.excludeSet[item.Id] || (item.RecurringEventId != "" && o.excludeSet[item.RecurringEventId])
+}
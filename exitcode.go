@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// 終了コード（シェルスクリプトから結果に応じて処理を分けられるようにする）
+const (
+	exitOK          = 0   // 正常終了
+	exitError       = 1   // その他のエラー（ファイルの読み書きなど）
+	exitUsage       = 2   // オプションの指定が不正
+	exitAuth        = 3   // 認証の失敗
+	exitAPI         = 4   // APIの呼び出しの失敗（割り当て上限を含む）
+	exitNoMatch     = 5   // 一致するイベントがない
+	exitTimeout     = 124 // -timeout で指定した時間の経過
+	exitInterrupted = 130 // Ctrl-C などによる中断
+)
+
+// exitCodeFor はエラーの種類に対応する終了コードを返す
+func exitCodeFor(err error) int {
+	var apiErr *googleapi.Error
+	var retrieveErr *oauth2.RetrieveError
+	switch {
+	case errors.Is(err, errInterrupted):
+		return exitInterrupted
+	case errors.Is(err, errTimeout):
+		return exitTimeout
+	case errors.As(err, &retrieveErr):
+		return exitAuth
+	case errors.As(err, &apiErr):
+		if apiErr.Code == http.StatusUnauthorized {
+			return exitAuth
+		}
+		return exitAPI
+	}
+	return exitError
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// exportTargets は export で指定できる出力先
+var exportTargets = []string{"sheets", "toggl", "harvest", "jira", "calendar", "pdf"}
+
+// printExportUsage はexportコマンドの使用方法を表示する
+func printExportUsage() {
+	printer.Printf("使用方法: gcal-sum export <出力先> [オプション]\n")
+	printer.Printf("出力先:\n")
+	printer.Printf("  sheets   Google スプレッドシートに集計結果を追記する\n")
+	printer.Printf("  toggl    一致したイベントをToggl TrackのCSVインポート形式で出力する\n")
+	printer.Printf("  harvest  一致したイベントをHarvestのCSVインポート形式で出力する\n")
+	printer.Printf("  jira     一致したイベントをJiraの課題の作業ログとして登録する\n")
+	printer.Printf("  calendar 集計結果をカレンダーに終日イベントとして書き込む\n")
+	printer.Printf("  pdf      一致したイベントから署名欄付きの作業報告書をPDFで出力する\n")
+}
+
+// registerExportFlags は出力先ごとの export のフラグを登録する（補完の候補に使用する）
+func registerExportFlags(fs *flag.FlagSet, cfg *Config, target string) {
+	switch target {
+	case "sheets":
+		registerExportSheetsFlags(fs, cfg)
+	case "toggl", "harvest":
+		registerExportTimeEntriesFlags(fs, cfg, target)
+	case "jira":
+		registerExportJiraFlags(fs, cfg)
+	case "calendar":
+		registerExportCalendarFlags(fs, cfg)
+	case "pdf":
+		registerExportPDFFlags(fs, cfg)
+	}
+}
+
+// runExportCommand は集計結果を外部サービスに出力するサブコマンドを実行する
+func runExportCommand(cfg *Config, args []string) {
+	if len(args) == 0 {
+		printer.Printf("エラー: 出力先を指定してください。\n")
+		printExportUsage()
+		os.Exit(exitUsage)
+	}
+
+	switch args[0] {
+	case "sheets":
+		runExportSheets(cfg, args[1:])
+	case "toggl", "harvest":
+		runExportTimeEntries(cfg, args[0], args[1:])
+	case "jira":
+		runExportJira(cfg, args[1:])
+	case "calendar":
+		runExportCalendar(cfg, args[1:])
+	case "pdf":
+		runExportPDF(cfg, args[1:])
+	default:
+		printer.Printf("エラー: 出力先 '%s' はサポートされていません。\n", args[0])
+		printExportUsage()
+		os.Exit(exitUsage)
+	}
+}
+
+// summaryRows は集計結果をスプレッドシートに追記する行に変換する
+func summaryRows(r *Report) [][]interface{} {
+	start := r.StartDate.Format("2006-01-02")
+	end := r.EndDate.Format("2006-01-02")
+
+	// 時間は小数表記にしてスプレッドシートで計算しやすくする
+	if len(r.Groups) == 0 {
+		return [][]interface{}{
+			{start, end, r.Name, "", r.count(), r.Total.Hours()},
+		}
+	}
+	rows := make([][]interface{}, 0, len(r.Groups))
+	for _, g := range r.Groups {
+		rows = append(rows, []interface{}{start, end, r.Name, g.Key, g.Count, g.Duration.Hours()})
+	}
+	return rows
+}
+
+// exportSheetsFlags は export sheets のフラグ
+type exportSheetsFlags struct {
+	opts          *queryOptions
+	spreadsheetID string
+	sheetName     string
+}
+
+// registerExportSheetsFlags は export sheets のフラグを登録する（補完の候補にも使用する）
+func registerExportSheetsFlags(fs *flag.FlagSet, cfg *Config) *exportSheetsFlags {
+	f := &exportSheetsFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.StringVar(&f.spreadsheetID, "spreadsheet", "", "追記先のスプレッドシートID")
+	fs.StringVar(&f.sheetName, "sheet", "Sheet1", "追記先のシート名")
+	return f
+}
+
+// runExportSheets は集計結果をGoogle スプレッドシートに追記する
+func runExportSheets(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("export sheets", flag.ExitOnError)
+	flags := registerExportSheetsFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	if flags.spreadsheetID == "" {
+		printer.Printf("エラー: スプレッドシートIDを指定してください。\n")
+		printer.Printf("使用方法: gcal-sum export sheets -spreadsheet=スプレッドシートID [-sheet=シート名] -month=YYYY-MM -name=イベント名\n")
+		os.Exit(exitUsage)
+	}
+	opts.validate()
+
+	// スプレッドシートへの書き込みの権限がまだない場合は、ここで追加の許可を求める
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	client := newHTTPClient(ctx, cfg.CredentialsPath, cfg.TokenPath,
+		calendar.CalendarReadonlyScope, sheets.SpreadsheetsScope)
+
+	calendarSrv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		fatal("Calendar APIの初期化に失敗しました", "error", err)
+	}
+	sheetsSrv, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		fatal("Sheets APIの初期化に失敗しました", "error", err)
+	}
+
+	report := runQuery(ctx, &googleSource{srv: calendarSrv}, cfg, opts)
+	rows := summaryRows(report)
+
+	_, err = sheetsSrv.Spreadsheets.Values.Append(flags.spreadsheetID, flags.sheetName+"!A1", &sheets.ValueRange{Values: rows}).
+		ValueInputOption("USER_ENTERED").
+		InsertDataOption("INSERT_ROWS").
+		Context(ctx).
+		Do()
+	if err != nil {
+		fatalAPI("スプレッドシートへの書き込みに失敗しました", contextError(ctx, err))
+	}
+	printer.Printf("%d行をスプレッドシートのシート '%s' に追記しました\n", len(rows), flags.sheetName)
+}
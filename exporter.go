@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exporter のデフォルトの待ち受けポートと集計の間隔
+const (
+	defaultExporterPort     = 9091
+	defaultExporterInterval = 15 * time.Minute
+)
+
+// 利用可能な集計期間
+var metricPeriods = []string{"day", "week", "month"}
+
+// metricResult は1件のクエリの最新の集計結果
+type metricResult struct {
+	query     MetricQuery
+	total     time.Duration
+	count     int
+	ok        bool
+	refreshed time.Time
+}
+
+// exporter は設定されたクエリを定期的に集計し、Prometheusのメトリクスとして公開する
+type exporter struct {
+	server  *server
+	queries []MetricQuery
+
+	mu      sync.Mutex
+	results []metricResult
+}
+
+// periodRange は集計期間の開始日と終了日を求める（週は月曜日始まり）
+func periodRange(period string, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case "day":
+		return today, today
+	case "week":
+		start := startOfWeek(today)
+		return start, start.AddDate(0, 0, 6)
+	}
+	start := today.AddDate(0, 0, 1-today.Day())
+	return start, start.AddDate(0, 1, -1)
+}
+
+// validateMetrics は設定ファイルのクエリを検証する
+func validateMetrics(queries []MetricQuery) error {
+	if len(queries) == 0 {
+		return errors.New(printer.Sprintf("設定ファイルに metrics が設定されていません"))
+	}
+	seen := make(map[string]bool)
+	for _, q := range queries {
+		if q.Name == "" {
+			return errors.New(printer.Sprintf("metrics の name を指定してください"))
+		}
+		if seen[q.Name] {
+			return errors.New(printer.Sprintf("metrics の name '%s' が重複しています", q.Name))
+		}
+		seen[q.Name] = true
+		if q.Period != "" && !containsString(metricPeriods, q.Period) {
+			return errors.New(printer.Sprintf("metrics '%s' の期間 '%s' はサポートされていません（%s）", q.Name, q.Period, strings.Join(metricPeriods, ", ")))
+		}
+	}
+	return nil
+}
+
+// containsString はスライスに文字列が含まれるかどうかを判定する
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh はすべてのクエリを集計し直す。失敗したクエリは前回の値を残し、成功しなかったことを記録する
+func (e *exporter) refresh(ctx context.Context) {
+	location, err := time.LoadLocation(e.server.cfg.Timezone)
+	if err != nil {
+		slog.Error("タイムゾーンの読み込みに失敗しました", "error", err)
+		return
+	}
+	now := time.Now().In(location)
+
+	results := make([]metricResult, len(e.queries))
+	e.mu.Lock()
+	copy(results, e.results)
+	e.mu.Unlock()
+
+	for i, q := range e.queries {
+		results[i].query = q
+		params := url.Values{}
+		for k, v := range q.Query {
+			params.Set(k, v)
+		}
+		start, end := periodRange(q.Period, now)
+		params.Set("start", start.Format("2006-01-02"))
+		params.Set("end", end.Format("2006-01-02"))
+		params.Del("month")
+		params.Del("period")
+		params.Del("week")
+
+		opts, err := e.server.options(params)
+		var report *Report
+		if err == nil {
+			report, err = e.server.query(ctx, opts)
+		}
+		if err != nil {
+			slog.Warn("メトリクスの集計に失敗しました", "name", q.Name, "error", err)
+			results[i].ok = false
+			continue
+		}
+		results[i].total, results[i].count = report.Total, report.count()
+		results[i].ok, results[i].refreshed = true, time.Now()
+		slog.Debug("メトリクスを集計しました", "name", q.Name, "total", formatDuration(report.Total))
+	}
+
+	e.mu.Lock()
+	e.results = results
+	e.mu.Unlock()
+}
+
+// escapeLabel はPrometheusのラベルの値として使用できるように文字列をエスケープする
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeMetrics は最新の集計結果をPrometheusのテキスト形式で出力する
+func (e *exporter) writeMetrics(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	metrics := []struct {
+		name, help string
+		value      func(r metricResult) float64
+	}{
+		{"gcal_event_hours", "Total hours of matching calendar events in the period.", func(r metricResult) float64 { return r.total.Hours() }},
+		{"gcal_event_count", "Number of matching calendar events in the period.", func(r metricResult) float64 { return float64(r.count) }},
+		{"gcal_query_success", "Whether the last refresh of the query succeeded.", func(r metricResult) float64 {
+			if r.ok {
+				return 1
+			}
+			return 0
+		}},
+		{"gcal_query_last_success_timestamp_seconds", "Unix time of the last successful refresh of the query.", func(r metricResult) float64 {
+			if r.refreshed.IsZero() {
+				return 0
+			}
+			return float64(r.refreshed.Unix())
+		}},
+	}
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", m.name)
+		for _, r := range e.results {
+			period := r.query.Period
+			if period == "" {
+				period = "month"
+			}
+			fmt.Fprintf(w, "%s{name=\"%s\",period=\"%s\"} %s\n", m.name, escapeLabel(r.query.Name), period,
+				strconv.FormatFloat(m.value(r), 'f', -1, 64))
+		}
+	}
+}
+
+// handleMetrics はメトリクスを返す（GET /metrics）
+func (e *exporter) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.writeMetrics(w)
+}
+
+// exporterFlags は exporter のフラグ
+type exporterFlags struct {
+	opts     *queryOptions
+	port     int
+	host     string
+	interval time.Duration
+}
+
+// registerExporterFlags は exporter のフラグを登録する（補完の候補にも使用する）
+func registerExporterFlags(fs *flag.FlagSet, cfg *Config) *exporterFlags {
+	f := &exporterFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.IntVar(&f.port, "port", defaultExporterPort, "待ち受けるポート番号")
+	fs.StringVar(&f.host, "host", "127.0.0.1", "待ち受けるアドレス（Prometheusが別のホストにある場合は 0.0.0.0）")
+	fs.DurationVar(&f.interval, "interval", defaultExporterInterval, "クエリを集計し直す間隔")
+	return f
+}
+
+// runExporterCommand は設定されたクエリを定期的に集計し、Prometheusのメトリクスとして公開するサブコマンドを実行する
+func runExporterCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("exporter", flag.ExitOnError)
+	flags := registerExporterFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+	// 集計は繰り返し実行されるため、取得の進み具合は表示しない
+	progressEnabled = false
+
+	if err := validateMetrics(cfg.Metrics); err != nil {
+		fatalCode(exitUsage, "設定ファイルの metrics が不正です", "error", err)
+	}
+
+	// 起動時に指定した集計のオプションは、すべてのクエリの既定値として使用する
+	// キャッシュの有効期間は、指定がない限り集計の間隔に合わせて古い値を返さないようにする
+	base := append([]string{"-cache-ttl=" + flags.interval.String()}, baseQueryArgs(fs, cfg)...)
+
+	ctx, cancel := newCommandContext(0)
+	defer cancel()
+	e := &exporter{
+		server:  &server{cfg: cfg, src: opts.newSource(ctx, cfg), base: base},
+		queries: cfg.Metrics,
+	}
+	release := catchFatal()
+	defer release()
+
+	// 最初の集計を終えてから待ち受けを始め、以降は一定の間隔で集計し直す
+	e.refresh(ctx)
+	go func() {
+		ticker := time.NewTicker(flags.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.refresh(ctx)
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", e.handleMetrics)
+	addr := net.JoinHostPort(flags.host, strconv.Itoa(flags.port))
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	slog.Info("メトリクスの公開を開始しました", "url", "http://"+addr+"/metrics", "queries", len(e.queries), "interval", flags.interval)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		release()
+		fatal("サーバーの起動に失敗しました", "error", err)
+	}
+}
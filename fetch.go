@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// デフォルトの並行して取得する数
+const defaultConcurrency = 4
+
+// 集計に使用するイベントのフィールド（partial responseで必要なフィールドだけを取得してレスポンスを小さくする）
+const eventFields = "id,iCalUID,recurringEventId,etag,updated,summary,description,location,status,colorId,transparency,hangoutLink," +
+	"start,end,originalStartTime,organizer(email,displayName),attendees(email,displayName,self,resource,responseStatus)," +
+	"conferenceData(entryPoints(entryPointType,uri))"
+
+// イベント一覧の取得時に要求するフィールド
+const eventListFields googleapi.Field = "nextPageToken,nextSyncToken,items(" + eventFields + ")"
+
+// fetchTask は1つのカレンダーの1つの期間のイベントを取得する処理を表す
+type fetchTask struct {
+	calendarID string
+	start      time.Time
+	end        time.Time
+}
+
+// splitRange は長い期間を月ごとに分割した取得処理の一覧を返す
+func splitRange(calendarID string, start, end time.Time, location *time.Location) []fetchTask {
+	var tasks []fetchTask
+	for from := start; from.Before(end); {
+		local := from.In(location)
+		to := earlier(time.Date(local.Year(), local.Month()+1, 1, 0, 0, 0, 0, location), end)
+		tasks = append(tasks, fetchTask{calendarID: calendarID, start: from, end: to})
+		from = to
+	}
+	return tasks
+}
+
+// runFetchTasks は最大workers個の処理を並行に実行し、取得結果を処理の順に返す
+// いずれかの処理が失敗した場合は、コンテキストをキャンセルして残りの処理を中止し、最初のエラーを返す
+// エラーの場合も完了した処理の結果は返す（完了していない処理の結果はnil）
+// 処理の中で fatal がパニックを起こした場合は、残りの処理を中止して、すべての処理の完了後に呼び出し元でパニックを起こし直す
+func runFetchTasks(ctx context.Context, tasks []fetchTask, workers int, fetch func(context.Context, fetchTask) ([]*calendar.Event, error)) ([][]*calendar.Event, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]*calendar.Event, len(tasks))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	// 処理の中で fatal が起こしたパニック
+	var fatalOnce sync.Once
+	var fatalErr *fatalError
+	for i, t := range tasks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, t fetchTask) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer func() {
+				if v := recover(); v != nil {
+					fe, ok := v.(*fatalError)
+					if !ok {
+						panic(v)
+					}
+					fatalOnce.Do(func() {
+						fatalErr = fe
+						cancel()
+					})
+				}
+			}()
+			items, err := fetch(ctx, t)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			if items == nil {
+				items = []*calendar.Event{}
+			}
+			results[i] = items
+		}(i, t)
+	}
+	wg.Wait()
+	if fatalErr != nil {
+		panic(fatalErr)
+	}
+	if firstErr == nil && ctx.Err() != nil {
+		return results, ctx.Err()
+	}
+	return results, firstErr
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestRunFetchTasks(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tasks := splitRange("primary", start, start.AddDate(0, 3, 0), time.UTC)
+	results, err := runFetchTasks(context.Background(), tasks, 2, func(_ context.Context, task fetchTask) ([]*calendar.Event, error) {
+		return []*calendar.Event{{Id: task.start.Format("2006-01")}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"2024-01", "2024-02", "2024-03"} {
+		if len(results[i]) != 1 || results[i][0].Id != want {
+			t.Errorf("results[%d] = %v; want %s", i, results[i], want)
+		}
+	}
+
+	errFetch := errors.New("fetch failed")
+	_, err = runFetchTasks(context.Background(), tasks, 2, func(_ context.Context, task fetchTask) ([]*calendar.Event, error) {
+		if task.start.Month() == time.February {
+			return nil, errFetch
+		}
+		return nil, nil
+	})
+	if !errors.Is(err, errFetch) {
+		t.Errorf("err = %v; want %v", err, errFetch)
+	}
+}
+
+func TestRunFetchTasksFatal(t *testing.T) {
+	defer catchFatal()()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tasks := splitRange("primary", start, start.AddDate(0, 3, 0), time.UTC)
+
+	// 取得処理のゴルーチンで起きた fatal のパニックは、呼び出し元のゴルーチンで recover できること
+	defer func() {
+		fe, ok := recover().(*fatalError)
+		if !ok || fe.code != exitAuth {
+			t.Errorf("recover = %v; want 終了コード %d の *fatalError", fe, exitAuth)
+		}
+	}()
+	runFetchTasks(context.Background(), tasks, 2, func(_ context.Context, task fetchTask) ([]*calendar.Event, error) {
+		if task.start.Month() == time.February {
+			fatalCode(exitAuth, "テスト")
+		}
+		return nil, nil
+	})
+	t.Error("パニックが呼び出し元に伝わりませんでした")
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+// tryLockFile はファイルのロックを使用できない環境では、常にロックを取得したものとする
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlockFile はファイルのロックを使用できない環境では何もしない
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+	"google.golang.org/api/calendar/v3"
+)
+
+// hasEventFilter はイベントを絞り込む条件が1つ以上指定されているかを判定する
+func (o *queryOptions) hasEventFilter() bool {
+	return o.name != "" || o.tag != "" ||
+		o.descriptionContains != "" || o.descriptionRegex != "" ||
+		o.attendee != "" || o.organizer != "" || o.minAttendees > 0 ||
+		o.location != "" || o.onlyWithMeet || o.withoutMeet || o.project != ""
+}
+
+// label は集計条件を表す表示用の名前を返す
+func (o *queryOptions) label() string {
+	var parts []string
+	if o.name != "" {
+		parts = append(parts, o.name)
+	}
+	if o.tag != "" {
+		parts = append(parts, "#"+strings.TrimPrefix(o.tag, "#"))
+	}
+	if o.descriptionContains != "" {
+		parts = append(parts, "説明:"+o.descriptionContains)
+	}
+	if o.descriptionRegex != "" {
+		parts = append(parts, "説明:/"+o.descriptionRegex+"/")
+	}
+	if o.attendee != "" {
+		parts = append(parts, "参加者:"+o.attendee)
+	}
+	if o.minAttendees > 0 {
+		parts = append(parts, fmt.Sprintf("参加者%d人以上", o.minAttendees))
+	}
+	if o.organizer != "" {
+		parts = append(parts, "主催者:"+o.organizer)
+	}
+	if o.project != "" {
+		parts = append(parts, "プロジェクト:"+o.project)
+	}
+	if o.location != "" {
+		parts = append(parts, "場所:"+o.location)
+	}
+	if o.onlyWithMeet {
+		parts = append(parts, "ビデオ会議あり")
+	}
+	if o.withoutMeet {
+		parts = append(parts, "ビデオ会議なし")
+	}
+	return strings.Join(parts, " ")
+}
+
+// matches はイベントが指定されたすべての条件に一致するかを判定する
+func (o *queryOptions) matches(item *calendar.Event) bool {
+	if o.name != "" && !o.matchName(item.Summary) {
+		return false
+	}
+	if o.tag != "" && !hasTag(item, o.tag) {
+		return false
+	}
+	// 説明は大文字小文字を区別せずに部分一致で比較
+	if o.descriptionContains != "" && !strings.Contains(strings.ToLower(item.Description), strings.ToLower(o.descriptionContains)) {
+		return false
+	}
+	if o.descriptionPattern != nil && !o.descriptionPattern.MatchString(item.Description) {
+		return false
+	}
+	if o.attendee != "" && !hasAttendee(item, o.attendee) {
+		return false
+	}
+	if o.minAttendees > 0 && attendeeCount(item) < o.minAttendees {
+		return false
+	}
+	if o.organizer != "" && !isOrganizer(item, o.organizer) {
+		return false
+	}
+	if o.project != "" {
+		p := projectFor(o.projects, item.Summary)
+		if p == "" || (o.project != "*" && !strings.EqualFold(p, o.project)) {
+			return false
+		}
+	}
+	if o.location != "" && !strings.Contains(strings.ToLower(item.Location), strings.ToLower(o.location)) {
+		return false
+	}
+	if (o.onlyWithMeet || o.withoutMeet) && hasConference(item) != o.onlyWithMeet {
+		return false
+	}
+	return true
+}
+
+// matchedRules はイベントが一致した集計の条件を、監査記録に残す形式（「name(exact)=定例」など）で返す
+// 条件を指定せずにすべてのイベントを集計した場合も、プロジェクトの対応付けに一致したルールは記録する
+func (o *queryOptions) matchedRules(item *calendar.Event) []string {
+	var rules []string
+	if o.name != "" {
+		names := o.nameAlternatives()
+		if i := o.matchedName(item.Summary); i >= 0 && names[i] != o.name {
+			rules = append(rules, fmt.Sprintf("name(%s)=%s (%s)", o.match, names[i], o.name))
+		} else {
+			rules = append(rules, fmt.Sprintf("name(%s)=%s", o.match, o.name))
+		}
+	}
+	if o.tag != "" {
+		rules = append(rules, "tag="+o.tag)
+	}
+	if o.descriptionContains != "" {
+		rules = append(rules, "description-contains="+o.descriptionContains)
+	}
+	if o.descriptionPattern != nil {
+		rules = append(rules, "description-regex="+o.descriptionPattern.String())
+	}
+	if o.attendee != "" {
+		rules = append(rules, "attendee="+o.attendee)
+	}
+	if o.minAttendees > 0 {
+		rules = append(rules, fmt.Sprintf("min-attendees=%d (%d)", o.minAttendees, attendeeCount(item)))
+	}
+	if o.organizer != "" {
+		rules = append(rules, "organizer="+o.organizer)
+	}
+	if rule := projectRuleFor(o.projects, item.Summary); rule != nil {
+		rules = append(rules, fmt.Sprintf("project=%s (%s)", rule.Project, rule.Pattern))
+	}
+	if o.location != "" {
+		rules = append(rules, "location="+o.location)
+	}
+	if o.onlyWithMeet {
+		rules = append(rules, "only-with-meet")
+	}
+	if o.withoutMeet {
+		rules = append(rules, "without-meet")
+	}
+	if o.minDuration > 0 {
+		rules = append(rules, "min-duration="+o.minDuration.String())
+	}
+	if o.maxDuration > 0 {
+		rules = append(rules, "max-duration="+o.maxDuration.String())
+	}
+	return rules
+}
+
+// inDurationRange はイベントの長さが -min-duration と -max-duration の範囲に含まれるかを判定する
+func (o *queryOptions) inDurationRange(d time.Duration) bool {
+	if d < o.minDuration {
+		return false
+	}
+	return o.maxDuration == 0 || d <= o.maxDuration
+}
+
+// hasConference はイベントにビデオ会議（Google MeetのリンクやconferenceDataの接続先）があるかを判定する
+func hasConference(item *calendar.Event) bool {
+	if item.HangoutLink != "" {
+		return true
+	}
+	if item.ConferenceData != nil {
+		for _, ep := range item.ConferenceData.EntryPoints {
+			if ep.EntryPointType == "video" && ep.Uri != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nameAlternatives は -name に指定されたイベント名を返す
+// 設定ファイルの aliases の別名（大文字小文字を区別しない）が指定された場合は、別名に対応するすべてのイベント名を返す
+func (o *queryOptions) nameAlternatives() []string {
+	for alias, names := range o.aliases {
+		if strings.EqualFold(alias, o.name) {
+			return names
+		}
+	}
+	return []string{o.name}
+}
+
+// matchName はイベント名が指定された比較方法で一致するかを判定する（正規表現以外は大文字小文字・全角半角・空白の違いを区別しない）
+func (o *queryOptions) matchName(summary string) bool {
+	return o.matchedName(summary) >= 0
+}
+
+// normalizeName はイベント名を比較するために、Unicodeの互換文字を正規化（NFKC）し、全角・半角の違いをなくす
+// 「ＭＴＧ」と「MTG」、半角カナと全角カナのように、見た目が同じイベント名を同じ名前として比較できる
+// 連続した空白は1つの空白にまとめ、前後の空白は取り除く（「Project  X 」と「Project X」は同じ名前になる）
+// -ignore-emoji の場合は、絵文字と前後の記号も取り除く。-strict の場合は正規化しない
+func (o *queryOptions) normalizeName(s string) string {
+	if o.strict {
+		return s
+	}
+	s = width.Fold.String(norm.NFKC.String(s))
+	if o.ignoreEmoji {
+		s = stripEmoji(s)
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripEmoji はイベント名から絵文字を取り除き、前後の記号と空白を取り除く
+// 絵文字の組み合わせに使用する文字（ZWJ、異体字セレクタ、肌の色の修飾子、キーキャップ）も取り除く
+func stripEmoji(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.So, r),
+			r == '\u200d', r == '\u20e3',
+			unicode.Is(unicode.Variation_Selector, r),
+			r >= 0x1f3fb && r <= 0x1f3ff:
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+}
+
+// matchedName はイベント名が一致した -name のイベント名（別名の場合は展開したイベント名）の位置を返す（一致しない場合は -1）
+func (o *queryOptions) matchedName(summary string) int {
+	// 正規表現は指定されたとおりに比較するため、正規化しない
+	normalized := o.normalizeName(summary)
+	for i, name := range o.nameAlternatives() {
+		var ok bool
+		switch {
+		case o.match == "regex":
+			ok = i < len(o.namePatterns) && o.namePatterns[i].MatchString(summary)
+		case o.strict && o.match == "contains":
+			ok = strings.Contains(summary, name)
+		case o.strict:
+			ok = summary == name
+		case o.match == "contains":
+			ok = strings.Contains(strings.ToLower(normalized), strings.ToLower(o.normalizeName(name)))
+		default:
+			ok = strings.EqualFold(normalized, o.normalizeName(name))
+		}
+		if ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// serverQuery はCalendar APIの検索（qパラメータ）に渡す検索語を返す
+// APIの検索はタイトル以外（説明・場所・参加者など）にも一致するため、取得後に改めて絞り込む
+// APIの検索は単語単位で行われ、イベント名の正規化も行わないため、取得後の比較で一致するイベントを取りこぼさない
+// -strict の完全一致の場合だけ検索し、部分一致や正規化して比較する場合はすべてのイベントを取得する
+// 一致したイベント以外も必要な場合（-overlap-all や他のイベントとの重なりで控除するルール）は検索を行わない
+// 複数のイベント名に展開される別名は、APIの検索ではいずれかに一致する条件を指定できないため検索を行わない
+func (o *queryOptions) serverQuery() string {
+	if o.noServerQuery || o.name == "" || o.match != "exact" || !o.strict || o.overlapAll {
+		return ""
+	}
+	for _, rule := range o.deductions {
+		if rule.Type == "event" {
+			return ""
+		}
+	}
+	names := o.nameAlternatives()
+	if len(names) != 1 {
+		return ""
+	}
+	return names[0]
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestServerQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"-strict の完全一致は検索する", []string{"-name", "Standup", "-strict"}, "Standup"},
+		{"-strict の完全一致は全角英数字も検索する", []string{"-name", "ＭＴＧ", "-strict"}, "ＭＴＧ"},
+		{"正規化して比較する完全一致は検索しない", []string{"-name", "Standup"}, ""},
+		{"全角英数字は正規化で変わるため検索しない", []string{"-name", "ＭＴＧ"}, ""},
+		{"含む場合は検索しない", []string{"-name", "開発", "-match", "contains"}, ""},
+		{"-strict の部分一致も検索しない", []string{"-name", "開発", "-match", "contains", "-strict"}, ""},
+		{"正規表現は検索しない", []string{"-name", "^MTG", "-match", "regex"}, ""},
+		{"-overlap-all の場合は検索しない", []string{"-name", "Standup", "-strict", "-detect-overlaps", "-overlap-all"}, ""},
+		{"-no-server-filter の場合は検索しない", []string{"-name", "Standup", "-strict", "-no-server-filter"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, _ := newTestQuery(t, append([]string{"-month", "2024-04"}, tt.args...)...)
+			if got := o.serverQuery(); got != tt.want {
+				t.Errorf("serverQuery = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
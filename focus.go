@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// デフォルトの集中時間とみなす空き時間の最小の長さ
+const defaultMinFocusBlock = time.Hour
+
+// focusDay は1日分の集中時間として使える空き時間帯を表す
+type focusDay struct {
+	Date   time.Time
+	Blocks []busyPeriod
+	Total  time.Duration
+}
+
+// eventBusyPeriods は時間指定のイベントのうち、予定ありとして扱うものの時間帯を返す
+// 終日イベント、「予定なし」として登録されたイベント、キャンセル・辞退したイベントは除く
+func eventBusyPeriods(items []*calendar.Event, o *queryOptions, location *time.Location) []busyPeriod {
+	var periods []busyPeriod
+	for _, item := range items {
+		if item.Transparency == "transparent" || o.isExcludedStatus(item) {
+			continue
+		}
+		start, end, ok := timedEventTimes(item, location)
+		if !ok {
+			continue
+		}
+		periods = append(periods, busyPeriod{Start: start, End: end})
+	}
+	return periods
+}
+
+// focusDays は勤務日ごとに、勤務時間帯の中で最小の長さ以上途切れずに空いている時間帯を求める
+func focusDays(periods []busyPeriod, schedule *workSchedule, startDate, endDate time.Time, minBlock time.Duration) []focusDay {
+	merged := mergeBusyPeriods(periods)
+	var days []focusDay
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		if !schedule.isWorkday(d) {
+			continue
+		}
+		windowStart, windowEnd := schedule.window(d)
+		day := focusDay{Date: d}
+		for _, b := range freeBlocks(merged, windowStart, windowEnd) {
+			if b.End.Sub(b.Start) < minBlock {
+				continue
+			}
+			day.Blocks = append(day.Blocks, b)
+			day.Total += b.End.Sub(b.Start)
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// printFocus は集中時間として使える空き時間帯の一覧を出力する
+func printFocus(w io.Writer, days []focusDay, startDate, endDate time.Time, minBlock time.Duration, location *time.Location) {
+	var total time.Duration
+	for _, d := range days {
+		total += d.Total
+	}
+	printer.Fprintf(w, "検索期間: %s から %s\n", startDate.Format("2006/01/02"), endDate.Format("2006/01/02"))
+	printer.Fprintf(w, "%s以上の空き時間の合計: %s（勤務日 %d日）\n\n", formatDuration(minBlock), formatDuration(total), len(days))
+
+	for _, d := range days {
+		fmt.Fprintf(w, "%s (%s): %s\n", d.Date.Format("2006/01/02"), weekdayLabel(weekdayIndex(d.Date)), formatDuration(d.Total))
+		for _, b := range d.Blocks {
+			fmt.Fprintf(w, "  - %s～%s [%s]\n", b.Start.In(location).Format("15:04"), b.End.In(location).Format("15:04"), formatDuration(b.End.Sub(b.Start)))
+		}
+	}
+}
+
+// focusFlags は focus のフラグ
+type focusFlags struct {
+	opts     *queryOptions
+	minBlock time.Duration
+}
+
+// registerFocusFlags は focus のフラグを登録する（補完の候補にも使用する）
+func registerFocusFlags(fs *flag.FlagSet, cfg *Config) *focusFlags {
+	f := &focusFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.DurationVar(&f.minBlock, "min-block", defaultMinFocusBlock, "集中時間とみなす空き時間の最小の長さ")
+	return f
+}
+
+// runFocusCommand は勤務時間帯の中で途切れずに空いている時間（集中時間）を集計するサブコマンドを実行する
+func runFocusCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("focus", flag.ExitOnError)
+	flags := registerFocusFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	if _, err := opts.checkDateRange(); err != nil {
+		printer.Printf("エラー: %v\n", err)
+		printer.Printf("使用方法: gcal-sum focus -month=YYYY-MM [-calendar=カレンダーID] [-work-hours=09:00-18:00] [-workdays=mon-fri] [-min-block=1h]\n")
+		os.Exit(exitUsage)
+	}
+
+	// 勤務時間帯の指定がない場合は平日の9時～18時の中で調べる
+	if opts.workHours == "" {
+		opts.workHours = defaultMeetingDayHours
+	}
+	if opts.workdays == "" {
+		opts.workdays = "mon-fri"
+	}
+	var err error
+	if opts.schedule, err = newWorkSchedule(opts.workHours, opts.workdays); err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	startDate, endDate := opts.dateRange(location)
+	searchEndDate := endDate.AddDate(0, 0, 1)
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	opts.loadHolidays(ctx, src, startDate, searchEndDate)
+	items := opts.fetchEvents(ctx, src, cfg, location, startDate, searchEndDate)
+
+	days := focusDays(eventBusyPeriods(items, opts, location), opts.schedule, startDate, endDate, flags.minBlock)
+	printFocus(os.Stdout, days, startDate, endDate, flags.minBlock, location)
+}
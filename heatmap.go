@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// 曜日の表示名（月曜日始まり）
+var weekdayLabels = [7]string{"月", "火", "水", "木", "金", "土", "日"}
+
+// 英語の曜日の表示名（表の列がずれないように、日本語と同じ表示幅の2文字にする）
+var englishWeekdayLabels = [7]string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+
+// weekdayLabel は表示言語に応じた曜日の表示名を返す（i は月曜日を0とする）
+func weekdayLabel(i int) string {
+	if displayLanguage == language.English {
+		return englishWeekdayLabels[i]
+	}
+	return weekdayLabels[i]
+}
+
+// 濃淡の表示に使用する文字（薄い順）
+var heatmapShades = []string{"  ", "░░", "▒▒", "▓▓", "██"}
+
+// heatmap は曜日×時間帯ごとのイベント時間の合計（行0は月曜日）
+type heatmap [7][24]time.Duration
+
+// weekdayIndex は月曜日を0とした曜日の番号を返す
+func weekdayIndex(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+// buildHeatmap はイベントの時間を曜日×時間帯のマスに振り分けて集計する
+func buildHeatmap(events []MatchedEvent, location *time.Location) *heatmap {
+	h := &heatmap{}
+	for _, e := range events {
+		t := e.Start.In(location)
+		end := e.End.In(location)
+		for t.Before(end) {
+			// 次の正時までをこのマスに加算する
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+			if next.After(end) {
+				next = end
+			}
+			h[weekdayIndex(t)][t.Hour()] += next.Sub(t)
+			t = next
+		}
+	}
+	return h
+}
+
+// printHeatmap は曜日×時間帯のヒートマップを出力する
+func printHeatmap(w io.Writer, h *heatmap) {
+	var maxDuration time.Duration
+	for _, row := range h {
+		for _, d := range row {
+			maxDuration = max(maxDuration, d)
+		}
+	}
+
+	fmt.Fprint(w, "    ")
+	for hour := 0; hour < 24; hour++ {
+		fmt.Fprintf(w, " %2d", hour)
+	}
+	fmt.Fprintln(w)
+
+	for i, row := range h {
+		fmt.Fprintf(w, "%s  ", weekdayLabel(i))
+		var total time.Duration
+		for _, d := range row {
+			shade := 0
+			if d > 0 && maxDuration > 0 {
+				// 0より大きい値は最も薄い濃さ以上で表示する
+				shade = 1 + int(float64(d)/float64(maxDuration)*float64(len(heatmapShades)-2)+0.5)
+			}
+			fmt.Fprintf(w, " %s", heatmapShades[shade])
+			total += d
+		}
+		fmt.Fprintf(w, "  %s\n", formatDuration(total))
+	}
+
+	fmt.Fprintln(w)
+	printer.Fprintf(w, "凡例: 少 %s 多（1マスの最大 %s）\n", strings.Join(heatmapShades[1:], " "), formatDuration(maxDuration))
+}
+
+// registerHeatmapFlags は heatmap のフラグを登録する（補完の候補にも使用する）
+func registerHeatmapFlags(fs *flag.FlagSet, cfg *Config) *queryOptions {
+	opts := &queryOptions{}
+	opts.register(fs, cfg)
+	return opts
+}
+
+// runHeatmapCommand は曜日×時間帯のヒートマップを表示するサブコマンドを実行する
+func runHeatmapCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("heatmap", flag.ExitOnError)
+	opts := registerHeatmapFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts.validate()
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	report := runQuery(ctx, src, cfg, opts)
+
+	printer.Printf("検索期間: %s から %s\n", report.StartDate.Format("2006/01/02"), report.EndDate.Format("2006/01/02"))
+	printer.Printf("イベント '%s' の曜日×時間帯ヒートマップ（合計 %s）:\n\n", report.Name, formatDuration(report.Total))
+	if len(report.Events) == 0 {
+		printer.Printf("一致するイベントが見つかりませんでした。\n")
+		exit(exitNoMatch)
+	}
+	printHeatmap(os.Stdout, buildHeatmap(report.Events, report.Location))
+}
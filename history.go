@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyEntry は集計結果の履歴1件分を表す
+type historyEntry struct {
+	RecordedAt   time.Time `json:"recorded_at"`
+	Name         string    `json:"name"`
+	CalendarID   string    `json:"calendar_id"`
+	StartDate    string    `json:"start_date"`
+	EndDate      string    `json:"end_date"`
+	Count        int       `json:"count"`
+	TotalMinutes float64   `json:"total_minutes"`
+}
+
+// appendHistory は集計結果を履歴ファイルに追記する
+func appendHistory(path, calendarID string, r *Report) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(historyEntry{
+		RecordedAt:   time.Now(),
+		Name:         r.Name,
+		CalendarID:   calendarID,
+		StartDate:    r.StartDate.Format("2006-01-02"),
+		EndDate:      r.EndDate.Format("2006-01-02"),
+		Count:        r.count(),
+		TotalMinutes: r.Total.Minutes(),
+	})
+}
+
+// loadHistory は履歴ファイルを読み込む。ファイルが存在しない場合は空の履歴を返す
+func loadHistory(path string) ([]historyEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e historyEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// latestByPeriod はイベント名とカレンダーが一致する履歴から、期間ごとに最新の記録を期間順に返す
+func latestByPeriod(entries []historyEntry, name, calendarID string) []historyEntry {
+	latest := make(map[string]historyEntry)
+	for _, e := range entries {
+		if !strings.EqualFold(e.Name, name) || (calendarID != "" && e.CalendarID != calendarID) {
+			continue
+		}
+		key := e.StartDate + "/" + e.EndDate
+		if prev, ok := latest[key]; !ok || e.RecordedAt.After(prev.RecordedAt) {
+			latest[key] = e
+		}
+	}
+
+	periods := make([]historyEntry, 0, len(latest))
+	for _, e := range latest {
+		periods = append(periods, e)
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		if periods[i].StartDate != periods[j].StartDate {
+			return periods[i].StartDate < periods[j].StartDate
+		}
+		return periods[i].EndDate < periods[j].EndDate
+	})
+	return periods
+}
+
+// formatChange は前の期間からの変化率を表示用の文字列に変換する
+func formatChange(prev, current float64) string {
+	if prev == 0 {
+		if current == 0 {
+			return "±0.0%"
+		}
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (current-prev)/prev*100)
+}
+
+// historyFlags は history のフラグ
+type historyFlags struct {
+	name       string
+	calendarID string
+}
+
+// registerHistoryFlags は history のフラグを登録する（補完の候補にも使用する）
+func registerHistoryFlags(fs *flag.FlagSet, cfg *Config) *historyFlags {
+	f := &historyFlags{}
+	fs.StringVar(&f.name, "name", "", "履歴を表示するイベント名")
+	fs.StringVar(&f.calendarID, "calendar", "", "カレンダーIDで絞り込む（省略時はすべてのカレンダー）")
+	return f
+}
+
+// runHistoryCommand は集計結果の履歴を表示するサブコマンドを実行する
+func runHistoryCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	flags := registerHistoryFlags(fs, cfg)
+	parseFlags(fs, args)
+
+	if flags.name == "" {
+		printer.Printf("エラー: イベント名を指定してください。\n")
+		printer.Printf("使用方法: gcal-sum history -name=イベント名 [-calendar=カレンダーID]\n")
+		os.Exit(exitUsage)
+	}
+
+	entries, err := loadHistory(cfg.HistoryPath)
+	if err != nil {
+		fatal("履歴ファイルの読み込みに失敗しました", "error", err)
+	}
+	periods := latestByPeriod(entries, flags.name, flags.calendarID)
+	if len(periods) == 0 {
+		printer.Printf("イベント '%s' の履歴が見つかりませんでした。\n", flags.name)
+		return
+	}
+
+	printer.Printf("イベント '%s' の合計時間の推移:\n", flags.name)
+	for i, p := range periods {
+		total := time.Duration(p.TotalMinutes * float64(time.Minute))
+		change := "-"
+		if i > 0 {
+			change = formatChange(periods[i-1].TotalMinutes, p.TotalMinutes)
+		}
+		printer.Printf("%s～%s: %s (%d件) [前期間比 %s]\n",
+			strings.ReplaceAll(p.StartDate, "-", "/"),
+			strings.ReplaceAll(p.EndDate, "-", "/"),
+			formatDuration(total),
+			p.Count,
+			change)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"time"
+)
+
+// HTMLレポートのテンプレート
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Name}} の集計レポート</title>
+<style>
+body { font-family: -apple-system, "Hiragino Sans", "Noto Sans JP", sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+h1 { font-size: 1.6em; border-bottom: 2px solid #4285f4; padding-bottom: .3em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+.summary { display: flex; gap: 1em; }
+.card { flex: 1; background: #f5f8ff; border-radius: 8px; padding: 1em; }
+.card .label { font-size: .85em; color: #666; }
+.card .value { font-size: 1.5em; font-weight: bold; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: .4em .6em; text-align: left; }
+th { background: #f0f0f0; }
+td.num { text-align: right; white-space: nowrap; }
+.bar { background: #4285f4; height: 1em; border-radius: 2px; }
+</style>
+</head>
+<body>
+<h1>イベント「{{.Report.Name}}」の集計レポート</h1>
+{{block "form" .}}{{end}}<p>検索期間: {{date .Report.StartDate}} から {{date .Report.EndDate}}</p>
+
+<div class="summary">
+  <div class="card"><div class="label">合計時間</div><div class="value">{{duration .Report.Total}}</div></div>
+  <div class="card"><div class="label">件数</div><div class="value">{{len .Report.Events}}件</div></div>
+  <div class="card"><div class="label">実施日数</div><div class="value">{{len .Days}}日</div></div>
+  <div class="card"><div class="label">平均 / 中央値</div><div class="value">{{duration .Report.Stats.Average}} / {{duration .Report.Stats.Median}}</div></div>
+{{with .Report.Comparison}}  <div class="card"><div class="label">比較期間（{{date .StartDate}} から {{date .EndDate}}）</div><div class="value">{{duration .Total}}</div></div>
+{{end}}</div>
+{{if .Report.Events}}
+<h2>日別の合計時間</h2>
+<table>
+<tr><th>日付</th><th>件数</th><th>合計時間</th><th style="width:50%"></th></tr>
+{{range .Days}}<tr><td>{{.Key}}</td><td class="num">{{.Count}}</td><td class="num">{{duration .Duration}}</td><td><div class="bar" style="width: {{.Percent}}%"></div></td></tr>
+{{end}}</table>
+{{if .Report.Groups}}
+<h2>{{groupLabel .Report.GroupBy}}別の合計時間</h2>
+<table>
+<tr><th>{{groupLabel .Report.GroupBy}}</th><th>件数</th><th>合計時間</th><th>割合</th><th style="width:35%"></th></tr>
+{{range .Report.Groups}}{{$share := share $.Report .Duration}}<tr><td>{{.Key}}</td><td class="num">{{.Count}}</td><td class="num">{{duration .Duration}}</td><td class="num">{{printf "%.1f" $share}}%</td><td><div class="bar" style="width: {{printf "%.1f" (clampPercent $share)}}%"></div></td></tr>
+{{end}}</table>
+{{end}}
+<h2>一致したイベント一覧</h2>
+<table>
+<tr><th>#</th><th>イベント名</th><th>開始</th><th>終了</th><th>時間</th></tr>
+{{range $i, $e := .Report.Events}}<tr><td class="num">{{inc $i}}</td><td>{{if $e.Manual}}[手動] {{end}}{{$e.Event.Summary}}</td><td>{{datetime $e.Start}}</td><td>{{if not $e.Manual}}{{datetime $e.End}}{{end}}</td><td class="num">{{duration $e.Duration}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>一致するイベントが見つかりませんでした。</p>
+{{end}}
+<p style="color:#888;font-size:.8em">gcal-sum により {{datetime .GeneratedAt}} に作成</p>
+</body>
+</html>
+`
+
+// htmlBar は棒グラフの1行分のデータ
+type htmlBar struct {
+	GroupTotal
+	Percent float64
+}
+
+// htmlPage はHTMLレポートのテンプレートに渡すデータ
+type htmlPage struct {
+	Report      *Report
+	Days        []htmlBar
+	GeneratedAt time.Time
+
+	// serve の検索フォームに表示する条件（レポートファイルの作成では使用しない）
+	Params url.Values
+	Error  string
+}
+
+// printHTML は集計結果をHTML形式で出力する
+func printHTML(w io.Writer, r *Report) error {
+	tmpl, err := newHTMLTemplate(r.Location)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, newHTMLPage(r))
+}
+
+// newHTMLTemplate はHTMLレポートのテンプレートを生成する
+func newHTMLTemplate(location *time.Location) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"duration":   formatDuration,
+		"groupLabel": groupByLabel,
+		"inc":        func(i int) int { return i + 1 },
+		"share":      func(r *Report, d time.Duration) float64 { return r.share(d) },
+		// タグなどのグループでは割合が100%を超えることがあるため、棒の幅は100%までにする
+		"clampPercent": func(p float64) float64 { return min(p, 100) },
+		"date": func(t time.Time) string {
+			return t.In(location).Format("2006/01/02")
+		},
+		"datetime": func(t time.Time) string {
+			return t.In(location).Format("2006/01/02 15:04")
+		},
+	}
+	return template.New("report").Funcs(funcs).Parse(htmlReportTemplate)
+}
+
+// newHTMLPage は集計結果から日別の内訳と棒グラフの幅を求め、テンプレートに渡すデータを作成する
+func newHTMLPage(r *Report) *htmlPage {
+	// 日別の内訳と棒グラフの幅を計算
+	days := groupEvents(r.Events, "day", r.Location)
+	var maxDuration time.Duration
+	for _, d := range days {
+		if d.Duration > maxDuration {
+			maxDuration = d.Duration
+		}
+	}
+	bars := make([]htmlBar, len(days))
+	for i, d := range days {
+		bars[i] = htmlBar{GroupTotal: d}
+		if maxDuration > 0 {
+			bars[i].Percent = float64(d.Duration) / float64(maxDuration) * 100
+		}
+	}
+
+	return &htmlPage{Report: r, Days: bars, GeneratedAt: time.Now()}
+}
+
+// reportFlags は report のフラグ
+type reportFlags struct {
+	opts     *queryOptions
+	htmlPath string
+}
+
+// registerReportFlags は report のフラグを登録する（補完の候補にも使用する）
+func registerReportFlags(fs *flag.FlagSet, cfg *Config) *reportFlags {
+	f := &reportFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.StringVar(&f.htmlPath, "html", "", "HTMLレポートの出力先ファイル")
+	return f
+}
+
+// runReportCommand はレポートファイルを作成するサブコマンドを実行する
+func runReportCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	flags := registerReportFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	if flags.htmlPath == "" {
+		printer.Printf("エラー: 出力先を指定してください。\n")
+		printer.Printf("使用方法: gcal-sum report -html=out.html -month=YYYY-MM -name=イベント名 [-calendar=カレンダーID]\n")
+		os.Exit(exitUsage)
+	}
+	opts.validate()
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	report := runQuery(ctx, src, cfg, opts)
+
+	f, err := os.Create(flags.htmlPath)
+	if err != nil {
+		fatal("レポートファイルの作成に失敗しました", "error", err)
+	}
+	defer f.Close()
+	if err := printHTML(f, report); err != nil {
+		fatal("HTMLレポートの作成に失敗しました", "error", err)
+	}
+	printer.Printf("レポートを %s に保存しました\n", flags.htmlPath)
+	if len(report.Events) == 0 {
+		exit(exitNoMatch)
+	}
+}
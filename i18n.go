@@ -0,0 +1,779 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// 利用可能な表示言語
+var languages = []string{"ja", "en"}
+
+// displayLanguage は現在の表示言語
+var displayLanguage = detectLanguage()
+
+// printer は表示言語に応じてメッセージを翻訳して出力する
+// 翻訳元のメッセージ（日本語）をそのままキーとして、カタログから翻訳を引く
+var printer = message.NewPrinter(displayLanguage)
+
+// detectLanguage は環境変数から表示言語を判定する
+// GCAL_SUM_LANG を優先し、未設定の場合は LC_ALL、LC_MESSAGES、LANG の順に参照する
+func detectLanguage() language.Tag {
+	for _, key := range []string{"GCAL_SUM_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := strings.ToLower(os.Getenv(key))
+		if v == "" {
+			continue
+		}
+		if strings.HasPrefix(v, "en") {
+			return language.English
+		}
+		return language.Japanese
+	}
+	return language.Japanese
+}
+
+// setLanguage は表示言語を設定する。空文字の場合は環境変数から判定した言語を使用する
+func setLanguage(lang string) error {
+	switch lang {
+	case "":
+		displayLanguage = detectLanguage()
+	case "ja":
+		displayLanguage = language.Japanese
+	case "en":
+		displayLanguage = language.English
+	default:
+		return errors.New(printer.Sprintf("表示言語 '%s' はサポートされていません（%s）", lang, strings.Join(languages, ", ")))
+	}
+	printer = message.NewPrinter(displayLanguage)
+	return nil
+}
+
+// translate はログのメッセージなど、書式指定を含まない文字列を翻訳する
+func translate(s string) string {
+	if strings.Contains(s, "%") {
+		return s
+	}
+	return printer.Sprintf(s)
+}
+
+// 英語のメッセージカタログ
+var englishMessages = map[string]string{
+	// 集計結果
+	"検索期間: %s から %s\n":            "Period: %s to %s\n",
+	"イベント '%s' の合計時間: %d時間 %d分\n": "Total time for '%s': %dh %dm\n",
+	"イベント '%s' の合計時間: %s\n":       "Total time for '%s': %s\n",
+	"%d時間%d分": "%dh%dm",
+	"（重複している %s を差し引き済み）\n":   "(%s of overlapping time deducted)\n",
+	"（控除ルールにより %s を差し引き済み）\n": "(%s deducted by deduction rules)\n",
+	"目標: %s\n":  "Target: %s\n",
+	"稼働率: %s\n": "Utilization: %s\n",
+	"比較期間 %s から %s の合計時間: %s\n": "Total for comparison period %s to %s: %s\n",
+	"一致するイベントが見つかりませんでした。\n":    "No matching events were found.\n",
+	"統計:\n":                       "Statistics:\n",
+	"- 件数: %d件\n":                 "- Count: %d\n",
+	"- 平均: %s / 中央値: %s\n":        "- Average: %s / Median: %s\n",
+	"- 最短: %s / 最長: %s\n":         "- Shortest: %s / Longest: %s\n",
+	"- 実施日あたりの平均: %s (%d日)\n":     "- Average per active day: %s (%d days)\n",
+	"%s別の合計時間:\n":                 "Total time by %s:\n",
+	"- %s ×%d: %s":                "- %s ×%d: %s",
+	"- %s: %s (%d件)":              "- %s: %s (%d events)",
+	"- %s: %s (%d件)\n":            "- %s: %s (%d events)\n",
+	"一致したイベント一覧:\n":               "Matching events:\n",
+	"%d. %s (%s～%s) [%s]":         "%d. %s (%s - %s) [%s]",
+	" (控除 %s)":                    " (deducted %s)",
+	"重複しているイベントはありません。\n":         "No overlapping events.\n",
+	"重複している時間帯 (%d件):\n":          "Overlapping periods (%d):\n",
+	"- %s～%s [%s]: %s / %s\n":     "- %s - %s [%s]: %s / %s\n",
+	"%s（差分 %s、%s）":                "%s (difference %s, %s)",
+	"%s に対して %.1f%%（残り %s）":       "%[2].1f%% of %[1]s (%[3]s remaining)",
+	"、期間終了時点の見込み %s":              ", projected %s at end of period",
+	"%.1f%%（稼働可能時間 %s = %d日 × %s": "%.1f%% (available time %s = %d days × %s",
+	"、祝日 %d日を除く":                  ", excluding %d holidays",
+	"）":                           ")",
+
+	// Slackへの通知
+	"*イベント「%s」の集計*（%s から %s）\n": "*Summary of '%s'* (%s to %s)\n",
+	"イベント「%s」の集計（%s から %s）":     "Summary of '%s' (%s to %s)",
+	"合計時間: *%s*（%d件）\n":         "Total time: *%s* (%d events)\n",
+	"• %s: %s (%d件)\n":          "• %s: %s (%d events)\n",
+
+	// Markdown形式の集計結果
+	"## イベント '%s' の集計\n\n":    "## Summary of '%s'\n\n",
+	"- 検索期間: %s から %s\n":      "- Period: %s to %s\n",
+	"- 合計時間: **%s**\n":        "- Total time: **%s**\n",
+	"- 差し引いた重複時間: %s\n":       "- Deducted overlapping time: %s\n",
+	"- 控除ルールにより差し引いた時間: %s\n": "- Time deducted by deduction rules: %s\n",
+	"- 目標: %s\n":              "- Target: %s\n",
+	"- 稼働率: %s\n":             "- Utilization: %s\n",
+	"- 比較期間 %s から %s: %s\n":   "- Comparison period %s to %s: %s\n",
+	"### 統計\n":                "### Statistics\n",
+	"| 件数 | 平均 | 中央値 | 最短 | 最長 | 実施日数 | 実施日あたりの平均 |\n": "| Count | Average | Median | Shortest | Longest | Active days | Average per active day |\n",
+	"### 重複している時間帯\n":                    "### Overlapping periods\n",
+	"| 開始 | 終了 | 時間 | イベント | 重複相手 |\n":   "| Start | End | Duration | Event | Overlaps with |\n",
+	"### %s別の合計時間\n\n":                   "### Total time by %s\n\n",
+	"| %s | 件数 | 合計時間 | 割合 |\n":          "| %s | Count | Total time | Share |\n",
+	"| **合計** | **%d** | **%s** | |\n\n": "| **Total** | **%d** | **%s** | |\n\n",
+	"### 一致したイベント一覧\n":                   "### Matching events\n",
+	"| # | イベント名 | 開始 | 終了 | 時間 |\n":     "| # | Event | Start | End | Duration |\n",
+	"| | **合計** | | | **%s** |\n":        "| | **Total** | | | **%s** |\n",
+
+	// グループ化の単位
+	"日付":       "date",
+	"週":        "week",
+	"月":        "month",
+	"イベント名":    "event name",
+	"タグ":       "tag",
+	"参加者":      "attendee",
+	"主催者":      "organizer",
+	"繰り返しイベント": "recurring event",
+	"プロジェクト":   "project",
+
+	// 使用方法と引数の検証
+	"使用方法: gcal-sum -start=YYYY-MM-DD -end=YYYY-MM-DD -name=イベント名 [-calendar=カレンダーID]\n": "Usage: gcal-sum -start=YYYY-MM-DD -end=YYYY-MM-DD -name=EVENT_NAME [-calendar=CALENDAR_ID]\n",
+	"または: gcal-sum -month=YYYY-MM -name=イベント名 [-calendar=カレンダーID]\n":                     "   or: gcal-sum -month=YYYY-MM -name=EVENT_NAME [-calendar=CALENDAR_ID]\n",
+	"または: gcal-sum -month=YYYY-MM -tag=タグ [-calendar=カレンダーID]\n":                         "   or: gcal-sum -month=YYYY-MM -tag=TAG [-calendar=CALENDAR_ID]\n",
+	"イベント名、タグ、説明、参加者、主催者、場所、またはプロジェクトの条件を指定してください。":                                      "Specify an event name, tag, description, attendee, organizer, location or project condition.",
+	"プロジェクトの対応付けを -projects または設定ファイルの projects で指定してください。":                              "Specify a project mapping with -projects or projects in the config file.",
+	"日付範囲を指定してください。":                                                                     "Specify a date range.",
+	"-only-with-meet と -without-meet は同時に指定できません。":                                       "-only-with-meet and -without-meet cannot be used together.",
+	"グループ化単位 '%s' はサポートされていません（%s）。":                                                     "Unsupported group-by unit '%s' (%s).",
+	"イベント名の正規表現が不正です: %v":                                                                "Invalid event name regular expression: %v",
+	"比較方法 '%s' はサポートされていません（exact, contains, regex）。":                                    "Unsupported match mode '%s' (exact, contains, regex).",
+	"説明の正規表現が不正です: %v":                                                                   "Invalid description regular expression: %v",
+	"エラー: %v\n": "Error: %v\n",
+	"エラー: ログの出力形式 '%s' はサポートされていません（%s）。\n": "Error: unsupported log format '%s' (%s).\n",
+	"エラー: テンプレートが不正です: %v\n":                "Error: invalid template: %v\n",
+	"エラー: 出力形式 '%s' はサポートされていません（%s）。\n":    "Error: unsupported output format '%s' (%s).\n",
+
+	// ログ
+	"エラー: ": "Error: ",
+	"警告: ":  "Warning: ",
+	"カレントディレクトリの取得に失敗しました":                      "Failed to get the current directory",
+	"サーバー起動エラー":                                 "Failed to start the local server",
+	"認証が完了する前に処理が終了しました":                        "Exited before authentication was completed",
+	"トークンの取得に失敗しました":                            "Failed to obtain a token",
+	"トークンの有効期限が切れています。更新を試みます":                  "The token has expired. Trying to refresh it",
+	"トークンの更新に失敗したため、再認証を行います":                   "Failed to refresh the token; re-authenticating",
+	"トークンが正常に更新されました":                           "The token was refreshed successfully",
+	"リフレッシュトークンがないため、再認証を行います":                  "No refresh token is available; re-authenticating",
+	"トークンを保存します":                                "Saving the token",
+	"トークンファイルの保存に失敗しました":                        "Failed to save the token file",
+	"トークンファイルのロックに失敗しました":                       "Failed to lock the token file",
+	"別のプロセスがトークンを更新しているため、完了を待ちます":              "Waiting for another process to finish updating the token",
+	"トークンファイルのロックを取得できませんでした":                   "Could not acquire the token file lock",
+	"リフレッシュトークンが失効しているため、トークンファイルを削除して再認証を行います": "The refresh token has been revoked or expired; deleting the token file and re-authenticating",
+	"トークンファイルの削除に失敗しました":                        "Failed to delete the token file",
+	"カレンダー一覧の取得に失敗しました":                         "Failed to list calendars",
+	"credentials.jsonの読み込みに失敗しました":              "Failed to read credentials.json",
+	"OAuth2の設定に失敗しました":                          "Failed to configure OAuth2",
+	"Calendar APIの初期化に失敗しました":                   "Failed to initialize the Calendar API",
+	"Sheets APIの初期化に失敗しました":                     "Failed to initialize the Sheets API",
+	"設定ファイルの読み込みに失敗しました":                        "Failed to read the config file",
+	"設定ファイルの保存に失敗しました":                          "Failed to save the config file",
+	"タイムゾーンの読み込みに失敗しました":                        "Failed to load the time zone",
+	"月指定の解析に失敗しました":                             "Failed to parse the month",
+	"開始日の解析に失敗しました":                             "Failed to parse the start date",
+	"終了日の解析に失敗しました":                             "Failed to parse the end date",
+	"比較期間の解析に失敗しました":                            "Failed to parse the comparison period",
+	"イベントの取得に失敗しました":                            "Failed to fetch events",
+	"APIからイベントを取得しました":                          "Fetched events from the API",
+	"キャッシュからイベントを読み込みました":                       "Loaded events from the cache",
+	"キャッシュの保存に失敗しました":                           "Failed to save the cache",
+	"イベントの読み込みに失敗しました":                          "Failed to read events",
+	"ファイルからイベントを読み込みました":                        "Loaded events from the file",
+	"ICSファイルのTZIDを読み込めないため、表示のタイムゾーンの日時として扱います": "Could not load the TZID in the ICS file; treating its times as the display time zone",
+	"ローカルストアへの同期はGoogle Calendarでのみ使用できます":      "The local store can only be synced with Google Calendar",
+	"ICSファイルやフィクスチャの集計では祝日カレンダーを使用できないため、祝日を考慮せずに計算します": "The holiday calendar is not available for ICS files or fixtures; holidays are not taken into account",
+	"-ics と -mock は同時に指定できません。":           "-ics and -mock cannot be used together.",
+	"祝日カレンダーの取得に失敗しました":                   "Failed to fetch the holiday calendar",
+	"空き時間情報の取得に失敗しました":                    "Failed to fetch free/busy information",
+	"履歴ファイルの読み込みに失敗しました":                  "Failed to read the history file",
+	"履歴の保存に失敗しました":                        "Failed to save the history",
+	"テンプレートの出力に失敗しました":                    "Failed to execute the template",
+	"端末の設定に失敗しました":                        "Failed to configure the terminal",
+	"サーバーを起動しました":                         "Server started",
+	"サーバーの起動に失敗しました":                      "Failed to start the server",
+	"レスポンスの書き込みに失敗しました":                   "Failed to write the response",
+	"メトリクスの集計に失敗しました":                     "Failed to refresh the metric",
+	"メトリクスを集計しました":                        "Refreshed the metric",
+	"メトリクスの公開を開始しました":                     "Started serving metrics",
+	"Slackへの通知に失敗しました":                    "Failed to post to Slack",
+	"Slackに集計結果を投稿しました":                   "Posted the summary to Slack",
+	"Webhookへの配信に失敗しました":                  "Failed to deliver to the webhook",
+	"Webhookに集計結果を配信しました":                 "Delivered the report to the webhook",
+	"メールの送信に失敗しました":                       "Failed to send the email",
+	"集計結果をメールで送信しました":                     "Sent the report by email",
+	"集計しました":                              "Computed the report",
+	"次の集計まで待機します":                         "Waiting for the next run",
+	"集計または配信に失敗しました":                      "Failed to compute or deliver the report",
+	"出力ファイルの作成に失敗しました":                    "Failed to create the output file",
+	"CSVの出力に失敗しました":                       "Failed to write the CSV",
+	"作業ログの取得に失敗しました":                      "Failed to fetch worklogs",
+	"作業ログの登録に失敗しました":                      "Failed to add the worklog",
+	"課題キーが見つからないイベントは登録しませんでした":           "Skipped events without an issue key",
+	"作成済みの集計結果のイベントの検索に失敗しました":            "Failed to search for an existing summary event",
+	"集計結果のイベントの更新に失敗しました":                 "Failed to update the summary event",
+	"集計結果のイベントの作成に失敗しました":                 "Failed to create the summary event",
+	"合計時間: %s（%d件）\n":                     "Total: %s (%d events)\n",
+	"このイベントは gcal-sum が作成しました。\n":         "This event was created by gcal-sum.\n",
+	"PDFの出力に失敗しました":                       "Failed to write the PDF",
+	"作業報告書":                               "Timesheet",
+	"%s 御中":                               "To: %s",
+	"作業者: %s":                             "Worker: %s",
+	"作業期間: %s ～ %s":                       "Period: %s - %s",
+	"合計作業時間: %s":                          "Total hours: %s",
+	"対象: %s":                              "Subject: %s",
+	"曜日":                                  "Day",
+	"開始":                                  "Start",
+	"終了":                                  "End",
+	"時間":                                  "Hours",
+	"作業内容":                                "Description",
+	"合計（実施日数 %d日）":                        "Total (%d days)",
+	"作業者署名":                               "Worker signature",
+	"承認者署名":                               "Approver signature",
+	"日付:":                                 "Date:",
+	"ICSファイルの書き出しに失敗しました":                 "Failed to write the ICS file",
+	"一致したイベントをICSファイルに書き出しました":            "Wrote the matched events to the ICS file",
+	"カレンダー一覧の保存に失敗しました":                   "Failed to save the calendar list",
+	"メインのカレンダー":                           "Primary calendar",
+	"%s イベントを取得しています %d/%d（%dページ、%d件、%s）": "%s Fetching events %d/%d (%d pages, %d events, %s)",
+	// 診断
+	"設定と認証の診断":       "Diagnose configuration and authentication",
+	"    対処方法: %s\n": "    Fix: %s\n",
+	"設定ファイル":         "Config file",
+	"タイムゾーン":         "Time zone",
+	"キャッシュ":          "Cache",
+	"認証情報ファイル":       "Credentials file",
+	"認証用のポート":        "Auth port",
+	"トークン":           "Token",
+	"トークンの更新":        "Token refresh",
+	"スコープ":           "Scopes",
+	"カレンダー":          "Calendar",
+	"Google Cloud Consoleの「APIとサービス」→「認証情報」で種類が「デスクトップアプリ」のOAuthクライアントIDを作成し、JSONを %s に保存してください": "Create an OAuth client ID of type \"Desktop app\" under \"APIs & Services\" > \"Credentials\" in the Google Cloud Console and save the JSON to %s",
+	"%s を読み込めません: %v":                                    "Cannot read %s: %v",
+	"JSONの形式が正しくありません: %v":                               "Invalid JSON: %v",
+	"OAuthクライアントの設定（installed または web）がありません":            "No OAuth client settings (installed or web)",
+	"サービスアカウントのキーやAPIキーではなく、OAuthクライアントIDのJSONを使用してください": "Use the JSON of an OAuth client ID, not a service account key or API key",
+	"%s がありません":            "Missing %s",
+	"OAuth2の設定に失敗しました: %v": "Failed to configure OAuth2: %v",
+	"%s（クライアントID: %s、種類: ウェブアプリケーション）":                   "%s (client ID: %s, type: web application)",
+	"%s（クライアントID: %s）":                                   "%s (client ID: %s)",
+	"gcal-sum を実行して、ブラウザで認証をやり直してください":                   "Run gcal-sum and authenticate again in the browser",
+	"%s がありません（まだ認証していません）":                              "%s does not exist (not authenticated yet)",
+	"%s を削除してから、gcal-sum を実行して認証をやり直してください":              "Delete %s, then run gcal-sum to authenticate again",
+	"%s にリフレッシュトークンがありません":                               "%s has no refresh token",
+	"アクセストークンの期限が切れるたびに再認証が必要になります。%s を削除して認証をやり直してください": "You will need to re-authenticate every time the access token expires. Delete %s and authenticate again",
+	"%s（アクセストークンは期限切れ、次回の実行時に更新します）":                     "%s (access token expired; it will be refreshed on the next run)",
+	"%s（アクセストークンの有効期限: %s）":                              "%s (access token expires: %s)",
+	"リフレッシュトークンが失効しています":                                 "The refresh token has been revoked or expired",
+	"%s を削除してから、gcal-sum を実行して認証をやり直してください（テスト中のOAuth同意画面では、トークンは7日で失効します）": "Delete %s, then run gcal-sum to authenticate again (tokens expire after 7 days while the OAuth consent screen is in testing)",
+	"クライアントIDまたはクライアントシークレットが正しくありません":                                      "The client ID or client secret is incorrect",
+	"トークンを発行したOAuthクライアントの credentials.json を使用するか、%s を削除して認証をやり直してください":    "Use the credentials.json of the OAuth client that issued the token, or delete %s and authenticate again",
+	"ネットワークの接続を確認してください":                                                    "Check your network connection",
+	"リフレッシュトークンでアクセストークンを更新できました":                                           "Refreshed the access token with the refresh token",
+	"スコープを確認できません: %v":                                                      "Cannot check scopes: %v",
+	"スコープを確認できません（HTTP %d）":                                                 "Cannot check scopes (HTTP %d)",
+	"カレンダーを読み取るスコープがありません（%s）":                                              "No scope to read calendars (%s)",
+	"Google Cloud ConsoleでGoogle Calendar APIを有効にしてください":                    "Enable the Google Calendar API in the Google Cloud Console",
+	"カレンダー一覧を取得できました（%d件）":                                                  "Fetched the calendar list (%d)",
+	"'%s' にアクセスできません: %v":                                                   "Cannot access '%s': %v",
+	"gcal-sum -list で利用可能なカレンダーのIDを確認し、設定ファイルの calendar を修正してください":          "Check the available calendar IDs with gcal-sum -list and fix calendar in the config file",
+	"%s を作成できません: %v":                                                       "Cannot create %s: %v",
+	"%s に書き込めません: %v":                                                       "Cannot write to %s: %v",
+	"設定ファイルで書き込み可能なディレクトリを指定してください":                                         "Specify a writable directory in the config file",
+	"ネットワークを使用する確認（トークンの更新、スコープ、APIへの接続）を行わない":                              "Skip checks that use the network (token refresh, scopes, API connection)",
+	"ネットワークを使用する確認のタイムアウト":                                                  "Timeout for checks that use the network",
+	"JSONの形式を修正するか、gcal-sum init で設定ファイルを作成し直してください":                        "Fix the JSON or recreate the config file with gcal-sum init",
+	"%s がないため、デフォルトの設定を使用します":                                               "%s does not exist; using the default settings",
+	"'%s' を読み込めません: %v":                                                     "Cannot load '%s': %v",
+	"設定ファイルの timezone に Asia/Tokyo のようなIANAのタイムゾーン名を指定してください":               "Set timezone in the config file to an IANA time zone name such as Asia/Tokyo",
+	"（-offline のため、ネットワークを使用する確認は行いません）\n":                                  "(Skipping checks that use the network because of -offline)\n",
+	"%d件の問題が見つかりました\n":                                                      "Found %d problem(s)\n",
+	"問題は見つかりませんでした（注意: %d件）\n":                                              "No problems found (%d warning(s))\n",
+	"問題は見つかりませんでした\n":                                                       "No problems found\n",
+	"認証時のリダイレクト先のポートは毎回変わるため、種類が「デスクトップアプリ」のOAuthクライアントIDを使用してください": "The redirect port changes for every authentication, so use an OAuth client ID of type \"Desktop app\"",
+	"127.0.0.1 で待機できません: %v": "Cannot listen on 127.0.0.1: %v",
+	"ループバックアドレスでの待機を妨げているファイアウォールなどの設定を確認してください":                 "Check firewall or other settings that prevent listening on the loopback address",
+	"127.0.0.1 の空いているポートで待機できます":                                 "Can listen on a free port on 127.0.0.1",
+	"認証コードが取得できませんでした":                                           "Could not get the authorization code",
+	"認証の準備に失敗しました":                                               "Failed to prepare authentication",
+	"認証用のローカルサーバーの起動に失敗しました":                                     "Failed to start the local server for authentication",
+	"一致したイベント一覧の並べ替えのキー（start: 開始日時, duration: 時間, name: イベント名）": "Sort key for the matched event list (start: start time, duration: duration, name: event name)",
+	"一致したイベント一覧を降順に並べる":                                          "Sort the matched event list in descending order",
+	"エラー: 並べ替えのキー '%s' はサポートされていません（%s）。\n":                      "Error: sort key '%s' is not supported (%s).\n",
+	"指定した長さ未満のイベントを集計しない（例: 30m）":                                "Exclude events shorter than the given length (e.g. 30m)",
+	"指定した長さを超えるイベントを集計しない（例: 4h）":                                "Exclude events longer than the given length (e.g. 4h)",
+	"-min-duration と -max-duration には0以上の長さを指定してください。":           "-min-duration and -max-duration must not be negative.",
+	"-min-duration が -max-duration より長くなっています。":                  "-min-duration is longer than -max-duration.",
+	"一致したイベント一覧に表示する件数（0の場合はすべて表示）":                              "Number of events to show in the matched event list (0 shows all)",
+	"エラー: -top には0以上の件数を指定してください。\n":                             "Error: -top must not be negative.\n",
+	"…ほか%d件\n":             "…and %d more\n",
+	"| | …ほか%d件 | | | |\n": "| | …and %d more | | | |\n",
+	"出力に色を付けない（環境変数 NO_COLOR を設定した場合も色を付けない）":                                            "Do not color the output (setting the NO_COLOR environment variable also disables color)",
+	"開始日（YYYY-MM-DD形式、または today、yesterday、3 days ago、last monday などの表現）":                 "Start date (YYYY-MM-DD, or an expression such as today, yesterday, 3 days ago, last monday)",
+	"終了日（YYYY-MM-DD形式、または today などの表現）":                                                  "End date (YYYY-MM-DD, or an expression such as today)",
+	"期間の表現（past 30 days、last week、this month、開始..終了 など）":                                 "Period expression (past 30 days, last week, this month, start..end, etc.)",
+	"-period は -month、-start、-end と同時に指定できません。":                                          "-period cannot be combined with -month, -start or -end.",
+	"日付 '%s' を解析できません（YYYY-MM-DD、today、yesterday、3 days ago、last monday などの形式で指定してください）": "Cannot parse the date '%s' (use YYYY-MM-DD, today, yesterday, 3 days ago, last monday, etc.)",
+	"期間 '%s' を解析できません（past 30 days、last week、this month、開始..終了 などの形式で指定してください）":          "Cannot parse the period '%s' (use past 30 days, last week, this month, start..end, etc.)",
+	"期間の解析に失敗しました":                       "Failed to parse the period",
+	"終了日が開始日より前になっています":                  "The end date is before the start date",
+	"週指定（ISO週番号、YYYY-Www形式、例: 2024-W23）": "Week (ISO week number in YYYY-Www format, e.g. 2024-W23)",
+	"今週を集計": "Summarize this week",
+	"先週を集計": "Summarize last week",
+	"週の初日（monday, sunday など、デフォルトは設定ファイルの値または monday）":                                "First day of the week (monday, sunday, etc.; defaults to the config file value or monday)",
+	"-month、-week、-this-week、-last-week、-period、-start と -end のうち、いずれか1つだけを指定してください。": "Specify only one of -month, -week, -this-week, -last-week, -period, or -start and -end.",
+	"週の初日 '%s' はサポートされていません（monday, sunday など）。":                                      "First day of the week '%s' is not supported (monday, sunday, etc.).",
+	"週 '%s' を解析できません（YYYY-Www形式、例: 2024-W23）":                                         "Cannot parse the week '%s' (use YYYY-Www, e.g. 2024-W23)",
+	"%s年に第%d週はありません":                            "%s has no week %d",
+	"週指定の解析に失敗しました":                             "Failed to parse the week",
+	"月の範囲（YYYY-MM..YYYY-MM形式）。月ごとに集計して比較表を表示する": "Month range (YYYY-MM..YYYY-MM); summarizes each month and prints a comparison table",
+	"-month、-months、-week、-this-week、-last-week、-period、-start と -end のうち、いずれか1つだけを指定してください。": "Specify only one of -month, -months, -week, -this-week, -last-week, -period, or -start and -end.",
+	"月の範囲 '%s' を解析できません（YYYY-MM..YYYY-MM形式、例: 2024-01..2024-06）":                              "Cannot parse the month range '%s' (use YYYY-MM..YYYY-MM, e.g. 2024-01..2024-06)",
+	"月の範囲 '%s' の終了月が開始月より前になっています":                                                            "The last month of the range '%s' is before the first month",
+	"-months で集計できるのは%dか月までです":                                                                "-months can cover at most %d months",
+	"月の範囲の解析に失敗しました":                                                                          "Failed to parse the month range",
+	"イベント '%s' の月別の合計時間:\n":                                                                   "Monthly totals for event '%s':\n",
+	"%s別の月別の合計時間:\n":                                                                          "Monthly totals by %s:\n",
+	"## イベント '%s' の月別の集計\n\n":                                                                 "## Monthly summary for event '%s'\n\n",
+	"### %s別の月別の合計時間\n\n":                                                                     "### Monthly totals by %s\n\n",
+	"| 月 | 合計時間 | 件数 | 前月比 |\n":                                                               "| Month | Total time | Count | vs. previous month |\n",
+	"| **合計** | **%s** | **%d** | |\n":                                                        "| **Total** | **%s** | **%d** | |\n",
+	"| 月平均 | %s | | |\n\n":                                                                    "| Monthly average | %s | | |\n\n",
+	" 合計 |\n":                                                                                 " Total |\n",
+	"合計時間":                                                                                    "Total time",
+	"件数":                                                                                      "Count",
+	"前月比":                                                                                     "vs. previous month",
+	"合計":                                                                                      "Total",
+	"月平均":                                                                                     "Monthly average",
+	"%d件":                                                                                     "%d",
+	"1件あたりの集計する時間の上限（例: 2h）。0の場合は上限なし":                          "Maximum time counted per event (e.g. 2h); 0 means no limit",
+	"長さが外れ値のイベントの扱い（flag: 表示する, exclude: 集計から除外する, off: 検出しない）": "How to handle events with outlier lengths (flag: show them, exclude: leave them out of the totals, off: do not detect)",
+	"-cap-duration には0以上の長さを指定してください。":                          "Specify a length of 0 or more for -cap-duration.",
+	"外れ値の扱い '%s' はサポートされていません（%s）。":                             "Outlier handling '%s' is not supported (%s).",
+	"（1件あたりの上限を超えた %s を切り詰め済み）\n":                               "(%s over the per-event limit has been cut)\n",
+	"- 1件あたりの上限を超えて切り詰めた時間: %s\n":                               "- Time cut by the per-event limit: %s\n",
+	" (上限により %s を切り詰め)":                                         " (%s cut by the limit)",
+	" (外れ値)":                                                    " (outlier)",
+	"%s以上":                                                      "%s or longer",
+	"同名のイベント%d件の中央値 %s":                                         "median of %d events with the same name: %s",
+	"長さが外れ値のイベント (%d件):\n":                                      "Events with outlier lengths (%d):\n",
+	"長さが外れ値のため集計から除外したイベント (%d件):\n":                            "Events excluded from the totals as length outliers (%d):\n",
+	"### 長さが外れ値のイベント\n\n":                                       "### Events with outlier lengths\n\n",
+	"### 長さが外れ値のため集計から除外したイベント\n\n":                             "### Events excluded from the totals as length outliers\n\n",
+	"| イベント | 開始 | 終了 | 長さ | 理由 |\n":                            "| Event | Start | End | Length | Reason |\n",
+	"集計の前に、一致したイベントを1件ずつ確認して除外できるようにする":                         "Confirm each matched event before summing so that it can be excluded",
+	"エラー: -interactive は端末から実行してください。\n":                        "Error: run -interactive from a terminal.\n",
+	"一致したイベントを集計に含めるかを確認します（y: 含める, n: 除外する, a: 残りをすべて含める, q: 中止する）\n": "Confirm whether to include each matched event (y: include, n: exclude, a: include all remaining, q: quit)\n",
+	"[%d/%d] %s (%s～%s) [%s]":               "[%d/%d] %s (%s - %s) [%s]",
+	"集計に含めますか？ [Y/n/a/q]: ":                 "Include in the total? [Y/n/a/q]: ",
+	"イベントの確認を中止しました":                        "Event confirmation was cancelled",
+	"イベントの確認中に入力が終了しました":                    "Input ended while confirming events",
+	"イベントの確認を中断しました":                        "Event confirmation was interrupted",
+	"（確認により %d件 %s を除外済み）\n":                "(%d events, %s excluded during confirmation)\n",
+	"- 確認により除外したイベント: %d件（%s）\n":            "- Events excluded during confirmation: %d (%s)\n",
+	"カレンダーにない作業時間を加えたり差し引いたりする調整ファイル（YAML）": "Adjustments file (YAML) that adds or subtracts time not on the calendar",
+	"調整ファイルの読み込みに失敗しました":                    "Failed to load the adjustments file",
+	"手動調整": "Manual adjustment",
+	"（手動調整 %d件 %s を含む）\n":            "(includes %d manual adjustments, %s)\n",
+	"- 手動調整: %d件（%s）\n":              "- Manual adjustments: %d (%s)\n",
+	"%d. [手動] %s (%s) [%s]\n":        "%d. [manual] %s (%s) [%s]\n",
+	"| %d | [手動] %s | %s | | %s |\n": "| %d | [manual] %s | %s | | %s |\n",
+	"集計から除外するイベントのID（繰り返し指定可能、カンマ区切りでも指定可能）":                                   "ID of an event to exclude from the totals (repeatable, or comma-separated)",
+	"設定ファイルの exclude_events を適用しない":                                            "Do not apply exclude_events from the config file",
+	"一致したイベント一覧にイベントのIDを表示（-exclude-id や設定ファイルの exclude_events に指定するIDの確認に使用）": "Show event IDs in the matched event list (to find IDs for -exclude-id or exclude_events in the config file)",
+	"（除外リストにより %d件を除外済み）\n":                                                    "(%d events excluded by the exclusion list)\n",
+	"- 除外リストにより除外したイベント: %d件\n":                                                "- Events excluded by the exclusion list: %d\n",
+	"説明の「clientA: 30m / clientB: 60m」形式の行に従って、プロジェクト別の集計でイベントの時間を分割する":         "Split event durations across projects in project-grouped reports using description lines like \"clientA: 30m / clientB: 60m\"",
+	" (分割: %s)": " (split: %s)",
+	"イベントの時間に参加者の人数を掛けた延べ時間と、主催者ごとの内訳を表示": "Show person-hours (duration multiplied by attendee count) with a per-organizer breakdown",
+	"延べ時間（参加者数×時間）: %s\n":                 "Person-hours (attendees × duration): %s\n",
+	"- %s: %s (%d件, 平均%.1f人, 時間 %s)":      "- %s: %s (%d events, avg %.1f people, duration %s)",
+	" ×%d人": " ×%d people",
+	"### 主催者別の延べ時間（参加者数×時間）\n\n":           "### Person-hours by organizer (attendees × duration)\n\n",
+	"| 主催者 | 件数 | 平均人数 | 時間 | 延べ時間 |\n":    "| Organizer | Count | Avg attendees | Duration | Person-hours |\n",
+	"| **合計** | | | **%s** | **%s** |\n\n": "| **Total** | | | **%s** | **%s** |\n\n",
+	"サービスアカウントによる認証の設定に失敗しました":             "Failed to set up service account authentication",
+	"イベント '%s' の利用者別の合計時間:\n":              "Total time for event '%s' by user:\n",
+	"利用者":   "User",
+	"実施日数":  "Active days",
+	"割合":    "Share",
+	"エラー":   "Error",
+	"1人あたり": "Per person",
+	"%d日":   "%d days",
+	"## イベント '%s' の利用者別の集計\n\n":          "## Summary of event '%s' by user\n\n",
+	"| 利用者 | 合計時間 | 件数 | 実施日数 | 割合 |\n":  "| User | Total | Count | Active days | Share |\n",
+	"| %s | エラー | | | |\n":               "| %s | Error | | | |\n",
+	"| **合計** | **%s** | **%d** | | |\n": "| **Total** | **%s** | **%d** | | |\n",
+	"| 1人あたり | %s | | | |\n\n":           "| Per person | %s | | | |\n\n",
+	"- %s の集計に失敗しました: %s\n":              "- Failed to summarize %s: %s\n",
+	"集計する利用者のメールアドレス、カンマ区切りで複数指定（デフォルトは設定ファイルの team.users）":             "Email addresses of users to summarize, comma-separated (defaults to team.users in the config file)",
+	"ドメイン全体の委任を許可したサービスアカウントの鍵ファイル（デフォルトは設定ファイルの team.service_account）": "Key file of a service account with domain-wide delegation (defaults to team.service_account in the config file)",
+	"同時に集計する利用者の数": "Number of users to summarize concurrently",
+	"エラー: -users または設定ファイルの team.users で集計する利用者を指定してください。\n":                             "Error: specify users to summarize with -users or team.users in the config file.\n",
+	"エラー: -service-account または設定ファイルの team.service_account でサービスアカウントの鍵ファイルを指定してください。\n": "Error: specify a service account key file with -service-account or team.service_account in the config file.\n",
+	"エラー: team では -months を指定できません。\n":                                                   "Error: -months cannot be used with team.\n",
+	"ICSファイルやフィクスチャを使用する場合は、すべての利用者で同じイベントを集計します":                                        "With an ICS file or fixture, the same events are summarized for every user",
+	"一部の利用者の集計に失敗しました":                                                                   "Failed to summarize some users",
+	"会議室別の予約時間と利用率:\n":                                                                   "Booked hours and utilization by room:\n",
+	"会議室":    "Room",
+	"予約時間":   "Booked",
+	"予約数":    "Bookings",
+	"利用可能時間": "Available",
+	"利用率":    "Utilization",
+	"空予約の候補": "No-show candidates",
+	"%s の参加を承諾した人がいない予約 (%d件):\n": "Bookings in %s with no accepted attendees (%d):\n",
+	"- %s (%s～%s) 主催者: %s\n":      "- %s (%s-%s) organizer: %s\n",
+	"エラー: -rooms または設定ファイルの rooms で会議室のリソースカレンダーのIDを指定してください。\n": "Error: specify room resource calendar IDs with -rooms or rooms in the config file.\n",
+	"会議室の予約の取得に失敗しました": "Failed to fetch room bookings",
+	"会議室のリソースカレンダーのID、カンマ区切りで複数指定可能（デフォルトは設定ファイルの rooms）":       "Room resource calendar IDs, comma-separated (defaults to rooms in the config file)",
+	"会議室を利用できる時間帯（HH:MM-HH:MM形式）":                               "Hours when rooms are available (HH:MM-HH:MM)",
+	"会議室を利用できる曜日（例: mon-fri, mon,wed,fri）":                      "Days when rooms are available (e.g. mon-fri, mon,wed,fri)",
+	"Calendar APIへのリクエストの1秒あたりの上限（利用者ごと）。0の場合は制限しない":            "Maximum Calendar API requests per second (per user); 0 disables the limit",
+	"Calendar APIに連続して送信できるリクエストの数":                             "Number of Calendar API requests that can be sent in a burst",
+	"エラー: -rate-limit には0以上、-rate-burst には1以上の値を指定してください。\n":    "Error: -rate-limit must be 0 or greater and -rate-burst must be 1 or greater.\n",
+	"リクエストの上限に達したため待機します":                                       "Waiting for the request rate limit",
+	"エラー: -no-list と -export-ics は同時に指定できません。\n":                "Error: -no-list and -export-ics cannot be used together.\n",
+	"エラー: -no-list と -chart を同時に指定する場合は -group-by も指定してください。\n": "Error: specify -group-by when using -no-list with -chart.\n",
+	"一致したイベント一覧を表示せず、合計時間・統計・グループ別の集計だけを表示（イベント一覧を保持せずに逐次集計するため、長い期間でもメモリの使用量が増えない）": "Show only the total, statistics and group totals without the list of matched events (aggregates in a streaming fashion without keeping the events, so memory use stays flat over long ranges)",
+	"イベント一覧を保持せずに逐次集計します":                              "Aggregating in a streaming fashion without keeping the event list",
+	"CPUプロファイル（pprof形式）を書き出すファイルのパス":                   "Path of the file to write the CPU profile to (pprof format)",
+	"終了時のメモリのプロファイル（pprof形式）を書き出すファイルのパス":              "Path of the file to write the memory profile at exit to (pprof format)",
+	"CPUプロファイルの作成に失敗しました":                              "Failed to create the CPU profile",
+	"CPUプロファイルの記録の開始に失敗しました":                           "Failed to start recording the CPU profile",
+	"CPUプロファイルの書き出しに失敗しました":                            "Failed to write the CPU profile",
+	"CPUプロファイルを書き出しました":                                "Wrote the CPU profile",
+	"メモリのプロファイルの書き出しに失敗しました":                           "Failed to write the memory profile",
+	"メモリのプロファイルを書き出しました":                               "Wrote the memory profile",
+	"イベントの取得と集計にかかった時間":                                "Time spent fetching and aggregating events",
+	"イベントを取得する期間を決めました":                                "Planned the event fetch windows",
+	"スナップショット %s はすでに存在します（上書きする場合は -force を指定してください）": "Snapshot %s already exists (use -force to overwrite it)",
+	"[手動] %s (%s)":         "[manual] %s (%s)",
+	"'%s'（%s 作成）":          "'%s' (created %s)",
+	"スナップショット %sと%sの比較:\n": "Comparison of snapshot %s with %s:\n",
+	"スナップショット %sと現在の集計結果の比較:\n": "Comparison of snapshot %s with the current results:\n",
+	"イベント: '%s'\n":                               "Event: '%s'\n",
+	"合計時間: %s → %s (%s)\n":                       "Total: %s → %s (%s)\n",
+	"スナップショットから変更はありません。\n":                      "No changes since the snapshot.\n",
+	"追加されたイベント (%d件, %s):\n":                     "Added events (%d, %s):\n",
+	"削除されたイベント (%d件, %s):\n":                     "Removed events (%d, %s):\n",
+	"時間が変わったイベント (%d件, %s):\n":                   "Events with changed durations (%d, %s):\n",
+	"## イベント '%s' のスナップショットとの比較\n\n":             "## Comparison of '%s' with a snapshot\n\n",
+	"- スナップショット: %s\n":                           "- Snapshot: %s\n",
+	"- 比較するスナップショット: %s\n":                       "- Compared with snapshot: %s\n",
+	"- 合計時間: %s → **%s** (%s)\n\n":               "- Total: %s → **%s** (%s)\n\n",
+	"| 変更 | イベント | 変更前 | 変更後 | 増減 |\n":           "| Change | Event | Before | After | Difference |\n",
+	"| 追加 | %s | | %s | %s |\n":                  "| Added | %s | | %s | %s |\n",
+	"| 削除 | %s | %s | | %s |\n":                  "| Removed | %s | %s | | %s |\n",
+	"| 変更 | %s | %s | %s | %s |\n":               "| Changed | %s | %s | %s | %s |\n",
+	"スナップショットの名前（デフォルトは作成日時）":                    "Name of the snapshot (default: the creation time)",
+	"同じ名前のスナップショットがある場合は上書きする":                   "Overwrite a snapshot with the same name",
+	"保存したスナップショットの一覧を表示":                         "List saved snapshots",
+	"スナップショットの読み込みに失敗しました":                       "Failed to load the snapshot",
+	"スナップショットがありません（%s）。\n":                      "No snapshots (%s).\n",
+	"%s  %s  '%s' %s～%s  %s (%d件)\n":             "%s  %s  '%s' %s-%s  %s (%d events)\n",
+	"エラー: snapshot では -months を指定できません。\n":       "Error: -months cannot be used with snapshot.\n",
+	"スナップショットの保存に失敗しました":                         "Failed to save the snapshot",
+	"スナップショット '%s' を保存しました: %s（%s、%d件）\n":        "Saved snapshot '%s': %s (%s, %d events)\n",
+	"エラー: 比較するスナップショットの名前またはファイルのパスを指定してください。\n": "Error: specify the name or file path of the snapshot to compare.\n",
+	"スナップショットの集計条件の解析に失敗しました":                    "Failed to parse the query of the snapshot",
+	"合計時間の根拠となったイベント（ID・iCalUID・取得時点の更新日時・一致した条件）を書き出す監査記録のファイルのパス（既存のファイルは上書きしない）": "Path of an audit record file listing the events behind the total (IDs, iCalUIDs, update times at fetch, matched rules); existing files are never overwritten",
+	"エラー: -audit は -no-list や -months と同時に指定できません。\n": "Error: -audit cannot be combined with -no-list or -months.\n",
+	"監査記録の書き出しに失敗しました":                                "Failed to write the audit record",
+	"監査記録を書き出しました":                                    "Wrote the audit record",
+	"監査記録 %s はすでに存在します（監査記録は上書きしません）":                 "Audit record %s already exists (audit records are never overwritten)",
+	"すべての出力でイベント名・参加者・主催者を仮名に置き換え、説明と場所を取り除く（時間とグループごとの集計はそのまま）":  "Replace event titles, attendees and organizers with pseudonyms and strip descriptions and locations in every output (durations and grouping are preserved)",
+	"エラー: -audit は元のイベントを特定するための記録のため、-anonymize と同時には指定できません。\n": "Error: -audit records the original events and cannot be combined with -anonymize.\n",
+	"認証と権限の管理":                                                     "Manage authentication and permissions",
+	"カレンダーの読み取り（集計）":                                               "Read calendars (aggregation)",
+	"カレンダーへのイベントの書き込み（export calendar）":                            "Write events to calendars (export calendar)",
+	"Google スプレッドシートへの書き込み（export sheets）":                         "Write to Google Sheets (export sheets)",
+	"この機能には追加の権限が必要です（端末から gcal-sum auth scopes -grant で許可してください）": "This feature needs additional permissions (grant them from a terminal with gcal-sum auth scopes -grant)",
+	"この機能には追加の権限が必要なため、ブラウザで許可してください":                              "This feature needs additional permissions; grant them in the browser",
+	"HTTP %d": "HTTP %d",
+	"権限 '%s' はサポートされていません（%s）。":                                     "Permission '%s' is not supported (%s).",
+	"追加で許可する権限（calendar-write, sheets）、カンマ区切りで複数指定可能":               "Permissions to grant in addition (calendar-write, sheets), comma-separated",
+	"許可済みの権限について、ブラウザで改めて許可する":                                      "Grant the already granted permissions again in the browser",
+	"処理全体のタイムアウト（例: 5m）。0の場合はタイムアウトしない":                             "Timeout for the whole run (e.g. 5m); 0 means no timeout",
+	"%s がありません（まだ認証していません）。gcal-sum を実行するか、-grant を指定して認証してください。\n": "%s does not exist (not authenticated yet). Run gcal-sum or authenticate with -grant.\n",
+	"トークンファイルの読み込みに失敗しました":                                          "Failed to read the token file",
+	"アクセストークンを取得できないため、保存したスコープを表示します":                              "Could not get an access token; showing the saved scopes",
+	"付与されているスコープを確認できないため、保存したスコープを表示します":                           "Could not check the granted scopes; showing the saved scopes",
+	"トークン: %s\n":     "Token: %s\n",
+	"権限":             "Permission",
+	"用途":             "Used for",
+	"その他のスコープ: %s\n": "Other scope: %s\n",
+	"gcal-sum auth scopes -reconsent を実行して、ブラウザで許可をやり直してください":                   "Run gcal-sum auth scopes -reconsent and grant the permissions again in the browser",
+	"環境変数 %s にトークンファイルを暗号化する鍵を指定してください（gcal-sum auth key で生成できます）":              "Set the key to encrypt the token file in the environment variable %s (generate one with gcal-sum auth key)",
+	"token_encryption '%s' はサポートされていません（%s）":                                    "token_encryption '%s' is not supported (%s)",
+	"トークンファイルを暗号化する鍵は、32バイトの値をBase64で表したものを指定してください（gcal-sum auth key で生成できます）": "The key to encrypt the token file must be 32 bytes encoded in Base64 (generate one with gcal-sum auth key)",
+	"この環境ではOSのキーリングを使用できません。環境変数 %s で鍵を指定してください":                                "The OS keyring is not available here; set the key in the environment variable %s",
+	"鍵が保存されていません": "No key is stored",
+	"OSのキーリングから鍵を取得できません（gcal-sum auth key -keyring で鍵を生成して保存してください）: %v":    "Could not get the key from the OS keyring (generate and store one with gcal-sum auth key -keyring): %v",
+	"平文のトークンファイルを暗号化しました":                                                    "Encrypted the plaintext token file",
+	"トークンファイルの暗号化に失敗しました":                                                    "Failed to encrypt the token file",
+	"トークンファイルの暗号方式 '%s' はサポートされていません":                                        "Token file encryption '%s' is not supported",
+	"トークンファイルは暗号化されています。復号する鍵を環境変数 %s または設定ファイルの token_encryption で指定してください": "The token file is encrypted; specify the key with the environment variable %s or token_encryption in the config file",
+	"トークンファイルを復号できません（暗号化した時と鍵が異なる可能性があります）":                                 "Could not decrypt the token file (the key may differ from the one used to encrypt it)",
+	"トークンファイルを暗号化する鍵を取得できません":                                                "Could not get the key to encrypt the token file",
+	"トークンファイルの暗号化の設定が不正です":                                                   "Invalid token file encryption setting",
+	"トークンファイルを読み込めません":                                                       "Could not read the token file",
+	"トークンの暗号化": "Token encryption",
+	"設定ファイルの token_encryption に env または keyring を指定してください":                   "Set token_encryption in the config file to env or keyring",
+	"トークンファイルを暗号化する鍵の生成":                                                     "Generate a key to encrypt the token file",
+	"生成した鍵を表示せずにOSのキーリングに保存する（設定ファイルの token_encryption に keyring を指定して使用する）": "Store the generated key in the OS keyring instead of printing it (use with token_encryption set to keyring in the config file)",
+	"OSのキーリングに保存済みの鍵を置き換える（置き換える前の鍵で暗号化したトークンファイルは読み込めなくなる）":                 "Replace the key stored in the OS keyring (token files encrypted with the previous key become unreadable)",
+	"エラー: OSのキーリングにはすでに鍵が保存されています（置き換える場合は -force を指定してください）。\n":             "Error: a key is already stored in the OS keyring (use -force to replace it).\n",
+	"鍵の生成に失敗しました": "Failed to generate a key",
+	"この鍵を環境変数 %s に指定すると、トークンファイルを暗号化して保存します。\n":                                             "Set this key in the environment variable %s to store the token file encrypted.\n",
+	"OSのキーリングへの鍵の保存に失敗しました":                                                                 "Failed to store the key in the OS keyring",
+	"鍵をOSのキーリングに保存しました（サービス: %s、アカウント: %s）。設定ファイルの token_encryption に keyring を指定してください。\n": "Stored the key in the OS keyring (service: %s, account: %s). Set token_encryption to keyring in the config file.\n",
+	"トークンをGoogleで取り消し、トークンファイルを削除する":                                                        "Revoke the token with Google and delete the token files",
+	"HTTP %d %s": "HTTP %d %s",
+	"確認せずに取り消す":  "Revoke without asking for confirmation",
+	"トークンを取り消せなかった場合もトークンファイルを削除する":                               "Delete the token files even if the token could not be revoked",
+	"トークンの取り消しのタイムアウト":                                            "Timeout for revoking the token",
+	"%s がありません（まだ認証していないか、すでに削除されています）。\n":                        "%s does not exist (not authenticated yet or already deleted).\n",
+	"エラー: 端末から実行するか、-yes を指定してください。\n":                            "Error: run this from a terminal or specify -yes.\n",
+	"gcal-sum に許可したカレンダーなどへのアクセスを取り消し、%s を削除します。よろしいですか？ [y/N]: ": "This revokes the access granted to gcal-sum and deletes %s. Continue? [y/N]: ",
+	"取り消しを中止しました。\n":                                              "Cancelled.\n",
+	"トークンファイルを読み込めないため、トークンを取り消せません":                              "Cannot revoke the token because the token file cannot be read",
+	"トークンの取り消しに失敗しました":                                            "Failed to revoke the token",
+	"トークンを取り消しました":                                                "Revoked the token",
+	"トークンはすでに無効になっています":                                           "The token is already invalid",
+	"トークンを取り消せなかったため、トークンファイルを削除していません（削除する場合は -force を指定してください）": "The token files were not deleted because the token could not be revoked (use -force to delete them)",
+	"取り消せなかったトークンは、Googleアカウントの「サードパーティ製のアプリとサービス」の画面から取り消してください": "Revoke the remaining tokens from the \"Third-party apps & services\" page of your Google Account",
+	"%s を削除しました\n": "Deleted %s\n",
+	"確認を中断しました":    "Confirmation was interrupted",
+	"指定されたOAuthクライアント（クライアントID: %s）にシークレットがありません":                           "The specified OAuth client (client ID: %s) has no secret",
+	"-client-secret または環境変数 GCAL_SUM_CLIENT_SECRET でクライアントのシークレットを指定してください": "Specify the client secret with -client-secret or the GCAL_SUM_CLIENT_SECRET environment variable",
+	"フラグ・環境変数・設定ファイルで指定したOAuthクライアント（クライアントID: %s）":                         "OAuth client specified by flag, environment variable or config file (client ID: %s)",
+	"組み込みのOAuthクライアント（クライアントID: %s）":                                        "Embedded OAuth client (client ID: %s)",
+	"指定されたOAuthクライアントを使用するため、credentials.json は不要です。\n":                     "Using the specified OAuth client, so credentials.json is not needed.\n",
+	"別名 '%s' のイベント名を指定してください。":                                              "Specify the event names for alias '%s'.",
+	"-strict と -ignore-emoji は同時に指定できません。":                                  "-strict and -ignore-emoji cannot be used together.",
+	"-daily-cap には0以上の長さを指定してください。":                                         "Specify a length of 0 or more for -daily-cap.",
+	"（1日あたりの上限 %s を超えた %s を切り詰め済み。上限を適用する前の合計: %s）\n":                       "(%[2]s over the daily cap of %[1]s was trimmed. Total before the cap: %[3]s)\n",
+	" (1日の上限により %s を切り詰め)":                                                  " (%s trimmed by the daily cap)",
+	"- 1日あたりの上限（%s）を超えて切り詰めた時間: %s（上限を適用する前の合計: %s）\n":                      "- Time trimmed by the daily cap (%s): %s (total before the cap: %s)\n",
+	"特別条項の上限 %s 以上":                          "at or above the special-clause limit of %s",
+	"月の上限 %s を超過":                            "over the monthly limit of %s",
+	"%dか月平均 %s が上限 %s を超過":                   "%d-month average %s is over the limit of %s",
+	"残業時間（所定労働時間: 1日 %s・週 %s）:\n":            "Overtime (contracted hours: %s per day, %s per week):\n",
+	"- 時間外労働の合計: %s（年の上限 %s）\n":              "- Total overtime: %s (yearly limit %s)\n",
+	"- 所定労働時間に対する過不足: %s\n":                  "- Balance against contracted hours: %s\n",
+	"- %s: %s / 上限 %s":                       "- %s: %s / limit %s",
+	"- 月の上限を超えた月が %d回あります（年%d回まで）\n":         "- %d months are over the monthly limit (up to %d per year)\n",
+	"- 月の上限を超えた月: %d回（年%d回まで）\n":             "- Months over the monthly limit: %d (up to %d per year)\n",
+	"- 月の上限を超えた月: %d回（年%d回まで）\n\n":           "- Months over the monthly limit: %d (up to %d per year)\n\n",
+	"- 時間外労働の合計が年の上限 %s を超えています\n":           "- Total overtime is over the yearly limit of %s\n",
+	"日ごとの残業時間:\n":                            "Daily overtime:\n",
+	"- %s: 実働 %s, 所定 %s, 残業 %s, 過不足の累計 %s\n": "- %s: worked %s, contracted %s, overtime %s, cumulative balance %s\n",
+	"### 残業時間（所定労働時間: 1日 %s・週 %s）\n\n":       "### Overtime (contracted hours: %s per day, %s per week)\n\n",
+	"| 月 | 時間外労働 | 上限 | 注意 |\n":              "| Month | Overtime | Limit | Notes |\n",
+	"| 日付 | 実働 | 所定 | 残業 | 過不足の累計 |\n":       "| Date | Worked | Contracted | Overtime | Cumulative balance |\n",
+	"（%s）": " (%s)",
+	"、":    ", ",
+	"-back-to-back-gap には0以上の長さを指定してください。":               "Specify a length of 0 or more for -back-to-back-gap.",
+	"予定の切り替え（%s 未満の間隔は連続した予定として数える）:\n":                  "Context switches (gaps shorter than %s count as back-to-back):\n",
+	"- 切り替え: %d回 / 連続した予定: %d回 / 予定の間隔の平均: %s\n":         "- Switches: %d / back-to-back: %d / average gap: %s\n",
+	"- 1日あたりの切り替え: %.1f回 / 連続した予定: %.1f回\n":              "- Switches per day: %.1f / back-to-back: %.1f\n",
+	"- %s: %d件, 切り替え %d回, 連続 %d回, 間隔の平均 %s\n":            "- %s: %d events, %d switches, %d back-to-back, average gap %s\n",
+	"### 予定の切り替え（%s 未満の間隔は連続した予定として数える）\n\n":             "### Context switches (gaps shorter than %s count as back-to-back)\n\n",
+	"| 日付 | 件数 | 切り替え | 連続 | 間隔の平均 |\n":                  "| Date | Events | Switches | Back-to-back | Average gap |\n",
+	"| **合計** | **%d** | **%d** | **%d** | **%s** |\n\n": "| **Total** | **%d** | **%d** | **%d** | **%s** |\n\n",
+	"エラー: run では -months を指定できません。\n":                    "Error: -months cannot be used with run.\n",
+	"集計ごとの合計時間:\n":                                       "Total time per query:\n",
+	"集計":                                                 "Query",
+	"## 集計ごとの合計時間\n\n":                                   "## Total time per query\n\n",
+	"- 検索期間: %s から %s\n\n":                               "- Period: %s to %s\n\n",
+	"| 集計 | 合計時間 | 件数 | 実施日数 |\n":                        "| Query | Total | Count | Active days |\n",
+	"カレンダーのタイムゾーンの取得に失敗したため、表示のタイムゾーンを使用します":             "Failed to get the calendar's time zone; using the display time zone",
+	"カレンダーのタイムゾーンの読み込みに失敗したため、表示のタイムゾーンを使用します":           "Failed to load the calendar's time zone; using the display time zone",
+	"カレンダーのタイムゾーンを読み込みました":                               "Loaded the calendar's time zone",
+	"イベントの日時の解析に失敗しました":                                  "Failed to parse the event's date and time",
+	"start または end がありません":                               "Start or end is missing",
+	"開始日時の解析に失敗しました":                                     "Failed to parse the start time",
+	"終了日時の解析に失敗しました":                                     "Failed to parse the end time",
+	"終了日時が開始日時より前になっています":                                "The end time is before the start time",
+	"ページの表示に失敗しました":                                      "Failed to render the page",
+	"HTMLレポートの作成に失敗しました":                                 "Failed to create the HTML report",
+	"レポートファイルの作成に失敗しました":                                 "Failed to create the report file",
+	"スプレッドシートへの書き込みに失敗しました":                              "Failed to write to the spreadsheet",
+	"ローカルストアの同期に失敗しました":                                  "Failed to sync the local store",
+	"カレンダーを同期しました":                                       "Synced the calendar",
+	"同期トークンが無効になったため、全件を取得し直します":                         "The sync token is no longer valid; fetching all events again",
+	"APIの呼び出しに失敗したため再試行します":                              "API call failed; retrying",
+	"APIの割り当て上限に達したため、イベントの取得を中断しました":                    "Stopped fetching events because the API quota was exhausted",
+	"割り当てが回復した後に同じ条件に -resume を付けて実行すると、続きから取得します":       "Run the same command with -resume after the quota recovers to continue",
+	"未取得の期間": "Remaining periods",
+	"チェックポイントの保存に失敗しました":   "Failed to save the checkpoint",
+	"チェックポイントの読み込みに失敗しました": "Failed to read the checkpoint",
+	"チェックポイントの削除に失敗しました":   "Failed to remove the checkpoint",
+	"前回の実行で取得済みの期間を再利用します": "Reusing periods fetched in the previous run",
+
+	// サブコマンドの使用方法と表示（busy, export, auth, completion, run, history, heatmap, init）
+	"使用方法: gcal-sum busy -month=YYYY-MM [-calendar=カレンダーID] [-work-hours=09:00-18:00] [-workdays=mon-fri]\n": "Usage: gcal-sum busy -month=YYYY-MM [-calendar=CALENDAR_ID] [-work-hours=09:00-18:00] [-workdays=mon-fri]\n",
+	"カレンダー %s の空き時間を取得できませんでした: %s":                                                                          "Could not fetch free/busy information for calendar %s: %s",
+	"カレンダー別の予定あり時間:\n":                                                                                       "Busy time by calendar:\n",
+	"対象時間: %s\n":      "Time considered: %s\n",
+	"予定あり: %s\n":      "Busy: %s\n",
+	"空き時間: %s\n":      "Free: %s\n",
+	"予定の割合: %.1f%%\n": "Busy ratio: %.1f%%\n",
+	"%s別の合計時間グラフ:\n":  "Chart of total time by %s:\n",
+	"使用方法: gcal-sum export <出力先> [オプション]\n": "Usage: gcal-sum export <target> [options]\n",
+	"出力先:\n": "Targets:\n",
+	"  sheets   Google スプレッドシートに集計結果を追記する\n":                                                          "  sheets   Append the totals to a Google Sheets spreadsheet\n",
+	"  toggl    一致したイベントをToggl TrackのCSVインポート形式で出力する\n":                                               "  toggl    Write the matched events in Toggl Track's CSV import format\n",
+	"  harvest  一致したイベントをHarvestのCSVインポート形式で出力する\n":                                                   "  harvest  Write the matched events in Harvest's CSV import format\n",
+	"  jira     一致したイベントをJiraの課題の作業ログとして登録する\n":                                                       "  jira     Log the matched events as work logs on Jira issues\n",
+	"  calendar 集計結果をカレンダーに終日イベントとして書き込む\n":                                                           "  calendar Write the totals to a calendar as all-day events\n",
+	"  pdf      一致したイベントから署名欄付きの作業報告書をPDFで出力する\n":                                                     "  pdf      Create a PDF timesheet with a signature field from the matched events\n",
+	"エラー: 出力先を指定してください。\n":                                                                            "Error: specify a target.\n",
+	"エラー: 出力先 '%s' はサポートされていません。\n":                                                                   "Error: target '%s' is not supported.\n",
+	"エラー: スプレッドシートIDを指定してください。\n":                                                                     "Error: specify the spreadsheet ID.\n",
+	"使用方法: gcal-sum export sheets -spreadsheet=スプレッドシートID [-sheet=シート名] -month=YYYY-MM -name=イベント名\n": "Usage: gcal-sum export sheets -spreadsheet=SPREADSHEET_ID [-sheet=SHEET_NAME] -month=YYYY-MM -name=EVENT_NAME\n",
+	"使用方法: gcal-sum auth <サブコマンド> [オプション]\n":                                                          "Usage: gcal-sum auth <subcommand> [options]\n",
+	"サブコマンド:\n": "Subcommands:\n",
+	"  scopes   トークンに付与されている権限の表示と、追加の権限の許可\n":      "  scopes   Show the permissions granted to the token and grant additional ones\n",
+	"  key      トークンファイルを暗号化する鍵の生成\n":               "  key      Generate a key for encrypting the token file\n",
+	"  revoke   トークンをGoogleで取り消し、トークンファイルを削除する\n":   "  revoke   Revoke the token with Google and delete the token file\n",
+	"エラー: サブコマンドを指定してください。\n":                       "Error: specify a subcommand.\n",
+	"エラー: サブコマンド '%s' はサポートされていません。\n":              "Error: subcommand '%s' is not supported.\n",
+	"エラー: シェルを指定してください（%s）。\n":                      "Error: specify a shell (%s).\n",
+	"使用方法: gcal-sum completion <シェル>\n":             "Usage: gcal-sum completion <shell>\n",
+	"設定ファイルに queries が設定されていません":                    "No queries are configured in the config file",
+	"queries の label を指定してください":                     "Specify a label for each query",
+	"queries の label '%s' が重複しています":                 "Query label '%s' is duplicated",
+	"集計 '%s' には期間（%s）を指定できません。期間は run の引数で指定してください": "Query '%s' cannot specify a period (%s); specify the period as an argument to run",
+	"集計 '%s' の条件 '%s' はサポートされていません":                 "Query '%s': condition '%s' is not supported",
+	"集計 '%s'": "Query '%s'",
+	"エラー: イベント名を指定してください。\n":                                   "Error: specify an event name.\n",
+	"使用方法: gcal-sum history -name=イベント名 [-calendar=カレンダーID]\n": "Usage: gcal-sum history -name=EVENT_NAME [-calendar=CALENDAR_ID]\n",
+	"イベント '%s' の履歴が見つかりませんでした。\n":                              "No history was found for event '%s'.\n",
+	"イベント '%s' の合計時間の推移:\n":                                    "Total time history for event '%s':\n",
+	"%s～%s: %s (%d件) [前期間比 %s]\n":                              "%s - %s: %s (%d events) [vs previous period %s]\n",
+	"イベント '%s' の曜日×時間帯ヒートマップ（合計 %s）:\n\n":                      "Weekday × hour heatmap for event '%s' (total %s):\n\n",
+	"凡例: 少 %s 多（1マスの最大 %s）\n":                                  "Legend: less %s more (max per cell %s)\n",
+	"gcal-sum の初期設定を開始します。\n":                                  "Starting gcal-sum setup.\n",
+	"[1/4] 認証情報ファイル\n":                                         "[1/4] Credentials file\n",
+	"credentials.json のパス":                                     "Path to credentials.json",
+	"ファイルを読み込めませんでした: %v\n":                                    "Could not read the file: %v\n",
+	"認証情報ファイルの形式が正しくありません: %v\n":                               "The credentials file is not in a valid format: %v\n",
+	"[2/4] Googleアカウントの認証\n":                                   "[2/4] Google account authentication\n",
+	"トークンファイルの保存先":                                             "Where to save the token file",
+	"認証に成功しました。\n":                                             "Authentication succeeded.\n",
+	"[3/4] デフォルトのカレンダー\n":                                      "[3/4] Default calendar\n",
+	"使用するカレンダーの番号またはID":                                        "Number or ID of the calendar to use",
+	"1 から %d の番号を入力してください。\n":                                  "Enter a number from 1 to %d.\n",
+	"[4/4] タイムゾーン\n":                                           "[4/4] Time zone\n",
+	"タイムゾーン（例: Asia/Tokyo）":                                    "Time zone (e.g. Asia/Tokyo)",
+	"タイムゾーンを読み込めませんでした: %v\n":                                  "Could not load the time zone: %v\n",
+	"設定を %s に保存しました。\n":                                        "Saved the settings to %s.\n",
+
+	// 設定ファイルと入力ファイルの検証のエラー
+	"「+2h 2024-06-03 メモ」の形式で時間と日付を指定してください":            "Specify the time and date in the form \"+2h 2024-06-03 note\"",
+	"日付 '%s' が不正です（YYYY-MM-DD形式で指定してください）":             "Invalid date '%s' (use the YYYY-MM-DD format)",
+	"時間 '%s' が不正です（+2h、-30m などの形式で指定してください）":           "Invalid duration '%s' (use a form such as +2h or -30m)",
+	"%s: 調整のリスト（adjustments）がありません":                    "%s: no list of adjustments (adjustments)",
+	"%s:%d: 調整はリストで指定してください":                           "%s:%d: specify the adjustments as a list",
+	"文字列またはマッピングで指定してください":                             "specify a string or a mapping",
+	"控除ルールの longer_than '%s' が不正です: %v":                "Invalid longer_than '%s' in a deduction rule: %v",
+	"控除ルールの deduct '%s' が不正です: %v":                     "Invalid deduct '%s' in a deduction rule: %v",
+	"控除ルール（type: event）には name を指定してください":              "Specify name for a deduction rule with type: event",
+	"控除ルールの type '%s' はサポートされていません（long_event, event）": "Deduction rule type '%s' is not supported (long_event, event)",
+	"設定ファイルの delivery.email.smtp_host を指定してください":       "Specify delivery.email.smtp_host in the config file",
+	"設定ファイルの metrics が不正です":                            "Invalid metrics in the config file",
+	"設定ファイルに metrics が設定されていません":                       "No metrics are configured in the config file",
+	"metrics の name を指定してください":                         "Specify name for each metrics entry",
+	"metrics の name '%s' が重複しています":                     "Duplicate metrics name '%s'",
+	"metrics '%s' の期間 '%s' はサポートされていません（%s）":           "Period '%[2]s' of metrics '%[1]s' is not supported (%[3]s)",
+	"表示言語 '%s' はサポートされていません（%s）":                       "Display language '%s' is not supported (%s)",
+	"DTSTARTの解析に失敗しました (%s): %v":                       "Failed to parse DTSTART (%s): %v",
+	"DTENDの解析に失敗しました (%s): %v":                         "Failed to parse DTEND (%s): %v",
+	"DURATIONの解析に失敗しました (%s): %v":                      "Failed to parse DURATION (%s): %v",
+	"RECURRENCE-IDの解析に失敗しました (%s): %v":                 "Failed to parse RECURRENCE-ID (%s): %v",
+	"不正な期間表記です":                                        "invalid duration notation",
+	"BYDAYが不正です: %s":                                   "invalid BYDAY: %s",
+	"BYMONTHDAYが不正です: %s":                              "invalid BYMONTHDAY: %s",
+	"%s はサポートされていません":                                  "%s is not supported",
+	"FREQ=YEARLY の BYDAY はサポートされていません":                 "BYDAY with FREQ=YEARLY is not supported",
+	"FREQ=%s の BYDAY には第何曜日を指定できません: %s":               "BYDAY with FREQ=%s cannot specify an ordinal weekday: %s",
+	"FREQ=%s の BYMONTHDAY=%s はサポートされていません":             "BYMONTHDAY=%[2]s with FREQ=%[1]s is not supported",
+	"FREQ=%s の BYMONTH=%s はサポートされていません":                "BYMONTH=%[2]s with FREQ=%[1]s is not supported",
+	"INTERVALが不正です: %s":                                "invalid INTERVAL: %s",
+	"COUNTが不正です: %s":                                   "invalid COUNT: %s",
+	"UNTILが不正です: %s":                                   "invalid UNTIL: %s",
+	"FREQ '%s' はサポートされていません":                           "FREQ '%s' is not supported",
+	"イベント '%s' の繰り返し設定の解析に失敗しました: %v":                  "Failed to parse the recurrence of event '%s': %v",
+	"イベント '%s' に start または end がありません":                 "Event '%s' has no start or end",
+	"イベント '%s': %v":                                    "Event '%s': %v",
+	"時間の表示形式 '%s' はサポートされていません（%s）":                    "Duration format '%s' is not supported (%s)",
+	"overtime の %s '%s' が不正です（例: 8h, 40h）":             "Invalid overtime %s '%s' (e.g. 8h, 40h)",
+	"プロジェクトの対応付けには pattern と project を指定してください":        "Specify pattern and project for each project mapping",
+	"プロジェクト '%s' のパターンが不正です":                           "Invalid pattern for project '%s'",
+	"%s:%d: pattern と project の2列を指定してください":            "%s:%d: specify two columns, pattern and project",
+	"目標時間 '%s' が不正です（例: 140h, 90m, 1h30m）: %v":         "Invalid target '%s' (e.g. 140h, 90m, 1h30m): %v",
+	"設定ファイルの watch が不正です":                              "Invalid watch jobs in the config file",
+	"-at の指定が不正です":                                     "Invalid -at value",
+	"設定ファイルに watch が設定されていません":                         "No watch jobs are configured in the config file",
+	"watch の name を指定してください":                           "Specify name for each watch job",
+	"watch '%s' の期間 '%s' はサポートされていません（%s）":             "Period '%[2]s' of watch '%[1]s' is not supported (%[3]s)",
+	"実行時刻 '%s' はHH:MM形式で指定してください":                      "Specify the run time '%s' in the HH:MM format",
+	"時刻 '%s' はHH:MM形式で指定してください":                        "Specify the time '%s' in the HH:MM format",
+	"時刻 '%s' が範囲外です":                                   "Time '%s' is out of range",
+	"曜日 '%s' が不正です（sun, mon, tue, wed, thu, fri, sat）": "Invalid weekday '%s' (sun, mon, tue, wed, thu, fri, sat)",
+	"勤務時間 '%s' はHH:MM-HH:MM形式で指定してください":                "Specify the work hours '%s' in the HH:MM-HH:MM format",
+	"カレンダー %s":   "Calendar %s",
+	"ICSファイル %s": "ICS file %s",
+	"フィクスチャ %s":  "Fixture %s",
+	"ローカルストアの読み込みに失敗しました":            "Failed to load the local store",
+	"イベントの同期に失敗しました":                 "Failed to sync the events",
+	"ローカルストアの保存に失敗しました":              "Failed to save the local store",
+	"（端末から gcal-sum を実行して再認証してください）": " (run gcal-sum from a terminal to sign in again)",
+	"中断されました":                        "interrupted",
+	"タイムアウトしました":                     "timed out",
+
+	// サブコマンドの使用方法と表示（focus, meetings, report, export, rooms, diff, tui, auth）
+	"%d行をスプレッドシートのシート '%s' に追記しました\n":                                                                                                        "Appended %d rows to sheet '%s' of the spreadsheet\n",
+	"%s以上の空き時間の合計: %s（勤務日 %d日）\n\n":                                                                                                          "Total free time of %s or longer: %s (%d workdays)\n\n",
+	"使用方法: gcal-sum focus -month=YYYY-MM [-calendar=カレンダーID] [-work-hours=09:00-18:00] [-workdays=mon-fri] [-min-block=1h]\n":                "Usage: gcal-sum focus -month=YYYY-MM [-calendar=CALENDAR_ID] [-work-hours=09:00-18:00] [-workdays=mon-fri] [-min-block=1h]\n",
+	"使用方法: gcal-sum report -html=out.html -month=YYYY-MM -name=イベント名 [-calendar=カレンダーID]\n":                                                  "Usage: gcal-sum report -html=out.html -month=YYYY-MM -name=EVENT_NAME [-calendar=CALENDAR_ID]\n",
+	"レポートを %s に保存しました\n":                                                                                                                     "Saved the report to %s\n",
+	"エラー: JiraのURL、メールアドレス、APIトークンを指定してください。\n":                                                                                              "Error: specify the Jira URL, email address and API token.\n",
+	"使用方法: gcal-sum export jira -jira-url=https://example.atlassian.net -jira-email=you@example.com -month=YYYY-MM -name=イベント名 [-dry-run]\n": "Usage: gcal-sum export jira -jira-url=https://example.atlassian.net -jira-email=you@example.com -month=YYYY-MM -name=EVENT_NAME [-dry-run]\n",
+	"（-dry-run のため、作業ログは登録していません）\n":                                                                                                         "(No worklogs were created because of -dry-run)\n",
+	"%d件の作業ログを登録しました（登録済みのため %d件をスキップ）\n":                                                                                                    "Created %d worklogs (skipped %d already logged)\n",
+	"ブラウザで以下のURLを開いてください:\n%v\n":                                                                                                             "Open the following URL in your browser:\n%v\n",
+	"利用可能なカレンダー一覧:\n":                                                                                                                        "Available calendars:\n",
+	"会議（%s）の合計時間: %s (%d件)\n":                                                                                                                "Total meeting time (%s): %s (%d events)\n",
+	"稼働時間に占める割合: %s\n":                                                                                                                       "Share of working time: %s\n",
+	"日別の会議時間と会議のない最長の時間帯:\n":                                                                                                                 "Meeting time and longest meeting-free block per day:\n",
+	"- %s (%s): 会議 %s / 最長の空き %s":                                                                                                            "- %s (%s): meetings %s / longest free %s",
+	"作業報告書を %s に保存しました\n":                                                                                                                    "Saved the timesheet to %s\n",
+	"エラー: Toggl Trackのユーザーのメールアドレスを指定してください。\n":                                                                                              "Error: specify the email address of the Toggl Track user.\n",
+	"使用方法: gcal-sum export toggl -email=you@example.com -month=YYYY-MM -name=イベント名 [-o=toggl.csv]\n":                                         "Usage: gcal-sum export toggl -email=you@example.com -month=YYYY-MM -name=EVENT_NAME [-o=toggl.csv]\n",
+	"%d件のタイムエントリーを %s に保存しました\n":                                                                                                             "Saved %d time entries to %s\n",
+	"カレンダー: %s   月: %s\n":                                                                                                                    "Calendar: %s   Month: %s\n",
+	"絞り込み: %s_\n":                                                                                                                            "Filter: %s_\n",
+	"←→: 月の移動  ↑↓: スクロール  Tab: カレンダーの選択  文字入力: イベント名で絞り込み  Esc: 入力の消去・終了\n\n": "←→: change month  ↑↓: scroll  Tab: select calendar  type: filter by event name  Esc: clear input / quit\n\n",
+	"カレンダーを選択してください（Enter: 決定、Esc: 取り消し）:\n":                                  "Select a calendar (Enter: choose, Esc: cancel):\n",
+	"イベントを取得しています…\n":            "Fetching events...\n",
+	"合計時間: %s（%d件、実施日数 %d日）\n\n": "Total: %s (%d events, %d active days)\n\n",
+	"使用方法: gcal-sum rooms -month=YYYY-MM -rooms=リソースカレンダーID [-work-hours=09:00-18:00] [-workdays=mon-fri]\n": "Usage: gcal-sum rooms -month=YYYY-MM -rooms=RESOURCE_CALENDAR_ID [-work-hours=09:00-18:00] [-workdays=mon-fri]\n",
+	"使用方法: gcal-sum diff [-output=markdown] スナップショット [比較するスナップショット]\n":                                       "Usage: gcal-sum diff [-output=markdown] SNAPSHOT [SNAPSHOT_TO_COMPARE]\n",
+	"エラー: tui は端末から実行してください。\n":                                                                              "Error: run tui from a terminal.\n",
+	"エラー: 集計結果のイベントを作成するカレンダーIDを指定してください。\n":                                                                 "Error: specify the ID of the calendar to create the summary event in.\n",
+	"使用方法: gcal-sum export calendar -to-calendar=カレンダーID -month=YYYY-MM -name=イベント名 [-title=タイトル]\n":         "Usage: gcal-sum export calendar -to-calendar=CALENDAR_ID -month=YYYY-MM -name=EVENT_NAME [-title=TITLE]\n",
+	"集計結果のイベント「%s」を更新しました\n":                                                                                 "Updated the summary event \"%s\"\n",
+	"集計結果のイベント「%s」を作成しました\n":                                                                                 "Created the summary event \"%s\"\n",
+}
+
+func init() {
+	for key, msg := range englishMessages {
+		message.SetString(language.English, key, msg)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEnglishMessages(t *testing.T) {
+	if err := setLanguage("en"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setLanguage("ja") })
+
+	if err := validateRunQueries(nil); err == nil || err.Error() != "No queries are configured in the config file" {
+		t.Errorf("validateRunQueries = %v; want 英語のエラー", err)
+	}
+	if got := weekdayLabel(0); got != "Mo" {
+		t.Errorf("weekdayLabel(0) = %q; want Mo", got)
+	}
+	var b bytes.Buffer
+	printHeatmap(&b, &heatmap{})
+	if !strings.Contains(b.String(), "Legend: less") || !strings.Contains(b.String(), "Su  ") {
+		t.Errorf("printHeatmap = %q; want 英語の凡例と曜日", b.String())
+	}
+}
+
+func TestJapaneseMessages(t *testing.T) {
+	if err := setLanguage("ja"); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateRunQueries([]RunQuery{{Label: "a"}, {Label: "a"}}); err == nil || err.Error() != "queries の label 'a' が重複しています" {
+		t.Errorf("validateRunQueries = %v; want 日本語のエラー", err)
+	}
+	if got := weekdayLabel(6); got != "日" {
+		t.Errorf("weekdayLabel(6) = %q; want 日", got)
+	}
+}
@@ -0,0 +1,742 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// icsProperty はICSファイルの1つのプロパティ（例: DTSTART;TZID=Asia/Tokyo:20230105T100000）を表す
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// icsEvent はICSファイルのVEVENTを表す
+type icsEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Status      string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	RRule       string
+	ExDates     []time.Time
+	Attendees   []*calendar.EventAttendee
+	Conference  string
+	Organizer   *calendar.EventOrganizer
+
+	// 繰り返しの特定の回を変更したイベントの場合に、変更前のその回の開始日時（RECURRENCE-ID）
+	RecurrenceID time.Time
+}
+
+// unfoldICSLines はICSファイルの折り返された行を1行に結合する
+func unfoldICSLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICSProperty は1行をプロパティ名・パラメータ・値に分解する
+func parseICSProperty(line string) icsProperty {
+	prop := icsProperty{Params: make(map[string]string)}
+
+	// 値の区切りとなる最初のコロンを探す（引用符内のコロンは無視する）
+	inQuote := false
+	sep := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuote = !inQuote
+		} else if c == ':' && !inQuote {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		prop.Name = strings.ToUpper(line)
+		return prop
+	}
+	prop.Value = line[sep+1:]
+
+	parts := strings.Split(line[:sep], ";")
+	prop.Name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			prop.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return prop
+}
+
+// unescapeICSText はICSのテキスト値のエスケープを解除する
+func unescapeICSText(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
+
+// parseICSTime はDTSTARTなどの日時を解析する。日付のみの場合はallDayがtrueになる
+func parseICSTime(prop icsProperty, location *time.Location) (t time.Time, allDay bool, err error) {
+	value := prop.Value
+	if prop.Params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, location)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := location
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// icsTZIDChecker はICSファイルのTZIDを読み込めるか確認し、読み込めないTZIDを警告する
+// 読み込めないTZID（Windowsのタイムゾーン名など）の日時は表示のタイムゾーンの日時として扱うため、TZIDごとに1回だけ警告する
+type icsTZIDChecker map[string]bool
+
+// check はプロパティのTZIDを確認する
+func (c icsTZIDChecker) check(prop icsProperty) {
+	tzid := prop.Params["TZID"]
+	if tzid == "" {
+		return
+	}
+	if _, checked := c[tzid]; checked {
+		return
+	}
+	_, err := time.LoadLocation(tzid)
+	c[tzid] = err == nil
+	if err != nil {
+		slog.Warn("ICSファイルのTZIDを読み込めないため、表示のタイムゾーンの日時として扱います", "tzid", tzid, "error", err)
+	}
+}
+
+// parseICS はICSファイルからイベントを読み込む
+func parseICS(r io.Reader, location *time.Location) ([]icsEvent, error) {
+	lines, err := unfoldICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []icsEvent
+	var current *icsEvent
+	var hasEnd bool
+	tzids := make(icsTZIDChecker)
+	var duration icsDuration
+	depth := 0
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop := parseICSProperty(line)
+
+		switch prop.Name {
+		case "BEGIN":
+			if strings.EqualFold(prop.Value, "VEVENT") && current == nil {
+				current = &icsEvent{}
+				hasEnd = false
+				duration = icsDuration{}
+				depth = 0
+			} else if current != nil {
+				// VALARMなどイベント内のコンポーネントは無視する
+				depth++
+			}
+			continue
+		case "END":
+			if current == nil {
+				continue
+			}
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if !hasEnd {
+				switch {
+				case duration.positive():
+					current.End = duration.addTo(current.Start)
+				case current.AllDay:
+					current.End = current.Start.AddDate(0, 0, 1)
+				default:
+					current.End = current.Start
+				}
+			}
+			events = append(events, *current)
+			current = nil
+			continue
+		}
+
+		if current == nil || depth > 0 {
+			continue
+		}
+
+		switch prop.Name {
+		case "UID":
+			current.UID = prop.Value
+		case "SUMMARY":
+			current.Summary = unescapeICSText(prop.Value)
+		case "DESCRIPTION":
+			current.Description = unescapeICSText(prop.Value)
+		case "LOCATION":
+			current.Location = unescapeICSText(prop.Value)
+		case "STATUS":
+			current.Status = strings.ToLower(prop.Value)
+		case "RRULE":
+			current.RRule = prop.Value
+		case "ORGANIZER":
+			current.Organizer = &calendar.EventOrganizer{
+				Email:       icsMailAddress(prop.Value),
+				DisplayName: prop.Params["CN"],
+			}
+		case "X-GOOGLE-CONFERENCE":
+			current.Conference = prop.Value
+		case "ATTENDEE":
+			current.Attendees = append(current.Attendees, &calendar.EventAttendee{
+				Email:          icsMailAddress(prop.Value),
+				DisplayName:    prop.Params["CN"],
+				ResponseStatus: icsResponseStatus(prop.Params["PARTSTAT"]),
+				Resource:       prop.Params["CUTYPE"] == "RESOURCE" || prop.Params["CUTYPE"] == "ROOM",
+			})
+		case "DTSTART":
+			tzids.check(prop)
+			t, allDay, err := parseICSTime(prop, location)
+			if err != nil {
+				return nil, errors.New(printer.Sprintf("DTSTARTの解析に失敗しました (%s): %v", prop.Value, err))
+			}
+			current.Start = t
+			current.AllDay = allDay
+		case "DTEND":
+			tzids.check(prop)
+			t, _, err := parseICSTime(prop, location)
+			if err != nil {
+				return nil, errors.New(printer.Sprintf("DTENDの解析に失敗しました (%s): %v", prop.Value, err))
+			}
+			current.End = t
+			hasEnd = true
+		case "DURATION":
+			d, err := parseICSDuration(prop.Value)
+			if err != nil {
+				return nil, errors.New(printer.Sprintf("DURATIONの解析に失敗しました (%s): %v", prop.Value, err))
+			}
+			duration = d
+		case "RECURRENCE-ID":
+			tzids.check(prop)
+			t, _, err := parseICSTime(prop, location)
+			if err != nil {
+				return nil, errors.New(printer.Sprintf("RECURRENCE-IDの解析に失敗しました (%s): %v", prop.Value, err))
+			}
+			current.RecurrenceID = t
+		case "EXDATE":
+			tzids.check(prop)
+			for _, v := range strings.Split(prop.Value, ",") {
+				exProp := prop
+				exProp.Value = v
+				if t, _, err := parseICSTime(exProp, location); err == nil {
+					current.ExDates = append(current.ExDates, t)
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+// icsDuration はICSの期間表記を、日数と時間に分けて表す
+// RFC 5545 では日と週の長さは暦の上の長さのため、夏時間が切り替わる日をまたぐ場合も、日数は同じ時計の時刻までの日数として加える
+type icsDuration struct {
+	days  int
+	exact time.Duration
+}
+
+// positive は期間が正の長さかどうかを判定する
+func (d icsDuration) positive() bool {
+	return d.days > 0 || d.exact > 0
+}
+
+// addTo は日時に期間を加える
+func (d icsDuration) addTo(t time.Time) time.Time {
+	return t.AddDate(0, 0, d.days).Add(d.exact)
+}
+
+// parseICSDuration はICSの期間表記（例: PT1H30M, P1D）を解析する
+func parseICSDuration(s string) (icsDuration, error) {
+	sign := 1
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(s, "P") {
+		return icsDuration{}, errors.New(printer.Sprintf("不正な期間表記です"))
+	}
+	s = s[1:]
+
+	var days int
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, c := range s {
+		switch {
+		case c == 'T':
+			inTime = true
+		case c >= '0' && c <= '9':
+			num += string(c)
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return icsDuration{}, errors.New(printer.Sprintf("不正な期間表記です"))
+			}
+			num = ""
+			switch {
+			case c == 'W':
+				days += n * 7
+			case c == 'D':
+				days += n
+			case c == 'H' && inTime:
+				d += time.Duration(n) * time.Hour
+			case c == 'M' && inTime:
+				d += time.Duration(n) * time.Minute
+			case c == 'S' && inTime:
+				d += time.Duration(n) * time.Second
+			default:
+				return icsDuration{}, errors.New(printer.Sprintf("不正な期間表記です"))
+			}
+		}
+	}
+	return icsDuration{days: sign * days, exact: time.Duration(sign) * d}, nil
+}
+
+// 曜日の略称（RRULEのBYDAYで使用）
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// 対応していないRRULEの指定（無視すると展開する回が変わるため、エラーにする）
+var unsupportedRRuleParts = []string{"BYSETPOS", "BYWEEKNO", "BYYEARDAY", "BYHOUR", "BYMINUTE", "BYSECOND"}
+
+// icsByDay はRRULEのBYDAYの1件（例: TU は毎週火曜日、2TU は第2火曜日、-1FR は最終金曜日）を表す
+type icsByDay struct {
+	ordinal int // 月の何番目の曜日か（負の場合は月末から数える。0の場合はすべての該当する曜日）
+	weekday time.Weekday
+}
+
+// parseICSByDay はBYDAYの値を解析する
+func parseICSByDay(v string) ([]icsByDay, error) {
+	var days []icsByDay
+	for _, d := range strings.Split(strings.ToUpper(v), ",") {
+		if len(d) < 2 {
+			return nil, errors.New(printer.Sprintf("BYDAYが不正です: %s", v))
+		}
+		wd, ok := icsWeekdays[d[len(d)-2:]]
+		if !ok {
+			return nil, errors.New(printer.Sprintf("BYDAYが不正です: %s", v))
+		}
+		bd := icsByDay{weekday: wd}
+		if n := d[:len(d)-2]; n != "" {
+			o, err := strconv.Atoi(n)
+			if err != nil || o == 0 || o < -5 || o > 5 {
+				return nil, errors.New(printer.Sprintf("BYDAYが不正です: %s", v))
+			}
+			bd.ordinal = o
+		}
+		days = append(days, bd)
+	}
+	return days, nil
+}
+
+// parseICSMonthDays はBYMONTHDAYの値（負の場合は月末から数える）を解析する
+func parseICSMonthDays(v string) ([]int, error) {
+	var days []int
+	for _, d := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(d)
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return nil, errors.New(printer.Sprintf("BYMONTHDAYが不正です: %s", v))
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
+
+// parseICSRRule はRRULEを解析し、対応していない指定の組み合わせをエラーにする
+// BYDAY は DAILY と WEEKLY では曜日のみ、MONTHLY では第何曜日の指定にも対応する
+// BYMONTHDAY は MONTHLY のみ対応し、YEARLY の BYMONTH と BYMONTHDAY は開始日と同じ月日の場合のみ受け付ける
+func parseICSRRule(e icsEvent) (rule map[string]string, byDay []icsByDay, byMonthDay []int, err error) {
+	rule = make(map[string]string)
+	for _, part := range strings.Split(e.RRule, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			rule[strings.ToUpper(k)] = v
+		}
+	}
+	freq := rule["FREQ"]
+	for _, k := range unsupportedRRuleParts {
+		if _, ok := rule[k]; ok {
+			return nil, nil, nil, errors.New(printer.Sprintf("%s はサポートされていません", k))
+		}
+	}
+
+	if v, ok := rule["BYDAY"]; ok {
+		if freq == "YEARLY" {
+			return nil, nil, nil, errors.New(printer.Sprintf("FREQ=YEARLY の BYDAY はサポートされていません"))
+		}
+		if byDay, err = parseICSByDay(v); err != nil {
+			return nil, nil, nil, err
+		}
+		for _, bd := range byDay {
+			if bd.ordinal != 0 && freq != "MONTHLY" {
+				return nil, nil, nil, errors.New(printer.Sprintf("FREQ=%s の BYDAY には第何曜日を指定できません: %s", freq, v))
+			}
+		}
+	}
+	if v, ok := rule["BYMONTHDAY"]; ok {
+		if byMonthDay, err = parseICSMonthDays(v); err != nil {
+			return nil, nil, nil, err
+		}
+		switch {
+		case freq == "MONTHLY":
+		case freq == "YEARLY" && len(byMonthDay) == 1 && byMonthDay[0] == e.Start.Day():
+			byMonthDay = nil
+		default:
+			return nil, nil, nil, errors.New(printer.Sprintf("FREQ=%s の BYMONTHDAY=%s はサポートされていません", freq, v))
+		}
+	}
+	if v, ok := rule["BYMONTH"]; ok {
+		if freq != "YEARLY" || v != strconv.Itoa(int(e.Start.Month())) {
+			return nil, nil, nil, errors.New(printer.Sprintf("FREQ=%s の BYMONTH=%s はサポートされていません", freq, v))
+		}
+	}
+	return rule, byDay, byMonthDay, nil
+}
+
+// monthlyDays は MONTHLY の繰り返しで、指定された月のうち繰り返しの対象となる日を昇順に返す
+// BYDAY と BYMONTHDAY の両方がある場合は両方に一致する日、どちらもない場合は開始日と同じ日を対象にする（31日などその日がない月は対象外）
+func monthlyDays(year int, month time.Month, startDay int, byDay []icsByDay, byMonthDay []int) []int {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	match := func(day int) bool {
+		if len(byDay) == 0 && len(byMonthDay) == 0 {
+			return day == startDay
+		}
+		if len(byMonthDay) > 0 && !slices.ContainsFunc(byMonthDay, func(md int) bool {
+			return md == day || (md < 0 && last+md+1 == day)
+		}) {
+			return false
+		}
+		if len(byDay) > 0 {
+			wd := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+			nth, nthLast := (day-1)/7+1, -((last-day)/7 + 1)
+			if !slices.ContainsFunc(byDay, func(bd icsByDay) bool {
+				return bd.weekday == wd && (bd.ordinal == 0 || bd.ordinal == nth || bd.ordinal == nthLast)
+			}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var days []int
+	for day := 1; day <= last; day++ {
+		if match(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// expandRRule は繰り返しイベントを期間内の個々の開始日時に展開する
+// FREQ（DAILY, WEEKLY, MONTHLY, YEARLY）、INTERVAL、COUNT、UNTIL、BYDAY、BYMONTHDAY（対応する組み合わせは parseICSRRule を参照）に対応する
+// RFC 5545 に従い、MONTHLY の31日や YEARLY の2月29日など、その日がない月や年は対象外にする
+func expandRRule(e icsEvent, rangeEnd time.Time) ([]time.Time, error) {
+	rule, byDay, byMonthDay, err := parseICSRRule(e)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := 1
+	if v, ok := rule["INTERVAL"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New(printer.Sprintf("INTERVALが不正です: %s", v))
+		}
+		interval = n
+	}
+	count := -1
+	if v, ok := rule["COUNT"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.New(printer.Sprintf("COUNTが不正です: %s", v))
+		}
+		count = n
+	}
+	until := rangeEnd
+	if v, ok := rule["UNTIL"]; ok {
+		prop := icsProperty{Value: v, Params: map[string]string{}}
+		t, allDay, err := parseICSTime(prop, e.Start.Location())
+		if err != nil {
+			return nil, errors.New(printer.Sprintf("UNTILが不正です: %s", v))
+		}
+		if allDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		if t.Before(until) {
+			until = t
+		}
+	}
+
+	hasWeekday := func(t time.Time) bool {
+		return slices.ContainsFunc(byDay, func(bd icsByDay) bool { return bd.weekday == t.Weekday() })
+	}
+	excluded := func(t time.Time) bool {
+		for _, ex := range e.ExDates {
+			if ex.Equal(t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var starts []time.Time
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if t.Before(e.Start) {
+			return true
+		}
+		if t.After(until) || (count >= 0 && emitted >= count) {
+			return false
+		}
+		emitted++
+		if !excluded(t) {
+			starts = append(starts, t)
+		}
+		return true
+	}
+
+	loc := e.Start.Location()
+	hour, minute, second := e.Start.Clock()
+expand:
+	for i := 0; ; i++ {
+		switch rule["FREQ"] {
+		case "DAILY":
+			base := e.Start.AddDate(0, 0, i*interval)
+			if base.After(until) {
+				break expand
+			}
+			if len(byDay) > 0 && !hasWeekday(base) {
+				continue
+			}
+			if !emit(base) {
+				break expand
+			}
+		case "WEEKLY":
+			base := e.Start.AddDate(0, 0, i*interval*7)
+			if base.After(until) {
+				break expand
+			}
+			if len(byDay) == 0 {
+				if !emit(base) {
+					break expand
+				}
+				continue
+			}
+			// BYDAY指定がある場合は、その週（開始日の曜日から7日間）の該当曜日を展開する
+			for offset := 0; offset < 7; offset++ {
+				if t := base.AddDate(0, 0, offset); hasWeekday(t) && !emit(t) {
+					break expand
+				}
+			}
+		case "MONTHLY":
+			// AddDate は31日などその月にない日を翌月に繰り越すため、月の初日から求める
+			first := time.Date(e.Start.Year(), e.Start.Month()+time.Month(i*interval), 1, 0, 0, 0, 0, loc)
+			if first.After(until) {
+				break expand
+			}
+			for _, day := range monthlyDays(first.Year(), first.Month(), e.Start.Day(), byDay, byMonthDay) {
+				if !emit(time.Date(first.Year(), first.Month(), day, hour, minute, second, 0, loc)) {
+					break expand
+				}
+			}
+		case "YEARLY":
+			base := e.Start.AddDate(i*interval, 0, 0)
+			if base.After(until) {
+				break expand
+			}
+			// 2月29日の繰り返しは、その日がない年（AddDate で3月1日に繰り越される）を対象外にする
+			if base.Day() != e.Start.Day() {
+				continue
+			}
+			if !emit(base) {
+				break expand
+			}
+		default:
+			return nil, errors.New(printer.Sprintf("FREQ '%s' はサポートされていません", rule["FREQ"]))
+		}
+	}
+	return starts, nil
+}
+
+// icsMailAddress は「mailto:alice@example.com」形式の値からメールアドレスを取り出す
+func icsMailAddress(value string) string {
+	if len(value) >= 7 && strings.EqualFold(value[:7], "mailto:") {
+		return value[7:]
+	}
+	return value
+}
+
+// icsResponseStatus はATTENDEEのPARTSTATをCalendar APIの出欠状況に変換する
+func icsResponseStatus(partstat string) string {
+	switch strings.ToUpper(partstat) {
+	case "ACCEPTED":
+		return "accepted"
+	case "DECLINED":
+		return "declined"
+	case "TENTATIVE":
+		return "tentative"
+	}
+	return "needsAction"
+}
+
+// endFor は繰り返しの開始日時に対応する終了日時を返す
+// 終日イベントは日数を保ち（夏時間が切り替わる日は1日の長さが23時間や25時間になるため、時間の長さでは求めない）、時間指定のイベントは元のイベントと同じ長さにする
+func (e icsEvent) endFor(start time.Time) time.Time {
+	if e.AllDay {
+		return start.AddDate(0, 0, daysBetween(e.Start, e.End))
+	}
+	return start.Add(e.End.Sub(e.Start))
+}
+
+// toCalendarEvent はICSのイベントをCalendar APIのイベント形式に変換する
+func (e icsEvent) toCalendarEvent(start time.Time) *calendar.Event {
+	end := e.endFor(start)
+	ev := &calendar.Event{
+		Id:          e.UID,
+		ICalUID:     e.UID,
+		Summary:     e.Summary,
+		Description: e.Description,
+		Location:    e.Location,
+		Status:      e.Status,
+		Attendees:   e.Attendees,
+		HangoutLink: e.Conference,
+		Organizer:   e.Organizer,
+		Start:       &calendar.EventDateTime{},
+		End:         &calendar.EventDateTime{},
+	}
+	if e.AllDay {
+		ev.Start.Date = start.Format("2006-01-02")
+		ev.End.Date = end.Format("2006-01-02")
+	} else {
+		ev.Start.DateTime = start.Format(time.RFC3339)
+		ev.End.DateTime = end.Format(time.RFC3339)
+	}
+	switch {
+	case !e.RecurrenceID.IsZero():
+		// 変更した回は、Calendar APIと同じく繰り返しのその回のイベントとして扱う
+		ev.RecurringEventId = e.UID
+		ev.Id = icsInstanceID(e.UID, e.RecurrenceID)
+		ev.OriginalStartTime = &calendar.EventDateTime{}
+		if e.AllDay {
+			ev.OriginalStartTime.Date = e.RecurrenceID.Format("2006-01-02")
+		} else {
+			ev.OriginalStartTime.DateTime = e.RecurrenceID.Format(time.RFC3339)
+		}
+	case e.RRule != "":
+		ev.RecurringEventId = e.UID
+		ev.Id = icsInstanceID(e.UID, start)
+	}
+	return ev
+}
+
+// icsInstanceID は繰り返しの回のイベントIDを、UIDと変更前の開始日時から求める
+func icsInstanceID(uid string, start time.Time) string {
+	return uid + "_" + start.UTC().Format("20060102T150405Z")
+}
+
+// loadICSEvents はICSファイルを読み込み、期間内のイベントを開始日時順に返す
+func loadICSEvents(path string, location *time.Location, rangeStart, rangeEnd time.Time) ([]*calendar.Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	parsed, err := parseICS(f, location)
+	if err != nil {
+		return nil, err
+	}
+
+	// RECURRENCE-ID のあるイベントは繰り返しの特定の回を変更したもののため、展開した回のうち同じ回の代わりに集計する
+	overrides := make(map[string]bool)
+	for _, e := range parsed {
+		if !e.RecurrenceID.IsZero() {
+			overrides[icsInstanceID(e.UID, e.RecurrenceID)] = true
+		}
+	}
+
+	var items []*calendar.Event
+	for _, e := range parsed {
+		starts := []time.Time{e.Start}
+		if e.RRule != "" && e.RecurrenceID.IsZero() {
+			starts, err = expandRRule(e, rangeEnd)
+			if err != nil {
+				return nil, errors.New(printer.Sprintf("イベント '%s' の繰り返し設定の解析に失敗しました: %v", e.Summary, err))
+			}
+			starts = slices.DeleteFunc(starts, func(start time.Time) bool {
+				return overrides[icsInstanceID(e.UID, start)]
+			})
+		}
+		for _, start := range starts {
+			end := e.endFor(start)
+			// 期間と重なるイベントのみを対象にする
+			if !start.Before(rangeEnd) || !end.After(rangeStart) {
+				continue
+			}
+			items = append(items, e.toCalendarEvent(start))
+		}
+	}
+
+	sortEventsByStart(items)
+	return items, nil
+}
+
+// eventStartKey は並べ替え用にイベントの開始日時を返す
+// 開始が日付だけで originalStartTime に日時がある場合は、その日時で並べる
+func eventStartKey(e *calendar.Event) string {
+	if dt := eventStartDateTime(e); dt.DateTime != "" {
+		t, err := time.Parse(time.RFC3339, dt.DateTime)
+		if err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+		return dt.DateTime
+	}
+	return e.Start.Date
+}
+
+// sortEventsByStart はイベントを開始日時順に並べ替える
+func sortEventsByStart(items []*calendar.Event) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return eventStartKey(items[i]) < eventStartKey(items[j])
+	})
+}
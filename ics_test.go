@@ -0,0 +1,275 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICSDurationAddToAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		duration string
+		start    time.Time
+		want     time.Time
+		elapsed  time.Duration
+	}{
+		{
+			name:     "夏時間の開始日をまたぐ1日は23時間",
+			duration: "P1D",
+			start:    time.Date(2024, 3, 9, 10, 0, 0, 0, newYork),
+			want:     time.Date(2024, 3, 10, 10, 0, 0, 0, newYork),
+			elapsed:  23 * time.Hour,
+		},
+		{
+			name:     "夏時間の終了日をまたぐ1日は25時間",
+			duration: "P1D",
+			start:    time.Date(2024, 11, 2, 10, 0, 0, 0, newYork),
+			want:     time.Date(2024, 11, 3, 10, 0, 0, 0, newYork),
+			elapsed:  25 * time.Hour,
+		},
+		{
+			name:     "時間で指定した期間は経過時間のまま",
+			duration: "PT24H",
+			start:    time.Date(2024, 3, 9, 10, 0, 0, 0, newYork),
+			want:     time.Date(2024, 3, 10, 11, 0, 0, 0, newYork),
+			elapsed:  24 * time.Hour,
+		},
+		{
+			name:     "日と時間を組み合わせた期間",
+			duration: "P1DT2H",
+			start:    time.Date(2024, 11, 2, 10, 0, 0, 0, newYork),
+			want:     time.Date(2024, 11, 3, 12, 0, 0, 0, newYork),
+			elapsed:  27 * time.Hour,
+		},
+		{
+			name:     "夏時間の開始をまたぐ時間指定の期間",
+			duration: "PT2H",
+			start:    time.Date(2024, 3, 10, 1, 0, 0, 0, newYork),
+			want:     time.Date(2024, 3, 10, 4, 0, 0, 0, newYork),
+			elapsed:  2 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parseICSDuration(tt.duration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := d.addTo(tt.start)
+			if !got.Equal(tt.want) {
+				t.Errorf("addTo = %v; want %v", got, tt.want)
+			}
+			if elapsed := got.Sub(tt.start); elapsed != tt.elapsed {
+				t.Errorf("経過時間 = %v; want %v", elapsed, tt.elapsed)
+			}
+		})
+	}
+}
+
+func TestICSEventEndForAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allDay := icsEvent{
+		Start:  time.Date(2024, 3, 1, 0, 0, 0, 0, newYork),
+		End:    time.Date(2024, 3, 2, 0, 0, 0, 0, newYork),
+		AllDay: true,
+	}
+	timed := icsEvent{
+		Start: time.Date(2024, 3, 1, 1, 0, 0, 0, newYork),
+		End:   time.Date(2024, 3, 1, 3, 0, 0, 0, newYork),
+	}
+
+	tests := []struct {
+		name  string
+		event icsEvent
+		start time.Time
+		want  time.Time
+	}{
+		{
+			name:  "夏時間の開始日の終日イベントは翌日の0時まで",
+			event: allDay,
+			start: time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			want:  time.Date(2024, 3, 11, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:  "夏時間の終了日の終日イベントは翌日の0時まで",
+			event: allDay,
+			start: time.Date(2024, 11, 3, 0, 0, 0, 0, newYork),
+			want:  time.Date(2024, 11, 4, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:  "夏時間の開始日の時間指定のイベントは同じ長さ",
+			event: timed,
+			start: time.Date(2024, 3, 10, 1, 0, 0, 0, newYork),
+			want:  time.Date(2024, 3, 10, 4, 0, 0, 0, newYork),
+		},
+		{
+			name:  "夏時間の終了日の時間指定のイベントは同じ長さ",
+			event: timed,
+			start: time.Date(2024, 11, 3, 0, 30, 0, 0, newYork),
+			want:  time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.endFor(tt.start); !got.Equal(tt.want) {
+				t.Errorf("endFor = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandRRule(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rangeEnd := time.Date(2025, 1, 1, 0, 0, 0, 0, tokyo)
+
+	tests := []struct {
+		name    string
+		start   time.Time
+		rrule   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "毎月第2火曜日",
+			start: time.Date(2024, 1, 9, 10, 0, 0, 0, tokyo),
+			rrule: "FREQ=MONTHLY;BYDAY=2TU;COUNT=3",
+			want:  []string{"2024-01-09", "2024-02-13", "2024-03-12"},
+		},
+		{
+			name:  "毎月最終金曜日",
+			start: time.Date(2024, 1, 26, 10, 0, 0, 0, tokyo),
+			rrule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			want:  []string{"2024-01-26", "2024-02-23", "2024-03-29"},
+		},
+		{
+			name:  "毎月31日はその日がない月を対象外にする",
+			start: time.Date(2024, 1, 31, 10, 0, 0, 0, tokyo),
+			rrule: "FREQ=MONTHLY;COUNT=4",
+			want:  []string{"2024-01-31", "2024-03-31", "2024-05-31", "2024-07-31"},
+		},
+		{
+			name:  "毎月の末日",
+			start: time.Date(2024, 1, 31, 10, 0, 0, 0, tokyo),
+			rrule: "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			want:  []string{"2024-01-31", "2024-02-29", "2024-03-31"},
+		},
+		{
+			name:  "毎年2月29日はその日がない年を対象外にする",
+			start: time.Date(2020, 2, 29, 10, 0, 0, 0, tokyo),
+			rrule: "FREQ=YEARLY;COUNT=2",
+			want:  []string{"2020-02-29", "2024-02-29"},
+		},
+		{
+			name:  "毎週月曜日と水曜日",
+			start: time.Date(2024, 1, 1, 10, 0, 0, 0, tokyo),
+			rrule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+			want:  []string{"2024-01-01", "2024-01-03", "2024-01-08", "2024-01-10"},
+		},
+		{
+			name:    "BYSETPOS はサポートしない",
+			start:   time.Date(2024, 1, 1, 10, 0, 0, 0, tokyo),
+			rrule:   "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			wantErr: true,
+		},
+		{
+			name:    "WEEKLY の第何曜日の指定はエラー",
+			start:   time.Date(2024, 1, 1, 10, 0, 0, 0, tokyo),
+			rrule:   "FREQ=WEEKLY;BYDAY=2MO",
+			wantErr: true,
+		},
+		{
+			name:    "YEARLY の BYDAY はサポートしない",
+			start:   time.Date(2024, 1, 1, 10, 0, 0, 0, tokyo),
+			rrule:   "FREQ=YEARLY;BYDAY=1MO",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts, err := expandRRule(icsEvent{Start: tt.start, End: tt.start.Add(time.Hour), RRule: tt.rrule}, rangeEnd)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("エラーになりませんでした: %v", starts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			var got []string
+			for _, s := range starts {
+				got = append(got, s.Format("2006-01-02"))
+				if s.Hour() != 10 {
+					t.Errorf("開始時刻 = %v; want 10:00", s)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expandRRule = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadICSEventsRecurrenceID(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:weekly@example.com",
+		"SUMMARY:定例",
+		"DTSTART;TZID=Asia/Tokyo:20240101T100000",
+		"DTEND;TZID=Asia/Tokyo:20240101T110000",
+		"RRULE:FREQ=WEEKLY;COUNT=3",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:weekly@example.com",
+		"SUMMARY:定例（延長）",
+		"RECURRENCE-ID;TZID=Asia/Tokyo:20240108T100000",
+		"DTSTART;TZID=Asia/Tokyo:20240109T140000",
+		"DTEND;TZID=Asia/Tokyo:20240109T160000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	path := filepath.Join(t.TempDir(), "calendar.ics")
+	if err := os.WriteFile(path, []byte(ics), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := loadICSEvents(path, tokyo, time.Date(2024, 1, 1, 0, 0, 0, 0, tokyo), time.Date(2024, 2, 1, 0, 0, 0, 0, tokyo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Summary+" "+item.Start.DateTime)
+	}
+	want := []string{
+		"定例 2024-01-01T10:00:00+09:00",
+		"定例（延長） 2024-01-09T14:00:00+09:00",
+		"定例 2024-01-15T10:00:00+09:00",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("イベント = %q; want %q", got, want)
+	}
+	if override := items[1]; override.RecurringEventId != "weekly@example.com" || override.OriginalStartTime == nil || override.OriginalStartTime.DateTime != "2024-01-08T10:00:00+09:00" {
+		t.Errorf("変更した回 = %+v; want 繰り返しの2024-01-08の回", override)
+	}
+}
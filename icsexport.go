@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ICSの日時の形式（UTC）
+const icsUTCFormat = "20060102T150405Z"
+
+// escapeICSText はICSのテキスト値で特別な意味を持つ文字をエスケープする
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine は1行が75オクテットを超えないように、マルチバイト文字の途中を避けて行を折り返す
+func foldICSLine(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// 継続行の先頭の空白も1オクテットとして数える
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
+
+// icsUID は書き出すイベントのUIDを返す
+// 繰り返しイベントの各回は元のイベントとUIDが同じため、回ごとに異なるIDから生成する
+func icsUID(e MatchedEvent) string {
+	if e.Event.ICalUID != "" && e.Event.RecurringEventId == "" {
+		return e.Event.ICalUID
+	}
+	return e.Event.Id + "@gcal-sum"
+}
+
+// writeICS は一致したイベントをICS（RFC 5545）形式で出力する
+func writeICS(w io.Writer, r *Report) error {
+	bw := bufio.NewWriter(w)
+	prop := func(name, value string) {
+		bw.WriteString(foldICSLine(name + ":" + value))
+	}
+	stamp := time.Now().UTC().Format(icsUTCFormat)
+
+	prop("BEGIN", "VCALENDAR")
+	prop("VERSION", "2.0")
+	prop("PRODID", "-//gcal-sum//gcal-sum//JA")
+	prop("CALSCALE", "GREGORIAN")
+	prop("METHOD", "PUBLISH")
+	for _, e := range r.Events {
+		// 手動調整はカレンダーのイベントではないため書き出さない
+		if e.Manual {
+			continue
+		}
+		ev := e.Event
+		prop("BEGIN", "VEVENT")
+		prop("UID", icsUID(e))
+		prop("DTSTAMP", stamp)
+		prop("DTSTART", e.Start.UTC().Format(icsUTCFormat))
+		prop("DTEND", e.End.UTC().Format(icsUTCFormat))
+		prop("SUMMARY", escapeICSText(ev.Summary))
+		if ev.Description != "" {
+			prop("DESCRIPTION", escapeICSText(ev.Description))
+		}
+		if ev.Location != "" {
+			prop("LOCATION", escapeICSText(ev.Location))
+		}
+		if ev.Organizer != nil && ev.Organizer.Email != "" {
+			prop("ORGANIZER", "mailto:"+ev.Organizer.Email)
+		}
+		for _, a := range ev.Attendees {
+			if a.Email == "" {
+				continue
+			}
+			partstat := "NEEDS-ACTION"
+			switch a.ResponseStatus {
+			case "accepted":
+				partstat = "ACCEPTED"
+			case "declined":
+				partstat = "DECLINED"
+			case "tentative":
+				partstat = "TENTATIVE"
+			}
+			prop("ATTENDEE;PARTSTAT="+partstat, "mailto:"+a.Email)
+		}
+		if ev.Status == "tentative" || ev.Status == "cancelled" {
+			prop("STATUS", strings.ToUpper(ev.Status))
+		}
+		prop("END", "VEVENT")
+	}
+	prop("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// exportICS は一致したイベントをICSファイルに書き出す
+func exportICS(path string, r *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = writeICS(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
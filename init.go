@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/calendar/v3"
+)
+
+// prompt は質問を表示し、入力された値を返す。空入力の場合はデフォルト値を返す
+func prompt(reader *bufio.Reader, question, defaultValue string) string {
+	question = translate(question)
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	input, err := reader.ReadString('\n')
+	if err != nil && input == "" {
+		return defaultValue
+	}
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return defaultValue
+	}
+	return input
+}
+
+// initFields は初期設定で質問する設定の項目
+var initFields = []func(c *Config) *string{
+	func(c *Config) *string { return &c.CredentialsPath },
+	func(c *Config) *string { return &c.TokenPath },
+	func(c *Config) *string { return &c.Calendar },
+	func(c *Config) *string { return &c.Timezone },
+}
+
+// runInit は対話形式で初期設定を行い、設定ファイルを作成する
+// 設定ファイルには、デフォルト値から変更した項目だけを保存する
+func runInit(appDir, configPath string) {
+	reader := bufio.NewReader(os.Stdin)
+
+	saved, err := loadConfig(configPath)
+	if err != nil {
+		fatal("設定ファイルの読み込みに失敗しました", "error", err)
+	}
+	// 質問のデフォルト値にはデフォルトのパスなども表示するが、設定ファイルには変更した値だけを保存する
+	defaults := *saved
+	defaults.applyDefaults(appDir)
+	cfg := defaults
+
+	printer.Printf("gcal-sum の初期設定を開始します。\n")
+	fmt.Println()
+
+	// 1. credentials.json の場所を確認して検証
+	printer.Printf("[1/4] 認証情報ファイル\n")
+	// 環境変数や組み込みのOAuthクライアントがある場合は、credentials.json を用意しなくてよい
+	envCfg := cfg
+	envCfg.applyEnv()
+	setOAuthClient(&envCfg)
+	// 認証で保存するトークンファイルも設定に従って暗号化するため、鍵を取得できない場合は認証の前に終了する
+	if err := setTokenEncryption(&envCfg); err != nil {
+		fatalCode(exitUsage, "トークンファイルの暗号化の設定が不正です", "error", err)
+	}
+	if _, err := tokenKey(); err != nil {
+		fatalCode(exitUsage, "トークンファイルを暗号化する鍵を取得できません", "error", err)
+	}
+	if oauthClientSource(cfg.CredentialsPath) != "credentials" {
+		printer.Printf("指定されたOAuthクライアントを使用するため、credentials.json は不要です。\n")
+	} else {
+		for {
+			cfg.CredentialsPath = prompt(reader, "credentials.json のパス", cfg.CredentialsPath)
+			credentials, err := os.ReadFile(cfg.CredentialsPath)
+			if err != nil {
+				printer.Printf("ファイルを読み込めませんでした: %v\n", err)
+				continue
+			}
+			if _, err := google.ConfigFromJSON(credentials, calendar.CalendarReadonlyScope); err != nil {
+				printer.Printf("認証情報ファイルの形式が正しくありません: %v\n", err)
+				continue
+			}
+			break
+		}
+	}
+	fmt.Println()
+
+	// 2. OAuth認証を実行
+	printer.Printf("[2/4] Googleアカウントの認証\n")
+	cfg.TokenPath = prompt(reader, "トークンファイルの保存先", cfg.TokenPath)
+	ctx := context.Background()
+	src := &googleSource{srv: newCalendarService(ctx, cfg.CredentialsPath, cfg.TokenPath)}
+	printer.Printf("認証に成功しました。\n")
+	fmt.Println()
+
+	// 3. デフォルトのカレンダーを選択
+	printer.Printf("[3/4] デフォルトのカレンダー\n")
+	calendars, err := src.Calendars(ctx)
+	if err != nil {
+		fatalAPI("カレンダー一覧の取得に失敗しました", err)
+	}
+	for i, item := range calendars {
+		fmt.Printf("%d. %s (ID: %s)\n", i+1, item.Summary, item.Id)
+	}
+	for {
+		answer := prompt(reader, "使用するカレンダーの番号またはID", cfg.Calendar)
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n < 1 || n > len(calendars) {
+				printer.Printf("1 から %d の番号を入力してください。\n", len(calendars))
+				continue
+			}
+			cfg.Calendar = calendars[n-1].Id
+		} else {
+			cfg.Calendar = answer
+		}
+		break
+	}
+	fmt.Println()
+
+	// 4. タイムゾーンを選択
+	printer.Printf("[4/4] タイムゾーン\n")
+	for {
+		cfg.Timezone = prompt(reader, "タイムゾーン（例: Asia/Tokyo）", cfg.Timezone)
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			printer.Printf("タイムゾーンを読み込めませんでした: %v\n", err)
+			continue
+		}
+		break
+	}
+	fmt.Println()
+
+	for _, field := range initFields {
+		if v := *field(&cfg); v != *field(&defaults) {
+			*field(saved) = v
+		}
+	}
+	if err := saveConfig(configPath, saved); err != nil {
+		fatal("設定ファイルの保存に失敗しました", "error", err)
+	}
+	printer.Printf("設定を %s に保存しました。\n", configPath)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Jiraの課題キー（PROJ-123 など）に一致する正規表現
+var issueKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[0-9]+\b`)
+
+// 作業ログのコメントに含めたイベントの識別文字列に一致する正規表現
+var worklogMarkerPattern = regexp.MustCompile(`\[gcal-sum:[^\]]+\]`)
+
+// JiraConfig はJiraへの作業ログの登録に使用する設定
+type JiraConfig struct {
+	BaseURL string `json:"base_url,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Token   string `json:"token,omitempty"`
+}
+
+// issueKey はイベントのタイトル、説明の順に探して、最初に見つかった課題キーを返す
+func issueKey(e MatchedEvent) string {
+	for _, text := range []string{e.Event.Summary, e.Event.Description} {
+		if key := issueKeyPattern.FindString(text); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// worklogMarker は作業ログのコメントに含める、登録元のイベントを識別する文字列
+// 同じイベントの作業ログを二重に登録しないように使用する
+func worklogMarker(e MatchedEvent) string {
+	return "[gcal-sum:" + e.Event.Id + "]"
+}
+
+// jiraClient はJiraのREST APIのクライアント
+type jiraClient struct {
+	baseURL string
+	email   string
+	token   string
+}
+
+// do はJiraのREST APIを呼び出す。レート制限やサーバーエラーの場合は再試行する
+func (c *jiraClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	return withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.baseURL, "/")+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(c.email, c.token)
+		req.Header.Set("Accept", "application/json")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return &httpStatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+		}
+		if out == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	})
+}
+
+// jiraWorklog はJiraの作業ログ
+type jiraWorklog struct {
+	Comment          string `json:"comment"`
+	Started          string `json:"started"`
+	TimeSpentSeconds int    `json:"timeSpentSeconds"`
+}
+
+// existingMarkers は課題に登録済みの作業ログから、gcal-sum が登録したイベントの識別文字列を集める
+func (c *jiraClient) existingMarkers(ctx context.Context, key string) (map[string]bool, error) {
+	var res struct {
+		Worklogs []jiraWorklog `json:"worklogs"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"/worklog", nil, &res); err != nil {
+		return nil, err
+	}
+	markers := make(map[string]bool)
+	for _, w := range res.Worklogs {
+		for _, m := range worklogMarkerPattern.FindAllString(w.Comment, -1) {
+			markers[m] = true
+		}
+	}
+	return markers, nil
+}
+
+// addWorklog は課題に作業ログを登録する
+func (c *jiraClient) addWorklog(ctx context.Context, key string, w jiraWorklog) error {
+	return c.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/worklog", w, nil)
+}
+
+// exportJiraFlags は export jira のフラグ
+type exportJiraFlags struct {
+	opts   *queryOptions
+	jc     *jiraClient
+	dryRun bool
+}
+
+// registerExportJiraFlags は export jira のフラグを登録する（補完の候補にも使用する）
+func registerExportJiraFlags(fs *flag.FlagSet, cfg *Config) *exportJiraFlags {
+	f := &exportJiraFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	f.jc = &jiraClient{}
+	fs.StringVar(&f.jc.baseURL, "jira-url", cfg.Jira.BaseURL, "JiraのURL（例: https://example.atlassian.net）")
+	fs.StringVar(&f.jc.email, "jira-email", cfg.Jira.Email, "Jiraのユーザーのメールアドレス")
+	fs.StringVar(&f.jc.token, "jira-token", cfg.Jira.Token, "JiraのAPIトークン（環境変数 GCAL_SUM_JIRA_TOKEN でも指定可能）")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "作業ログを登録せずに、登録する内容だけを表示")
+	return f
+}
+
+// runExportJira は一致したイベントを、タイトルや説明に含まれる課題キーのJiraの作業ログとして登録する
+func runExportJira(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("export jira", flag.ExitOnError)
+	flags := registerExportJiraFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+	jc := flags.jc
+
+	if jc.baseURL == "" || (!flags.dryRun && (jc.email == "" || jc.token == "")) {
+		printer.Printf("エラー: JiraのURL、メールアドレス、APIトークンを指定してください。\n")
+		printer.Printf("使用方法: gcal-sum export jira -jira-url=https://example.atlassian.net -jira-email=you@example.com -month=YYYY-MM -name=イベント名 [-dry-run]\n")
+		os.Exit(exitUsage)
+	}
+	opts.validate()
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	report := runQuery(ctx, src, cfg, opts)
+
+	var created, skipped, noKey int
+	markers := make(map[string]map[string]bool)
+	for _, e := range report.Events {
+		key := issueKey(e)
+		if key == "" {
+			noKey++
+			continue
+		}
+
+		// 同じイベントの作業ログが登録済みの場合は登録しない
+		if !flags.dryRun {
+			if _, ok := markers[key]; !ok {
+				m, err := jc.existingMarkers(ctx, key)
+				if err != nil {
+					fatal("作業ログの取得に失敗しました", "issue", key, "error", contextError(ctx, err))
+				}
+				markers[key] = m
+			}
+			if markers[key][worklogMarker(e)] {
+				skipped++
+				continue
+			}
+		}
+
+		start := e.Start.In(report.Location)
+		w := jiraWorklog{
+			Comment:          e.Event.Summary + " " + worklogMarker(e),
+			Started:          start.Format("2006-01-02T15:04:05.000-0700"),
+			TimeSpentSeconds: int(e.Duration.Round(time.Minute).Seconds()),
+		}
+		fmt.Printf("%s: %s %s [%s]\n", key, start.Format("2006/01/02 15:04"), e.Event.Summary, formatDuration(e.Duration))
+		if flags.dryRun || w.TimeSpentSeconds == 0 {
+			continue
+		}
+		if err := jc.addWorklog(ctx, key, w); err != nil {
+			fatal("作業ログの登録に失敗しました", "issue", key, "error", contextError(ctx, err))
+		}
+		created++
+	}
+
+	if flags.dryRun {
+		printer.Printf("（-dry-run のため、作業ログは登録していません）\n")
+	} else {
+		printer.Printf("%d件の作業ログを登録しました（登録済みのため %d件をスキップ）\n", created, skipped)
+	}
+	if noKey > 0 {
+		slog.Warn("課題キーが見つからないイベントは登録しませんでした", "count", noKey)
+	}
+}
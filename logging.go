@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/term"
+)
+
+// 利用可能なログの出力形式
+var logFormats = []string{"text", "json"}
+
+// 詳細なデバッグ情報（API呼び出しごとのログなど）を出力するログレベル
+const levelTrace = slog.LevelDebug - 4
+
+// logOptions はログの出力に関する共通オプション
+type logOptions struct {
+	verbose     bool
+	veryVerbose bool
+	quiet       bool
+	format      string
+}
+
+// register はログの出力に関するフラグを登録する
+func (l *logOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&l.verbose, "v", false, "詳細なログを出力")
+	fs.BoolVar(&l.veryVerbose, "vv", false, "API呼び出しごとのログも含めて、さらに詳細なログを出力")
+	fs.BoolVar(&l.quiet, "quiet", false, "エラー以外のログと取得の進み具合を出力しない")
+	fs.StringVar(&l.format, "log-format", "text", "ログの出力形式（text, json）")
+}
+
+// level は指定されたオプションに対応するログレベルを返す
+func (l *logOptions) level() slog.Level {
+	switch {
+	case l.quiet:
+		return slog.LevelError
+	case l.veryVerbose:
+		return levelTrace
+	case l.verbose:
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// setup はオプションに従って標準のロガーを設定する
+func (l *logOptions) setup() {
+	var handler slog.Handler
+	switch l.format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: l.level()})
+	case "text", "":
+		handler = newConsoleHandler(os.Stderr, l.level())
+	default:
+		printer.Printf("エラー: ログの出力形式 '%s' はサポートされていません（%s）。\n", l.format, strings.Join(logFormats, ", "))
+		os.Exit(exitUsage)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	// 進み具合は端末に表示する場合だけ、ログと同じ標準エラー出力に表示する
+	progressEnabled = !l.quiet && l.format != "json" && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// commonFlags はすべてのサブコマンドに共通するフラグ
+type commonFlags struct {
+	log     logOptions
+	rate    rateOptions
+	profile profileOptions
+	lang    string
+	noColor bool
+}
+
+// registerCommonFlags はログ、表示言語、リクエストの頻度の制限、プロファイルとOAuthクライアントに関するフラグを登録する（補完の候補にも使用する）
+func registerCommonFlags(fs *flag.FlagSet) *commonFlags {
+	c := &commonFlags{}
+	c.log.register(fs)
+	c.rate.register(fs)
+	c.profile.register(fs)
+	oauthClient.register(fs)
+	fs.StringVar(&c.lang, "lang", "", "表示言語（ja, en）。未指定の場合は環境変数 GCAL_SUM_LANG や LANG から判定")
+	fs.BoolVar(&c.noColor, "no-color", false, "出力に色を付けない（環境変数 NO_COLOR を設定した場合も色を付けない）")
+	return c
+}
+
+// setup は解析したフラグに従って、ロガーと表示言語などを設定する
+func (c *commonFlags) setup() {
+	colorDisabled = c.noColor
+	if err := setLanguage(c.lang); err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	c.log.setup()
+	c.rate.setup()
+	c.profile.setup()
+}
+
+// parseFlags は共通のフラグを登録してから引数を解析し、ロガーと表示言語を設定する
+func parseFlags(fs *flag.FlagSet, args []string) {
+	c := registerCommonFlags(fs)
+	fs.Parse(args)
+	c.setup()
+}
+
+// fatalHook はエラーで終了する前に実行する処理（端末の状態の復元など）
+type fatalHook struct {
+	f func()
+}
+
+var (
+	fatalHooksMu sync.Mutex
+	fatalHooks   []*fatalHook
+)
+
+// onFatal はエラーで終了する前に実行する処理を登録し、登録を解除する関数を返す
+// 処理が不要になった時点（ロックの解放後など）で解除して、登録した処理が溜まらないようにする
+func onFatal(f func()) (unregister func()) {
+	h := &fatalHook{f: f}
+	fatalHooksMu.Lock()
+	fatalHooks = append(fatalHooks, h)
+	fatalHooksMu.Unlock()
+	return func() {
+		fatalHooksMu.Lock()
+		defer fatalHooksMu.Unlock()
+		fatalHooks = slices.DeleteFunc(fatalHooks, func(x *fatalHook) bool { return x == h })
+	}
+}
+
+// runFatalHooks は登録されているエラーで終了する前の処理を実行する
+func runFatalHooks() {
+	fatalHooksMu.Lock()
+	hooks := slices.Clone(fatalHooks)
+	fatalHooksMu.Unlock()
+	// 処理の中で登録を解除できるように、ロックを解放してから実行する
+	for _, h := range hooks {
+		h.f()
+	}
+}
+
+// recoverFatal が0より大きい場合、fatal はプロセスを終了せずに *fatalError でパニックを起こす
+// serve のように、1件のリクエストの失敗でプロセス全体を終了させたくない場合に catchFatal で有効にする
+// パニックはそのゴルーチンでしか recover できないため、fatal を呼び出す可能性のあるゴルーチンでは
+// recover して、呼び出し元のゴルーチンでパニックを起こし直す（runFetchTasks を参照）
+var recoverFatal atomic.Int32
+
+// catchFatal は、戻り値の関数を呼び出すまで fatal がプロセスを終了せずにパニックを起こすようにする
+// 入れ子で有効にした場合は、すべての戻り値の関数を呼び出すまで有効のままにする
+func catchFatal() (release func()) {
+	recoverFatal.Add(1)
+	var once sync.Once
+	return func() { once.Do(func() { recoverFatal.Add(-1) }) }
+}
+
+// fatalError は recoverFatal が有効な場合に fatal から送出されるエラー
+type fatalError struct {
+	code int
+	msg  string
+	args []any
+}
+
+func (e *fatalError) Error() string {
+	var b strings.Builder
+	b.WriteString(translate(e.msg))
+	for i := 0; i+1 < len(e.args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", e.args[i], e.args[i+1])
+	}
+	return b.String()
+}
+
+// fatal はエラーをログに出力して終了する
+func fatal(msg string, args ...any) {
+	fatalCode(exitError, msg, args...)
+}
+
+// fatalCode はエラーをログに出力して、指定された終了コードで終了する
+// パニックを起こす場合は、プロセスを終了しないため登録された処理を実行しない（ロックの解放などは defer で行う）
+func fatalCode(code int, msg string, args ...any) {
+	if recoverFatal.Load() > 0 {
+		slog.Error(msg, args...)
+		panic(&fatalError{code: code, msg: msg, args: args})
+	}
+	runFatalHooks()
+	slog.Error(msg, args...)
+	exit(code)
+}
+
+// fatalAPI はAPIの呼び出しのエラーをログに出力して、エラーの種類に対応する終了コードで終了する
+func fatalAPI(msg string, err error) {
+	fatalCode(exitCodeFor(err), msg, "error", err)
+}
+
+// consoleHandler は端末で読みやすい形式（時刻を省略し、属性をメッセージの後ろに並べる）でログを出力する
+type consoleHandler struct {
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+	mu    *sync.Mutex
+}
+
+// newConsoleHandler は指定されたレベル以上のログを出力するハンドラーを生成する
+func newConsoleHandler(w io.Writer, level slog.Level) *consoleHandler {
+	return &consoleHandler{w: w, level: level, mu: &sync.Mutex{}}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString(printer.Sprintf("エラー: "))
+	case r.Level >= slog.LevelWarn:
+		b.WriteString(printer.Sprintf("警告: "))
+	case r.Level < slog.LevelInfo:
+		b.WriteString("[debug] ")
+	}
+	b.WriteString(translate(r.Message))
+
+	writeAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value.Any())
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return writeAboveProgress(h.w, b.String())
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup はグループ名を使用しないため、同じハンドラーを返す
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestOnFatalUnregister(t *testing.T) {
+	var ran []string
+	unregisterA := onFatal(func() { ran = append(ran, "a") })
+	unregisterB := onFatal(func() { ran = append(ran, "b") })
+	t.Cleanup(func() {
+		unregisterA()
+		unregisterB()
+	})
+
+	unregisterA()
+	runFatalHooks()
+	if len(ran) != 1 || ran[0] != "b" {
+		t.Errorf("実行した処理 = %v; want [b]", ran)
+	}
+
+	// 処理の中で自身の登録を解除してもデッドロックしないこと
+	var unregisterC func()
+	unregisterC = onFatal(func() { unregisterC() })
+	runFatalHooks()
+	fatalHooksMu.Lock()
+	n := len(fatalHooks)
+	fatalHooksMu.Unlock()
+	if n != 1 {
+		t.Errorf("登録されている処理 = %d件; want 1件", n)
+	}
+}
+
+func TestCatchFatal(t *testing.T) {
+	outer := catchFatal()
+	inner := catchFatal()
+	inner()
+	inner()
+	if recoverFatal.Load() != 1 {
+		t.Errorf("内側の解除後 = %d; want 外側が有効なまま", recoverFatal.Load())
+	}
+
+	func() {
+		defer func() {
+			if _, ok := recover().(*fatalError); !ok {
+				t.Error("fatal が *fatalError でパニックを起こしませんでした")
+			}
+		}()
+		fatal("テスト")
+	}()
+
+	outer()
+	if recoverFatal.Load() != 0 {
+		t.Errorf("すべての解除後 = %d; want 0", recoverFatal.Load())
+	}
+}
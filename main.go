@@ -2,18 +2,23 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/option"
 )
@@ -26,7 +31,7 @@ func getAppDir() string {
 		// エラーが発生した場合はカレントディレクトリを使用
 		currentDir, err := os.Getwd()
 		if err != nil {
-			log.Fatalf("カレントディレクトリの取得に失敗しました: %v", err)
+			fatal("カレントディレクトリの取得に失敗しました", "error", err)
 		}
 		return currentDir
 	}
@@ -34,17 +39,56 @@ func getAppDir() string {
 	return filepath.Dir(execPath)
 }
 
+// authCallback は認証後のリダイレクトで受け取った結果
+type authCallback struct {
+	code string
+	err  string
+}
+
+// newAuthState はリダイレクトが自分の開始した認証に対するものかを確かめるための、推測できない state の値を生成する
+func newAuthState() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		fatal("認証の準備に失敗しました", "error", err)
+	}
+	return hex.EncodeToString(b)
+}
+
 // getTokenFromWeb はウェブブラウザを通じてトークンを取得する
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	// ローカルサーバーを起動してリダイレクトを処理
-	var authCode string
-	codeCh := make(chan string)
+// リダイレクトは 127.0.0.1 の空いているポートで待機するローカルサーバーで受け取り、1つ目の結果を受け取った時点でサーバーを停止する
+// コンテキストがキャンセルされた場合は、ローカルサーバーを停止してから終了する
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config) *oauth2.Token {
+	// 同じネットワークの他のマシンから接続できないように、ループバックアドレスだけで待機する
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fatalCode(exitAuth, "認証用のローカルサーバーの起動に失敗しました", "error", err)
+	}
+	// デスクトップアプリのOAuthクライアントでは、ループバックアドレスの任意のポートをリダイレクト先にできる
+	authConfig := *config
+	authConfig.RedirectURL = "http://" + listener.Addr().String() + "/"
+	state := newAuthState()
 
 	// リダイレクト先のハンドラーを設定
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		if code != "" {
-			codeCh <- code
+	// state が一致しない要求と、結果を受け取った後の要求は拒否する
+	callbackCh := make(chan authCallback, 1)
+	var received atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "認証の要求が一致しません。", http.StatusBadRequest)
+			return
+		}
+		if !received.CompareAndSwap(false, true) {
+			http.Error(w, "認証はすでに完了しています。", http.StatusGone)
+			return
+		}
+		cb := authCallback{code: q.Get("code"), err: q.Get("error")}
+		if cb.code == "" && cb.err == "" {
+			cb.err = "no_code"
+		}
+		callbackCh <- cb
+		if cb.code != "" {
 			w.Write([]byte("認証が完了しました。このページを閉じて構いません。"))
 		} else {
 			w.Write([]byte("認証コードが取得できませんでした。"))
@@ -52,101 +96,157 @@ func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
 	})
 
 	// 一時的なサーバーを起動
-	server := &http.Server{Addr: ":8080"} // localhostの8080ポートで待機
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("サーバー起動エラー: %v", err)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Warn("サーバー起動エラー", "error", err)
 		}
 	}()
 
 	// access_typeをofflineに設定し、approval_promptをforceに設定することで、
 	// 毎回リフレッシュトークンが必ず発行されるようにする
-	authURL := config.AuthCodeURL("state-token",
+	// 追加の権限を許可する場合も、許可済みの権限を含むトークンが発行されるように include_granted_scopes を指定する
+	authURL := authConfig.AuthCodeURL(state,
 		oauth2.AccessTypeOffline,
-		oauth2.ApprovalForce)
-	fmt.Printf("ブラウザで以下のURLを開いてください:\n%v\n", authURL)
-
-	// 認証コードを受け取る
-	authCode = <-codeCh
+		oauth2.ApprovalForce,
+		oauth2.SetAuthURLParam("include_granted_scopes", "true"))
+	printer.Fprintf(os.Stderr, "ブラウザで以下のURLを開いてください:\n%v\n", authURL)
+
+	// 認証の結果を受け取る
+	var cb authCallback
+	var interrupted error
+	select {
+	case cb = <-callbackCh:
+	case <-ctx.Done():
+		interrupted = context.Cause(ctx)
+	}
 
-	// サーバーを停止
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// サーバーを停止（応答中のリダイレクトには応答し終えてから停止する）
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	server.Shutdown(ctx)
+	server.Shutdown(shutdownCtx)
+	if interrupted != nil {
+		fatalCode(exitCodeFor(interrupted), "認証が完了する前に処理が終了しました", "reason", interrupted)
+	}
+	if cb.code == "" {
+		fatalCode(exitAuth, "認証コードが取得できませんでした", "error", cb.err)
+	}
 
 	// 認証コードを使ってトークンを取得
-	tok, err := config.Exchange(context.TODO(), authCode)
+	tok, err := authConfig.Exchange(ctx, cb.code)
 	if err != nil {
-		log.Fatalf("トークンの取得に失敗しました: %v", err)
+		fatalCode(exitAuth, "トークンの取得に失敗しました", "error", contextError(ctx, err))
 	}
 	return tok
 }
 
+// トークンの有効期限がこの時間以内に迫っている場合は、期限切れになる前に更新する
+const tokenRefreshMargin = 5 * time.Minute
+
 // tokenFromFile はファイルからトークンを読み込む
+// 暗号化されたトークンファイルは復号してから読み込む
 func tokenFromFile(file string) (*oauth2.Token, error) {
-	f, err := os.Open(file)
+	b, err := readTokenFile(file)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 	tok := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(tok)
+	err = json.Unmarshal(b, tok)
 	return tok, err
 }
 
 // getClient はOAuth2クライアントを取得する
-func getClient(config *oauth2.Config, tokenFilePath string) *http.Client {
+func getClient(ctx context.Context, config *oauth2.Config, tokenFilePath string) *http.Client {
 	tok, err := tokenFromFile(tokenFilePath)
+	if err == nil && !tok.Expiry.Before(time.Now().Add(tokenRefreshMargin)) {
+		return oauth2.NewClient(ctx, newReauthTokenSource(ctx, config, tokenFilePath, tok))
+	}
+	// 鍵が違う場合などに認証をやり直すと、暗号化したトークンファイルを上書きしてしまうため、ここで終了する
+	var keyErr *tokenKeyError
+	if errors.As(err, &keyErr) {
+		fatalCode(exitAuth, "トークンファイルを読み込めません", "error", err, "path", tokenFilePath)
+	}
+
+	// 同時に実行された別のプロセスと重ねて更新・保存しないように、トークンファイルをロックする
+	unlock := lockTokenFile(ctx, tokenFilePath)
+	defer unlock()
+
+	// ロックを待つ間に別のプロセスが更新した場合は、更新後のトークンをそのまま使用する
+	tok, err = tokenFromFile(tokenFilePath)
 	if err != nil {
-		tok = getTokenFromWeb(config)
+		tok = getTokenFromWeb(ctx, config)
 		saveToken(tokenFilePath, tok)
 	} else {
-		// トークンの有効期限を確認し、期限切れなら更新を試みる
-		if tok.Expiry.Before(time.Now()) {
-			fmt.Println("トークンの有効期限が切れています。更新を試みます...")
+		// トークンの有効期限を確認し、期限切れまたは期限が近い場合は更新を試みる
+		if tok.Expiry.Before(time.Now().Add(tokenRefreshMargin)) {
+			slog.Info("トークンの有効期限が切れています。更新を試みます")
 
 			// RefreshTokenがある場合は、それを使用してトークンを更新
 			if tok.RefreshToken != "" {
-				tokenSource := config.TokenSource(context.Background(), tok)
+				tokenSource := config.TokenSource(ctx, tok)
 				newToken, err := tokenSource.Token()
-				if err != nil {
-					fmt.Printf("トークンの更新に失敗しました: %v\n再認証を行います...\n", err)
-					tok = getTokenFromWeb(config)
-				} else {
-					fmt.Println("トークンが正常に更新されました")
+				switch {
+				case err == nil:
+					slog.Info("トークンが正常に更新されました")
 					tok = newToken
+					saveToken(tokenFilePath, tok)
+				case isInvalidGrant(err):
+					if tok, err = reauthenticate(ctx, config, tokenFilePath, err); err != nil {
+						fatalCode(exitAuth, "リフレッシュトークンが失効しています", "error", err, "path", tokenFilePath)
+					}
+				default:
+					slog.Warn("トークンの更新に失敗したため、再認証を行います", "error", err)
+					tok = getTokenFromWeb(ctx, config)
+					saveToken(tokenFilePath, tok)
 				}
-				saveToken(tokenFilePath, tok)
 			} else {
-				fmt.Println("リフレッシュトークンがないため、再認証を行います...")
-				tok = getTokenFromWeb(config)
+				slog.Info("リフレッシュトークンがないため、再認証を行います")
+				tok = getTokenFromWeb(ctx, config)
 				saveToken(tokenFilePath, tok)
 			}
 		}
 	}
-	return config.Client(context.Background(), tok)
+	// 実行中にリフレッシュトークンが失効した場合も、再認証して処理を続けられるようにする
+	return oauth2.NewClient(ctx, newReauthTokenSource(ctx, config, tokenFilePath, tok))
 }
 
 // saveToken はトークンをファイルに保存する
+// 保存中に別のプロセスが読み込んでも壊れたファイルが見えないように、一時ファイルを経由して置き換える
+// 付与されたスコープは応答に含まれるものを保存し、含まれない場合（更新時など）は保存済みのスコープを引き継ぐ
 func saveToken(path string, token *oauth2.Token) {
-	fmt.Printf("トークンを %s に保存します\n", path)
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	slog.Info("トークンを保存します", "path", path)
+	saved := savedToken{Token: token, Scope: tokenScope(token)}
+	if saved.Scope == "" {
+		saved.Scope, _ = readTokenScope(path)
+	}
+	b, err := json.Marshal(saved)
+	if err == nil {
+		b, err = encodeTokenFile(b)
+	}
+	if err == nil {
+		err = writeFileAtomic(path, b, 0600)
+	}
 	if err != nil {
-		log.Fatalf("トークンファイルの保存に失敗しました: %v", err)
+		fatal("トークンファイルの保存に失敗しました", "error", err)
 	}
-	defer f.Close()
-	json.NewEncoder(f).Encode(token)
 }
 
 // 利用可能なカレンダーを一覧表示する関数
-func listCalendars(srv *calendar.Service) {
-	calendarList, err := srv.CalendarList.List().Do()
+func listCalendars(ctx context.Context, src CalendarSource, cacheDir string) {
+	calendarList, err := src.Calendars(ctx)
 	if err != nil {
-		log.Fatalf("カレンダー一覧の取得に失敗しました: %v", err)
+		fatalAPI("カレンダー一覧の取得に失敗しました", contextError(ctx, err))
+	}
+
+	// シェルの補完でカレンダーIDを候補にするため、一覧を保存しておく
+	if !isLocalSource(src) {
+		if err := saveCalendarList(cacheDir, calendarList); err != nil {
+			slog.Warn("カレンダー一覧の保存に失敗しました", "error", err)
+		}
 	}
 
-	fmt.Println("利用可能なカレンダー一覧:")
-	for i, item := range calendarList.Items {
+	printer.Printf("利用可能なカレンダー一覧:\n")
+	for i, item := range calendarList {
 		fmt.Printf("%d. %s (ID: %s)\n", i+1, item.Summary, item.Id)
 	}
 }
@@ -168,158 +268,339 @@ func getMonthDates(monthStr string, location *time.Location) (time.Time, time.Ti
 	return startDate, endDate, nil
 }
 
+// newHTTPClient は認証を行い、指定されたスコープでAPIにアクセスできるHTTPクライアントを生成する
+// 保存したトークンに指定されたスコープが付与されていない場合は、その時点で追加の権限の許可を求める
+func newHTTPClient(ctx context.Context, credentialsPath, tokenPath string, scopes ...string) *http.Client {
+	config := mustOAuthConfig(credentialsPath, scopes...)
+	ensureScopes(ctx, config, tokenPath)
+	return withRateLimit(getClient(ctx, config, tokenPath))
+}
+
+// newCalendarService は認証を行い、Calendar APIのサービスを生成する
+func newCalendarService(ctx context.Context, credentialsPath, tokenPath string) *calendar.Service {
+	client := newHTTPClient(ctx, credentialsPath, tokenPath, calendar.CalendarReadonlyScope)
+
+	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		fatal("Calendar APIの初期化に失敗しました", "error", err)
+	}
+	return srv
+}
+
 func main() {
+	// フラグを解析するまでは標準の形式でログを出力する
+	slog.SetDefault(slog.New(newConsoleHandler(os.Stderr, slog.LevelInfo)))
+	// プロファイルの書き出しはフラグの解析時に設定されるため、終了時に設定後の stopProfile を呼び出す
+	defer func() { stopProfile() }()
+
 	// アプリケーションのディレクトリを取得
 	appDir := getAppDir()
 
-	// 設定ファイルとトークンファイルのパス
-	credentialsPath := filepath.Join(appDir, "credentials.json")
-	tokenPath := filepath.Join(appDir, "token.json")
+	// 設定ファイルのパス（環境変数 GCAL_SUM_CONFIG で変更可能）
+	configPath := filepath.Join(appDir, "config.json")
+	if v := os.Getenv("GCAL_SUM_CONFIG"); v != "" {
+		configPath = v
+	}
 
-	// コマンドライン引数の解析
-	startDateStr := flag.String("start", "", "開始日（YYYY-MM-DD形式）")
-	endDateStr := flag.String("end", "", "終了日（YYYY-MM-DD形式）")
-	monthStr := flag.String("month", "", "月指定（YYYY-MM形式）")
-	eventName := flag.String("name", "", "検索するイベント名")
-	calendarID := flag.String("calendar", "primary", "カレンダーID（デフォルトは 'primary'）")
-	isList := flag.Bool("list", false, "利用可能なカレンダーの一覧を表示")
-	flag.Parse()
+	// 初期設定は設定ファイルの読み込み前に実行する
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		runInit(appDir, configPath)
+		return
+	}
 
-	// 認証設定
-	ctx := context.Background()
-	b, err := os.ReadFile(credentialsPath)
-	if err != nil {
-		log.Fatalf("credentials.jsonの読み込みに失敗しました: %v\n設定ファイルパス: %s", err, credentialsPath)
+	// 診断は設定ファイルの問題も報告できるように、設定ファイルの読み込み前に実行する
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		runDoctor(appDir, configPath, os.Args[2:])
+		return
 	}
 
-	config, err := google.ConfigFromJSON(b, calendar.CalendarReadonlyScope)
+	// 設定ファイルの読み込み
+	cfg, err := loadConfig(configPath)
 	if err != nil {
-		log.Fatalf("OAuth2の設定に失敗しました: %v", err)
+		fatal("設定ファイルの読み込みに失敗しました", "error", err, "path", configPath)
+	}
+	cfg.applyEnv()
+	cfg.applyDefaults(appDir)
+	if err := setTokenEncryption(cfg); err != nil {
+		fatalCode(exitUsage, "トークンファイルの暗号化の設定が不正です", "error", err)
+	}
+	setOAuthClient(cfg)
+
+	// サブコマンドの処理
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "report":
+			runReportCommand(cfg, os.Args[2:])
+			return
+		case "export":
+			runExportCommand(cfg, os.Args[2:])
+			return
+		case "auth":
+			runAuthCommand(cfg, os.Args[2:])
+			return
+		case "sync":
+			runSyncCommand(cfg, os.Args[2:])
+			return
+		case "history":
+			runHistoryCommand(cfg, os.Args[2:])
+			return
+		case "heatmap":
+			runHeatmapCommand(cfg, os.Args[2:])
+			return
+		case "busy":
+			runBusyCommand(cfg, os.Args[2:])
+			return
+		case "meetings":
+			runMeetingsCommand(cfg, os.Args[2:])
+			return
+		case "focus":
+			runFocusCommand(cfg, os.Args[2:])
+			return
+		case "rooms":
+			runRoomsCommand(cfg, os.Args[2:])
+			return
+		case "team":
+			runTeamCommand(cfg, os.Args[2:])
+			return
+		case "snapshot":
+			runSnapshotCommand(cfg, os.Args[2:])
+			return
+		case "diff":
+			runDiffCommand(cfg, os.Args[2:])
+			return
+		case "serve":
+			runServeCommand(cfg, os.Args[2:])
+			return
+		case "watch":
+			runWatchCommand(cfg, os.Args[2:])
+			return
+		case "run":
+			runRunCommand(cfg, os.Args[2:])
+			return
+		case "exporter":
+			runExporterCommand(cfg, os.Args[2:])
+			return
+		case "tui":
+			runTUICommand(cfg, os.Args[2:])
+			return
+		case "completion":
+			runCompletionCommand(os.Args[2:])
+			return
+		case "__complete":
+			runCompleteCommand(cfg, os.Args[2:])
+			return
+		}
 	}
-	client := getClient(config, tokenPath)
 
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		log.Fatalf("Calendar APIの初期化に失敗しました: %v", err)
+	runSumCommand(cfg, os.Args[1:])
+}
+
+// sumFlags は gcal-sum のフラグ
+type sumFlags struct {
+	opts              *queryOptions
+	isList            bool
+	outputFormat      string
+	sortKey           string
+	sortDesc          bool
+	showIDs           bool
+	top               int
+	showChart         bool
+	notifySlackURL    string
+	deliverWebhookURL string
+	deliverEmailTo    string
+	exportICSPath     string
+	auditPath         string
+	format            string
+}
+
+// registerSumFlags は gcal-sum のフラグを登録する（補完の候補にも使用する）
+func registerSumFlags(fs *flag.FlagSet, cfg *Config) *sumFlags {
+	f := &sumFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.BoolVar(&f.isList, "list", false, "利用可能なカレンダーの一覧を表示")
+	fs.StringVar(&f.outputFormat, "output", "text", "出力形式（text, markdown）")
+	fs.StringVar(&f.sortKey, "sort", "start", "一致したイベント一覧の並べ替えのキー（start: 開始日時, duration: 時間, name: イベント名）")
+	fs.BoolVar(&f.sortDesc, "desc", false, "一致したイベント一覧を降順に並べる")
+	fs.BoolVar(&f.opts.interactive, "interactive", false, "集計の前に、一致したイベントを1件ずつ確認して除外できるようにする")
+	fs.BoolVar(&f.showIDs, "show-ids", false, "一致したイベント一覧にイベントのIDを表示（-exclude-id や設定ファイルの exclude_events に指定するIDの確認に使用）")
+	fs.IntVar(&f.top, "top", 0, "一致したイベント一覧に表示する件数（0の場合はすべて表示）")
+	fs.BoolVar(&f.opts.noList, "no-list", false, "一致したイベント一覧を表示せず、合計時間・統計・グループ別の集計だけを表示（イベント一覧を保持せずに逐次集計するため、長い期間でもメモリの使用量が増えない）")
+	fs.BoolVar(&f.showChart, "chart", false, "グループ別（未指定の場合は日別）の合計時間を棒グラフで表示")
+	fs.StringVar(&f.notifySlackURL, "notify-slack", cfg.SlackWebhook, "集計結果を投稿するSlackのIncoming WebhookのURL（デフォルトは設定ファイルの slack_webhook）")
+	fs.StringVar(&f.deliverWebhookURL, "deliver-webhook", cfg.Delivery.Webhook, "集計結果をJSON形式でPOSTするURL（デフォルトは設定ファイルの delivery.webhook）")
+	var defaultEmailTo string
+	if cfg.Delivery.Email != nil {
+		defaultEmailTo = strings.Join(cfg.Delivery.Email.To, ",")
 	}
+	fs.StringVar(&f.deliverEmailTo, "deliver-email", defaultEmailTo, "集計結果を送信するメールアドレス、カンマ区切りで複数指定可能（SMTPサーバーは設定ファイルの delivery.email で指定）")
+	fs.StringVar(&f.exportICSPath, "export-ics", "", "一致したイベントを書き出すICSファイルのパス")
+	fs.StringVar(&f.auditPath, "audit", "", "合計時間の根拠となったイベント（ID・iCalUID・取得時点の更新日時・一致した条件）を書き出す監査記録のファイルのパス（既存のファイルは上書きしない）")
+	fs.StringVar(&f.format, "format", "", "集計結果をGoのテンプレートで整形して出力（例: '{{.Total.Hours}}h across {{.Count}} events'）")
+	return f
+}
 
-	if *isList {
-		listCalendars(srv)
-		return
+// runSumCommand はサブコマンドを指定しない場合の集計を実行する
+func runSumCommand(cfg *Config, args []string) {
+	// コマンドライン引数の解析
+	fs := flag.NewFlagSet("gcal-sum", flag.ExitOnError)
+	flags := registerSumFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	if !isValidOutputFormat(flags.outputFormat) {
+		printer.Printf("エラー: 出力形式 '%s' はサポートされていません（%s）。\n", flags.outputFormat, strings.Join(outputFormats, ", "))
+		os.Exit(exitUsage)
+	}
+	if !isValidEventSortKey(flags.sortKey) {
+		printer.Printf("エラー: 並べ替えのキー '%s' はサポートされていません（%s）。\n", flags.sortKey, strings.Join(eventSortKeys, ", "))
+		os.Exit(exitUsage)
+	}
+	if opts.interactive && !canConfirm() {
+		printer.Printf("エラー: -interactive は端末から実行してください。\n")
+		os.Exit(exitUsage)
+	}
+	if flags.top < 0 {
+		printer.Printf("エラー: -top には0以上の件数を指定してください。\n")
+		os.Exit(exitUsage)
+	}
+	if opts.noList && flags.exportICSPath != "" {
+		printer.Printf("エラー: -no-list と -export-ics は同時に指定できません。\n")
+		os.Exit(exitUsage)
+	}
+	if flags.auditPath != "" && (opts.noList || opts.months != "") {
+		printer.Printf("エラー: -audit は -no-list や -months と同時に指定できません。\n")
+		os.Exit(exitUsage)
+	}
+	if flags.auditPath != "" && opts.anonymize {
+		printer.Printf("エラー: -audit は元のイベントを特定するための記録のため、-anonymize と同時には指定できません。\n")
+		os.Exit(exitUsage)
+	}
+	if opts.noList && flags.showChart && opts.groupBy == "" {
+		printer.Printf("エラー: -no-list と -chart を同時に指定する場合は -group-by も指定してください。\n")
+		os.Exit(exitUsage)
+	}
+	var tmpl *template.Template
+	if flags.format != "" {
+		var err error
+		if tmpl, err = parseFormatTemplate(flags.format); err != nil {
+			printer.Printf("エラー: テンプレートが不正です: %v\n", err)
+			os.Exit(exitUsage)
+		}
 	}
 
 	// 引数の検証
-	if !*isList && *eventName == "" {
-		fmt.Println("エラー: イベント名を指定してください。")
-		fmt.Println("使用方法: gcal-sum -start=YYYY-MM-DD -end=YYYY-MM-DD -name=イベント名 [-calendar=カレンダーID]")
-		fmt.Println("または: gcal-sum -month=YYYY-MM -name=イベント名 [-calendar=カレンダーID]")
-		os.Exit(1)
+	if !flags.isList {
+		opts.validate()
 	}
 
-	// 日付文字列をTime型に変換
-	jst, err := time.LoadLocation("Asia/Tokyo")
-	if err != nil {
-		log.Fatalf("タイムゾーンの読み込みに失敗しました: %v", err)
+	// 認証設定
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	if flags.isList {
+		listCalendars(ctx, opts.newSource(ctx, cfg), cfg.CacheDir)
+		return
 	}
-
-	var startDate, endDate time.Time
-
-	// month引数が指定されている場合は、その月の初日と末日を計算
-	if *monthStr != "" {
-		startDate, endDate, err = getMonthDates(*monthStr, jst)
-		if err != nil {
-			log.Fatalf("月指定の解析に失敗しました: %v", err)
-		}
-	} else if *startDateStr != "" && *endDateStr != "" {
-		// startとendが両方指定されている場合は従来通りそれらを使用
-		startDate, err = time.ParseInLocation("2006-01-02", *startDateStr, jst)
-		if err != nil {
-			log.Fatalf("開始日の解析に失敗しました: %v", err)
+	src := opts.newSource(ctx, cfg)
+
+	// 月の範囲が指定された場合は、月ごとに集計して比較表だけを表示する
+	if opts.months != "" {
+		reports := runMonthlyQueries(ctx, src, cfg, opts)
+		printMonthlyComparison(os.Stdout, reports, flags.outputFormat)
+		if _, count, _ := monthlyTotals(reports); count == 0 {
+			exit(exitNoMatch)
 		}
+		return
+	}
 
-		endDate, err = time.ParseInLocation("2006-01-02", *endDateStr, jst)
-		if err != nil {
-			log.Fatalf("終了日の解析に失敗しました: %v", err)
+	// 監査記録には取得した時点のイベントの内容を記録するため、キャッシュを使用しない
+	if flags.auditPath != "" {
+		opts.noCache = true
+	}
+	fetchedAt := time.Now()
+
+	// イベントの集計と結果の表示
+	report := runQuery(ctx, src, cfg, opts)
+
+	// 表示するイベント一覧だけを並べ替えて件数を絞る（ICSファイルや配信にはすべてのイベントを開始日時の順で出力する）
+	view := *report
+	view.Events = sortedEvents(report.Events, flags.sortKey, flags.sortDesc)
+	view.ListLimit = flags.top
+	view.ShowIDs = flags.showIDs
+	view.NoList = opts.noList
+	if tmpl != nil {
+		// テンプレートが指定された場合は、スクリプトから扱いやすいようにテンプレートの出力だけを表示する
+		if err := printTemplate(os.Stdout, tmpl, &view); err != nil {
+			fatal("テンプレートの出力に失敗しました", "error", err)
 		}
 	} else {
-		// どちらの形式も指定されていない場合はエラー
-		fmt.Println("エラー: 日付範囲を指定してください。")
-		fmt.Println("使用方法: gcal-sum -start=YYYY-MM-DD -end=YYYY-MM-DD -name=イベント名 [-calendar=カレンダーID]")
-		fmt.Println("または: gcal-sum -month=YYYY-MM -name=イベント名 [-calendar=カレンダーID]")
-		os.Exit(1)
-	}
-
-	// endDateに対しては検索時に「終日」を含めるために1日追加する
-	searchEndDate := endDate.AddDate(0, 0, 1)
-
-	// 指定日範囲の表示
-	fmt.Printf("検索期間: %s から %s\n", startDate.Format("2006/01/02"), endDate.Format("2006/01/02"))
-
-	// カレンダーイベントの取得（calendarIDを使用）
-	events, err := srv.Events.List(*calendarID).
-		TimeMin(startDate.Format(time.RFC3339)).
-		TimeMax(searchEndDate.Format(time.RFC3339)).
-		SingleEvents(true).
-		OrderBy("startTime").
-		Do()
-	if err != nil {
-		log.Fatalf("イベントの取得に失敗しました: %v", err)
+		printReport(os.Stdout, &view, flags.outputFormat)
 	}
 
-	// イベントの集計
-	var totalDuration time.Duration
-	var matchedEvents []*calendar.Event
-
-	for _, item := range events.Items {
-		// 終日イベントはスキップ
-		if item.Start.DateTime == "" {
-			continue
+	// 一致したイベントをICSファイルに書き出す
+	if flags.exportICSPath != "" {
+		if err := exportICS(flags.exportICSPath, report); err != nil {
+			fatal("ICSファイルの書き出しに失敗しました", "error", err, "path", flags.exportICSPath)
 		}
+		slog.Info("一致したイベントをICSファイルに書き出しました", "path", flags.exportICSPath, "count", len(report.Events))
+	}
 
-		// イベント名の大文字小文字を区別せずに比較
-		if strings.EqualFold(item.Summary, *eventName) {
-			startTime, err := time.Parse(time.RFC3339, item.Start.DateTime)
-			if err != nil {
-				log.Printf("開始時間の解析に失敗しました: %v", err)
-				continue
-			}
-
-			endTime, err := time.Parse(time.RFC3339, item.End.DateTime)
-			if err != nil {
-				log.Printf("終了時間の解析に失敗しました: %v", err)
-				continue
-			}
-
-			duration := endTime.Sub(startTime)
-			totalDuration += duration
-			matchedEvents = append(matchedEvents, item)
+	// 合計時間の根拠となったイベントを監査記録に書き出す
+	if flags.auditPath != "" {
+		if err := writeAuditRecord(flags.auditPath, newAuditRecord(report, opts, fetchedAt, os.Args[1:])); err != nil {
+			fatal("監査記録の書き出しに失敗しました", "error", err, "path", flags.auditPath)
 		}
+		slog.Info("監査記録を書き出しました", "path", flags.auditPath, "count", len(report.Events))
 	}
 
-	// 結果の表示
-	fmt.Printf("イベント '%s' の合計時間: %d時間 %d分\n\n", *eventName, int(totalDuration.Hours()), int(totalDuration.Minutes())%60)
-
-	if len(matchedEvents) == 0 {
-		fmt.Println("一致するイベントが見つかりませんでした。")
-		return
+	// Slackへの通知（テンプレートが指定された場合はテンプレートの出力を投稿する）
+	if flags.notifySlackURL != "" {
+		text := slackSummary(report)
+		if tmpl != nil {
+			var b strings.Builder
+			printTemplate(&b, tmpl, report)
+			text = b.String()
+		}
+		if err := notifySlack(ctx, flags.notifySlackURL, text); err != nil {
+			fatal("Slackへの通知に失敗しました", "error", contextError(ctx, err))
+		}
+		slog.Info("Slackに集計結果を投稿しました")
 	}
 
-	fmt.Println("一致したイベント一覧:")
-	for i, event := range matchedEvents {
-		startTime, _ := time.Parse(time.RFC3339, event.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, event.End.DateTime)
-		duration := endTime.Sub(startTime)
+	// 集計結果の配信（いずれかが失敗した場合も、残りの配信先には送信してから終了する）
+	var deliveryFailed bool
+	if flags.deliverWebhookURL != "" {
+		if err := deliverWebhook(ctx, flags.deliverWebhookURL, report); err != nil {
+			slog.Error("Webhookへの配信に失敗しました", "error", contextError(ctx, err))
+			deliveryFailed = true
+		} else {
+			slog.Info("Webhookに集計結果を配信しました", "url", flags.deliverWebhookURL)
+		}
+	}
+	if to := splitList(flags.deliverEmailTo); len(to) > 0 {
+		if err := deliverEmail(ctx, cfg.Delivery.Email, to, report); err != nil {
+			slog.Error("メールの送信に失敗しました", "error", contextError(ctx, err))
+			deliveryFailed = true
+		} else {
+			slog.Info("集計結果をメールで送信しました", "to", strings.Join(to, ", "))
+		}
+	}
+	if deliveryFailed {
+		exit(exitError)
+	}
 
-		// 日本時間に変換して表示
-		startTimeJST := startTime.In(jst)
-		endTimeJST := endTime.In(jst)
+	if flags.showChart {
+		groups, groupBy := report.Groups, report.GroupBy
+		if len(groups) == 0 {
+			groupBy = "day"
+			groups = groupEvents(report.Events, groupBy, report.Location)
+		}
+		fmt.Println()
+		printChart(os.Stdout, groups, groupByLabel(groupBy), terminalWidth())
+	}
 
-		fmt.Printf("%d. %s (%s～%s) [%d時間%d分]\n",
-			i+1,
-			event.Summary,
-			startTimeJST.Format("2006/01/02 15:04"),
-			endTimeJST.Format("2006/01/02 15:04"),
-			int(duration.Hours()),
-			int(duration.Minutes())%60)
+	// 一致するイベントがない場合は、スクリプトから判定できるように専用の終了コードで終了する
+	if report.count() == 0 {
+		exit(exitNoMatch)
 	}
 }
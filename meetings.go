@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// 会議とみなす参加者の人数（自分を含む）のデフォルト値
+const defaultMeetingAttendees = 2
+
+// 会議のない時間帯を調べる1日の時間帯のデフォルト値（-work-hours が指定されていない場合）
+const defaultMeetingDayHours = "09:00-18:00"
+
+// meetingDay は1日分の会議の時間と、会議のない最長の時間帯を表す
+type meetingDay struct {
+	Date        time.Time
+	Meeting     time.Duration
+	LongestFree busyPeriod
+}
+
+// meetingDays は勤務日ごとに会議の時間と、時間帯内で会議が入っていない最長の時間帯を求める
+func meetingDays(r *Report, schedule *workSchedule) []meetingDay {
+	var periods []busyPeriod
+	for _, e := range r.Events {
+		periods = append(periods, busyPeriod{Start: e.Start, End: e.End})
+	}
+	merged := mergeBusyPeriods(periods)
+
+	var days []meetingDay
+	for d := r.StartDate; !d.After(r.EndDate); d = d.AddDate(0, 0, 1) {
+		if !schedule.isWorkday(d) {
+			continue
+		}
+		windowStart, windowEnd := schedule.window(d)
+
+		day := meetingDay{Date: d}
+		free := time.Duration(0)
+		for _, b := range freeBlocks(merged, windowStart, windowEnd) {
+			free += b.End.Sub(b.Start)
+			if b.End.Sub(b.Start) > day.LongestFree.End.Sub(day.LongestFree.Start) {
+				day.LongestFree = b
+			}
+		}
+		day.Meeting = windowEnd.Sub(windowStart) - free
+		days = append(days, day)
+	}
+	return days
+}
+
+// printMeetings は会議の負荷のレポートを出力する
+func printMeetings(w io.Writer, r *Report, days []meetingDay) {
+	printer.Fprintf(w, "検索期間: %s から %s\n", r.StartDate.Format("2006/01/02"), r.EndDate.Format("2006/01/02"))
+	printer.Fprintf(w, "会議（%s）の合計時間: %s (%d件)\n", r.Name, formatDuration(r.Total), len(r.Events))
+	if r.Utilization != nil {
+		printer.Fprintf(w, "稼働時間に占める割合: %s\n", utilizationSummary(r.Utilization))
+	}
+	fmt.Fprintln(w)
+
+	printer.Fprintf(w, "日別の会議時間と会議のない最長の時間帯:\n")
+	for _, d := range days {
+		free := d.LongestFree.End.Sub(d.LongestFree.Start)
+		printer.Fprintf(w, "- %s (%s): 会議 %s / 最長の空き %s",
+			d.Date.Format("2006/01/02"), weekdayLabel(weekdayIndex(d.Date)), formatDuration(d.Meeting), formatDuration(free))
+		if free > 0 {
+			fmt.Fprintf(w, " (%s～%s)", d.LongestFree.Start.In(r.Location).Format("15:04"), d.LongestFree.End.In(r.Location).Format("15:04"))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// registerMeetingsFlags は meetings のフラグを登録する（補完の候補にも使用する）
+func registerMeetingsFlags(fs *flag.FlagSet, cfg *Config) *queryOptions {
+	opts := &queryOptions{}
+	opts.register(fs, cfg)
+	fs.Set("min-attendees", fmt.Sprint(defaultMeetingAttendees))
+	return opts
+}
+
+// runMeetingsCommand はイベント名に関係なく、参加者が複数いる会議の負荷を集計するサブコマンドを実行する
+func runMeetingsCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("meetings", flag.ExitOnError)
+	opts := registerMeetingsFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts.utilization = true
+	opts.validate()
+
+	// 会議のない時間帯は勤務時間帯（未指定の場合は平日の9時～18時）の中で調べる
+	schedule := opts.schedule
+	if schedule == nil || opts.workHours == "" {
+		workdays := opts.workdays
+		if workdays == "" {
+			workdays = "mon-fri"
+		}
+		var err error
+		if schedule, err = newWorkSchedule(defaultMeetingDayHours, workdays); err != nil {
+			printer.Printf("エラー: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	report := runQuery(ctx, src, cfg, opts)
+	schedule.holidays = report.Holidays
+	printMeetings(os.Stdout, report, meetingDays(report, schedule))
+}
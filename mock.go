@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// parseMockFixture はフィクスチャのJSONを読み込む
+// Calendar APIの Events.list の応答（{"items": [...]}）と、イベントの配列のどちらの形式にも対応する
+func parseMockFixture(b []byte) ([]*calendar.Event, error) {
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		var items []*calendar.Event
+		err := json.Unmarshal(b, &items)
+		return items, err
+	}
+	var events calendar.Events
+	err := json.Unmarshal(b, &events)
+	return events.Items, err
+}
+
+// mockEventRange はフィクスチャのイベントの開始日時と終了日時を返す
+func mockEventRange(e *calendar.Event, location *time.Location) (start, end time.Time, err error) {
+	if e.Start == nil || e.End == nil {
+		return start, end, errors.New(printer.Sprintf("イベント '%s' に start または end がありません", e.Summary))
+	}
+	if start, end, _, err = resolveEventTimes(e, location); err != nil {
+		return start, end, errors.New(printer.Sprintf("イベント '%s': %v", e.Summary, err))
+	}
+	return start, end, nil
+}
+
+// loadMockEvents はフィクスチャのJSONファイルを読み込み、期間内のイベントを開始日時順に返す
+// Calendar APIと同じく、キャンセルされたイベントは includeCancelled が true の場合だけ返す
+func loadMockEvents(path string, location *time.Location, rangeStart, rangeEnd time.Time, includeCancelled bool) ([]*calendar.Event, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseMockFixture(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*calendar.Event
+	for i, e := range parsed {
+		if e.Status == "cancelled" && !includeCancelled {
+			continue
+		}
+		start, end, err := mockEventRange(e, location)
+		if err != nil {
+			return nil, err
+		}
+		// 期間と重なるイベントのみを対象にする
+		if !start.Before(rangeEnd) || !end.After(rangeStart) {
+			continue
+		}
+		if e.Id == "" {
+			e.Id = fmt.Sprintf("mock%d", i+1)
+		}
+		items = append(items, e)
+	}
+
+	sortEventsByStart(items)
+	return items, nil
+}
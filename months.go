@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// 1回の -months で集計できる月数の上限
+const maxMonths = 120
+
+// parseMonthRange は「YYYY-MM..YYYY-MM」形式（または1か月だけの「YYYY-MM」）の月の範囲を解析し、各月の初日を返す
+func parseMonthRange(spec string, location *time.Location) ([]time.Time, error) {
+	from, to, ok := strings.Cut(spec, "..")
+	if !ok {
+		to = from
+	}
+	start, err := time.ParseInLocation("2006-01", strings.TrimSpace(from), location)
+	if err != nil {
+		return nil, errors.New(printer.Sprintf("月の範囲 '%s' を解析できません（YYYY-MM..YYYY-MM形式、例: 2024-01..2024-06）", spec))
+	}
+	end, err := time.ParseInLocation("2006-01", strings.TrimSpace(to), location)
+	if err != nil {
+		return nil, errors.New(printer.Sprintf("月の範囲 '%s' を解析できません（YYYY-MM..YYYY-MM形式、例: 2024-01..2024-06）", spec))
+	}
+	if end.Before(start) {
+		return nil, errors.New(printer.Sprintf("月の範囲 '%s' の終了月が開始月より前になっています", spec))
+	}
+	var months []time.Time
+	for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+		if len(months) == maxMonths {
+			return nil, errors.New(printer.Sprintf("-months で集計できるのは%dか月までです", maxMonths))
+		}
+		months = append(months, m)
+	}
+	return months, nil
+}
+
+// runMonthlyQueries は -months の各月について集計を実行し、月の順に集計結果を返す
+// イベントと祝日は期間全体をまとめて1回で取得し、月ごとに振り分けて集計する
+// 月ごとの比較表に前月との差分を表示するため、-compare-to や -vs-previous による比較は行わない
+func runMonthlyQueries(ctx context.Context, src CalendarSource, cfg *Config, o *queryOptions) []*Report {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	months, err := parseMonthRange(o.months, location)
+	if err != nil {
+		fatalCode(exitUsage, "月の範囲の解析に失敗しました", "error", err)
+	}
+	searchEndDate := months[len(months)-1].AddDate(0, 1, 0)
+	holidays := o.loadHolidays(ctx, src, months[0], searchEndDate)
+	reports := o.collectMonthlyReports(ctx, src, cfg, location, months)
+	for i, m := range months {
+		mo := *o
+		mo.months = ""
+		mo.month = m.Format("2006-01")
+		mo.compareTo, mo.vsPrevious = "", false
+		reports[i].Holidays = holidays
+		reports[i] = mo.completeReport(ctx, src, cfg, reports[i])
+	}
+	return reports
+}
+
+// collectMonthlyReports は期間全体のイベントをまとめて取得し、重なる月ごとに振り分けて集計する
+// 月の境界をまたぐイベントは、月ごとに取得した場合と同じように両方の月で集計する
+func (o *queryOptions) collectMonthlyReports(ctx context.Context, src CalendarSource, cfg *Config, location *time.Location, months []time.Time) []*Report {
+	searchEndDate := months[len(months)-1].AddDate(0, 1, 0)
+	reports := make([]*Report, len(months))
+	if o.canStream() {
+		builders := make([]*reportBuilder, len(months))
+		for i, m := range months {
+			builders[i] = newReportBuilder(o, m, m.AddDate(0, 1, -1), location, false)
+		}
+		o.streamEvents(ctx, src, months[0], searchEndDate, func(item *calendar.Event) {
+			for i, m := range months {
+				if overlapsRange(item, o.zones.location(item, location), m, m.AddDate(0, 1, 0)) {
+					builders[i].add(item)
+				}
+			}
+		})
+		for i, b := range builders {
+			reports[i] = b.finish()
+		}
+		return reports
+	}
+
+	items := o.fetchEvents(ctx, src, cfg, location, months[0], searchEndDate)
+	for i, m := range months {
+		var inMonth []*calendar.Event
+		for _, item := range items {
+			if overlapsRange(item, o.zones.location(item, location), m, m.AddDate(0, 1, 0)) {
+				inMonth = append(inMonth, item)
+			}
+		}
+		reports[i] = buildReport(inMonth, o, m, m.AddDate(0, 1, -1), location)
+	}
+	return reports
+}
+
+// overlapsRange はイベントが start～end（end は含まない）の期間と重なるかどうかを判定する（終日イベントは location の日付として扱う）
+func overlapsRange(item *calendar.Event, location *time.Location, start, end time.Time) bool {
+	itemStart, itemEnd, ok := eventTimes(item, location)
+	if !ok {
+		return false
+	}
+	return itemStart.Before(end) && itemEnd.After(start)
+}
+
+// monthlyGroupKeys は全期間のグループのキーを、合計時間の多い順に返す
+// 日・週・月ごとのグループ化は月ごとの比較表と重なるため、キーを返さない
+func monthlyGroupKeys(reports []*Report) []string {
+	if len(reports) == 0 || reports[0].GroupBy == "" || reports[0].GroupBy == "day" || reports[0].GroupBy == "week" || reports[0].GroupBy == "month" {
+		return nil
+	}
+	var all []MatchedEvent
+	streamed := false
+	for _, r := range reports {
+		all = append(all, r.Events...)
+		streamed = streamed || r.Streamed > 0
+	}
+	var keys []string
+	if streamed {
+		// 逐次集計した月はイベント一覧を保持しないため、月ごとのグループの合計時間を足し合わせる
+		totals := make(map[string]time.Duration)
+		for _, r := range reports {
+			for _, g := range r.Groups {
+				if _, ok := totals[g.Key]; !ok {
+					keys = append(keys, g.Key)
+				}
+				totals[g.Key] += g.Duration
+			}
+		}
+		sort.SliceStable(keys, func(i, j int) bool { return totals[keys[i]] > totals[keys[j]] })
+		return keys
+	}
+	for _, g := range groupEvents(all, reports[0].GroupBy, reports[0].Location) {
+		keys = append(keys, g.Key)
+	}
+	return keys
+}
+
+// groupDuration はグループのキーに対応する合計時間を返す（グループがない場合は0）
+func groupDuration(r *Report, key string) time.Duration {
+	for _, g := range r.Groups {
+		if g.Key == key {
+			return g.Duration
+		}
+	}
+	return 0
+}
+
+// monthlyTotals は全期間の合計時間、件数、月あたりの平均時間を返す
+func monthlyTotals(reports []*Report) (time.Duration, int, time.Duration) {
+	var total time.Duration
+	count := 0
+	for _, r := range reports {
+		total += r.Total
+		count += r.count()
+	}
+	if len(reports) == 0 {
+		return 0, 0, 0
+	}
+	return total, count, total / time.Duration(len(reports))
+}
+
+// printMonthlyComparison は月ごとの合計時間の比較表を出力形式に従って出力する
+func printMonthlyComparison(w io.Writer, reports []*Report, format string) {
+	if format == "markdown" {
+		printMonthlyMarkdown(w, reports)
+		return
+	}
+	printMonthlyText(w, reports)
+}
+
+// printMonthlyText は月ごとの合計時間の比較表をテキスト形式で出力する
+func printMonthlyText(w io.Writer, reports []*Report) {
+	if len(reports) == 0 {
+		return
+	}
+	first, last := reports[0], reports[len(reports)-1]
+	printer.Fprintf(w, "検索期間: %s から %s\n", first.StartDate.Format("2006/01/02"), last.EndDate.Format("2006/01/02"))
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("イベント '%s' の月別の合計時間:\n", first.Name)))
+
+	// 列を揃えて表示するため、先に各行のセルを作ってから列の幅を求める
+	total, count, average := monthlyTotals(reports)
+	var maxTotal time.Duration
+	for _, r := range reports {
+		maxTotal = max(maxTotal, r.Total)
+	}
+	rows := [][]string{{printer.Sprintf("月"), printer.Sprintf("合計時間"), printer.Sprintf("件数"), printer.Sprintf("前月比")}}
+	for i, r := range reports {
+		diff := ""
+		if i > 0 {
+			diff = formatSignedDuration(r.Total - reports[i-1].Total)
+		}
+		rows = append(rows, []string{r.StartDate.Format("2006/01"), formatDuration(r.Total), printer.Sprintf("%d件", r.count()), diff})
+	}
+	rows = append(rows,
+		[]string{printer.Sprintf("合計"), formatDuration(total), printer.Sprintf("%d件", count), ""},
+		[]string{printer.Sprintf("月平均"), formatDuration(average), "", ""})
+	widths := columnWidths(rows)
+	for i, row := range rows {
+		line := formatRow(row, widths)
+		switch {
+		case i == 0:
+			line = colorize(w, colorBold, strings.TrimRight(line, " "))
+		case i <= len(reports):
+			// 最も多い月を基準にした棒で推移を表示する
+			share := 0.0
+			if maxTotal > 0 {
+				share = float64(reports[i-1].Total) / float64(maxTotal) * 100
+			}
+			if bar := shareBar(share); bar != "" {
+				line += "  " + colorize(w, colorCyan, bar)
+			} else {
+				line = strings.TrimRight(line, " ")
+			}
+		default:
+			line = strings.TrimRight(line, " ")
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+
+	keys := monthlyGroupKeys(reports)
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("%s別の月別の合計時間:\n", groupByLabel(first.GroupBy))))
+	header := []string{groupByLabel(first.GroupBy)}
+	for _, r := range reports {
+		header = append(header, r.StartDate.Format("2006/01"))
+	}
+	rows = [][]string{append(header, printer.Sprintf("合計"))}
+	for _, key := range keys {
+		row := []string{key}
+		var sum time.Duration
+		for _, r := range reports {
+			d := groupDuration(r, key)
+			sum += d
+			row = append(row, formatDuration(d))
+		}
+		rows = append(rows, append(row, formatDuration(sum)))
+	}
+	widths = columnWidths(rows)
+	for i, row := range rows {
+		line := strings.TrimRight(formatRow(row, widths), " ")
+		if i == 0 {
+			line = colorize(w, colorBold, line)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+// columnWidths は表の列ごとの最大の表示幅を返す
+func columnWidths(rows [][]string) []int {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	return widths
+}
+
+// formatRow は表の1行を、列の幅に揃えて2文字の空白で区切る
+func formatRow(row []string, widths []int) string {
+	cells := make([]string, len(row))
+	for i, cell := range row {
+		cells[i] = padRight(cell, widths[i])
+	}
+	return strings.Join(cells, "  ")
+}
+
+// printMonthlyMarkdown は月ごとの合計時間の比較表をMarkdown形式で出力する
+func printMonthlyMarkdown(w io.Writer, reports []*Report) {
+	if len(reports) == 0 {
+		return
+	}
+	first, last := reports[0], reports[len(reports)-1]
+	printer.Fprintf(w, "## イベント '%s' の月別の集計\n\n", escapeMarkdown(first.Name))
+	printer.Fprintf(w, "- 検索期間: %s から %s\n\n", first.StartDate.Format("2006/01/02"), last.EndDate.Format("2006/01/02"))
+
+	total, count, average := monthlyTotals(reports)
+	printer.Fprintf(w, "| 月 | 合計時間 | 件数 | 前月比 |\n")
+	printer.Fprintf(w, "|---|---:|---:|---:|\n")
+	for i, r := range reports {
+		diff := ""
+		if i > 0 {
+			diff = formatSignedDuration(r.Total - reports[i-1].Total)
+		}
+		fmt.Fprintf(w, "| %s | %s | %d | %s |\n", r.StartDate.Format("2006/01"), formatDuration(r.Total), r.count(), diff)
+	}
+	printer.Fprintf(w, "| **合計** | **%s** | **%d** | |\n", formatDuration(total), count)
+	printer.Fprintf(w, "| 月平均 | %s | | |\n\n", formatDuration(average))
+
+	keys := monthlyGroupKeys(reports)
+	if len(keys) == 0 {
+		return
+	}
+	printer.Fprintf(w, "### %s別の月別の合計時間\n\n", groupByLabel(first.GroupBy))
+	fmt.Fprintf(w, "| %s |", groupByLabel(first.GroupBy))
+	for _, r := range reports {
+		fmt.Fprintf(w, " %s |", r.StartDate.Format("2006/01"))
+	}
+	printer.Fprintf(w, " 合計 |\n")
+	fmt.Fprint(w, "|---|"+strings.Repeat("---:|", len(reports)+1)+"\n")
+	for _, key := range keys {
+		fmt.Fprintf(w, "| %s |", escapeMarkdown(key))
+		var sum time.Duration
+		for _, r := range reports {
+			d := groupDuration(r, key)
+			sum += d
+			fmt.Fprintf(w, " %s |", formatDuration(d))
+		}
+		fmt.Fprintf(w, " %s |\n", formatDuration(sum))
+	}
+	fmt.Fprintln(w)
+}
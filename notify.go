@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// httpStatusError は通知先のサーバーがエラーのステータスを返したことを表す
+type httpStatusError struct {
+	Code int
+	Body string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.Code, e.Body)
+}
+
+// postJSON は値をJSON形式で送信する。レート制限やサーバーエラーの場合は再試行する
+func postJSON(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return &httpStatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+		}
+		return nil
+	})
+}
+
+// slackSummary は集計結果をSlackのメッセージ（mrkdwn形式）に変換する
+func slackSummary(r *Report) string {
+	var b strings.Builder
+	b.WriteString(printer.Sprintf("*イベント「%s」の集計*（%s から %s）\n", r.Name, r.StartDate.Format("2006/01/02"), r.EndDate.Format("2006/01/02")))
+	b.WriteString(printer.Sprintf("合計時間: *%s*（%d件）\n", formatDuration(r.Total), r.count()))
+	if r.Target != nil {
+		b.WriteString(printer.Sprintf("目標: %s\n", targetSummary(r.Target)))
+	}
+	if r.Utilization != nil {
+		b.WriteString(printer.Sprintf("稼働率: %s\n", utilizationSummary(r.Utilization)))
+	}
+	if r.Comparison != nil {
+		b.WriteString(printer.Sprintf("比較期間 %s から %s の合計時間: %s\n",
+			r.Comparison.StartDate.Format("2006/01/02"), r.Comparison.EndDate.Format("2006/01/02"), comparisonSummary(r)))
+	}
+	if len(r.Groups) > 0 {
+		b.WriteString(printer.Sprintf("%s別の合計時間:\n", groupByLabel(r.GroupBy)))
+		for _, g := range r.Groups {
+			b.WriteString(printer.Sprintf("• %s: %s (%d件)\n", g.Key, formatDuration(g.Duration), g.Count))
+		}
+	}
+	return b.String()
+}
+
+// notifySlack はSlackのIncoming Webhookにメッセージを投稿する
+func notifySlack(ctx context.Context, webhookURL, text string) error {
+	return postJSON(ctx, webhookURL, map[string]string{"text": text})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// 配布するパッケージに組み込むOAuthクライアント（ビルド時に -ldflags "-X main.defaultClientID=... -X main.defaultClientSecret=..." で設定する）
+// credentials.json もクライアントIDの指定もない場合に使用するため、利用者がGoogle Cloudのプロジェクトを作成しなくても認証できる
+var (
+	defaultClientID     string
+	defaultClientSecret string
+)
+
+// oauthClientOptions は credentials.json の代わりに使用するOAuthクライアントのIDとシークレット
+type oauthClientOptions struct {
+	id     string
+	secret string
+}
+
+// oauthClient はフラグ、環境変数、設定ファイルで指定されたOAuthクライアント（優先順位: フラグ > 環境変数 > 設定ファイル）
+var oauthClient oauthClientOptions
+
+// register はOAuthクライアントを指定するフラグを登録する
+// シークレットがヘルプに表示されないように、設定ファイルの値はデフォルト値にせず、指定された場合だけ上書きする
+func (o *oauthClientOptions) register(fs *flag.FlagSet) {
+	fs.Func("client-id", "credentials.json の代わりに使用するOAuthクライアントID（環境変数 GCAL_SUM_CLIENT_ID でも指定可能）", func(s string) error {
+		o.id = s
+		return nil
+	})
+	fs.Func("client-secret", "credentials.json の代わりに使用するOAuthクライアントのシークレット（環境変数 GCAL_SUM_CLIENT_SECRET でも指定可能）", func(s string) error {
+		o.secret = s
+		return nil
+	})
+}
+
+// setOAuthClient はフラグで指定されていない項目に、設定ファイル（環境変数を反映した値）のOAuthクライアントを設定する
+func setOAuthClient(cfg *Config) {
+	if oauthClient.id == "" {
+		oauthClient.id = cfg.ClientID
+	}
+	if oauthClient.secret == "" {
+		oauthClient.secret = cfg.ClientSecret
+	}
+}
+
+// oauthClientSource はOAuthクライアントの設定をどこから読み込むかを返す（credentials: credentials.json, option: フラグなど, embedded: 組み込み）
+func oauthClientSource(credentialsPath string) string {
+	if oauthClient.id != "" {
+		return "option"
+	}
+	if _, err := os.Stat(credentialsPath); errors.Is(err, os.ErrNotExist) && defaultClientID != "" {
+		return "embedded"
+	}
+	return "credentials"
+}
+
+// oauthConfig は指定されたスコープで認証するOAuth2の設定を返す
+// クライアントIDが指定された場合はそれを使用し、ない場合は credentials.json、それもない場合は組み込みのクライアントを使用する
+func oauthConfig(credentialsPath string, scopes ...string) (*oauth2.Config, error) {
+	switch oauthClientSource(credentialsPath) {
+	case "option":
+		return &oauth2.Config{ClientID: oauthClient.id, ClientSecret: oauthClient.secret, Endpoint: google.Endpoint, Scopes: scopes}, nil
+	case "embedded":
+		return &oauth2.Config{ClientID: defaultClientID, ClientSecret: defaultClientSecret, Endpoint: google.Endpoint, Scopes: scopes}, nil
+	}
+	b, err := os.ReadFile(credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+	return google.ConfigFromJSON(b, scopes...)
+}
+
+// mustOAuthConfig はOAuth2の設定を返す。読み込みに失敗した場合は認証のエラーで終了する
+func mustOAuthConfig(credentialsPath string, scopes ...string) *oauth2.Config {
+	config, err := oauthConfig(credentialsPath, scopes...)
+	var pathErr *fs.PathError
+	switch {
+	case errors.As(err, &pathErr):
+		fatalCode(exitAuth, "credentials.jsonの読み込みに失敗しました", "error", err, "path", credentialsPath)
+	case err != nil:
+		fatalCode(exitAuth, "OAuth2の設定に失敗しました", "error", err)
+	}
+	return config
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// 外れ値の扱い（flag: 表示だけ行う, exclude: 集計から除外する, off: 検出しない）
+var outlierModes = []string{"flag", "exclude", "off"}
+
+// 同名のイベントがこの件数以上ある場合に、その中での外れ値を検出する
+const minOutlierSeries = 5
+
+// 修正Zスコア（中央値と中央絶対偏差によるスコア）がこの値を超えるイベントを外れ値とする
+const outlierScore = 3.5
+
+// 中央値との差がこの時間未満のイベントは、外れ値とみなさない（30分の会議が45分になった場合など）
+const minOutlierExcess = 30 * time.Minute
+
+// 同名のイベントの件数にかかわらず、この時間以上続くイベントは終了し忘れの可能性があるとみなす
+const suspiciousDuration = 12 * time.Hour
+
+// Outlier は長さが外れ値となっているイベントを表す
+type Outlier struct {
+	Event MatchedEvent
+
+	// 同名のイベントの件数と長さの中央値（長さだけで検出した場合は0）
+	Series int
+	Median time.Duration
+}
+
+// isValidOutlierMode は外れ値の扱いがサポートされているかを判定する
+func isValidOutlierMode(mode string) bool {
+	for _, m := range outlierModes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
+// medianDuration は時間の中央値を返す（values は並べ替える）
+func medianDuration(values []time.Duration) time.Duration {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// findOutliers は同名のイベントの中で長さが極端に長いイベントと、終了し忘れの可能性がある長すぎるイベントを検出する
+// 期間の境界での切り詰めや控除の影響を受けないよう、イベント自体の長さで判定する
+func findOutliers(events []MatchedEvent) []Outlier {
+	series := make(map[string][]time.Duration)
+	for _, e := range events {
+		key := strings.ToLower(strings.TrimSpace(e.Event.Summary))
+		series[key] = append(series[key], e.End.Sub(e.Start))
+	}
+	type seriesStats struct {
+		count       int
+		median, mad time.Duration
+	}
+	stats := make(map[string]seriesStats)
+	for key, lengths := range series {
+		if len(lengths) < minOutlierSeries {
+			continue
+		}
+		median := medianDuration(lengths)
+		deviations := make([]time.Duration, len(lengths))
+		for i, d := range lengths {
+			deviations[i] = (d - median).Abs()
+		}
+		stats[key] = seriesStats{count: len(lengths), median: median, mad: medianDuration(deviations)}
+	}
+
+	var outliers []Outlier
+	for _, e := range events {
+		length := e.End.Sub(e.Start)
+		if s, ok := stats[strings.ToLower(strings.TrimSpace(e.Event.Summary))]; ok && length-s.median >= minOutlierExcess {
+			// ほとんどの回が同じ長さ（中央絶対偏差が0）の場合は、中央値の2倍以上を外れ値とする
+			isOutlier := length >= 2*s.median
+			if s.mad > 0 {
+				isOutlier = 0.6745*float64(length-s.median)/float64(s.mad) > outlierScore
+			}
+			if isOutlier {
+				outliers = append(outliers, Outlier{Event: e, Series: s.count, Median: s.median})
+				continue
+			}
+		}
+		if length >= suspiciousDuration {
+			outliers = append(outliers, Outlier{Event: e})
+		}
+	}
+	return outliers
+}
+
+// withoutOutliers は外れ値のイベントを除いたイベントを返す
+func withoutOutliers(events []MatchedEvent, outliers []Outlier) []MatchedEvent {
+	excluded := make(map[*calendar.Event]bool)
+	for _, o := range outliers {
+		excluded[o.Event.Event] = true
+	}
+	var kept []MatchedEvent
+	for _, e := range events {
+		if !excluded[e.Event] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// isOutlier はイベントが外れ値として検出されたかどうかを判定する
+func (r *Report) isOutlier(e MatchedEvent) bool {
+	for _, o := range r.Outliers {
+		if o.Event.Event == e.Event {
+			return true
+		}
+	}
+	return false
+}
+
+// outlierReason は外れ値と判定した理由を表示用の文字列に変換する
+func outlierReason(o Outlier) string {
+	if o.Series == 0 {
+		return printer.Sprintf("%s以上", formatDuration(suspiciousDuration))
+	}
+	return printer.Sprintf("同名のイベント%d件の中央値 %s", o.Series, formatDuration(o.Median))
+}
+
+// printOutliers は外れ値のイベントをテキスト形式で出力する
+func printOutliers(w io.Writer, r *Report) {
+	if len(r.Outliers) == 0 {
+		return
+	}
+	heading := printer.Sprintf("長さが外れ値のイベント (%d件):\n", len(r.Outliers))
+	if r.OutliersExcluded {
+		heading = printer.Sprintf("長さが外れ値のため集計から除外したイベント (%d件):\n", len(r.Outliers))
+	}
+	fmt.Fprint(w, colorize(w, colorYellow, heading))
+	for _, o := range r.Outliers {
+		printer.Fprintf(w, "- %s (%s～%s) [%s]: %s\n",
+			o.Event.Event.Summary,
+			o.Event.Start.In(r.Location).Format("2006/01/02 15:04"),
+			o.Event.End.In(r.Location).Format("2006/01/02 15:04"),
+			formatDuration(o.Event.End.Sub(o.Event.Start)),
+			outlierReason(o))
+	}
+	fmt.Fprintln(w)
+}
+
+// printOutliersMarkdown は外れ値のイベントをMarkdown形式の表で出力する
+func printOutliersMarkdown(w io.Writer, r *Report) {
+	if len(r.Outliers) == 0 {
+		return
+	}
+	if r.OutliersExcluded {
+		printer.Fprintf(w, "### 長さが外れ値のため集計から除外したイベント\n\n")
+	} else {
+		printer.Fprintf(w, "### 長さが外れ値のイベント\n\n")
+	}
+	printer.Fprintf(w, "| イベント | 開始 | 終了 | 長さ | 理由 |\n")
+	printer.Fprintf(w, "|---|---|---|---:|---|\n")
+	for _, o := range r.Outliers {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			escapeMarkdown(o.Event.Event.Summary),
+			o.Event.Start.In(r.Location).Format("2006/01/02 15:04"),
+			o.Event.End.In(r.Location).Format("2006/01/02 15:04"),
+			formatDuration(o.Event.End.Sub(o.Event.Start)),
+			escapeMarkdown(outlierReason(o)))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 利用可能な出力形式
+var outputFormats = []string{"text", "markdown"}
+
+// isValidOutputFormat は出力形式が有効かどうかを判定する
+func isValidOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// 一致したイベント一覧の並べ替えに使用できるキー
+var eventSortKeys = []string{"start", "duration", "name"}
+
+// isValidEventSortKey は並べ替えのキーが有効かどうかを判定する
+func isValidEventSortKey(key string) bool {
+	for _, k := range eventSortKeys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedEvents はイベントを指定のキー（start: 開始日時, duration: 時間, name: イベント名）で並べ替えたコピーを返す
+// 時間やイベント名が同じイベントは開始日時の順に並べる
+func sortedEvents(events []MatchedEvent, key string, desc bool) []MatchedEvent {
+	sorted := append([]MatchedEvent(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	compare := func(a, b MatchedEvent) int {
+		switch key {
+		case "duration":
+			return cmp.Compare(a.Duration, b.Duration)
+		case "name":
+			return strings.Compare(a.Event.Summary, b.Event.Summary)
+		}
+		return a.Start.Compare(b.Start)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if desc {
+			return compare(sorted[i], sorted[j]) > 0
+		}
+		return compare(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
+
+// 利用可能な時間の表示形式
+var durationFormats = []string{"hm", "decimal", "iso8601", "minutes"}
+
+// durationFormat は時間の表示形式（-duration-format で変更する）
+var durationFormat = "hm"
+
+// setDurationFormat は時間の表示形式を設定する
+func setDurationFormat(format string) error {
+	for _, f := range durationFormats {
+		if format == f {
+			durationFormat = format
+			return nil
+		}
+	}
+	return errors.New(printer.Sprintf("時間の表示形式 '%s' はサポートされていません（%s）", format, strings.Join(durationFormats, ", ")))
+}
+
+// formatDuration は時間を表示形式に従った文字列に変換する
+// デフォルトでは「X時間Y分」形式、decimal は「7.75」、iso8601 は「PT7H45M」、minutes は分数で表す
+func formatDuration(d time.Duration) string {
+	switch durationFormat {
+	case "decimal":
+		return fmt.Sprintf("%.2f", d.Hours())
+	case "iso8601":
+		return formatISO8601Duration(d)
+	case "minutes":
+		return fmt.Sprintf("%d", int(d.Minutes()))
+	}
+	return printer.Sprintf("%d時間%d分", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// formatISO8601Duration は時間をISO 8601形式（PT7H45M）の文字列に変換する
+func formatISO8601Duration(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatISO8601Duration(-d)
+	}
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("PT%dM", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("PT%dH", hours)
+	}
+	return fmt.Sprintf("PT%dH%dM", hours, minutes)
+}
+
+// groupByLabel はグループ化の単位の表示名を返す
+func groupByLabel(groupBy string) string {
+	switch groupBy {
+	case "day":
+		return printer.Sprintf("日付")
+	case "week":
+		return printer.Sprintf("週")
+	case "month":
+		return printer.Sprintf("月")
+	case "name":
+		return printer.Sprintf("イベント名")
+	case "tag":
+		return printer.Sprintf("タグ")
+	case "attendee":
+		return printer.Sprintf("参加者")
+	case "organizer":
+		return printer.Sprintf("主催者")
+	case "recurrence":
+		return printer.Sprintf("繰り返しイベント")
+	case "project":
+		return printer.Sprintf("プロジェクト")
+	}
+	return groupBy
+}
+
+// printReport は指定された形式で集計結果を出力する
+func printReport(w io.Writer, r *Report, format string) {
+	switch format {
+	case "markdown":
+		printMarkdown(w, r)
+	default:
+		printText(w, r)
+	}
+}
+
+// printText は集計結果をテキスト形式で出力する
+func printText(w io.Writer, r *Report) {
+	printer.Fprintf(w, "検索期間: %s から %s\n", r.StartDate.Format("2006/01/02"), r.EndDate.Format("2006/01/02"))
+	if durationFormat == "hm" {
+		fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("イベント '%s' の合計時間: %d時間 %d分\n", r.Name, int(r.Total.Hours()), int(r.Total.Minutes())%60)))
+	} else {
+		fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("イベント '%s' の合計時間: %s\n", r.Name, formatDuration(r.Total))))
+	}
+	if r.OverlapDeducted > 0 {
+		printer.Fprintf(w, "（重複している %s を差し引き済み）\n", formatDuration(r.OverlapDeducted))
+	}
+	if r.Deducted > 0 {
+		printer.Fprintf(w, "（控除ルールにより %s を差し引き済み）\n", formatDuration(r.Deducted))
+	}
+	if r.Capped > 0 {
+		printer.Fprintf(w, "（1件あたりの上限を超えた %s を切り詰め済み）\n", formatDuration(r.Capped))
+	}
+	if len(r.CappedDays) > 0 {
+		printer.Fprintf(w, "（1日あたりの上限 %s を超えた %s を切り詰め済み。上限を適用する前の合計: %s）\n",
+			formatDuration(r.DailyCap), formatDuration(r.dailyCapped()), formatDuration(r.Total+r.dailyCapped()))
+		for _, day := range r.CappedDays {
+			printer.Fprintf(w, "  - %s: %s → %s\n", day.Date.Format("2006/01/02"), formatDuration(day.Raw), formatDuration(day.Counted))
+		}
+	}
+	if r.IDExcluded > 0 {
+		printer.Fprintf(w, "（除外リストにより %d件を除外済み）\n", r.IDExcluded)
+	}
+	if len(r.Excluded) > 0 {
+		printer.Fprintf(w, "（確認により %d件 %s を除外済み）\n", len(r.Excluded), formatDuration(excludedDuration(r.Excluded)))
+	}
+	if manual, n := r.manualDuration(); n > 0 {
+		printer.Fprintf(w, "（手動調整 %d件 %s を含む）\n", n, formatSignedDuration(manual))
+	}
+	if r.Target != nil {
+		// 目標を超えた場合は赤、期間終了時点で超える見込みの場合は黄色、ちょうど達成した場合は緑で表示する
+		fmt.Fprint(w, colorize(w, targetColor(r.Target), printer.Sprintf("目標: %s\n", targetSummary(r.Target))))
+	}
+	if r.Utilization != nil {
+		printer.Fprintf(w, "稼働率: %s\n", utilizationSummary(r.Utilization))
+	}
+	if r.Comparison != nil {
+		printer.Fprintf(w, "比較期間 %s から %s の合計時間: %s\n",
+			r.Comparison.StartDate.Format("2006/01/02"),
+			r.Comparison.EndDate.Format("2006/01/02"),
+			comparisonSummary(r))
+	}
+	fmt.Fprintln(w)
+
+	if r.count() == 0 {
+		fmt.Fprint(w, colorize(w, colorYellow, printer.Sprintf("一致するイベントが見つかりませんでした。\n")))
+		return
+	}
+
+	st := r.Stats
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("統計:\n")))
+	printer.Fprintf(w, "- 件数: %d件\n", st.Count)
+	printer.Fprintf(w, "- 平均: %s / 中央値: %s\n", formatDuration(st.Average), formatDuration(st.Median))
+	printer.Fprintf(w, "- 最短: %s / 最長: %s\n", formatDuration(st.Shortest), formatDuration(st.Longest))
+	printer.Fprintf(w, "- 実施日あたりの平均: %s (%d日)\n", formatDuration(st.PerDay), st.ActiveDays)
+	fmt.Fprintln(w)
+
+	printOutliers(w, r)
+	if r.OverlapsChecked {
+		printOverlaps(w, r)
+	}
+
+	if len(r.Groups) > 0 {
+		fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("%s別の合計時間:\n", groupByLabel(r.GroupBy))))
+		// 合計時間に占める割合と棒を揃えて表示するため、先に各行の表示を作って幅を求める
+		lines := make([]string, len(r.Groups))
+		lineWidth := 0
+		for i, g := range r.Groups {
+			// 繰り返しイベントは「Daily Standup ×22」のように回数と合わせて表示する
+			if r.GroupBy == "recurrence" {
+				lines[i] = printer.Sprintf("- %s ×%d: %s", g.Key, g.Count, formatDuration(g.Duration))
+			} else {
+				lines[i] = printer.Sprintf("- %s: %s (%d件)", g.Key, formatDuration(g.Duration), g.Count)
+			}
+			lineWidth = max(lineWidth, displayWidth(lines[i]))
+		}
+		for i, g := range r.Groups {
+			share := r.share(g.Duration)
+			fmt.Fprintf(w, "%s  %5.1f%% %s\n", padRight(lines[i], lineWidth), share, colorize(w, colorCyan, shareBar(share)))
+		}
+		fmt.Fprintln(w)
+	}
+	printPersonHours(w, r)
+	printOvertime(w, r)
+	printContextSwitches(w, r)
+	if r.NoList {
+		return
+	}
+
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("一致したイベント一覧:\n")))
+	listed, rest := r.listedEvents()
+	for i, e := range listed {
+		// 手動調整は日付と符号付きの時間で表示する
+		if e.Manual {
+			printer.Fprintf(w, "%d. [手動] %s (%s) [%s]\n", i+1, e.Event.Summary, e.Start.In(r.Location).Format("2006/01/02"), formatSignedDuration(e.Duration))
+			continue
+		}
+		// 設定されたタイムゾーンに変換して表示
+		printer.Fprintf(w, "%d. %s (%s～%s) [%s]",
+			i+1,
+			e.Event.Summary,
+			e.Start.In(r.Location).Format("2006/01/02 15:04"),
+			e.End.In(r.Location).Format("2006/01/02 15:04"),
+			formatDuration(e.Duration))
+		if e.Deducted > 0 {
+			printer.Fprintf(w, " (控除 %s)", formatDuration(e.Deducted))
+		}
+		if e.Capped > 0 {
+			printer.Fprintf(w, " (上限により %s を切り詰め)", formatDuration(e.Capped))
+		}
+		if e.DailyCapped > 0 {
+			printer.Fprintf(w, " (1日の上限により %s を切り詰め)", formatDuration(e.DailyCapped))
+		}
+		if r.isOutlier(e) {
+			fmt.Fprint(w, colorize(w, colorYellow, printer.Sprintf(" (外れ値)")))
+		}
+		if r.PersonHours != nil {
+			printer.Fprintf(w, " ×%d人", personCount(e.Event))
+		}
+		if len(e.Splits) > 0 {
+			printer.Fprintf(w, " (分割: %s)", formatSplits(e.Splits))
+		}
+		if r.ShowIDs {
+			fmt.Fprintf(w, " id=%s", e.Event.Id)
+		}
+		fmt.Fprintln(w)
+	}
+	if rest > 0 {
+		printer.Fprintf(w, "…ほか%d件\n", rest)
+	}
+}
+
+// escapeMarkdown はMarkdownの表のセル内で特別な意味を持つ文字をエスケープする
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// printMarkdown は集計結果をMarkdown形式で出力する
+func printMarkdown(w io.Writer, r *Report) {
+	printer.Fprintf(w, "## イベント '%s' の集計\n\n", escapeMarkdown(r.Name))
+	printer.Fprintf(w, "- 検索期間: %s から %s\n", r.StartDate.Format("2006/01/02"), r.EndDate.Format("2006/01/02"))
+	printer.Fprintf(w, "- 合計時間: **%s**\n", formatDuration(r.Total))
+	if r.OverlapDeducted > 0 {
+		printer.Fprintf(w, "- 差し引いた重複時間: %s\n", formatDuration(r.OverlapDeducted))
+	}
+	if r.Deducted > 0 {
+		printer.Fprintf(w, "- 控除ルールにより差し引いた時間: %s\n", formatDuration(r.Deducted))
+	}
+	if r.Capped > 0 {
+		printer.Fprintf(w, "- 1件あたりの上限を超えて切り詰めた時間: %s\n", formatDuration(r.Capped))
+	}
+	if len(r.CappedDays) > 0 {
+		printer.Fprintf(w, "- 1日あたりの上限（%s）を超えて切り詰めた時間: %s（上限を適用する前の合計: %s）\n",
+			formatDuration(r.DailyCap), formatDuration(r.dailyCapped()), formatDuration(r.Total+r.dailyCapped()))
+	}
+	if r.IDExcluded > 0 {
+		printer.Fprintf(w, "- 除外リストにより除外したイベント: %d件\n", r.IDExcluded)
+	}
+	if len(r.Excluded) > 0 {
+		printer.Fprintf(w, "- 確認により除外したイベント: %d件（%s）\n", len(r.Excluded), formatDuration(excludedDuration(r.Excluded)))
+	}
+	if manual, n := r.manualDuration(); n > 0 {
+		printer.Fprintf(w, "- 手動調整: %d件（%s）\n", n, formatSignedDuration(manual))
+	}
+	printer.Fprintf(w, "- 件数: %d件\n", r.count())
+	if r.Target != nil {
+		printer.Fprintf(w, "- 目標: %s\n", targetSummary(r.Target))
+	}
+	if r.Utilization != nil {
+		printer.Fprintf(w, "- 稼働率: %s\n", utilizationSummary(r.Utilization))
+	}
+	if r.Comparison != nil {
+		printer.Fprintf(w, "- 比較期間 %s から %s: %s\n",
+			r.Comparison.StartDate.Format("2006/01/02"),
+			r.Comparison.EndDate.Format("2006/01/02"),
+			comparisonSummary(r))
+	}
+	fmt.Fprintln(w)
+
+	if r.count() == 0 {
+		printer.Fprintf(w, "一致するイベントが見つかりませんでした。\n")
+		return
+	}
+
+	st := r.Stats
+	printer.Fprintf(w, "### 統計\n")
+	fmt.Fprintln(w)
+	printer.Fprintf(w, "| 件数 | 平均 | 中央値 | 最短 | 最長 | 実施日数 | 実施日あたりの平均 |\n")
+	printer.Fprintf(w, "|---:|---:|---:|---:|---:|---:|---:|\n")
+	printer.Fprintf(w, "| %d | %s | %s | %s | %s | %d | %s |\n\n",
+		st.Count, formatDuration(st.Average), formatDuration(st.Median),
+		formatDuration(st.Shortest), formatDuration(st.Longest), st.ActiveDays, formatDuration(st.PerDay))
+
+	printOutliersMarkdown(w, r)
+	if len(r.Overlaps) > 0 {
+		printer.Fprintf(w, "### 重複している時間帯\n")
+		fmt.Fprintln(w)
+		printer.Fprintf(w, "| 開始 | 終了 | 時間 | イベント | 重複相手 |\n")
+		printer.Fprintf(w, "|---|---|---:|---|---|\n")
+		for _, o := range r.Overlaps {
+			printer.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+				o.Start.In(r.Location).Format("2006/01/02 15:04"),
+				o.End.In(r.Location).Format("2006/01/02 15:04"),
+				formatDuration(o.End.Sub(o.Start)),
+				escapeMarkdown(o.A.Summary),
+				escapeMarkdown(o.B.Summary))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.Groups) > 0 {
+		printer.Fprintf(w, "### %s別の合計時間\n\n", groupByLabel(r.GroupBy))
+		printer.Fprintf(w, "| %s | 件数 | 合計時間 | 割合 |\n", groupByLabel(r.GroupBy))
+		printer.Fprintf(w, "|---|---:|---:|---|\n")
+		for _, g := range r.Groups {
+			share := r.share(g.Duration)
+			printer.Fprintf(w, "| %s | %d | %s | %.1f%% %s |\n", escapeMarkdown(g.Key), g.Count, formatDuration(g.Duration), share, shareBar(share))
+		}
+		printer.Fprintf(w, "| **合計** | **%d** | **%s** | |\n\n", r.count(), formatDuration(r.Total))
+	}
+	printPersonHoursMarkdown(w, r)
+	printOvertimeMarkdown(w, r)
+	printContextSwitchesMarkdown(w, r)
+	if r.NoList {
+		return
+	}
+
+	printer.Fprintf(w, "### 一致したイベント一覧\n")
+	fmt.Fprintln(w)
+	printer.Fprintf(w, "| # | イベント名 | 開始 | 終了 | 時間 |\n")
+	printer.Fprintf(w, "|---:|---|---|---|---:|\n")
+	listed, rest := r.listedEvents()
+	for i, e := range listed {
+		if e.Manual {
+			printer.Fprintf(w, "| %d | [手動] %s | %s | | %s |\n", i+1, escapeMarkdown(e.Event.Summary), e.Start.In(r.Location).Format("2006/01/02"), formatSignedDuration(e.Duration))
+			continue
+		}
+		printer.Fprintf(w, "| %d | %s | %s | %s | %s |\n",
+			i+1,
+			escapeMarkdown(e.Event.Summary),
+			e.Start.In(r.Location).Format("2006/01/02 15:04"),
+			e.End.In(r.Location).Format("2006/01/02 15:04"),
+			formatDuration(e.Duration))
+	}
+	if rest > 0 {
+		printer.Fprintf(w, "| | …ほか%d件 | | | |\n", rest)
+	}
+	printer.Fprintf(w, "| | **合計** | | | **%s** |\n", formatDuration(r.Total))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Overlap は2つのイベントが重複している時間帯を表す
+type Overlap struct {
+	Start time.Time
+	End   time.Time
+	A     *calendar.Event
+	B     *calendar.Event
+}
+
+// timedEvents は終日イベントを除くすべてのイベントを返す
+func timedEvents(items []*calendar.Event) []MatchedEvent {
+	var events []MatchedEvent
+	for _, item := range items {
+		start, end, ok := timedEventTimes(item, time.UTC)
+		if !ok {
+			continue
+		}
+		events = append(events, MatchedEvent{Event: item, Start: start, End: end, Duration: end.Sub(start)})
+	}
+	return events
+}
+
+// findOverlaps は一致したイベントと、比較対象のイベントが重複している時間帯を開始日時順に返す
+func findOverlaps(matched, others []MatchedEvent) []Overlap {
+	type pair struct{ a, b *calendar.Event }
+	seen := make(map[pair]bool)
+
+	var overlaps []Overlap
+	for _, m := range matched {
+		for _, o := range others {
+			if m.Event == o.Event || seen[pair{o.Event, m.Event}] {
+				continue
+			}
+			start := later(m.Start, o.Start)
+			end := earlier(m.End, o.End)
+			if !start.Before(end) {
+				continue
+			}
+			seen[pair{m.Event, o.Event}] = true
+			overlaps = append(overlaps, Overlap{Start: start, End: end, A: m.Event, B: o.Event})
+		}
+	}
+
+	sort.SliceStable(overlaps, func(i, j int) bool {
+		return overlaps[i].Start.Before(overlaps[j].Start)
+	})
+	return overlaps
+}
+
+// subtractOverlaps は一致したイベント同士で重複している時間を、後から始まるイベントの時間から差し引く
+// 重複は集計する部分（periodStart から periodEnd までに切り詰めた時間帯）同士で判定し、ゼロ値の場合は切り詰めない
+// 勤務時間帯（schedule）が指定されている場合は、重複していない部分のうち勤務時間帯に含まれる時間だけを残す
+// 差し引いた時間の合計を返す
+func subtractOverlaps(events []MatchedEvent, periodStart, periodEnd time.Time, schedule *workSchedule, location *time.Location) time.Duration {
+	order := make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return events[order[i]].Start.Before(events[order[j]].Start)
+	})
+
+	var deducted time.Duration
+	var coveredUntil time.Time
+	for _, i := range order {
+		e := &events[i]
+		start, end := e.Start, e.End
+		if !periodEnd.IsZero() {
+			start, end = later(start, periodStart), earlier(end, periodEnd)
+		}
+		if start.Before(coveredUntil) {
+			// 重複していない部分の長さは、イベントの時間と同じく勤務時間帯に含まれる部分だけで数える
+			remaining := max(end.Sub(coveredUntil), 0)
+			if schedule != nil && coveredUntil.Before(end) {
+				remaining = schedule.overlap(coveredUntil, end, location)
+			}
+			effective := min(e.Duration, remaining)
+			deducted += e.Duration - effective
+			e.Duration = effective
+			// プロジェクトごとに分割した時間も、差し引いた後の時間に合わせて分割し直す
+			if len(e.Splits) > 0 {
+				e.Splits = splitDuration(e.Splits, effective, nil)
+			}
+		}
+		coveredUntil = later(coveredUntil, end)
+	}
+	return deducted
+}
+
+// later は2つの日時のうち遅い方を返す
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// earlier は2つの日時のうち早い方を返す
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// printOverlaps は重複している時間帯の一覧を出力する
+func printOverlaps(w io.Writer, r *Report) {
+	if len(r.Overlaps) == 0 {
+		printer.Fprintf(w, "重複しているイベントはありません。\n")
+		fmt.Fprintln(w)
+		return
+	}
+	printer.Fprintf(w, "重複している時間帯 (%d件):\n", len(r.Overlaps))
+	for _, o := range r.Overlaps {
+		printer.Fprintf(w, "- %s～%s [%s]: %s / %s\n",
+			o.Start.In(r.Location).Format("2006/01/02 15:04"),
+			o.End.In(r.Location).Format("15:04"),
+			formatDuration(o.End.Sub(o.Start)),
+			o.A.Summary,
+			o.B.Summary)
+	}
+	fmt.Fprintln(w)
+}
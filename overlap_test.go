@@ -0,0 +1,126 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubtractOverlaps(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 4, day, hour, minute, 0, 0, tokyo)
+	}
+	periodStart, periodEnd := at(1, 0, 0), at(2, 0, 0)
+
+	tests := []struct {
+		name       string
+		events     []MatchedEvent
+		start, end time.Time
+		want       []time.Duration
+		deducted   time.Duration
+	}{
+		{
+			name: "後から始まるイベントの重複している部分を差し引く",
+			events: []MatchedEvent{
+				{Start: at(1, 10, 0), End: at(1, 11, 0), Duration: time.Hour},
+				{Start: at(1, 10, 30), End: at(1, 12, 0), Duration: 90 * time.Minute},
+			},
+			start: periodStart, end: periodEnd,
+			want:     []time.Duration{time.Hour, time.Hour},
+			deducted: 30 * time.Minute,
+		},
+		{
+			name: "期間の終わりをまたぐイベントは期間内の部分同士で判定する",
+			events: []MatchedEvent{
+				{Start: at(1, 23, 0), End: at(2, 1, 0), Duration: time.Hour},
+				{Start: at(1, 23, 30), End: at(2, 2, 0), Duration: 30 * time.Minute},
+			},
+			start: periodStart, end: periodEnd,
+			want:     []time.Duration{time.Hour, 0},
+			deducted: 30 * time.Minute,
+		},
+		{
+			name: "期間の始まりをまたぐイベントは期間内の部分同士で判定する",
+			events: []MatchedEvent{
+				{Start: at(1, 0, 30), End: at(1, 1, 0), Duration: 30 * time.Minute},
+				{Start: time.Date(2024, 3, 31, 23, 0, 0, 0, tokyo), End: at(1, 2, 0), Duration: 2 * time.Hour},
+			},
+			start: periodStart, end: periodEnd,
+			want:     []time.Duration{0, 2 * time.Hour},
+			deducted: 30 * time.Minute,
+		},
+		{
+			name: "-no-clip の場合はイベント全体で判定する",
+			events: []MatchedEvent{
+				{Start: at(1, 23, 0), End: at(2, 1, 0), Duration: 2 * time.Hour},
+				{Start: at(1, 23, 30), End: at(2, 2, 0), Duration: 150 * time.Minute},
+			},
+			want:     []time.Duration{2 * time.Hour, time.Hour},
+			deducted: 90 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subtractOverlaps(tt.events, tt.start, tt.end, nil, tokyo); got != tt.deducted {
+				t.Errorf("差し引いた時間 = %v; want %v", got, tt.deducted)
+			}
+			for i, e := range tt.events {
+				if e.Duration != tt.want[i] {
+					t.Errorf("イベント%d の時間 = %v; want %v", i, e.Duration, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSubtractOverlapsSplits(t *testing.T) {
+	start := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	events := []MatchedEvent{
+		{Start: start, End: start.Add(time.Hour), Duration: time.Hour},
+		{
+			Start:    start.Add(30 * time.Minute),
+			End:      start.Add(150 * time.Minute),
+			Duration: 2 * time.Hour,
+			Splits:   []Split{{Project: "clientA", Duration: time.Hour}, {Project: "clientB", Duration: time.Hour}},
+		},
+	}
+	subtractOverlaps(events, time.Time{}, time.Time{}, nil, time.UTC)
+
+	// 差し引いた後の時間を、分割の比率のまま分割し直す
+	splits := events[1].Splits
+	if events[1].Duration != 90*time.Minute || len(splits) != 2 || splits[0].Duration != 45*time.Minute || splits[1].Duration != 45*time.Minute {
+		t.Errorf("時間 = %v, 分割 = %+v; want 1h30m を 45m ずつ", events[1].Duration, splits)
+	}
+	if splits[0].Project != "clientA" || splits[1].Project != "clientB" {
+		t.Errorf("分割のプロジェクト = %+v; want clientA, clientB", splits)
+	}
+}
+
+func TestSubtractOverlapsWorkHours(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 4, 1, hour, minute, 0, 0, tokyo)
+	}
+	ws, err := newWorkSchedule("09:00-18:00", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// イベントの時間は勤務時間帯に含まれる部分（9:00〜10:00 と 9:30〜18:00）
+	events := []MatchedEvent{
+		{Start: at(9, 0), End: at(10, 0), Duration: time.Hour},
+		{Start: at(9, 30), End: at(20, 0), Duration: 8*time.Hour + 30*time.Minute},
+	}
+	if got := subtractOverlaps(events, at(0, 0), at(24, 0), ws, tokyo); got != 30*time.Minute {
+		t.Errorf("差し引いた時間 = %v; want 30m", got)
+	}
+	// 重複していない 10:00〜20:00 のうち、勤務時間帯に含まれる 10:00〜18:00 だけを残す
+	if events[1].Duration != 8*time.Hour {
+		t.Errorf("後のイベントの時間 = %v; want 8h", events[1].Duration)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// OvertimeConfig は残業時間の計算に使用する所定労働時間と、時間外労働の上限（36協定）
+// 省略した項目は労働基準法の原則の値（1日8時間・週40時間、月45時間・年360時間、特別条項の月100時間・2〜6か月平均80時間）を使用する
+type OvertimeConfig struct {
+	HoursPerDay         string `json:"hours_per_day,omitempty"`
+	HoursPerWeek        string `json:"hours_per_week,omitempty"`
+	MonthlyLimit        string `json:"monthly_limit,omitempty"`
+	YearlyLimit         string `json:"yearly_limit,omitempty"`
+	SpecialMonthlyLimit string `json:"special_monthly_limit,omitempty"`
+	AverageLimit        string `json:"average_limit,omitempty"`
+}
+
+// overtimeRules は所定労働時間と時間外労働の上限を解析した値
+type overtimeRules struct {
+	perDay         time.Duration
+	perWeek        time.Duration
+	monthlyLimit   time.Duration
+	yearlyLimit    time.Duration
+	specialMonthly time.Duration
+	averageLimit   time.Duration
+}
+
+// 時間外労働の上限（月45時間）を超えられる月数（年6回まで）
+const overLimitMonthsPerYear = 6
+
+// parse は所定労働時間と時間外労働の上限を解析する
+func (c OvertimeConfig) parse() (overtimeRules, error) {
+	rules := overtimeRules{
+		perDay:         8 * time.Hour,
+		perWeek:        40 * time.Hour,
+		monthlyLimit:   45 * time.Hour,
+		yearlyLimit:    360 * time.Hour,
+		specialMonthly: 100 * time.Hour,
+		averageLimit:   80 * time.Hour,
+	}
+	for _, f := range []struct {
+		key   string
+		value string
+		d     *time.Duration
+	}{
+		{"hours_per_day", c.HoursPerDay, &rules.perDay},
+		{"hours_per_week", c.HoursPerWeek, &rules.perWeek},
+		{"monthly_limit", c.MonthlyLimit, &rules.monthlyLimit},
+		{"yearly_limit", c.YearlyLimit, &rules.yearlyLimit},
+		{"special_monthly_limit", c.SpecialMonthlyLimit, &rules.specialMonthly},
+		{"average_limit", c.AverageLimit, &rules.averageLimit},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			return rules, errors.New(printer.Sprintf("overtime の %s '%s' が不正です（例: 8h, 40h）", f.key, f.value))
+		}
+		*f.d = d
+	}
+	return rules, nil
+}
+
+// Overtime は所定労働時間に対する残業時間の集計結果を表す（-overtime）
+type Overtime struct {
+	rules overtimeRules
+
+	// 時間外労働の合計と、所定労働時間に対する過不足の累計
+	Total   time.Duration
+	Balance time.Duration
+
+	// 日ごとと月ごとの集計（日付順）
+	Days   []OvertimeDay
+	Months []OvertimeMonth
+
+	// 月の上限を超えた月数
+	OverLimitMonths int
+}
+
+// OvertimeDay は1日の実働時間と残業時間を表す
+type OvertimeDay struct {
+	Date       time.Time
+	Contracted time.Duration
+	Worked     time.Duration
+	Overtime   time.Duration
+
+	// 期間の初日からの所定労働時間に対する過不足の累計
+	Balance time.Duration
+}
+
+// OvertimeMonth は月ごとの時間外労働の合計と、上限との比較を表す
+type OvertimeMonth struct {
+	Month    time.Time
+	Overtime time.Duration
+
+	// 期間内のこの月までの2〜6か月の平均のうち最も長いもの（期間内に前の月がない場合は0）
+	Average       time.Duration
+	AverageMonths int
+}
+
+// computeOvertime は一致したイベントを実働時間として、日ごとの残業時間と月ごとの時間外労働を求める
+// 1日の所定労働時間を超えた時間と、1日の所定労働時間の範囲で働いた時間のうち週の所定労働時間を超えた時間を時間外労働とする
+// イベントは開始日の時間として数え、勤務日以外と祝日は所定労働時間を0とする
+func computeOvertime(r *Report, rules overtimeRules, workdays [7]bool, holidays map[string]bool) *Overtime {
+	worked := make(map[string]time.Duration)
+	for _, e := range r.Events {
+		worked[e.localStart(r.Location).Format("2006-01-02")] += e.Duration
+	}
+
+	ov := &Overtime{rules: rules}
+	var weekRegular time.Duration
+	for d := r.StartDate; !d.After(r.EndDate); d = d.AddDate(0, 0, 1) {
+		if startOfWeek(d).Equal(d) {
+			weekRegular = 0
+		}
+		key := d.Format("2006-01-02")
+		day := OvertimeDay{Date: d, Worked: max(worked[key], 0)}
+		if workdays[d.Weekday()] && !holidays[key] {
+			day.Contracted = rules.perDay
+		}
+
+		daily := max(day.Worked-rules.perDay, 0)
+		before := weekRegular
+		weekRegular += day.Worked - daily
+		weekly := max(weekRegular-rules.perWeek, 0) - max(before-rules.perWeek, 0)
+		day.Overtime = daily + weekly
+
+		ov.Total += day.Overtime
+		ov.Balance += day.Worked - day.Contracted
+		day.Balance = ov.Balance
+		if day.Worked > 0 || day.Contracted > 0 {
+			ov.Days = append(ov.Days, day)
+		}
+
+		month := time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, d.Location())
+		if n := len(ov.Months); n == 0 || !ov.Months[n-1].Month.Equal(month) {
+			ov.Months = append(ov.Months, OvertimeMonth{Month: month})
+		}
+		ov.Months[len(ov.Months)-1].Overtime += day.Overtime
+	}
+
+	for i := range ov.Months {
+		m := &ov.Months[i]
+		if m.Overtime > rules.monthlyLimit {
+			ov.OverLimitMonths++
+		}
+		var sum time.Duration
+		for n := 1; n <= 6 && i-n+1 >= 0; n++ {
+			sum += ov.Months[i-n+1].Overtime
+			if avg := sum / time.Duration(n); n >= 2 && avg > m.Average {
+				m.Average, m.AverageMonths = avg, n
+			}
+		}
+	}
+	return ov
+}
+
+// overtimeWarnings は月の時間外労働が超えている上限の説明を返す（超えていない場合は空）
+func (ov *Overtime) overtimeWarnings(m OvertimeMonth) []string {
+	var warnings []string
+	if m.Overtime >= ov.rules.specialMonthly {
+		warnings = append(warnings, printer.Sprintf("特別条項の上限 %s 以上", formatDuration(ov.rules.specialMonthly)))
+	} else if m.Overtime > ov.rules.monthlyLimit {
+		warnings = append(warnings, printer.Sprintf("月の上限 %s を超過", formatDuration(ov.rules.monthlyLimit)))
+	}
+	if m.Average > ov.rules.averageLimit {
+		warnings = append(warnings, printer.Sprintf("%dか月平均 %s が上限 %s を超過", m.AverageMonths, formatDuration(m.Average), formatDuration(ov.rules.averageLimit)))
+	}
+	return warnings
+}
+
+// printOvertime は残業時間の集計結果を出力する
+func printOvertime(w io.Writer, r *Report) {
+	ov := r.Overtime
+	if ov == nil {
+		return
+	}
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("残業時間（所定労働時間: 1日 %s・週 %s）:\n", formatDuration(ov.rules.perDay), formatDuration(ov.rules.perWeek))))
+	printer.Fprintf(w, "- 時間外労働の合計: %s（年の上限 %s）\n", formatDuration(ov.Total), formatDuration(ov.rules.yearlyLimit))
+	printer.Fprintf(w, "- 所定労働時間に対する過不足: %s\n", formatSignedDuration(ov.Balance))
+	for _, m := range ov.Months {
+		line := printer.Sprintf("- %s: %s / 上限 %s", m.Month.Format("2006/01"), formatDuration(m.Overtime), formatDuration(ov.rules.monthlyLimit))
+		if warnings := ov.overtimeWarnings(m); len(warnings) > 0 {
+			line = colorize(w, colorRed, line+printer.Sprintf("（%s）", strings.Join(warnings, printer.Sprintf("、"))))
+		}
+		fmt.Fprintln(w, line)
+	}
+	if ov.OverLimitMonths > overLimitMonthsPerYear {
+		fmt.Fprint(w, colorize(w, colorRed, printer.Sprintf("- 月の上限を超えた月が %d回あります（年%d回まで）\n", ov.OverLimitMonths, overLimitMonthsPerYear)))
+	} else if ov.OverLimitMonths > 0 {
+		printer.Fprintf(w, "- 月の上限を超えた月: %d回（年%d回まで）\n", ov.OverLimitMonths, overLimitMonthsPerYear)
+	}
+	if ov.Total > ov.rules.yearlyLimit {
+		fmt.Fprint(w, colorize(w, colorRed, printer.Sprintf("- 時間外労働の合計が年の上限 %s を超えています\n", formatDuration(ov.rules.yearlyLimit))))
+	}
+	fmt.Fprintln(w)
+
+	if r.NoList {
+		return
+	}
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("日ごとの残業時間:\n")))
+	for _, d := range ov.Days {
+		printer.Fprintf(w, "- %s: 実働 %s, 所定 %s, 残業 %s, 過不足の累計 %s\n",
+			d.Date.Format("2006/01/02"), formatDuration(d.Worked), formatDuration(d.Contracted), formatDuration(d.Overtime), formatSignedDuration(d.Balance))
+	}
+	fmt.Fprintln(w)
+}
+
+// printOvertimeMarkdown は残業時間の集計結果をMarkdown形式の表で出力する
+func printOvertimeMarkdown(w io.Writer, r *Report) {
+	ov := r.Overtime
+	if ov == nil {
+		return
+	}
+	printer.Fprintf(w, "### 残業時間（所定労働時間: 1日 %s・週 %s）\n\n", formatDuration(ov.rules.perDay), formatDuration(ov.rules.perWeek))
+	printer.Fprintf(w, "- 時間外労働の合計: %s（年の上限 %s）\n", formatDuration(ov.Total), formatDuration(ov.rules.yearlyLimit))
+	printer.Fprintf(w, "- 所定労働時間に対する過不足: %s\n", formatSignedDuration(ov.Balance))
+	printer.Fprintf(w, "- 月の上限を超えた月: %d回（年%d回まで）\n\n", ov.OverLimitMonths, overLimitMonthsPerYear)
+	printer.Fprintf(w, "| 月 | 時間外労働 | 上限 | 注意 |\n")
+	printer.Fprintf(w, "|---|---:|---:|---|\n")
+	for _, m := range ov.Months {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", m.Month.Format("2006/01"), formatDuration(m.Overtime), formatDuration(ov.rules.monthlyLimit), strings.Join(ov.overtimeWarnings(m), printer.Sprintf("、")))
+	}
+	fmt.Fprintln(w)
+
+	if r.NoList {
+		return
+	}
+	printer.Fprintf(w, "| 日付 | 実働 | 所定 | 残業 | 過不足の累計 |\n")
+	printer.Fprintf(w, "|---|---:|---:|---:|---:|\n")
+	for _, d := range ov.Days {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			d.Date.Format("2006/01/02"), formatDuration(d.Worked), formatDuration(d.Contracted), formatDuration(d.Overtime), formatSignedDuration(d.Balance))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4の用紙サイズ（ポイント）
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+)
+
+// PDFで使用する日本語フォント
+// フォントは埋め込まず、PDFビューアが標準で持つ Adobe-Japan1 のフォントで表示する
+const pdfFontName = "HeiseiKakuGo-W5"
+
+// pdfPage はPDFの1ページ分の描画内容
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// pdfDocument はライブラリを使用せずに生成する、テキストと罫線だけのPDF文書
+type pdfDocument struct {
+	pages []*pdfPage
+}
+
+// addPage は新しいページを追加して返す
+func (d *pdfDocument) addPage() *pdfPage {
+	p := &pdfPage{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// pdfTextWidth は文字列を指定の文字サイズで描画した場合の幅を返す（全角文字は正方形、半角文字はその半分の幅）
+func pdfTextWidth(s string, size float64) float64 {
+	return float64(displayWidth(s)) * size / 2
+}
+
+// pdfTruncate は文字列が指定の幅に収まるように、末尾を「…」に置き換えて切り詰める
+func pdfTruncate(s string, size, maxWidth float64) string {
+	if pdfTextWidth(s, size) <= maxWidth {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && pdfTextWidth(string(r)+"…", size) > maxWidth {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// pdfString は文字列をフォントのエンコーディング（UCS-2のビッグエンディアン）の16進文字列に変換する
+// UCS-2で表せない文字は「?」に置き換える
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		if r > 0xffff {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// text は左下を (x, y) として文字列を描画する
+func (p *pdfPage) text(x, y, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F1 %.1f Tf %.2f %.2f Td %s Tj ET\n", size, x, y, pdfString(s))
+}
+
+// textRight は右端を x に揃えて文字列を描画する
+func (p *pdfPage) textRight(x, y, size float64, s string) {
+	p.text(x-pdfTextWidth(s, size), y, size, s)
+}
+
+// line は線を描画する
+func (p *pdfPage) line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", lineWidth, x1, y1, x2, y2)
+}
+
+// fillRect は灰色で塗りつぶした四角形を描画する
+func (p *pdfPage) fillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "%.2f g %.2f %.2f %.2f %.2f re f 0 g\n", gray, x, y, w, h)
+}
+
+// write はPDF文書を出力する
+func (d *pdfDocument) write(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: カタログ、2: ページツリー、3-5: フォント、6以降: ページと描画内容
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /" + pdfFontName + " /Encoding /UniJIS-UCS2-H /DescendantFonts [4 0 R] >>")
+	// 半角文字（CID 1-95, 231-632）は全角の半分の幅にする
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /" + pdfFontName +
+		" /CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> /FontDescriptor 5 0 R" +
+		" /DW 1000 /W [1 95 500 231 632 500] >>")
+	object("<< /Type /FontDescriptor /FontName /" + pdfFontName +
+		" /Flags 4 /FontBBox [-92 -250 1010 922] /ItalicAngle 0 /Ascent 752 /Descent -221 /CapHeight 737 /StemV 114 >>")
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
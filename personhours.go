@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// PersonHours はイベントの時間に参加者の人数を掛けた延べ時間（人時）を表す
+type PersonHours struct {
+	Total time.Duration
+
+	// 主催者ごとの延べ時間（延べ時間の多い順）
+	Organizers []OrganizerHours
+}
+
+// OrganizerHours は主催者ごとのイベントの件数、時間、延べ時間を表す
+type OrganizerHours struct {
+	Organizer   string
+	Count       int
+	Duration    time.Duration
+	PersonHours time.Duration
+
+	// 参加者の人数の合計（平均人数の表示に使用する）
+	Attendees int
+}
+
+// personCount は延べ時間の計算に使用する参加者の人数を返す
+// 会議室などのリソースと参加を辞退した人は数えず、参加者がいない（自分だけの）予定は1人として数える
+func personCount(e *calendar.Event) int {
+	n := 0
+	for _, a := range e.Attendees {
+		if !a.Resource && a.ResponseStatus != "declined" {
+			n++
+		}
+	}
+	return max(n, 1)
+}
+
+// computePersonHours は集計したイベントの延べ時間を、全体と主催者ごとに計算する（手動調整は含めない）
+func computePersonHours(r *Report) *PersonHours {
+	ph := &PersonHours{}
+	index := make(map[string]int)
+	for _, e := range r.Events {
+		if e.Manual {
+			continue
+		}
+		n := personCount(e.Event)
+		hours := e.Duration * time.Duration(n)
+		ph.Total += hours
+
+		key := organizerKey(e.Event)
+		i, ok := index[key]
+		if !ok {
+			i = len(ph.Organizers)
+			index[key] = i
+			ph.Organizers = append(ph.Organizers, OrganizerHours{Organizer: key})
+		}
+		o := &ph.Organizers[i]
+		o.Count++
+		o.Duration += e.Duration
+		o.PersonHours += hours
+		o.Attendees += n
+	}
+	sort.SliceStable(ph.Organizers, func(i, j int) bool {
+		return ph.Organizers[i].PersonHours > ph.Organizers[j].PersonHours
+	})
+	return ph
+}
+
+// averageAttendees は主催者のイベントの平均の参加者数を返す
+func (o OrganizerHours) averageAttendees() float64 {
+	if o.Count == 0 {
+		return 0
+	}
+	return float64(o.Attendees) / float64(o.Count)
+}
+
+// printPersonHours は延べ時間と主催者ごとの内訳をテキスト形式で出力する
+func printPersonHours(w io.Writer, r *Report) {
+	ph := r.PersonHours
+	if ph == nil {
+		return
+	}
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("延べ時間（参加者数×時間）: %s\n", formatDuration(ph.Total))))
+	lines := make([]string, len(ph.Organizers))
+	lineWidth := 0
+	for i, o := range ph.Organizers {
+		lines[i] = printer.Sprintf("- %s: %s (%d件, 平均%.1f人, 時間 %s)", o.Organizer, formatDuration(o.PersonHours), o.Count, o.averageAttendees(), formatDuration(o.Duration))
+		lineWidth = max(lineWidth, displayWidth(lines[i]))
+	}
+	for i, o := range ph.Organizers {
+		share := 0.0
+		if ph.Total > 0 {
+			share = float64(o.PersonHours) / float64(ph.Total) * 100
+		}
+		fmt.Fprintf(w, "%s  %5.1f%% %s\n", padRight(lines[i], lineWidth), share, colorize(w, colorCyan, shareBar(share)))
+	}
+	fmt.Fprintln(w)
+}
+
+// printPersonHoursMarkdown は延べ時間と主催者ごとの内訳をMarkdown形式の表で出力する
+func printPersonHoursMarkdown(w io.Writer, r *Report) {
+	ph := r.PersonHours
+	if ph == nil {
+		return
+	}
+	printer.Fprintf(w, "### 主催者別の延べ時間（参加者数×時間）\n\n")
+	printer.Fprintf(w, "| 主催者 | 件数 | 平均人数 | 時間 | 延べ時間 |\n")
+	printer.Fprintf(w, "|---|---:|---:|---:|---:|\n")
+	var total time.Duration
+	for _, o := range ph.Organizers {
+		total += o.Duration
+		fmt.Fprintf(w, "| %s | %d | %.1f | %s | %s |\n", escapeMarkdown(o.Organizer), o.Count, o.averageAttendees(), formatDuration(o.Duration), formatDuration(o.PersonHours))
+	}
+	printer.Fprintf(w, "| **合計** | | | **%s** | **%s** |\n\n", formatDuration(total), formatDuration(ph.Total))
+}
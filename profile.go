@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// profileOptions はCPUとメモリのプロファイルの書き出しに関する共通オプション
+type profileOptions struct {
+	cpu string
+	mem string
+}
+
+// register はプロファイルの書き出しに関するフラグを登録する
+func (p *profileOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.cpu, "profile-cpu", "", "CPUプロファイル（pprof形式）を書き出すファイルのパス")
+	fs.StringVar(&p.mem, "profile-mem", "", "終了時のメモリのプロファイル（pprof形式）を書き出すファイルのパス")
+}
+
+// stopProfile はCPUプロファイルの記録を終了し、メモリのプロファイルを書き出す（プロファイルを指定しない場合は何もしない）
+var stopProfile = func() {}
+
+// setup はCPUプロファイルの記録を開始し、終了時にプロファイルを書き出すように設定する
+func (p *profileOptions) setup() {
+	if p.cpu == "" && p.mem == "" {
+		return
+	}
+	var cpuFile *os.File
+	if p.cpu != "" {
+		var err error
+		if cpuFile, err = os.Create(p.cpu); err != nil {
+			fatal("CPUプロファイルの作成に失敗しました", "error", err, "path", p.cpu)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			fatal("CPUプロファイルの記録の開始に失敗しました", "error", err)
+		}
+	}
+	mem := p.mem
+	// fatal からも呼び出されるため、失敗してもログに出力するだけにする
+	stopProfile = sync.OnceFunc(func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				slog.Warn("CPUプロファイルの書き出しに失敗しました", "error", err, "path", cpuFile.Name())
+			} else {
+				slog.Debug("CPUプロファイルを書き出しました", "path", cpuFile.Name())
+			}
+		}
+		if mem != "" {
+			if err := writeHeapProfile(mem); err != nil {
+				slog.Warn("メモリのプロファイルの書き出しに失敗しました", "error", err, "path", mem)
+			} else {
+				slog.Debug("メモリのプロファイルを書き出しました", "path", mem)
+			}
+		}
+	})
+}
+
+// writeHeapProfile はメモリのプロファイルをファイルに書き出す
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	// 最新の割り当て状況を反映するため、書き出す前にガベージコレクションを実行する
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exit はプロファイルを書き出してから、指定された終了コードで終了する
+func exit(code int) {
+	stopProfile()
+	os.Exit(code)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 取得がこの時間より長くかかった場合だけ進み具合を表示する（キャッシュから読み込む場合などに表示がちらつかないようにする）
+const progressDelay = 500 * time.Millisecond
+
+// 進み具合の表示を更新する間隔
+const progressInterval = 120 * time.Millisecond
+
+// progressEnabled は進み具合を表示するかどうか
+// 標準エラー出力が端末で、-quiet や -log-format=json が指定されていない場合に parseFlags で有効にする
+var progressEnabled bool
+
+// progressMu は進み具合の表示とログの出力が同じ行に混ざらないようにするためのロック
+var progressMu sync.Mutex
+
+// progressVisible は進み具合の行を表示中かどうか（progressMu で保護する）
+var progressVisible bool
+
+// スピナーの表示に使用する文字
+var spinnerFrames = []string{"|", "/", "-", `\`}
+
+// progress はイベントの取得中に、取得が完了した期間の数、取得したページ数、イベント数を表示する
+type progress struct {
+	total     int
+	start     time.Time
+	tasksDone atomic.Int64
+	pages     atomic.Int64
+	events    atomic.Int64
+	stop      chan struct{}
+	stopped   sync.WaitGroup
+}
+
+// startProgress は total 個の取得処理の進み具合の表示を開始する。表示しない場合はnilを返す
+func startProgress(total int) *progress {
+	if !progressEnabled {
+		return nil
+	}
+	p := &progress{total: total, start: time.Now(), stop: make(chan struct{})}
+	p.stopped.Add(1)
+	go p.run()
+	return p
+}
+
+// run は一定間隔で進み具合の表示を更新する
+func (p *progress) run() {
+	defer p.stopped.Done()
+	select {
+	case <-time.After(progressDelay):
+	case <-p.stop:
+		return
+	}
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for frame := 0; ; frame++ {
+		p.render(spinnerFrames[frame%len(spinnerFrames)])
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// render は進み具合の行を描画する
+func (p *progress) render(spinner string) {
+	line := printer.Sprintf("%s イベントを取得しています %d/%d（%dページ、%d件、%s）", spinner,
+		p.tasksDone.Load(), p.total, p.pages.Load(), p.events.Load(), time.Since(p.start).Round(time.Second))
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	fmt.Fprint(os.Stderr, "\r\x1b[K"+line)
+	progressVisible = true
+}
+
+// addPage は取得した1ページ分のイベント数を加える
+func (p *progress) addPage(events int) {
+	if p == nil {
+		return
+	}
+	p.pages.Add(1)
+	p.events.Add(int64(events))
+}
+
+// taskDone は取得処理が1つ完了したことを記録する
+func (p *progress) taskDone() {
+	if p == nil {
+		return
+	}
+	p.tasksDone.Add(1)
+}
+
+// finish は進み具合の表示を終了し、表示中の行を消す
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.stopped.Wait()
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	clearProgressLine()
+}
+
+// clearProgressLine は表示中の進み具合の行を消す（progressMu を取得した状態で呼び出す）
+func clearProgressLine() {
+	if progressVisible {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+		progressVisible = false
+	}
+}
+
+// writeAboveProgress は進み具合の行を消してから出力する。行は次の更新で描画し直される
+func writeAboveProgress(w io.Writer, s string) error {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	clearProgressLine()
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// progressKey はコンテキストに進み具合の表示を保持するためのキー
+type progressKey struct{}
+
+// withProgress は取得処理から進み具合を記録できるように、コンテキストに進み具合の表示を保持する
+func withProgress(ctx context.Context, p *progress) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// progressFrom はコンテキストから進み具合の表示を取り出す（ない場合はnil）
+func progressFrom(ctx context.Context) *progress {
+	p, _ := ctx.Value(progressKey{}).(*progress)
+	return p
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// プロジェクトに対応付けられなかったイベントのグループ名
+const noProjectLabel = "(プロジェクトなし)"
+
+// ProjectRule はイベント名のパターンとプロジェクト（クライアント）の対応
+// パターンを /.../ で囲んだ場合は正規表現、それ以外は大文字小文字を区別しない部分一致で比較する
+type ProjectRule struct {
+	Pattern string `json:"pattern"`
+	Project string `json:"project"`
+
+	re *regexp.Regexp
+}
+
+// parse はパターンを解析する
+func (r *ProjectRule) parse() error {
+	if r.Pattern == "" || r.Project == "" {
+		return errors.New(printer.Sprintf("プロジェクトの対応付けには pattern と project を指定してください"))
+	}
+	if len(r.Pattern) >= 2 && strings.HasPrefix(r.Pattern, "/") && strings.HasSuffix(r.Pattern, "/") {
+		re, err := regexp.Compile(r.Pattern[1 : len(r.Pattern)-1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", printer.Sprintf("プロジェクト '%s' のパターンが不正です", r.Project), err)
+		}
+		r.re = re
+	}
+	return nil
+}
+
+// matches はイベント名がパターンに一致するかを判定する
+func (r *ProjectRule) matches(summary string) bool {
+	if r.re != nil {
+		return r.re.MatchString(summary)
+	}
+	return strings.Contains(strings.ToLower(summary), strings.ToLower(r.Pattern))
+}
+
+// projectFor はイベント名に対応するプロジェクトを返す（最初に一致したルールを使用し、一致しない場合は空文字）
+func projectFor(rules []ProjectRule, summary string) string {
+	if rule := projectRuleFor(rules, summary); rule != nil {
+		return rule.Project
+	}
+	return ""
+}
+
+// projectRuleFor はイベント名に最初に一致したプロジェクトの対応付けを返す（一致しない場合はnil）
+func projectRuleFor(rules []ProjectRule, summary string) *ProjectRule {
+	for i := range rules {
+		if rules[i].matches(summary) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// loadProjectRules はCSVファイル（pattern,project の2列）からプロジェクトの対応付けを読み込む
+// 1行目が見出し（pattern,project）の場合と、# で始まる行は読み飛ばす
+func loadProjectRules(path string) ([]ProjectRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cr := csv.NewReader(f)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var rules []ProjectRule
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && len(rec) >= 2 && strings.EqualFold(rec[0], "pattern") && strings.EqualFold(rec[1], "project") {
+			continue
+		}
+		if len(rec) < 2 {
+			return nil, errors.New(printer.Sprintf("%s:%d: pattern と project の2列を指定してください", path, line))
+		}
+		rules = append(rules, ProjectRule{Pattern: strings.TrimSpace(rec[0]), Project: strings.TrimSpace(rec[1])})
+	}
+	return rules, nil
+}
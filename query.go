@@ -0,0 +1,855 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// queryOptions はイベントの集計に関する共通オプション
+type queryOptions struct {
+	startDate string
+	endDate   string
+	month     string
+	months    string
+	period    string
+	week      string
+	thisWeek  bool
+	lastWeek  bool
+	weekStart string
+	name      string
+	tag       string
+
+	match         string
+	namePatterns  []*regexp.Regexp
+	noServerQuery bool
+	aliases       map[string][]string
+	ignoreEmoji   bool
+	strict        bool
+
+	descriptionContains string
+	descriptionRegex    string
+	descriptionPattern  *regexp.Regexp
+
+	attendee     string
+	organizer    string
+	minAttendees int
+
+	projectsPath string
+	projects     []ProjectRule
+	project      string
+	splitMarkers bool
+
+	minDuration time.Duration
+	maxDuration time.Duration
+	capDuration time.Duration
+	dailyCap    time.Duration
+	outliers    string
+	interactive bool
+
+	adjustmentsPath string
+
+	excludeIDs    idList
+	excludeList   []ExcludedEvent
+	noExcludeList bool
+	excludeSet    map[string]bool
+
+	location     string
+	onlyWithMeet bool
+	withoutMeet  bool
+
+	calendarID string
+	groupBy    string
+	icsPath    string
+	mockPath   string
+	cacheTTL   time.Duration
+	noCache    bool
+	sync       bool
+	noHistory  bool
+	noList     bool
+	anonymize  bool
+	compareTo  string
+	vsPrevious bool
+	noDedupe   bool
+	noClip     bool
+
+	durationFormat string
+
+	// -calendar-timezone で、イベントごとに取得元のカレンダーのタイムゾーンを記録する（オプションの写しでも同じ記録を使用するため、ポインタで共有する）
+	calendarTimezone bool
+	zones            *eventZones
+
+	// -anonymize で仮名の生成に使用する（オプションの写しでも同じ仮名にするため、ポインタで共有する）
+	anonymizer *anonymizer
+
+	concurrency int
+	resume      bool
+	timeout     time.Duration
+
+	includeCancelled bool
+	includeDeclined  bool
+
+	detectOverlaps   bool
+	overlapAll       bool
+	subtractOverlaps bool
+
+	workHours string
+	workdays  string
+	schedule  *workSchedule
+
+	deductions   []DeductionRule
+	noDeductions bool
+
+	target         string
+	targets        map[string]string
+	targetDuration time.Duration
+
+	utilization     bool
+	personHours     bool
+	hoursPerDay     time.Duration
+	holidayCalendar string
+	noHolidays      bool
+
+	overtime       bool
+	overtimeConfig OvertimeConfig
+	overtimeRules  overtimeRules
+
+	contextSwitches bool
+	backToBackGap   time.Duration
+}
+
+// register は集計に関するフラグを登録する
+func (o *queryOptions) register(fs *flag.FlagSet, cfg *Config) {
+	o.registerDateRange(fs, cfg)
+	fs.StringVar(&o.name, "name", "", "検索するイベント名（設定ファイルの aliases の別名も指定可能）")
+	o.aliases = cfg.Aliases
+	fs.StringVar(&o.match, "match", "exact", "イベント名の比較方法（exact: 完全一致, contains: 部分一致, regex: 正規表現）")
+	fs.BoolVar(&o.ignoreEmoji, "ignore-emoji", cfg.IgnoreEmoji, "イベント名の絵文字と前後の記号を取り除いて比較する（例: 「🏃 Run」を -name=Run に一致させる）")
+	fs.BoolVar(&o.strict, "strict", false, "イベント名を正規化せず、大文字小文字も区別して、バイト単位で一致するかを比較する")
+	fs.BoolVar(&o.noServerQuery, "no-server-filter", false, "Calendar APIの検索（qパラメータ）による絞り込みを行わず、すべてのイベントを取得して絞り込む")
+	fs.StringVar(&o.tag, "tag", "", "タイトルまたは説明に含まれるタグ（#clientA など）で絞り込む")
+	fs.StringVar(&o.descriptionContains, "description-contains", "", "説明に指定の文字列を含むイベントで絞り込む")
+	fs.StringVar(&o.descriptionRegex, "description-regex", "", "説明が正規表現に一致するイベントで絞り込む")
+	fs.StringVar(&o.attendee, "attendee", "", "指定したメールアドレスの人が参加者に含まれるイベントで絞り込む")
+	fs.IntVar(&o.minAttendees, "min-attendees", 0, "参加者が指定人数以上（自分を含む）のイベントだけを集計")
+	fs.StringVar(&o.organizer, "organizer", "", "指定したメールアドレスの人が主催するイベントで絞り込む")
+	fs.StringVar(&o.projectsPath, "projects", "", "イベント名とプロジェクトの対応付けのCSVファイル（未指定の場合は設定ファイルの projects を使用）")
+	o.projects = cfg.Projects
+	fs.StringVar(&o.project, "project", "", "対応付けたプロジェクトで絞り込む（* の場合はいずれかのプロジェクトに対応付けられたイベント）")
+	fs.BoolVar(&o.splitMarkers, "split-markers", cfg.SplitMarkers, "説明の「clientA: 30m / clientB: 60m」形式の行に従って、プロジェクト別の集計でイベントの時間を分割する")
+	fs.DurationVar(&o.minDuration, "min-duration", 0, "指定した長さ未満のイベントを集計しない（例: 30m）")
+	fs.DurationVar(&o.maxDuration, "max-duration", 0, "指定した長さを超えるイベントを集計しない（例: 4h）")
+	fs.DurationVar(&o.capDuration, "cap-duration", 0, "1件あたりの集計する時間の上限（例: 2h）。0の場合は上限なし")
+	fs.DurationVar(&o.dailyCap, "daily-cap", 0, "1日あたりの集計する時間の上限（例: 8h）。0の場合は上限なし")
+	fs.StringVar(&o.adjustmentsPath, "adjustments", "", "カレンダーにない作業時間を加えたり差し引いたりする調整ファイル（YAML）")
+	fs.StringVar(&o.outliers, "outliers", "flag", "長さが外れ値のイベントの扱い（flag: 表示する, exclude: 集計から除外する, off: 検出しない）")
+	fs.StringVar(&o.location, "location", "", "場所に指定の文字列を含むイベントで絞り込む")
+	fs.BoolVar(&o.onlyWithMeet, "only-with-meet", false, "ビデオ会議（Google Meetなど）のリンクがあるイベントだけを集計")
+	fs.BoolVar(&o.withoutMeet, "without-meet", false, "ビデオ会議のリンクがないイベントだけを集計")
+	fs.StringVar(&o.calendarID, "calendar", cfg.Calendar, "カレンダーID、カンマ区切りで複数指定可能（デフォルトは設定ファイルの値または 'primary'）")
+	fs.StringVar(&o.groupBy, "group-by", "", "集計のグループ化単位（day, week, month, name, tag, attendee, organizer, recurrence, project）")
+	fs.StringVar(&o.icsPath, "ics", "", "Google Calendarの代わりに読み込むICSファイル（オフラインで集計）")
+	fs.StringVar(&o.mockPath, "mock", "", "Google Calendarの代わりに読み込むイベントのフィクスチャ（Calendar APIの応答形式のJSON、認証不要）")
+	fs.DurationVar(&o.cacheTTL, "cache-ttl", defaultCacheTTL, "取得したイベントのキャッシュ有効期間")
+	fs.BoolVar(&o.noCache, "no-cache", false, "キャッシュを使用せずにAPIからイベントを取得")
+	fs.BoolVar(&o.sync, "sync", false, "ローカルストアを差分同期し、そこから集計")
+	fs.BoolVar(&o.noHistory, "no-history", false, "集計結果を履歴に保存しない")
+	fs.BoolVar(&o.anonymize, "anonymize", false, "すべての出力でイベント名・参加者・主催者を仮名に置き換え、説明と場所を取り除く（時間とグループごとの集計はそのまま）")
+	fs.StringVar(&o.compareTo, "compare-to", "", "比較する期間（YYYY-MM形式、またはYYYY-MM-DD..YYYY-MM-DD形式）")
+	fs.BoolVar(&o.vsPrevious, "vs-previous", false, "直前の同じ長さの期間と比較")
+	fs.StringVar(&o.durationFormat, "duration-format", "hm", "時間の表示形式（hm: X時間Y分, decimal: 小数の時間数, iso8601: PT7H45M, minutes: 分数）")
+	fs.BoolVar(&o.noClip, "no-clip", false, "期間の境界をまたぐイベントも期間外の部分を含めて集計")
+	fs.BoolVar(&o.calendarTimezone, "calendar-timezone", cfg.CalendarTimezone, "日ごとの振り分けと終日イベントの日付に、表示のタイムゾーンの代わりに各カレンダーのタイムゾーンの設定を使用")
+	fs.IntVar(&o.concurrency, "concurrency", defaultConcurrency, "カレンダー・期間ごとのイベントを並行に取得する数")
+	fs.DurationVar(&o.timeout, "timeout", 0, "処理全体のタイムアウト（例: 5m）。0の場合はタイムアウトしない")
+	fs.BoolVar(&o.resume, "resume", false, "APIの割り当て上限で中断した前回の取得を、チェックポイントから続けて実行")
+	fs.BoolVar(&o.noDedupe, "no-dedupe", false, "複数のカレンダーに含まれる同じイベントの重複を取り除かない")
+	fs.BoolVar(&o.includeCancelled, "include-cancelled", false, "キャンセルされたイベントも集計に含める")
+	fs.BoolVar(&o.includeDeclined, "include-declined", false, "自分が出席を辞退したイベントも集計に含める")
+	fs.BoolVar(&o.detectOverlaps, "detect-overlaps", false, "一致したイベント同士の重複（ダブルブッキング）を検出")
+	fs.BoolVar(&o.overlapAll, "overlap-all", false, "-detect-overlaps で一致したイベント以外との重複も検出")
+	fs.BoolVar(&o.subtractOverlaps, "subtract-overlaps", false, "一致したイベント同士で重複している時間を合計から差し引く")
+	fs.StringVar(&o.workHours, "work-hours", "", "勤務時間帯内の時間だけを集計（HH:MM-HH:MM形式、例: 09:00-18:00）")
+	fs.StringVar(&o.workdays, "workdays", "", "勤務日の曜日だけを集計（例: mon-fri, mon,wed,fri）")
+	fs.BoolVar(&o.noDeductions, "no-deductions", false, "設定ファイルの控除ルールを適用しない")
+	fs.Var(&o.excludeIDs, "exclude-id", "集計から除外するイベントのID（繰り返し指定可能、カンマ区切りでも指定可能）")
+	fs.BoolVar(&o.noExcludeList, "no-exclude-list", false, "設定ファイルの exclude_events を適用しない")
+	o.excludeList = cfg.ExcludeEvents
+	o.deductions = cfg.Deductions
+	fs.StringVar(&o.target, "target", "", "期間の目標時間（例: 140h）。未指定の場合は設定ファイルの targets を使用")
+	o.targets = cfg.Targets
+	fs.BoolVar(&o.utilization, "utilization", false, "稼働可能時間に対する割合（稼働率）を表示")
+	fs.BoolVar(&o.personHours, "person-hours", false, "イベントの時間に参加者の人数を掛けた延べ時間と、主催者ごとの内訳を表示")
+	fs.BoolVar(&o.overtime, "overtime", false, "一致したイベントを実働時間として、設定ファイルの overtime の所定労働時間に対する残業時間と時間外労働の上限との比較を表示")
+	o.overtimeConfig = cfg.Overtime
+	fs.BoolVar(&o.contextSwitches, "context-switches", false, "一致したイベントの間の切り替えの回数、休憩なしの連続した予定の回数、日ごとの予定の間隔の平均を表示")
+	fs.DurationVar(&o.backToBackGap, "back-to-back-gap", defaultBackToBackGap, "-context-switches で連続した予定とみなす、前の予定の終了から次の予定の開始までの間隔")
+	hoursPerDay := defaultHoursPerDay
+	if d, err := time.ParseDuration(cfg.HoursPerDay); err == nil {
+		hoursPerDay = d
+	}
+	fs.DurationVar(&o.hoursPerDay, "hours-per-day", hoursPerDay, "稼働率の計算に使用する1日あたりの稼働時間")
+	fs.StringVar(&o.holidayCalendar, "holiday-calendar", cfg.HolidayCalendar, "稼働日から除外する祝日カレンダーのID（デフォルトは日本の祝日）")
+	fs.BoolVar(&o.noHolidays, "no-holidays", false, "祝日を稼働日から除外しない")
+}
+
+// printUsage は集計コマンドの使用方法を表示する
+func printUsage() {
+	printer.Printf("使用方法: gcal-sum -start=YYYY-MM-DD -end=YYYY-MM-DD -name=イベント名 [-calendar=カレンダーID]\n")
+	printer.Printf("または: gcal-sum -month=YYYY-MM -name=イベント名 [-calendar=カレンダーID]\n")
+	printer.Printf("または: gcal-sum -month=YYYY-MM -tag=タグ [-calendar=カレンダーID]\n")
+}
+
+// validate はオプションを検証し、不正な場合は使用方法を表示して終了する
+func (o *queryOptions) validate() {
+	showUsage, err := o.check()
+	if err == nil {
+		return
+	}
+	printer.Printf("エラー: %v\n", err)
+	if showUsage {
+		printUsage()
+	}
+	os.Exit(exitUsage)
+}
+
+// check はオプションを検証し、正規表現や勤務時間帯などの解析結果を設定する
+// 集計の条件や期間が指定されていない場合は、使用方法を表示すべきことを showUsage で示す
+func (o *queryOptions) check() (showUsage bool, err error) {
+	if !o.hasEventFilter() {
+		return true, errors.New(printer.Sprintf("イベント名、タグ、説明、参加者、主催者、場所、またはプロジェクトの条件を指定してください。"))
+	}
+	if showUsage, err := o.checkDateRange(); err != nil {
+		return showUsage, err
+	}
+	if o.icsPath != "" && o.mockPath != "" {
+		return false, errors.New(printer.Sprintf("-ics と -mock は同時に指定できません。"))
+	}
+	if o.minDuration < 0 || o.maxDuration < 0 {
+		return false, errors.New(printer.Sprintf("-min-duration と -max-duration には0以上の長さを指定してください。"))
+	}
+	if o.maxDuration > 0 && o.minDuration > o.maxDuration {
+		return false, errors.New(printer.Sprintf("-min-duration が -max-duration より長くなっています。"))
+	}
+	if o.capDuration < 0 {
+		return false, errors.New(printer.Sprintf("-cap-duration には0以上の長さを指定してください。"))
+	}
+	if o.dailyCap < 0 {
+		return false, errors.New(printer.Sprintf("-daily-cap には0以上の長さを指定してください。"))
+	}
+	if !isValidOutlierMode(o.outliers) {
+		return false, errors.New(printer.Sprintf("外れ値の扱い '%s' はサポートされていません（%s）。", o.outliers, strings.Join(outlierModes, ", ")))
+	}
+	if o.onlyWithMeet && o.withoutMeet {
+		return false, errors.New(printer.Sprintf("-only-with-meet と -without-meet は同時に指定できません。"))
+	}
+	if o.strict && o.ignoreEmoji {
+		return false, errors.New(printer.Sprintf("-strict と -ignore-emoji は同時に指定できません。"))
+	}
+	if o.calendarTimezone && o.zones == nil {
+		o.zones = newEventZones()
+	}
+	if o.anonymize && o.anonymizer == nil {
+		if o.anonymizer, err = newAnonymizer(); err != nil {
+			return false, err
+		}
+	}
+	if err := setDurationFormat(o.durationFormat); err != nil {
+		return false, err
+	}
+	if !isValidGroupBy(o.groupBy) {
+		return false, errors.New(printer.Sprintf("グループ化単位 '%s' はサポートされていません（%s）。", o.groupBy, strings.Join(groupByOptions, ", ")))
+	}
+
+	for alias, names := range o.aliases {
+		if len(names) == 0 || slices.Contains(names, "") {
+			return false, errors.New(printer.Sprintf("別名 '%s' のイベント名を指定してください。", alias))
+		}
+	}
+	switch o.match {
+	case "exact", "contains":
+	case "regex":
+		if o.name == "" {
+			break
+		}
+		o.namePatterns = nil
+		for _, name := range o.nameAlternatives() {
+			p, err := regexp.Compile(name)
+			if err != nil {
+				return false, errors.New(printer.Sprintf("イベント名の正規表現が不正です: %v", err))
+			}
+			o.namePatterns = append(o.namePatterns, p)
+		}
+	default:
+		return false, errors.New(printer.Sprintf("比較方法 '%s' はサポートされていません（exact, contains, regex）。", o.match))
+	}
+	if o.descriptionRegex != "" {
+		if o.descriptionPattern, err = regexp.Compile(o.descriptionRegex); err != nil {
+			return false, errors.New(printer.Sprintf("説明の正規表現が不正です: %v", err))
+		}
+	}
+
+	if o.schedule, err = newWorkSchedule(o.workHours, o.workdays); err != nil {
+		return false, err
+	}
+
+	if o.projectsPath != "" {
+		if o.projects, err = loadProjectRules(o.projectsPath); err != nil {
+			return false, err
+		}
+	}
+	for i := range o.projects {
+		if err := o.projects[i].parse(); err != nil {
+			return false, err
+		}
+	}
+	// 説明による分割を行う場合は、分割の指定に書かれた名前をプロジェクトとして集計できる
+	if (o.project != "" || (o.groupBy == "project" && !o.splitMarkers)) && len(o.projects) == 0 {
+		return false, errors.New(printer.Sprintf("プロジェクトの対応付けを -projects または設定ファイルの projects で指定してください。"))
+	}
+
+	if o.noDeductions {
+		o.deductions = nil
+	}
+	for i := range o.deductions {
+		if err := o.deductions[i].parse(); err != nil {
+			return false, err
+		}
+	}
+
+	o.buildExcludeSet()
+
+	if o.targetDuration, err = targetFor(o.target, o.targets, o.name); err != nil {
+		return false, err
+	}
+	if o.backToBackGap < 0 {
+		return false, errors.New(printer.Sprintf("-back-to-back-gap には0以上の長さを指定してください。"))
+	}
+	if o.overtime {
+		if o.overtimeRules, err = o.overtimeConfig.parse(); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// registerDateRange は検索期間に関するフラグを登録する
+func (o *queryOptions) registerDateRange(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&o.startDate, "start", "", "開始日（YYYY-MM-DD形式、または today、yesterday、3 days ago、last monday などの表現）")
+	fs.StringVar(&o.endDate, "end", "", "終了日（YYYY-MM-DD形式、または today などの表現）")
+	fs.StringVar(&o.month, "month", "", "月指定（YYYY-MM形式）")
+	fs.StringVar(&o.months, "months", "", "月の範囲（YYYY-MM..YYYY-MM形式）。月ごとに集計して比較表を表示する")
+	fs.StringVar(&o.period, "period", "", "期間の表現（past 30 days、last week、this month、開始..終了 など）")
+	fs.StringVar(&o.week, "week", "", "週指定（ISO週番号、YYYY-Www形式、例: 2024-W23）")
+	fs.BoolVar(&o.thisWeek, "this-week", false, "今週を集計")
+	fs.BoolVar(&o.lastWeek, "last-week", false, "先週を集計")
+	fs.StringVar(&o.weekStart, "week-start", cfg.WeekStart, "週の初日（monday, sunday など、デフォルトは設定ファイルの値または monday）")
+}
+
+// checkDateRange は検索期間の指定を検証し、週の初日を設定する
+// 期間が指定されていない場合は、使用方法を表示すべきことを showUsage で示す
+func (o *queryOptions) checkDateRange() (showUsage bool, err error) {
+	if err := setWeekStart(o.weekStart); err != nil {
+		return false, err
+	}
+	selectors := 0
+	for _, set := range []bool{o.month != "", o.months != "", o.period != "", o.week != "", o.thisWeek, o.lastWeek, o.startDate != "" || o.endDate != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors > 1 {
+		return false, errors.New(printer.Sprintf("-month、-months、-week、-this-week、-last-week、-period、-start と -end のうち、いずれか1つだけを指定してください。"))
+	}
+	if selectors == 0 || (o.startDate != "") != (o.endDate != "") {
+		return true, errors.New(printer.Sprintf("日付範囲を指定してください。"))
+	}
+	return false, nil
+}
+
+// dateRange はオプションから検索期間の開始日と終了日を求める
+// -this-week、-last-week や、-start、-end、-period の相対的な表現は、現在の日付を基準にする
+func (o *queryOptions) dateRange(location *time.Location) (time.Time, time.Time) {
+	// month引数が指定されている場合は、その月の初日と末日を計算
+	if o.month != "" {
+		startDate, endDate, err := getMonthDates(o.month, location)
+		if err != nil {
+			fatalCode(exitUsage, "月指定の解析に失敗しました", "error", err)
+		}
+		return startDate, endDate
+	}
+
+	// months引数が指定されている場合は、最初の月の初日から最後の月の末日まで
+	if o.months != "" {
+		months, err := parseMonthRange(o.months, location)
+		if err != nil {
+			fatalCode(exitUsage, "月の範囲の解析に失敗しました", "error", err)
+		}
+		return months[0], months[len(months)-1].AddDate(0, 1, -1)
+	}
+
+	if o.week != "" {
+		startDate, endDate, err := getWeekDates(o.week, location)
+		if err != nil {
+			fatalCode(exitUsage, "週指定の解析に失敗しました", "error", err)
+		}
+		return startDate, endDate
+	}
+
+	now := time.Now().In(location)
+	if o.thisWeek || o.lastWeek {
+		startDate := startOfWeek(startOfDay(now))
+		if o.lastWeek {
+			startDate = startDate.AddDate(0, 0, -7)
+		}
+		return startDate, startDate.AddDate(0, 0, 6)
+	}
+	if o.period != "" {
+		startDate, endDate, err := parsePeriodExpr(o.period, now)
+		if err != nil {
+			fatalCode(exitUsage, "期間の解析に失敗しました", "error", err)
+		}
+		return startDate, endDate
+	}
+
+	// startとendが両方指定されている場合はそれらを使用
+	startDate, err := parseDateExpr(o.startDate, now)
+	if err != nil {
+		fatalCode(exitUsage, "開始日の解析に失敗しました", "error", err)
+	}
+
+	endDate, err := parseDateExpr(o.endDate, now)
+	if err != nil {
+		fatalCode(exitUsage, "終了日の解析に失敗しました", "error", err)
+	}
+	if endDate.Before(startDate) {
+		fatalCode(exitUsage, "終了日が開始日より前になっています", "start", startDate.Format("2006-01-02"), "end", endDate.Format("2006-01-02"))
+	}
+	return startDate, endDate
+}
+
+// newSource はイベントの取得元を生成する。ICSファイルやフィクスチャを使用する場合は認証を行わない
+func (o *queryOptions) newSource(ctx context.Context, cfg *Config) CalendarSource {
+	if o.icsPath != "" || o.mockPath != "" {
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+		}
+		if o.icsPath != "" {
+			return &icsSource{path: o.icsPath, location: location}
+		}
+		return &mockSource{path: o.mockPath, location: location}
+	}
+	return &googleSource{srv: newCalendarService(ctx, cfg.CredentialsPath, cfg.TokenPath)}
+}
+
+// eventQuery はイベントの取得元に渡す取得条件を返す
+func (o *queryOptions) eventQuery() eventQuery {
+	return eventQuery{Text: o.serverQuery(), ShowDeleted: o.includeCancelled}
+}
+
+// calendarIDs は集計対象のカレンダーIDの一覧を返す（カンマ区切りで複数指定可能）
+func (o *queryOptions) calendarIDs() []string {
+	var ids []string
+	for _, id := range strings.Split(o.calendarID, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// planFetch はカレンダーごとに期間内のイベントを取得する処理の一覧を作成する
+// 通常は並行に取得できるように期間を月ごとに分割するが、ローカルストアはカレンダー全体を同期するため分割しない
+// 月ごとに集計する場合（-group-by=month や -months）は、月ごとに振り分けるのは手元で行えるため、期間全体を1回でまとめて取得する
+// （APIの呼び出しはカレンダーごとのページ数だけになり、イベントの少ない月ごとに呼び出すことがない）
+func (o *queryOptions) planFetch(ids []string, location *time.Location, startDate, searchEndDate time.Time) []fetchTask {
+	shared := o.sync || o.sharedWindow()
+	var tasks []fetchTask
+	for _, id := range ids {
+		if shared {
+			tasks = append(tasks, fetchTask{calendarID: id, start: startDate, end: searchEndDate})
+			continue
+		}
+		tasks = append(tasks, splitRange(id, startDate, searchEndDate, location)...)
+	}
+	slog.Debug("イベントを取得する期間を決めました", "tasks", len(tasks), "shared", shared)
+	return tasks
+}
+
+// sharedWindow は期間全体を1回でまとめて取得し、月ごとに振り分けて集計するかどうかを判定する
+func (o *queryOptions) sharedWindow() bool {
+	return o.groupBy == "month" || o.months != ""
+}
+
+// fetchEvents は期間内のイベントを取得元の指定されたすべてのカレンダーから取得する
+// カレンダーごと・月ごとに分けて並行に取得し、いずれかが失敗した場合は残りの取得を中止する
+func (o *queryOptions) fetchEvents(ctx context.Context, src CalendarSource, cfg *Config, location *time.Location, startDate, searchEndDate time.Time) []*calendar.Event {
+	// ICSファイルやフィクスチャは、期間を分割せずにまとめて読み込む
+	if isLocalSource(src) {
+		items, err := src.Events(ctx, "", startDate, searchEndDate, o.eventQuery())
+		if err != nil {
+			fatal("イベントの読み込みに失敗しました", "error", err)
+		}
+		slog.Debug("ファイルからイベントを読み込みました", "source", o.sourceName(), "count", len(items))
+		o.zones.load(ctx, src, []string{""})
+		o.zones.record("", items...)
+		return items
+	}
+
+	ids := o.calendarIDs()
+	o.zones.load(ctx, src, ids)
+	tasks := o.planFetch(ids, location, startDate, searchEndDate)
+
+	// -resume の場合は、前回の実行で取得が完了した分をチェックポイントから読み込む
+	checkpoint, err := loadCheckpoint(o.checkpointPath(cfg.CacheDir, startDate, searchEndDate))
+	if err != nil {
+		fatal("チェックポイントの読み込みに失敗しました", "error", err)
+	}
+	if !o.resume {
+		checkpoint.Completed = make(map[string][]*calendar.Event)
+	}
+	var pending []fetchTask
+	for _, t := range tasks {
+		if _, ok := checkpoint.Completed[t.key()]; !ok {
+			pending = append(pending, t)
+		}
+	}
+	if o.resume && len(pending) < len(tasks) {
+		slog.Info("前回の実行で取得済みの期間を再利用します", "completed", len(tasks)-len(pending), "total", len(tasks))
+	}
+
+	// 取得に時間がかかる場合は、取得が完了した期間の数や取得したページ数を表示する
+	p := startProgress(len(pending))
+	fetched, err := runFetchTasks(withProgress(ctx, p), pending, o.concurrency, func(ctx context.Context, t fetchTask) ([]*calendar.Event, error) {
+		items, err := o.fetchCalendarEvents(ctx, src, cfg, t.calendarID, location, t.start, t.end)
+		p.taskDone()
+		return items, err
+	})
+	p.finish()
+	for i, t := range pending {
+		if fetched[i] != nil {
+			checkpoint.Completed[t.key()] = fetched[i]
+		}
+	}
+	if err != nil {
+		// 割り当て上限に達した場合は、取得が完了した分を保存して -resume で続きから取得できるようにする
+		if isQuotaError(err) {
+			o.reportQuotaExhausted(checkpoint, tasks, err)
+		}
+		fatalAPI("イベントの取得に失敗しました", contextError(ctx, err))
+	}
+	if err := checkpoint.remove(); err != nil {
+		slog.Warn("チェックポイントの削除に失敗しました", "error", err)
+	}
+	results := make([][]*calendar.Event, len(tasks))
+	for i, t := range tasks {
+		results[i] = checkpoint.Completed[t.key()]
+	}
+
+	// 分割した期間の境界をまたぐイベントは両方の期間で取得されるため、カレンダーごとに1件にまとめる
+	var items []*calendar.Event
+	seen := make(map[string]bool)
+	for i, t := range tasks {
+		for _, item := range results[i] {
+			key := t.calendarID + "\n" + item.Id
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			items = append(items, item)
+			o.zones.record(t.calendarID, item)
+		}
+	}
+
+	// 複数のカレンダーに含まれる同じイベントの重複を取り除く
+	if len(ids) > 1 && !o.noDedupe {
+		items = dedupeEvents(items)
+	}
+	sortEventsByStart(items)
+	return items
+}
+
+// reportQuotaExhausted はAPIの割り当て上限に達した時点までの進み具合を表示し、チェックポイントを保存して終了する
+func (o *queryOptions) reportQuotaExhausted(checkpoint *fetchCheckpoint, tasks []fetchTask, err error) {
+	slog.Error("APIの割り当て上限に達したため、イベントの取得を中断しました", "error", err,
+		"completed", len(checkpoint.Completed), "total", len(tasks))
+	for _, t := range tasks {
+		if _, ok := checkpoint.Completed[t.key()]; !ok {
+			slog.Warn("未取得の期間", "calendar", t.calendarID,
+				"start", t.start.Format("2006/01/02"), "end", t.end.AddDate(0, 0, -1).Format("2006/01/02"))
+		}
+	}
+	if err := checkpoint.save(); err != nil {
+		fatal("チェックポイントの保存に失敗しました", "error", err)
+	}
+	fatalCode(exitAPI, "割り当てが回復した後に同じ条件に -resume を付けて実行すると、続きから取得します")
+}
+
+// fetchCalendarEvents は1つのカレンダーから期間内のイベントをローカルストア、キャッシュ、または取得元から取得する
+func (o *queryOptions) fetchCalendarEvents(ctx context.Context, src CalendarSource, cfg *Config, calendarID string, location *time.Location, startDate, searchEndDate time.Time) ([]*calendar.Event, error) {
+	// ローカルストアを差分同期して、その中から期間内のイベントを取り出す
+	if o.sync {
+		gs, ok := src.(*googleSource)
+		if !ok {
+			return nil, errors.New(printer.Sprintf("ローカルストアへの同期はGoogle Calendarでのみ使用できます"))
+		}
+		store, err := syncStore(ctx, gs.srv, cfg.StoreDir, calendarID)
+		if err != nil {
+			return nil, err
+		}
+		// 終日イベントは、カレンダーのタイムゾーンの日付として期間と重なるかを判定する
+		return store.eventsInRange(o.zones.calendar(calendarID, location), startDate, searchEndDate), nil
+	}
+
+	timeMin := startDate.Format(time.RFC3339)
+	timeMax := searchEndDate.Format(time.RFC3339)
+
+	// 同じカレンダー・期間の取得結果がキャッシュにあればそれを使用する
+	// キャッシュにはキャンセルされたイベントが含まれないため、それらを含める場合は使用しない
+	cache := &eventCache{dir: cfg.CacheDir, ttl: o.cacheTTL}
+	useCache := !o.noCache && !o.includeCancelled
+	q := o.eventQuery()
+	if useCache {
+		if items, ok := cache.load(calendarID, q.Text, timeMin, timeMax); ok {
+			slog.Debug("キャッシュからイベントを読み込みました", "calendar", calendarID, "start", timeMin, "end", timeMax, "count", len(items))
+			return items, nil
+		}
+	}
+
+	items, err := src.Events(ctx, calendarID, startDate, searchEndDate, q)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", printer.Sprintf("カレンダー %s", calendarID), err)
+	}
+
+	slog.Debug("APIからイベントを取得しました", "calendar", calendarID, "start", timeMin, "end", timeMax, "query", q.Text, "count", len(items))
+	if useCache {
+		if err := cache.save(calendarID, q.Text, timeMin, timeMax, items); err != nil {
+			slog.Warn("キャッシュの保存に失敗しました", "error", err)
+		}
+	}
+	return items, nil
+}
+
+// collectReport は期間内のイベントを取得して集計する
+// イベント一覧を表示しない場合で、すべてのイベントを必要とするオプションがなければ、イベント一覧を保持せずに逐次集計する
+func (o *queryOptions) collectReport(ctx context.Context, src CalendarSource, cfg *Config, location *time.Location, startDate, endDate time.Time) *Report {
+	if o.canStream() {
+		return o.streamReport(ctx, src, location, startDate, endDate)
+	}
+	started := time.Now()
+	items := o.fetchEvents(ctx, src, cfg, location, startDate, endDate.AddDate(0, 0, 1))
+	fetched := time.Now()
+	report := buildReport(items, o, startDate, endDate, location)
+	slog.Debug("イベントの取得と集計にかかった時間", "fetch", fetched.Sub(started).Round(time.Millisecond),
+		"compute", time.Since(fetched).Round(time.Millisecond), "events", len(items), "matched", report.count())
+	return report
+}
+
+// canStream はイベント一覧を保持せずに逐次集計できるかどうかを判定する
+// 重複の検出、外れ値の除外、確認、手動調整、延べ時間、残業時間、予定の切り替え、休憩イベントの控除、1日あたりの上限は集計したすべてのイベントを使用するため、逐次集計しない
+// ローカルストアへの同期や -resume は取得した期間ごとにイベントを保存するため、逐次集計しない
+func (o *queryOptions) canStream() bool {
+	if !o.noList || o.sync || o.resume || o.detectOverlaps || o.subtractOverlaps || o.outliers == "exclude" ||
+		o.interactive || o.adjustmentsPath != "" || o.personHours || o.overtime || o.contextSwitches || o.dailyCap > 0 {
+		return false
+	}
+	for _, rule := range o.deductions {
+		if rule.Type == "event" {
+			return false
+		}
+	}
+	return true
+}
+
+// streamReport はカレンダーごとにイベントを1ページずつ取得しながら集計する（キャッシュは使用しない）
+func (o *queryOptions) streamReport(ctx context.Context, src CalendarSource, location *time.Location, startDate, endDate time.Time) *Report {
+	b := newReportBuilder(o, startDate, endDate, location, false)
+	o.streamEvents(ctx, src, startDate, endDate.AddDate(0, 0, 1), b.add)
+	return b.finish()
+}
+
+// streamEvents はカレンダーごとに期間内のイベントを1ページずつ取得し、1件ずつ f に渡す
+// 期間を分割せずに取得するため、同じカレンダーのイベントが重複することはなく、複数のカレンダーの間の重複だけを取り除く
+func (o *queryOptions) streamEvents(ctx context.Context, src CalendarSource, startDate, searchEndDate time.Time, f func(*calendar.Event)) {
+	ids := o.calendarIDs()
+	if isLocalSource(src) {
+		ids = []string{""}
+	}
+	dedupe := len(ids) > 1 && !o.noDedupe
+	seen := make(map[string]bool)
+	o.zones.load(ctx, src, ids)
+
+	slog.Debug("イベント一覧を保持せずに逐次集計します", "calendars", len(ids))
+	started := time.Now()
+	// 取得しながら集計するため、集計にかかった時間を足し合わせ、残りを取得にかかった時間とする
+	var compute time.Duration
+	events := 0
+	p := startProgress(len(ids))
+	pctx := withProgress(ctx, p)
+	for _, id := range ids {
+		err := eachEvent(pctx, src, id, startDate, searchEndDate, o.eventQuery(), func(item *calendar.Event) {
+			t := time.Now()
+			defer func() { compute += time.Since(t) }()
+			events++
+			if dedupe {
+				key := dedupeKey(item)
+				if seen[key] {
+					return
+				}
+				seen[key] = true
+			}
+			// イベント一覧を保持しないため、タイムゾーンの記録も集計に加えた後に取り除く
+			o.zones.record(id, item)
+			f(item)
+			o.zones.forget(item)
+		})
+		p.taskDone()
+		if err != nil {
+			p.finish()
+			if isLocalSource(src) {
+				fatal("イベントの読み込みに失敗しました", "error", err)
+			}
+			fatalAPI("イベントの取得に失敗しました", contextError(ctx, fmt.Errorf("%s: %w", printer.Sprintf("カレンダー %s", id), err)))
+		}
+	}
+	p.finish()
+	elapsed := time.Since(started)
+	slog.Debug("イベントの取得と集計にかかった時間", "fetch", (elapsed - compute).Round(time.Millisecond),
+		"compute", compute.Round(time.Millisecond), "events", events)
+}
+
+// runQuery は取得元からイベントを取得して集計する
+func runQuery(ctx context.Context, src CalendarSource, cfg *Config, o *queryOptions) *Report {
+	// 日付文字列をTime型に変換
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	startDate, endDate := o.dateRange(location)
+
+	// endDateに対しては検索時に「終日」を含めるために1日追加する
+	searchEndDate := endDate.AddDate(0, 0, 1)
+
+	// 勤務日の指定や稼働率の計算では祝日を除外する
+	holidays := o.loadHolidays(ctx, src, startDate, searchEndDate)
+
+	report := o.collectReport(ctx, src, cfg, location, startDate, endDate)
+	report.Holidays = holidays
+
+	return o.completeReport(ctx, src, cfg, report)
+}
+
+// completeReport は集計結果に対して、確認、手動調整、履歴の保存、目標・稼働率・延べ時間の計算と比較期間の集計を行う
+func (o *queryOptions) completeReport(ctx context.Context, src CalendarSource, cfg *Config, report *Report) *Report {
+	location, startDate, endDate, holidays := report.Location, report.StartDate, report.EndDate, report.Holidays
+
+	// 履歴の保存や目標・稼働率の計算の前に、集計に含めるイベントを確認する
+	if o.interactive {
+		confirmEvents(ctx, report, stdinLines(), os.Stderr)
+	}
+
+	// 調整ファイルの手動調整を加える（確認の対象にはしない）
+	if o.adjustmentsPath != "" {
+		adjustments, err := loadAdjustments(o.adjustmentsPath, location)
+		if err != nil {
+			fatalCode(exitUsage, "調整ファイルの読み込みに失敗しました", "error", err)
+		}
+		applyAdjustments(report, adjustments, o)
+	}
+
+	// 集計結果を履歴に保存する
+	if !o.noHistory {
+		if err := appendHistory(cfg.HistoryPath, o.sourceName(), report); err != nil {
+			slog.Warn("履歴の保存に失敗しました", "error", err)
+		}
+	}
+
+	if o.targetDuration > 0 {
+		report.Target = computeTarget(report, o.targetDuration, time.Now())
+	}
+
+	// 稼働率の計算（祝日は稼働日から除く）
+	if o.utilization {
+		workdays := defaultWorkdays
+		if o.schedule != nil && o.workdays != "" {
+			workdays = o.schedule.days
+		}
+		report.Utilization = computeUtilization(report, workdays, holidays, o.hoursPerDay)
+	}
+
+	// 所定労働時間に対する残業時間の計算（稼働率と同じく、勤務日以外と祝日は所定労働時間を0とする）
+	if o.overtime {
+		workdays := defaultWorkdays
+		if o.schedule != nil && o.workdays != "" {
+			workdays = o.schedule.days
+		}
+		report.Overtime = computeOvertime(report, o.overtimeRules, workdays, holidays)
+	}
+
+	// 会議にかかっている組織としての時間（延べ時間）の計算
+	if o.personHours {
+		report.PersonHours = computePersonHours(report)
+	}
+
+	// 予定の切り替えの回数と、予定の間隔の計算
+	if o.contextSwitches {
+		report.ContextSwitches = computeContextSwitches(report, o.backToBackGap)
+	}
+
+	// 比較期間が指定されている場合は、その期間も集計する
+	compareStart, compareEnd, ok, err := o.comparisonRange(startDate, endDate, location)
+	if err != nil {
+		fatalCode(exitUsage, "比較期間の解析に失敗しました", "error", err)
+	}
+	if ok {
+		o.loadHolidays(ctx, src, compareStart, compareEnd.AddDate(0, 0, 1))
+		report.Comparison = o.collectReport(ctx, src, cfg, location, compareStart, compareEnd)
+	}
+
+	// 履歴には元のイベント名で保存し、表示・書き出し・配信する集計結果だけを仮名にする
+	if o.anonymize {
+		o.anonymizeReport(report)
+	}
+	return report
+}
+
+// loadHolidays は勤務日の指定または稼働率の計算が必要な場合に、祝日カレンダーから期間内の祝日を取得する
+// 取得した祝日は勤務時間帯の判定にも反映する
+func (o *queryOptions) loadHolidays(ctx context.Context, src CalendarSource, startDate, searchEndDate time.Time) map[string]bool {
+	if o.noHolidays || o.holidayCalendar == "" || (!o.utilization && !o.overtime && o.workdays == "") {
+		return nil
+	}
+	if isLocalSource(src) {
+		slog.Warn("ICSファイルやフィクスチャの集計では祝日カレンダーを使用できないため、祝日を考慮せずに計算します")
+		return nil
+	}
+	holidays, err := fetchHolidays(ctx, src, o.holidayCalendar, startDate, searchEndDate)
+	if err != nil {
+		fatalAPI("祝日カレンダーの取得に失敗しました", contextError(ctx, err))
+	}
+	if o.schedule != nil && o.workdays != "" {
+		o.schedule.holidays = holidays
+	}
+	return holidays
+}
+
+// sourceName は集計対象のカレンダーを表す名前を返す
+func (o *queryOptions) sourceName() string {
+	if o.icsPath != "" {
+		return "ics:" + o.icsPath
+	}
+	if o.mockPath != "" {
+		return "mock:" + o.mockPath
+	}
+	return o.calendarID
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Calendar APIへのリクエストの1秒あたりの上限と、連続して送信できるリクエストの数のデフォルト値
+// Calendar APIの利用者ごとの割り当て（デフォルトでは1分あたり600リクエスト）を超えないように、余裕を持たせた値にする
+const (
+	defaultRateLimit = 5.0
+	defaultRateBurst = 10
+)
+
+// apiRateLimit と apiRateBurst は -rate-limit と -rate-burst で指定された、利用者ごとのリクエストの上限
+var (
+	apiRateLimit = defaultRateLimit
+	apiRateBurst = defaultRateBurst
+)
+
+// rateOptions はAPIへのリクエストの頻度の制限に関する共通オプション
+type rateOptions struct {
+	limit float64
+	burst int
+}
+
+// register はリクエストの頻度の制限に関するフラグを登録する
+func (r *rateOptions) register(fs *flag.FlagSet) {
+	fs.Float64Var(&r.limit, "rate-limit", defaultRateLimit, "Calendar APIへのリクエストの1秒あたりの上限（利用者ごと）。0の場合は制限しない")
+	fs.IntVar(&r.burst, "rate-burst", defaultRateBurst, "Calendar APIに連続して送信できるリクエストの数")
+}
+
+// setup はオプションを検証し、リクエストの上限を設定する
+func (r *rateOptions) setup() {
+	if r.limit < 0 || r.burst < 1 {
+		printer.Printf("エラー: -rate-limit には0以上、-rate-burst には1以上の値を指定してください。\n")
+		os.Exit(exitUsage)
+	}
+	apiRateLimit, apiRateBurst = r.limit, r.burst
+}
+
+// rateLimiter はトークンバケット方式でリクエストの頻度を制限する（複数のゴルーチンから同時に使用できる）
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter は1秒あたり rate 回、最大 burst 回まで連続したリクエストを許可する制限を生成する
+// rate が0の場合は制限しないため、nilを返す
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait はリクエストを送信できるまで待つ。待つ間にコンテキストが終了した場合はエラーを返す
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// 先にトークンを予約し、足りない分が補充されるまで待つ（待っている間に来たリクエストはさらに後ろに並ぶ）
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	slog.Log(ctx, levelTrace, "リクエストの上限に達したため待機します", "wait", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// rateLimitedTransport はリクエストの送信前に頻度の制限を待つ http.RoundTripper
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+// RoundTrip は頻度の制限を待ってからリクエストを送信する
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// withRateLimit はHTTPクライアントのリクエストを、-rate-limit と -rate-burst の上限に従って制限する
+// 割り当ては利用者ごとのため、利用者（認証情報）ごとに1つのクライアントに対して呼び出す
+func withRateLimit(client *http.Client) *http.Client {
+	limiter := newRateLimiter(apiRateLimit, apiRateBurst)
+	if limiter == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	limited := *client
+	limited.Transport = &rateLimitedTransport{base: base, limiter: limiter}
+	return &limited
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// MatchedEvent は集計対象となったイベントを表す
+type MatchedEvent struct {
+	Event    *calendar.Event
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+
+	// 控除ルールによって差し引いた時間
+	Deducted time.Duration
+
+	// -cap-duration によって切り詰めた時間
+	Capped time.Duration
+
+	// -daily-cap によって、1日の上限を超えたため切り詰めた時間
+	DailyCapped time.Duration
+
+	// 調整ファイルによる手動調整かどうか（Duration が負の場合は合計から差し引く）
+	Manual bool
+
+	// イベント名から対応付けたプロジェクト（対応付けがない場合は空文字）
+	Project string
+
+	// 説明の記述に従ってプロジェクトごとに分割した時間（-split-markers で分割の指定がある場合のみ）
+	Splits []Split
+
+	// 日ごとに振り分けるタイムゾーン（-calendar-timezone で取得元のカレンダーのタイムゾーンを使用する場合のみ）
+	Location *time.Location
+}
+
+// GroupTotal はグループごとの集計結果を表す
+type GroupTotal struct {
+	Key      string
+	Count    int
+	Duration time.Duration
+}
+
+// Report は集計結果全体を表す
+type Report struct {
+	Name      string
+	StartDate time.Time
+	EndDate   time.Time
+	Location  *time.Location
+	Total     time.Duration
+	Events    []MatchedEvent
+	Stats     Stats
+	GroupBy   string
+	Groups    []GroupTotal
+
+	// 重複している時間帯と、合計時間から差し引いた重複時間
+	OverlapsChecked bool
+	Overlaps        []Overlap
+	OverlapDeducted time.Duration
+
+	// 控除ルールによって差し引いた時間の合計
+	Deducted time.Duration
+
+	// -cap-duration によって切り詰めた時間の合計
+	Capped time.Duration
+
+	// -daily-cap で指定した1日あたりの上限と、上限を超えた日（上限を指定しない場合は0とnil）
+	DailyCap   time.Duration
+	CappedDays []CappedDay
+
+	// -exclude-id や設定ファイルの除外リストにより集計から除外したイベントの件数
+	IDExcluded int
+
+	// 一致したイベント一覧にイベントのIDを表示するかどうか（-show-ids）
+	ShowIDs bool
+
+	// -interactive の確認で集計から除外したイベント
+	Excluded []MatchedEvent
+
+	// 長さが外れ値となっているイベントと、それらを集計から除外したかどうか
+	Outliers         []Outlier
+	OutliersExcluded bool
+
+	// 目標時間に対する進捗（目標が指定されていない場合はnil）
+	Target *TargetProgress
+
+	// 稼働可能時間に対する割合（-utilization が指定されていない場合はnil）
+	Utilization *Utilization
+
+	// 参加者の人数を掛けた延べ時間（-person-hours が指定されていない場合はnil）
+	PersonHours *PersonHours
+
+	// 所定労働時間に対する残業時間（-overtime が指定されていない場合はnil）
+	Overtime *Overtime
+
+	// 予定の切り替えの回数と予定の間隔（-context-switches が指定されていない場合はnil）
+	ContextSwitches *ContextSwitches
+
+	// 期間内の祝日（勤務日の指定または稼働率の計算を行った場合のみ）
+	Holidays map[string]bool
+
+	// 比較期間の集計結果（比較が指定されていない場合はnil）
+	Comparison *Report
+
+	// 一致したイベント一覧に表示する件数（0の場合はすべて表示する）
+	ListLimit int
+
+	// 一致したイベント一覧を表示しないかどうか（-no-list）
+	NoList bool
+
+	// イベント一覧を保持せずに逐次集計したイベントの件数と、そのうち集計時点までに始まったイベントの時間の合計
+	// （-no-list で逐次集計した場合のみ。目標の達成見込みの計算に使用する）
+	Streamed       int
+	StreamedToDate time.Duration
+}
+
+// count は一致したイベントの件数を返す（逐次集計したイベントも含む）
+func (r *Report) count() int {
+	return len(r.Events) + r.Streamed
+}
+
+// share は時間が合計時間に占める割合（%）を返す
+// タグや参加者によるグループでは1件のイベントが複数のグループに含まれるため、グループの割合の合計は100%を超えることがある
+func (r *Report) share(d time.Duration) float64 {
+	if r.Total <= 0 {
+		return 0
+	}
+	return float64(d) / float64(r.Total) * 100
+}
+
+// listedEvents は一致したイベント一覧に表示するイベントと、表示しないイベントの件数を返す
+func (r *Report) listedEvents() ([]MatchedEvent, int) {
+	if r.ListLimit <= 0 || len(r.Events) <= r.ListLimit {
+		return r.Events, 0
+	}
+	return r.Events[:r.ListLimit], len(r.Events) - r.ListLimit
+}
+
+// 利用可能なグループ化の単位
+var groupByOptions = []string{"day", "week", "month", "name", "tag", "attendee", "organizer", "recurrence", "project"}
+
+// isValidGroupBy はグループ化の単位が有効かどうかを判定する
+func isValidGroupBy(groupBy string) bool {
+	if groupBy == "" {
+		return true
+	}
+	for _, opt := range groupByOptions {
+		if groupBy == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKeys はイベントが属するグループのキーを返す（タグや参加者によるグループでは複数のグループに属することがある）
+func groupKeys(e MatchedEvent, groupBy string, location *time.Location) []string {
+	start := e.localStart(location)
+	switch groupBy {
+	case "day":
+		return []string{start.Format("2006/01/02")}
+	case "week":
+		// 週の初日をキーにする
+		day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location)
+		return []string{startOfWeek(day).Format("2006/01/02") + "週"}
+	case "month":
+		return []string{start.Format("2006/01")}
+	case "name":
+		return []string{e.Event.Summary}
+	case "tag":
+		if tags := extractTags(e.Event); len(tags) > 0 {
+			return tags
+		}
+		return []string{noTagLabel}
+	case "attendee":
+		if keys := attendeeKeys(e.Event); len(keys) > 0 {
+			return keys
+		}
+		return []string{noAttendeeLabel}
+	case "organizer":
+		return []string{organizerKey(e.Event)}
+	case "recurrence":
+		return []string{e.Event.Summary}
+	case "project":
+		if e.Project != "" {
+			return []string{e.Project}
+		}
+		return []string{noProjectLabel}
+	}
+	return nil
+}
+
+// buildReport はイベント一覧から条件に一致するイベントを抽出し、集計結果を作成する
+func buildReport(items []*calendar.Event, o *queryOptions, startDate, endDate time.Time, location *time.Location) *Report {
+	b := newReportBuilder(o, startDate, endDate, location, true)
+	b.items = items
+	for _, item := range items {
+		b.add(item)
+	}
+	r := b.r
+
+	// 長さが外れ値のイベントの検出と、集計からの除外
+	if o.outliers != "off" {
+		r.Outliers = findOutliers(r.Events)
+		if o.outliers == "exclude" && len(r.Outliers) > 0 {
+			r.Events = withoutOutliers(r.Events, r.Outliers)
+			r.OutliersExcluded = true
+		}
+	}
+
+	// 重複の検出と、重複している時間の差し引き
+	if o.detectOverlaps {
+		others := r.Events
+		if o.overlapAll {
+			others = timedEvents(items)
+		}
+		r.OverlapsChecked = true
+		r.Overlaps = findOverlaps(r.Events, others)
+	}
+	if o.subtractOverlaps {
+		// 期間の境界をまたぐイベントは、集計した期間内の部分同士で重複を判定する
+		var periodStart, periodEnd time.Time
+		if !o.noClip {
+			periodStart, periodEnd = r.StartDate, r.EndDate.AddDate(0, 0, 1)
+		}
+		r.OverlapDeducted = subtractOverlaps(r.Events, periodStart, periodEnd, o.schedule, r.Location)
+	}
+
+	// 重複などを差し引いた後の時間に、1日あたりの上限を適用する
+	if o.dailyCap > 0 {
+		r.DailyCap = o.dailyCap
+		r.CappedDays = applyDailyCap(r.Events, o.dailyCap, location)
+	}
+
+	r.recompute()
+	return r
+}
+
+// reportBuilder はイベントを1件ずつ受け取って、条件に一致するイベントを集計する
+// イベント一覧を保持しない場合（-no-list で逐次集計する場合）は、合計時間・統計・グループ別の集計だけを更新する
+type reportBuilder struct {
+	r *Report
+	o *queryOptions
+
+	// 控除ルールで休憩イベントを探す、期間内のすべてのイベント（逐次集計する場合はnil）
+	items []*calendar.Event
+
+	keepEvents bool
+	stats      statsAccumulator
+	groups     *groupAccumulator
+}
+
+// newReportBuilder は集計を開始する
+func newReportBuilder(o *queryOptions, startDate, endDate time.Time, location *time.Location, keepEvents bool) *reportBuilder {
+	b := &reportBuilder{
+		r: &Report{
+			Name:      o.label(),
+			StartDate: startDate,
+			EndDate:   endDate,
+			Location:  location,
+			GroupBy:   o.groupBy,
+		},
+		o:          o,
+		keepEvents: keepEvents,
+	}
+	if o.groupBy != "" {
+		b.groups = newGroupAccumulator(o.groupBy, location)
+	}
+	return b
+}
+
+// add はイベントが条件に一致する場合に集計に加える
+func (b *reportBuilder) add(item *calendar.Event) {
+	// 終日イベントはスキップ（開始が日付だけでも、originalStartTime と終了の日時から時刻がわかるイベントは集計する）
+	startTime, endTime, allDay, timeErr := resolveEventTimes(item, b.r.Location)
+	if timeErr == nil && allDay {
+		return
+	}
+
+	// キャンセルされたイベントや辞退したイベントは、指定がない限り集計しない
+	if b.o.isExcludedStatus(item) {
+		return
+	}
+
+	if !b.o.matches(item) {
+		return
+	}
+
+	// -exclude-id や設定ファイルの除外リストで指定されたイベントは集計しない
+	if b.o.isExcludedID(item) {
+		b.r.IDExcluded++
+		return
+	}
+
+	if timeErr != nil {
+		slog.Warn("イベントの日時の解析に失敗しました", "id", item.Id, "summary", item.Summary, "error", timeErr)
+		return
+	}
+
+	// 短いリマインダーなどを除外するため、イベント自体の長さで絞り込む（期間の境界での切り詰めや控除の前の長さで判定する）
+	if !b.o.inDurationRange(endTime.Sub(startTime)) {
+		return
+	}
+
+	// 期間の境界をまたぐイベントは、期間内の部分だけを集計する
+	countStart, countEnd := startTime, endTime
+	if !b.o.noClip {
+		countStart = later(startTime, b.r.StartDate)
+		countEnd = earlier(endTime, b.r.EndDate.AddDate(0, 0, 1))
+		if !countStart.Before(countEnd) {
+			return
+		}
+	}
+	duration := countEnd.Sub(countStart)
+
+	// 勤務時間帯が指定されている場合は、その時間帯に含まれる部分だけを集計する
+	if b.o.schedule != nil {
+		duration = b.o.schedule.overlap(countStart, countEnd, b.r.Location)
+		if duration == 0 {
+			return
+		}
+	}
+
+	// 控除ルールに従って休憩などの時間を差し引く
+	var deducted time.Duration
+	if len(b.o.deductions) > 0 {
+		deducted = deductionFor(b.o.deductions, item, countStart, countEnd, duration, b.items)
+		duration -= deducted
+	}
+
+	// 終了し忘れたイベントなどで合計が膨らまないよう、1件あたりの時間を上限までに切り詰める
+	var capped time.Duration
+	if b.o.capDuration > 0 && duration > b.o.capDuration {
+		capped = duration - b.o.capDuration
+		duration = b.o.capDuration
+	}
+
+	// 説明に「clientA: 30m / clientB: 60m」形式の行がある場合は、プロジェクトごとに時間を分割する
+	var splits []Split
+	if b.o.splitMarkers {
+		if markers := parseSplitMarkers(item.Description); len(markers) > 0 {
+			splits = splitDuration(markers, duration, b.o.projects)
+		}
+	}
+
+	b.addMatched(MatchedEvent{
+		Event:    item,
+		Start:    startTime,
+		End:      endTime,
+		Duration: duration,
+		Deducted: deducted,
+		Capped:   capped,
+		Project:  projectFor(b.o.projects, item.Summary),
+		Splits:   splits,
+		Location: b.o.zones.of(item),
+	})
+}
+
+// addMatched は条件に一致したイベントを集計に加える。イベント一覧を保持しない場合は合計時間などだけを更新する
+func (b *reportBuilder) addMatched(e MatchedEvent) {
+	if b.keepEvents {
+		b.r.Events = append(b.r.Events, e)
+		return
+	}
+	b.r.Streamed++
+	if e.Start.Before(time.Now()) {
+		b.r.StreamedToDate += e.Duration
+	}
+	b.r.Total += e.Duration
+	b.r.Deducted += e.Deducted
+	b.r.Capped += e.Capped
+	b.stats.add(e, b.r.Location)
+	if b.groups != nil {
+		b.groups.add(e)
+	}
+}
+
+// finish は逐次集計した統計とグループ別の集計を集計結果に設定して返す
+func (b *reportBuilder) finish() *Report {
+	b.r.Stats = b.stats.result()
+	if b.groups != nil {
+		b.r.Groups = b.groups.result()
+	}
+	return b.r
+}
+
+// recompute はイベントの時間から合計時間・控除時間・統計・グループ別の集計を計算し直す
+func (r *Report) recompute() {
+	r.Total, r.Deducted, r.Capped = 0, 0, 0
+	for _, e := range r.Events {
+		r.Total += e.Duration
+		r.Deducted += e.Deducted
+		r.Capped += e.Capped
+	}
+	// 統計値はカレンダーのイベントだけで求める（手動調整は含めない）
+	var calendarEvents []MatchedEvent
+	for _, e := range r.Events {
+		if !e.Manual {
+			calendarEvents = append(calendarEvents, e)
+		}
+	}
+	r.Stats = computeStats(calendarEvents, r.Location)
+	r.Groups = nil
+	if r.GroupBy != "" {
+		r.Groups = groupEvents(r.Events, r.GroupBy, r.Location)
+	}
+}
+
+// groupEvents はイベントをグループごとに集計する
+func groupEvents(events []MatchedEvent, groupBy string, location *time.Location) []GroupTotal {
+	acc := newGroupAccumulator(groupBy, location)
+	for _, e := range events {
+		acc.add(e)
+	}
+	return acc.result()
+}
+
+// groupAccumulator はイベントを1件ずつ受け取ってグループごとに集計する
+type groupAccumulator struct {
+	groupBy  string
+	location *time.Location
+	index    map[string]int
+	groups   []GroupTotal
+}
+
+// newGroupAccumulator はグループ化の単位を指定して、グループごとの集計を開始する
+func newGroupAccumulator(groupBy string, location *time.Location) *groupAccumulator {
+	return &groupAccumulator{groupBy: groupBy, location: location, index: make(map[string]int)}
+}
+
+// add はイベントを属するグループの集計に加える
+func (a *groupAccumulator) add(e MatchedEvent) {
+	keys := groupKeys(e, a.groupBy, a.location)
+	// プロジェクトごとに分割したイベントは、分割した時間をそれぞれのプロジェクトに加える
+	var durations []time.Duration
+	if a.groupBy == "project" && len(e.Splits) > 0 {
+		keys = nil
+		for _, s := range e.Splits {
+			keys = append(keys, s.Project)
+			durations = append(durations, s.Duration)
+		}
+	}
+	for k, key := range keys {
+		indexKey := key
+		switch a.groupBy {
+		case "tag":
+			// タグは大文字小文字を区別せずにまとめる
+			indexKey = strings.ToLower(key)
+		case "recurrence":
+			// 同じ繰り返しイベントの各回をまとめる（同じ名前でも別のシリーズは別の行にする）
+			indexKey = seriesKey(e.Event)
+		}
+		i, ok := a.index[indexKey]
+		if !ok {
+			i = len(a.groups)
+			a.index[indexKey] = i
+			a.groups = append(a.groups, GroupTotal{Key: key})
+		}
+		a.groups[i].Count++
+		if durations != nil {
+			a.groups[i].Duration += durations[k]
+		} else {
+			a.groups[i].Duration += e.Duration
+		}
+	}
+}
+
+// result はグループごとの集計結果を並べて返す
+// 日付によるグループはキー順、それ以外のグループは合計時間の降順に並べる
+func (a *groupAccumulator) result() []GroupTotal {
+	groups := a.groups
+	if a.groupBy != "day" && a.groupBy != "week" && a.groupBy != "month" {
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i].Duration > groups[j].Duration
+		})
+	} else {
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i].Key < groups[j].Key
+		})
+	}
+	return groups
+}
+
+// seriesKey は繰り返しイベントのシリーズを識別するキーを返す。繰り返しでないイベントは自身のIDを返す
+func seriesKey(e *calendar.Event) string {
+	if e.RecurringEventId != "" {
+		return e.RecurringEventId
+	}
+	if e.Id != "" {
+		return e.Id
+	}
+	return dedupeKey(e)
+}
+
+// dedupeKey は同じイベントを識別するためのキーを返す
+// 繰り返しイベントの各回は同じiCalUIDを持つため、開始日時も含める
+func dedupeKey(e *calendar.Event) string {
+	id := e.ICalUID
+	if id == "" {
+		id = e.Id
+	}
+	return id + "@" + eventStartKey(e)
+}
+
+// dedupeEvents は複数のカレンダーに含まれる同じイベントを1つにまとめる
+func dedupeEvents(items []*calendar.Event) []*calendar.Event {
+	seen := make(map[string]bool)
+	deduped := items[:0:0]
+	for _, item := range items {
+		key := dedupeKey(item)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, item)
+	}
+	return deduped
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// ベンチマークで生成するイベントの件数
+const benchEvents = 10000
+
+// benchScenario はイベントの絞り込みと集計のベンチマークの1つの条件
+type benchScenario struct {
+	Name string
+	// 集計の条件を指定するフラグ（期間は生成したイベントの期間を使用する）
+	Args []string
+	// 控除ルール（設定ファイルの deductions に相当する）
+	Deductions []DeductionRule
+	// イベント一覧を保持しない逐次集計（-no-list）の処理を計測する
+	Stream bool
+}
+
+// benchScenarios はベンチマークを実行する条件の一覧
+// 絞り込みの種類ごとにイベント1件あたりの処理が重くなっていないかを確認できるように、条件を分けて計測する
+var benchScenarios = []benchScenario{
+	{Name: "name", Args: []string{"-name", "定例"}},
+	{Name: "name-regex", Args: []string{"-name", "^(開発|設計)", "-match", "regex"}},
+	{Name: "tag-group", Args: []string{"-tag", "clientA", "-group-by", "tag"}},
+	{Name: "description-regex", Args: []string{"-description-regex", "JIRA-[0-9]+", "-group-by", "day"}},
+	{Name: "attendee", Args: []string{"-attendee", "alice@example.com", "-min-attendees", "2", "-group-by", "attendee"}},
+	{Name: "project-split", Args: []string{"-project", "*", "-group-by", "project", "-split-markers"}},
+	{Name: "work-hours-deduction", Args: []string{"-match", "contains", "-name", "開発", "-work-hours", "10:00-17:00"},
+		Deductions: []DeductionRule{{Type: "long_event", LongerThan: "2h", Deduct: "30m"}, {Type: "event", Name: "Lunch"}}},
+	{Name: "overlaps", Args: []string{"-match", "contains", "-name", "開発", "-detect-overlaps", "-subtract-overlaps"}},
+	{Name: "stream-tag-group", Args: []string{"-tag", "clientA", "-group-by", "tag"}, Stream: true},
+}
+
+// benchConfig はベンチマークで使用する設定（利用者の設定ファイルに依存しない結果にするため、固定の設定を使用する）
+func benchConfig() *Config {
+	return &Config{
+		Timezone: defaultTimezone,
+		Projects: []ProjectRule{
+			{Pattern: "#clientA", Project: "clientA"},
+			{Pattern: "/^設計/", Project: "design"},
+		},
+	}
+}
+
+// syntheticEvents はベンチマークに使用する n 件のイベントを生成する（同じ件数であれば毎回同じイベントになる）
+// 1日に8件ずつ、タイトル・説明・参加者・長さの異なるイベントを start の日から並べる
+func syntheticEvents(n int, start time.Time) []*calendar.Event {
+	summaries := []string{"開発 #clientA", "設計レビュー", "定例", "開発 #clientB", "Lunch", "1on1", "開発 調査 #clientA", "設計 #clientC"}
+	descriptions := []string{"", "JIRA-1234 の対応", "clientA: 30m / clientB: 30m", "", "議事録: https://example.com/doc"}
+	people := []string{"alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com"}
+	events := make([]*calendar.Event, n)
+	for i := range events {
+		day := start.AddDate(0, 0, i/8)
+		begin := day.Add(time.Duration(9+i%8) * time.Hour).Add(time.Duration(i%3*15) * time.Minute)
+		end := begin.Add(time.Duration(30+i%5*30) * time.Minute)
+		e := &calendar.Event{
+			Id:          fmt.Sprintf("bench%06d", i),
+			Summary:     summaries[i%len(summaries)],
+			Description: descriptions[i%len(descriptions)],
+			Status:      "confirmed",
+			Start:       &calendar.EventDateTime{DateTime: begin.Format(time.RFC3339)},
+			End:         &calendar.EventDateTime{DateTime: end.Format(time.RFC3339)},
+			Organizer:   &calendar.EventOrganizer{Email: people[i%3]},
+		}
+		for j := 0; j < i%4+1; j++ {
+			e.Attendees = append(e.Attendees, &calendar.EventAttendee{Email: people[(i+j)%len(people)], ResponseStatus: "accepted"})
+		}
+		if i%16 == 0 {
+			e.RecurringEventId = "benchseries"
+		}
+		events[i] = e
+	}
+	return events
+}
+
+// BenchmarkReport は生成したイベントに対して、条件ごとに絞り込みと集計を繰り返し実行して計測する
+// 前回の結果との比較は benchstat で行う（例: go test -run '^$' -bench Report -count 10 > new.txt && benchstat old.txt new.txt）
+func BenchmarkReport(b *testing.B) {
+	cfg := benchConfig()
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		b.Fatal(err)
+	}
+	startDate := time.Date(2020, 1, 1, 0, 0, 0, 0, location)
+	endDate := startDate.AddDate(0, 0, (benchEvents-1)/8)
+	items := syntheticEvents(benchEvents, startDate)
+
+	for _, sc := range benchScenarios {
+		b.Run(sc.Name, func(b *testing.B) {
+			o := &queryOptions{}
+			fs := flag.NewFlagSet(sc.Name, flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			o.register(fs, cfg)
+			o.deductions = append([]DeductionRule(nil), sc.Deductions...)
+			args := append([]string{"-start", startDate.Format("2006-01-02"), "-end", endDate.Format("2006-01-02")}, sc.Args...)
+			if err := fs.Parse(args); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := o.check(); err != nil {
+				b.Fatal(err)
+			}
+
+			run := func() *Report {
+				if sc.Stream {
+					rb := newReportBuilder(o, startDate, endDate, location, false)
+					for _, item := range items {
+						rb.add(item)
+					}
+					return rb.finish()
+				}
+				return buildReport(items, o, startDate, endDate, location)
+			}
+			matched := run().count()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				run()
+			}
+			// 生成するイベントの件数を変えても比較できるように、イベント1件あたりの時間も報告する
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(len(items)), "ns/event")
+			b.ReportMetric(float64(matched), "matched")
+		})
+	}
+}
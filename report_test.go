@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestQuery はコマンドラインと同じ引数から集計のオプションを作成する
+// 設定ファイルの既定値は一時ディレクトリを基準にするため、キャッシュや履歴を汚さない
+func newTestQuery(t *testing.T, args ...string) (*queryOptions, *Config) {
+	t.Helper()
+	cfg := &Config{}
+	cfg.applyDefaults(t.TempDir())
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	o := &queryOptions{}
+	o.register(fs, cfg)
+	if err := fs.Parse(append(args, "-no-history")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.check(); err != nil {
+		t.Fatal(err)
+	}
+	return o, cfg
+}
+
+// writeTestFile は一時ディレクトリにファイルを書き込み、そのパスを返す
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testReport は取得元からイベントを取得して集計する
+func testReport(t *testing.T, o *queryOptions, cfg *Config) *Report {
+	t.Helper()
+	ctx := context.Background()
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startDate, endDate := o.dateRange(location)
+	items := o.fetchEvents(ctx, o.newSource(ctx, cfg), cfg, location, startDate, endDate.AddDate(0, 0, 1))
+	return buildReport(items, o, startDate, endDate, location)
+}
+
+// eventSummaries は一致したイベントの開始日時とイベント名の一覧を返す
+func eventSummaries(r *Report) []string {
+	var s []string
+	for _, e := range r.Events {
+		s = append(s, e.Start.In(r.Location).Format("01/02 15:04")+" "+e.Event.Summary)
+	}
+	return s
+}
+
+const testFixture = `{
+  "timeZone": "Asia/Tokyo",
+  "items": [
+    {"summary": "Standup", "start": {"dateTime": "2024-04-01T10:00:00+09:00"}, "end": {"dateTime": "2024-04-01T10:30:00+09:00"}},
+    {"summary": "Standup", "start": {"dateTime": "2024-04-02T10:00:00", "timeZone": "Asia/Tokyo"}, "end": {"dateTime": "2024-04-02T11:00:00", "timeZone": "Asia/Tokyo"}},
+    {"summary": "Standup", "start": {"date": "2024-04-03"}, "end": {"date": "2024-04-04"}},
+    {"summary": "Review", "start": {"dateTime": "2024-04-02T14:00:00+09:00"}, "end": {"dateTime": "2024-04-02T15:00:00+09:00"}},
+    {"summary": "Standup", "status": "cancelled", "start": {"dateTime": "2024-04-04T10:00:00+09:00"}, "end": {"dateTime": "2024-04-04T10:30:00+09:00"}},
+    {"summary": "Standup", "start": {"dateTime": "2024-05-01T10:00:00+09:00"}, "end": {"dateTime": "2024-05-01T10:30:00+09:00"}}
+  ]
+}`
+
+func TestBuildReportMockSource(t *testing.T) {
+	path := writeTestFile(t, "fixture.json", testFixture)
+	o, cfg := newTestQuery(t, "-mock", path, "-month", "2024-04", "-name", "Standup", "-group-by", "day")
+	r := testReport(t, o, cfg)
+
+	want := []string{"04/01 10:00 Standup", "04/02 10:00 Standup"}
+	if got := eventSummaries(r); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("イベント = %q; want %q", got, want)
+	}
+	if r.Total != 90*time.Minute {
+		t.Errorf("Total = %v; want 1h30m", r.Total)
+	}
+	if r.Stats.ActiveDays != 2 || r.Stats.Longest != time.Hour {
+		t.Errorf("Stats = %+v; want ActiveDays 2, Longest 1h", r.Stats)
+	}
+	if len(r.Groups) != 2 || r.Groups[0].Duration != 30*time.Minute || r.Groups[1].Duration != time.Hour {
+		t.Errorf("Groups = %+v; want 30m, 1h", r.Groups)
+	}
+}
+
+func TestBuildReportMockSourceIncludeCancelled(t *testing.T) {
+	path := writeTestFile(t, "fixture.json", testFixture)
+	o, cfg := newTestQuery(t, "-mock", path, "-month", "2024-04", "-name", "Standup", "-include-cancelled")
+	if r := testReport(t, o, cfg); len(r.Events) != 3 || r.Total != 2*time.Hour {
+		t.Errorf("件数, Total = %d, %v; want 3, 2h", len(r.Events), r.Total)
+	}
+}
+
+func TestBuildReportICSSource(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"SUMMARY:Standup",
+		"DTSTART;TZID=Asia/Tokyo:20240401T100000",
+		"DTEND;TZID=Asia/Tokyo:20240401T103000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"EXDATE;TZID=Asia/Tokyo:20240415T100000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"SUMMARY:Standup",
+		"RECURRENCE-ID;TZID=Asia/Tokyo:20240422T100000",
+		"DTSTART;TZID=Asia/Tokyo:20240423T110000",
+		"DTEND;TZID=Asia/Tokyo:20240423T120000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:review@example.com",
+		"SUMMARY:Review",
+		"DTSTART:20240402T050000Z",
+		"DURATION:PT1H",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	path := writeTestFile(t, "calendar.ics", ics)
+	o, cfg := newTestQuery(t, "-ics", path, "-month", "2024-04", "-name", "Standup")
+	r := testReport(t, o, cfg)
+
+	want := []string{"04/01 10:00 Standup", "04/08 10:00 Standup", "04/23 11:00 Standup", "04/29 10:00 Standup"}
+	if got := eventSummaries(r); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("イベント = %q; want %q", got, want)
+	}
+	if r.Total != 2*time.Hour+30*time.Minute {
+		t.Errorf("Total = %v; want 2h30m", r.Total)
+	}
+
+	o, cfg = newTestQuery(t, "-ics", path, "-month", "2024-04", "-name", "Review")
+	if r := testReport(t, o, cfg); r.Total != time.Hour || eventSummaries(r)[0] != "04/02 14:00 Review" {
+		t.Errorf("Review = %q, %v; want 04/02 14:00 の1時間", eventSummaries(r), r.Total)
+	}
+}
+
+func TestFetchEventsLocalSourcePeriod(t *testing.T) {
+	// 期間の境界をまたぐイベントは期間と重なるため取得し、-no-clip を指定しない場合は期間内の部分だけを集計する
+	path := writeTestFile(t, "fixture.json", `[
+  {"summary": "Night", "start": {"dateTime": "2024-03-31T23:00:00+09:00"}, "end": {"dateTime": "2024-04-01T01:00:00+09:00"}},
+  {"summary": "Night", "start": {"dateTime": "2024-04-30T23:30:00+09:00"}, "end": {"dateTime": "2024-05-01T00:30:00+09:00"}},
+  {"summary": "Night", "start": {"dateTime": "2024-05-01T23:00:00+09:00"}, "end": {"dateTime": "2024-05-02T01:00:00+09:00"}}
+]`)
+	o, cfg := newTestQuery(t, "-mock", path, "-month", "2024-04", "-name", "Night")
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startDate, endDate := o.dateRange(location)
+	ctx := context.Background()
+	items := o.fetchEvents(ctx, o.newSource(ctx, cfg), cfg, location, startDate, endDate.AddDate(0, 0, 1))
+	if len(items) != 2 {
+		t.Fatalf("取得したイベント = %d件; want 2件", len(items))
+	}
+	if r := buildReport(items, o, startDate, endDate, location); r.Total != 90*time.Minute {
+		t.Errorf("Total = %v; want 1h30m", r.Total)
+	}
+}
+
+func TestBuildReportSubtractOverlapsWorkHours(t *testing.T) {
+	path := writeTestFile(t, "fixture.json", `[
+  {"summary": "MTG", "start": {"dateTime": "2024-04-01T09:00:00+09:00"}, "end": {"dateTime": "2024-04-01T10:00:00+09:00"}},
+  {"summary": "MTG", "start": {"dateTime": "2024-04-01T09:30:00+09:00"}, "end": {"dateTime": "2024-04-01T20:00:00+09:00"}}
+]`)
+	o, cfg := newTestQuery(t, "-mock", path, "-month", "2024-04", "-name", "MTG", "-work-hours", "09:00-18:00", "-subtract-overlaps")
+	// 勤務時間帯の 9:00〜18:00 を二重に数えない
+	if r := testReport(t, o, cfg); r.Total != 9*time.Hour || r.OverlapDeducted != 30*time.Minute {
+		t.Errorf("Total, 差し引いた時間 = %v, %v; want 9h, 30m", r.Total, r.OverlapDeducted)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// API呼び出しを再試行する最大回数
+const maxRetries = 5
+
+// 最初の再試行までの待ち時間（再試行のたびに2倍にする）
+const initialBackoff = time.Second
+
+// isRetryable は一時的なエラー（レート制限やサーバーエラー）で、再試行すれば成功する可能性があるかを判定する
+func isRetryable(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+	}
+	// SMTPサーバーの4xx応答（一時的な失敗）は再試行する
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests, apiErr.Code >= 500:
+		return true
+	case apiErr.Code == http.StatusForbidden:
+		for _, e := range apiErr.Errors {
+			if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withRetry はAPI呼び出しを実行し、一時的なエラーの場合は指数バックオフで待ってから再試行する
+func withRetry(ctx context.Context, call func() error) error {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt > maxRetries || !isRetryable(err) {
+			return err
+		}
+
+		// 複数の処理が同時に再試行しないように、待ち時間にばらつきを持たせる
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)))
+		slog.Warn("APIの呼び出しに失敗したため再試行します", "wait", wait.Round(100*time.Millisecond), "attempt", attempt, "max", maxRetries, "error", err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// 会議室の利用可能な時間帯のデフォルト値（-work-hours、-workdays が指定されていない場合）
+const (
+	defaultRoomHours    = "09:00-18:00"
+	defaultRoomWorkdays = "mon-fri"
+)
+
+// roomUsage は1つの会議室（リソースカレンダー）の予約の集計結果を表す
+type roomUsage struct {
+	ID        string
+	Name      string
+	Bookings  int
+	Booked    time.Duration
+	Available time.Duration
+
+	// 参加を承諾した人がいない予約（使われなかった可能性がある予約）
+	NoShows []*calendar.Event
+}
+
+// percent は利用可能な時間に対する予約されている時間の割合（%）を返す
+func (u *roomUsage) percent() float64 {
+	if u.Available <= 0 {
+		return 0
+	}
+	return float64(u.Booked) / float64(u.Available) * 100
+}
+
+// acceptedCount は会議室などのリソースを除いて、参加を承諾した人数を返す
+func acceptedCount(e *calendar.Event) int {
+	n := 0
+	for _, a := range e.Attendees {
+		if !a.Resource && a.ResponseStatus == "accepted" {
+			n++
+		}
+	}
+	return n
+}
+
+// roomName はイベントの参加者に含まれる会議室の表示名を返す（見つからない場合は空文字）
+func roomName(items []*calendar.Event, roomID string) string {
+	for _, e := range items {
+		for _, a := range e.Attendees {
+			if a.Resource && strings.EqualFold(a.Email, roomID) && a.DisplayName != "" {
+				return a.DisplayName
+			}
+		}
+	}
+	return ""
+}
+
+// computeRoomUsage は会議室のカレンダーのイベントから、利用可能な時間帯に予約されている時間と、使われなかった可能性がある予約を求める
+// 同じ時間帯の重複した予約は1回分として数える
+func computeRoomUsage(id string, items []*calendar.Event, schedule *workSchedule, location *time.Location, startDate, rangeEnd time.Time) *roomUsage {
+	u := &roomUsage{ID: id, Name: roomName(items, id), Available: schedule.overlap(startDate, rangeEnd, location)}
+	var periods []busyPeriod
+	for _, e := range items {
+		// 終日イベントやキャンセルされた予約は集計しない
+		if e.Status == "cancelled" {
+			continue
+		}
+		start, end, ok := timedEventTimes(e, location)
+		if !ok {
+			continue
+		}
+		clippedStart, clippedEnd := later(start, startDate), earlier(end, rangeEnd)
+		if !clippedStart.Before(clippedEnd) || schedule.overlap(clippedStart, clippedEnd, location) == 0 {
+			continue
+		}
+		u.Bookings++
+		periods = append(periods, busyPeriod{Start: start, End: end})
+		if acceptedCount(e) == 0 {
+			u.NoShows = append(u.NoShows, e)
+		}
+	}
+	u.Booked = busyDuration(periods, schedule, location, startDate, rangeEnd)
+	return u
+}
+
+// printRoomUsage は会議室ごとの予約時間、利用率と、使われなかった可能性がある予約を出力する
+func printRoomUsage(w io.Writer, rooms []*roomUsage, location *time.Location, startDate, endDate time.Time) {
+	printer.Fprintf(w, "検索期間: %s から %s\n", startDate.Format("2006/01/02"), endDate.Format("2006/01/02"))
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("会議室別の予約時間と利用率:\n")))
+
+	rows := [][]string{{printer.Sprintf("会議室"), printer.Sprintf("予約時間"), printer.Sprintf("予約数"), printer.Sprintf("利用可能時間"), printer.Sprintf("利用率"), printer.Sprintf("空予約の候補")}}
+	for _, u := range rooms {
+		name := u.ID
+		if u.Name != "" {
+			name = u.Name
+		}
+		rows = append(rows, []string{name, formatDuration(u.Booked), printer.Sprintf("%d件", u.Bookings), formatDuration(u.Available), fmt.Sprintf("%.1f%%", u.percent()), printer.Sprintf("%d件", len(u.NoShows))})
+	}
+	widths := columnWidths(rows)
+	for i, row := range rows {
+		line := formatRow(row, widths)
+		if i == 0 {
+			fmt.Fprintln(w, colorize(w, colorBold, strings.TrimRight(line, " ")))
+			continue
+		}
+		if bar := shareBar(rooms[i-1].percent()); bar != "" {
+			line += "  " + colorize(w, colorCyan, bar)
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintln(w)
+
+	for _, u := range rooms {
+		if len(u.NoShows) == 0 {
+			continue
+		}
+		name := u.ID
+		if u.Name != "" {
+			name = u.Name
+		}
+		fmt.Fprint(w, colorize(w, colorYellow, printer.Sprintf("%s の参加を承諾した人がいない予約 (%d件):\n", name, len(u.NoShows))))
+		for _, e := range u.NoShows {
+			start, end, _ := timedEventTimes(e, location)
+			printer.Fprintf(w, "- %s (%s～%s) 主催者: %s\n", e.Summary, start.In(location).Format("2006/01/02 15:04"), end.In(location).Format("15:04"), organizerKey(e))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// registerRoomsFlags は rooms のフラグを登録する（補完の候補にも使用する）
+func registerRoomsFlags(fs *flag.FlagSet, cfg *Config) *queryOptions {
+	opts := &queryOptions{}
+	opts.registerDateRange(fs, cfg)
+	fs.StringVar(&opts.calendarID, "rooms", strings.Join(cfg.Rooms, ","), "会議室のリソースカレンダーのID、カンマ区切りで複数指定可能（デフォルトは設定ファイルの rooms）")
+	fs.StringVar(&opts.workHours, "work-hours", defaultRoomHours, "会議室を利用できる時間帯（HH:MM-HH:MM形式）")
+	fs.StringVar(&opts.workdays, "workdays", defaultRoomWorkdays, "会議室を利用できる曜日（例: mon-fri, mon,wed,fri）")
+	fs.StringVar(&opts.icsPath, "ics", "", "Google Calendarの代わりに読み込むICSファイル（オフラインで集計）")
+	fs.StringVar(&opts.mockPath, "mock", "", "Google Calendarの代わりに読み込むイベントのフィクスチャ（Calendar APIの応答形式のJSON、認証不要）")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "処理全体のタイムアウト（例: 5m）。0の場合はタイムアウトしない")
+	return opts
+}
+
+// runRoomsCommand は会議室などのリソースカレンダーの予約時間と利用率を集計するサブコマンドを実行する
+func runRoomsCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("rooms", flag.ExitOnError)
+	opts := registerRoomsFlags(fs, cfg)
+	parseFlags(fs, args)
+
+	const usage = "使用方法: gcal-sum rooms -month=YYYY-MM -rooms=リソースカレンダーID [-work-hours=09:00-18:00] [-workdays=mon-fri]\n"
+	if _, err := opts.checkDateRange(); err != nil {
+		printer.Printf("エラー: %v\n", err)
+		printer.Printf(usage)
+		os.Exit(exitUsage)
+	}
+	ids := opts.calendarIDs()
+	if len(ids) == 0 {
+		printer.Printf("エラー: -rooms または設定ファイルの rooms で会議室のリソースカレンダーのIDを指定してください。\n")
+		printer.Printf(usage)
+		os.Exit(exitUsage)
+	}
+	schedule, err := newWorkSchedule(opts.workHours, opts.workdays)
+	if err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	startDate, endDate := opts.dateRange(location)
+	rangeEnd := endDate.AddDate(0, 0, 1)
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	var rooms []*roomUsage
+	for _, id := range ids {
+		items, err := src.Events(ctx, id, startDate, rangeEnd, eventQuery{})
+		if err != nil {
+			fatalAPI("会議室の予約の取得に失敗しました", contextError(ctx, err))
+		}
+		rooms = append(rooms, computeRoomUsage(id, items, schedule, location, startDate, rangeEnd))
+	}
+	printRoomUsage(os.Stdout, rooms, location, startDate, endDate)
+}
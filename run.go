@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// RunQuery は run でまとめて実行する集計の1件
+type RunQuery struct {
+	// 集計結果の表に表示する名前
+	Label string `json:"label"`
+
+	// 集計の条件（serve のクエリパラメータと同じ名前で指定する）
+	// 期間は run の引数で指定するため、start、end、month、week、period は指定できない
+	Query map[string]string `json:"query"`
+}
+
+// 集計ごとには指定できない、期間を指定するクエリパラメータ
+var runDateParams = []string{"start", "end", "month", "week", "period"}
+
+// runResult は run の1件の集計結果を表す
+type runResult struct {
+	Label  string
+	Report *Report
+}
+
+// validateRunQueries は設定ファイルの集計を検証する
+func validateRunQueries(queries []RunQuery) error {
+	if len(queries) == 0 {
+		return errors.New(printer.Sprintf("設定ファイルに queries が設定されていません"))
+	}
+	seen := make(map[string]bool)
+	for _, q := range queries {
+		if q.Label == "" {
+			return errors.New(printer.Sprintf("queries の label を指定してください"))
+		}
+		if seen[q.Label] {
+			return errors.New(printer.Sprintf("queries の label '%s' が重複しています", q.Label))
+		}
+		seen[q.Label] = true
+		for k := range q.Query {
+			if containsString(runDateParams, k) {
+				return errors.New(printer.Sprintf("集計 '%s' には期間（%s）を指定できません。期間は run の引数で指定してください", q.Label, k))
+			}
+			if !containsString(serveParams, k) {
+				return errors.New(printer.Sprintf("集計 '%s' の条件 '%s' はサポートされていません", q.Label, k))
+			}
+		}
+	}
+	return nil
+}
+
+// runQueryOptions は run の引数と設定ファイルの集計の条件から、集計ごとのオプションを作成する
+func runQueryOptions(cfg *Config, base []string, queries []RunQuery) ([]*queryOptions, error) {
+	s := &server{cfg: cfg, base: base}
+	var opts []*queryOptions
+	for _, q := range queries {
+		params := url.Values{}
+		for k, v := range q.Query {
+			params.Set(k, v)
+		}
+		o, err := s.options(params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", printer.Sprintf("集計 '%s'", q.Label), err)
+		}
+		opts = append(opts, o)
+	}
+	return opts, nil
+}
+
+// fetchRunEvents はすべての集計が対象とするカレンダーから、期間内のイベントをカレンダーごとに1回ずつ取得する
+// 取得したイベントは集計ごとに手元で絞り込むため、Calendar APIの検索は行わない
+// ICSファイルやフィクスチャは1回だけ読み込み、空のカレンダーIDに対応付ける
+func fetchRunEvents(ctx context.Context, src CalendarSource, cfg *Config, opts []*queryOptions, location *time.Location, startDate, searchEndDate time.Time) map[string][]*calendar.Event {
+	fo := *opts[0]
+	fo.noServerQuery = true
+	var ids []string
+	for _, o := range opts {
+		fo.includeCancelled = fo.includeCancelled || o.includeCancelled
+		for _, id := range o.calendarIDs() {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if isLocalSource(src) {
+		ids = []string{""}
+	}
+
+	events := make(map[string][]*calendar.Event)
+	for _, id := range ids {
+		fo.calendarID = id
+		events[id] = fo.fetchEvents(ctx, src, cfg, location, startDate, searchEndDate)
+	}
+	// -calendar-timezone で記録したタイムゾーンを、それぞれの集計からも参照できるようにする
+	for _, o := range opts {
+		if o.zones != nil {
+			o.zones = fo.zones
+		}
+	}
+	return events
+}
+
+// runQueryEvents は取得したイベントのうち、集計が対象とするカレンダーのイベントを返す
+// 複数のカレンダーに含まれる同じイベントの重複は、fetchEvents と同じく取り除く
+func runQueryEvents(events map[string][]*calendar.Event, src CalendarSource, o *queryOptions) []*calendar.Event {
+	if isLocalSource(src) {
+		return events[""]
+	}
+	ids := o.calendarIDs()
+	var items []*calendar.Event
+	for _, id := range ids {
+		items = append(items, events[id]...)
+	}
+	if len(ids) > 1 && !o.noDedupe {
+		items = dedupeEvents(items)
+	}
+	sortEventsByStart(items)
+	return items
+}
+
+// runQueries は期間内のイベントを1回だけ取得し、設定ファイルのすべての集計を行う
+func runQueries(ctx context.Context, src CalendarSource, cfg *Config, queries []RunQuery, opts []*queryOptions) []runResult {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	startDate, endDate := opts[0].dateRange(location)
+	searchEndDate := endDate.AddDate(0, 0, 1)
+	events := fetchRunEvents(ctx, src, cfg, opts, location, startDate, searchEndDate)
+
+	results := make([]runResult, len(queries))
+	for i, o := range opts {
+		holidays := o.loadHolidays(ctx, src, startDate, searchEndDate)
+		report := buildReport(runQueryEvents(events, src, o), o, startDate, endDate, location)
+		report.Holidays = holidays
+		results[i] = runResult{Label: queries[i].Label, Report: o.completeReport(ctx, src, cfg, report)}
+	}
+	return results
+}
+
+// runCount はすべての集計で一致したイベントの件数を返す
+func runCount(results []runResult) int {
+	count := 0
+	for _, r := range results {
+		count += r.Report.count()
+	}
+	return count
+}
+
+// printRunResults は集計ごとの合計時間の一覧を出力形式に従って出力する
+func printRunResults(w io.Writer, results []runResult, format string) {
+	if format == "markdown" {
+		printRunMarkdown(w, results)
+		return
+	}
+	printRunText(w, results)
+}
+
+// printRunText は集計ごとの合計時間の一覧をテキスト形式で出力する
+// 集計の条件は重なることがあるため、合計時間の合計は表示しない
+func printRunText(w io.Writer, results []runResult) {
+	first := results[0].Report
+	printer.Fprintf(w, "検索期間: %s から %s\n", first.StartDate.Format("2006/01/02"), first.EndDate.Format("2006/01/02"))
+	fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("集計ごとの合計時間:\n")))
+
+	rows := [][]string{{printer.Sprintf("集計"), printer.Sprintf("合計時間"), printer.Sprintf("件数"), printer.Sprintf("実施日数")}}
+	for _, r := range results {
+		rows = append(rows, []string{r.Label, formatDuration(r.Report.Total), printer.Sprintf("%d件", r.Report.count()), printer.Sprintf("%d日", r.Report.Stats.ActiveDays)})
+	}
+	widths := columnWidths(rows)
+	for i, row := range rows {
+		line := strings.TrimRight(formatRow(row, widths), " ")
+		if i == 0 {
+			line = colorize(w, colorBold, line)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+// printRunMarkdown は集計ごとの合計時間の一覧をMarkdown形式の表で出力する
+func printRunMarkdown(w io.Writer, results []runResult) {
+	first := results[0].Report
+	printer.Fprintf(w, "## 集計ごとの合計時間\n\n")
+	printer.Fprintf(w, "- 検索期間: %s から %s\n\n", first.StartDate.Format("2006/01/02"), first.EndDate.Format("2006/01/02"))
+	printer.Fprintf(w, "| 集計 | 合計時間 | 件数 | 実施日数 |\n")
+	printer.Fprintf(w, "|---|---:|---:|---:|\n")
+	for _, r := range results {
+		fmt.Fprintf(w, "| %s | %s | %d | %d |\n", escapeMarkdown(r.Label), formatDuration(r.Report.Total), r.Report.count(), r.Report.Stats.ActiveDays)
+	}
+	fmt.Fprintln(w)
+}
+
+// runFlags は run のフラグ
+type runFlags struct {
+	opts         *queryOptions
+	outputFormat string
+	detail       bool
+}
+
+// registerRunFlags は run のフラグを登録する（補完の候補にも使用する）
+func registerRunFlags(fs *flag.FlagSet, cfg *Config) *runFlags {
+	f := &runFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.StringVar(&f.outputFormat, "output", "text", "出力形式（text, markdown）")
+	fs.BoolVar(&f.detail, "detail", false, "集計ごとの合計時間の一覧の後に、それぞれの集計結果を表示")
+	return f
+}
+
+// runRunCommand は設定ファイルの複数の集計を、期間内のイベントを1回だけ取得してまとめて実行するサブコマンドを実行する
+func runRunCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	flags := registerRunFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	if !isValidOutputFormat(flags.outputFormat) {
+		printer.Printf("エラー: 出力形式 '%s' はサポートされていません（%s）。\n", flags.outputFormat, strings.Join(outputFormats, ", "))
+		os.Exit(exitUsage)
+	}
+	if opts.months != "" {
+		printer.Printf("エラー: run では -months を指定できません。\n")
+		os.Exit(exitUsage)
+	}
+	if err := validateRunQueries(cfg.Queries); err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	// 集計ごとの条件は、run の引数で指定したオプションに追加して解析する
+	// 集計ごとの結果は一覧にまとめるため、履歴には保存しない
+	queryOpts, err := runQueryOptions(cfg, baseQueryArgs(fs, cfg), cfg.Queries)
+	if err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	results := runQueries(ctx, opts.newSource(ctx, cfg), cfg, cfg.Queries, queryOpts)
+	printRunResults(os.Stdout, results, flags.outputFormat)
+	if flags.detail {
+		for _, r := range results {
+			if flags.outputFormat == "markdown" {
+				fmt.Fprintf(os.Stdout, "## %s\n\n", escapeMarkdown(r.Label))
+			} else {
+				fmt.Fprintln(os.Stdout, colorize(os.Stdout, colorBold, "== "+r.Label+" =="))
+			}
+			printReport(os.Stdout, r.Report, flags.outputFormat)
+			fmt.Fprintln(os.Stdout)
+		}
+	}
+
+	// 一致するイベントがない場合は、スクリプトから判定できるように専用の終了コードで終了する
+	if runCount(results) == 0 {
+		exit(exitNoMatch)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"html/template"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// serve のデフォルトの待ち受けポート
+const defaultServePort = 9090
+
+// serveParams はWeb画面やURLのクエリパラメータで指定できる集計の条件
+// ICSファイルなどサーバー上のファイルを参照するオプションは、サーバーの起動時にだけ指定できる
+var serveParams = []string{
+	"start", "end", "month", "week", "period", "name", "match", "tag",
+	"description-contains", "description-regex", "attendee", "min-attendees", "organizer",
+	"location", "project", "only-with-meet", "without-meet", "include-cancelled", "include-declined",
+	"calendar", "group-by", "work-hours", "workdays", "duration-format",
+}
+
+// 検索フォームのテンプレート（HTMLレポートの "form" ブロックを置き換える）
+const serveFormTemplate = `<form method="get" action="/" style="background:#f5f8ff;border-radius:8px;padding:1em;margin-bottom:1em">
+{{if .Error}}<p style="color:#c00">{{.Error}}</p>{{end}}
+<label>月 <input type="month" name="month" value="{{.Params.Get "month"}}"></label>
+<label>または期間 <input type="date" name="start" value="{{.Params.Get "start"}}"> ～ <input type="date" name="end" value="{{.Params.Get "end"}}"></label><br>
+<label>イベント名 <input type="text" name="name" value="{{.Params.Get "name"}}"></label>
+<select name="match">
+{{range $m := matchModes}}<option value="{{$m}}"{{if eq $m ($.Params.Get "match")}} selected{{end}}>{{$m}}</option>
+{{end}}</select>
+<label>タグ <input type="text" name="tag" value="{{.Params.Get "tag"}}"></label><br>
+<label>カレンダー <input type="text" name="calendar" value="{{.Params.Get "calendar"}}" placeholder="未指定の場合はデフォルトのカレンダー"></label>
+<label>グループ化 <select name="group-by"><option value="">なし</option>
+{{range $g := groupByOptions}}<option value="{{$g}}"{{if eq $g ($.Params.Get "group-by")}} selected{{end}}>{{groupLabel $g}}</option>
+{{end}}</select></label>
+<button type="submit">集計</button>
+</form>
+`
+
+// 集計の条件が指定されていない場合や、エラーの場合に表示するページのテンプレート
+const serveIndexTemplate = `<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>gcal-sum</title>
+<style>
+body { font-family: -apple-system, "Hiragino Sans", "Noto Sans JP", sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+h1 { font-size: 1.6em; border-bottom: 2px solid #4285f4; padding-bottom: .3em; }
+</style>
+</head>
+<body>
+<h1>イベントの集計</h1>
+{{template "form" .}}
+</body>
+</html>
+`
+
+// server はWeb画面から集計を行うサーバー
+type server struct {
+	cfg  *Config
+	src  CalendarSource
+	base []string
+
+	// -api で起動した場合に、リクエストに必要なトークン（空の場合は確認しない）
+	apiToken string
+
+	// 集計の条件の解析（check）は時間の表示形式や週の始まりなどの共有の状態を設定し、集計や結果の出力でも参照するため、
+	// 同時に届いたリクエストは、解析から結果の出力までこのロックを保持して1件ずつ処理する
+	mu sync.Mutex
+}
+
+// options はサーバーの起動時の引数とクエリパラメータから集計のオプションを作成する
+func (s *server) options(params url.Values) (*queryOptions, error) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts := &queryOptions{}
+	opts.register(fs, s.cfg)
+
+	args := append([]string(nil), s.base...)
+	for _, name := range serveParams {
+		for _, v := range params[name] {
+			if v != "" {
+				args = append(args, "-"+name+"="+v)
+			}
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if _, err := opts.check(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// query は集計を実行する。集計中のエラーはプロセスを終了せずにエラーとして返す
+// 並行に呼び出す場合は、options から結果の出力までを s.mu を保持して実行する
+func (s *server) query(ctx context.Context, opts *queryOptions) (report *Report, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			fe, ok := v.(*fatalError)
+			if !ok {
+				panic(v)
+			}
+			report, err = nil, fe
+		}
+	}()
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	return runQuery(ctx, s.src, s.cfg, opts), nil
+}
+
+// templates はWeb画面のテンプレートを生成する
+func (s *server) templates() (*template.Template, error) {
+	location, err := time.LoadLocation(s.cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := newHTMLTemplate(location)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"matchModes":     func() []string { return []string{"exact", "contains", "regex"} },
+		"groupByOptions": func() []string { return groupByOptions },
+	})
+	if _, err := tmpl.New("form").Parse(serveFormTemplate); err != nil {
+		return nil, err
+	}
+	if _, err := tmpl.New("index").Parse(serveIndexTemplate); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// handleIndex は検索フォームと集計結果を表示する
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmpl, err := s.templates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	params := r.URL.Query()
+	page := &htmlPage{Params: params}
+	status := http.StatusOK
+	if len(params) > 0 {
+		opts, err := s.options(params)
+		if err == nil {
+			var report *Report
+			if report, err = s.query(r.Context(), opts); err == nil {
+				page = newHTMLPage(report)
+				page.Params = params
+			}
+		}
+		if err != nil {
+			page.Error = err.Error()
+			status = http.StatusBadRequest
+			if fe, ok := err.(*fatalError); ok && fe.code != exitUsage {
+				status = http.StatusInternalServerError
+			}
+		}
+	}
+
+	name := "index"
+	if page.Report != nil {
+		name = "report"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := tmpl.ExecuteTemplate(w, name, page); err != nil {
+		slog.Warn("ページの表示に失敗しました", "error", err)
+	}
+}
+
+// baseQueryArgs はサーバーの起動時に指定された集計のオプションを、リクエストごとの解析に渡す引数に変換する
+// サーバーからの集計は履歴に保存しない
+func baseQueryArgs(fs *flag.FlagSet, cfg *Config) []string {
+	queryFlags := flag.NewFlagSet("query", flag.ContinueOnError)
+	(&queryOptions{}).register(queryFlags, cfg)
+	base := []string{"-no-history"}
+	fs.Visit(func(f *flag.Flag) {
+		if queryFlags.Lookup(f.Name) != nil {
+			base = append(base, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return base
+}
+
+// serveFlags は serve のフラグ
+type serveFlags struct {
+	opts     *queryOptions
+	port     int
+	host     string
+	api      bool
+	apiToken string
+}
+
+// registerServeFlags は serve のフラグを登録する（補完の候補にも使用する）
+func registerServeFlags(fs *flag.FlagSet, cfg *Config) *serveFlags {
+	f := &serveFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.IntVar(&f.port, "port", defaultServePort, "待ち受けるポート番号")
+	fs.StringVar(&f.host, "host", "127.0.0.1", "待ち受けるアドレス（他の端末から利用する場合は 0.0.0.0）")
+	fs.BoolVar(&f.api, "api", false, "Web画面の代わりに、集計結果をJSON形式で返すAPI（GET /v1/sum）を提供")
+	fs.StringVar(&f.apiToken, "api-token", os.Getenv("GCAL_SUM_API_TOKEN"), "APIの呼び出しに必要なトークン（Authorization: Bearer ヘッダーで指定）")
+	return f
+}
+
+// runServeCommand はWeb画面から集計できるローカルサーバーを起動するサブコマンドを実行する
+func runServeCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags := registerServeFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+	// 集計は繰り返し実行されるため、取得の進み具合は表示しない
+	progressEnabled = false
+
+	// 起動時に指定した集計のオプションは、すべてのリクエストの既定値として使用する
+	base := baseQueryArgs(fs, cfg)
+
+	ctx, cancel := newCommandContext(0)
+	defer cancel()
+	s := &server{cfg: cfg, src: opts.newSource(ctx, cfg), base: base, apiToken: flags.apiToken}
+	release := catchFatal()
+	defer release()
+
+	mux := http.NewServeMux()
+	if flags.api {
+		mux.HandleFunc("/v1/sum", s.handleSum)
+	} else {
+		mux.HandleFunc("/", s.handleIndex)
+	}
+	addr := net.JoinHostPort(flags.host, strconv.Itoa(flags.port))
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	slog.Info("サーバーを起動しました", "url", "http://"+addr+"/")
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		release()
+		fatal("サーバーの起動に失敗しました", "error", err)
+	}
+}
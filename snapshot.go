@@ -0,0 +1,455 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotDateFlags は期間を指定するフラグ（スナップショットには解析した期間を -start と -end で保存する）
+var snapshotDateFlags = map[string]bool{
+	"start": true, "end": true, "month": true, "months": true, "period": true, "week": true, "this-week": true, "last-week": true,
+}
+
+// snapshot はある時点の集計結果（一致したイベントごとの時間）を表す
+type snapshot struct {
+	Label        string    `json:"label"`
+	CreatedAt    time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	Source       string    `json:"source"`
+	StartDate    string    `json:"start_date"`
+	EndDate      string    `json:"end_date"`
+	TotalMinutes float64   `json:"total_minutes"`
+
+	// 集計に使用したフラグ（diff で同じ条件の集計をやり直すために使用する）
+	Args []string `json:"args"`
+
+	Events []snapshotEvent `json:"events"`
+}
+
+// snapshotEvent はスナップショットに含まれる1件のイベントを表す
+type snapshotEvent struct {
+	// 比較に使用するキー（イベントのID、手動調整は日付と名前）
+	Key     string    `json:"key"`
+	Summary string    `json:"summary"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Minutes float64   `json:"minutes"`
+	Manual  bool      `json:"manual,omitempty"`
+}
+
+// duration はイベントの集計した時間を返す（保存時の端数の誤差を除くため、秒単位に丸める）
+func (e snapshotEvent) duration() time.Duration {
+	return time.Duration(e.Minutes * float64(time.Minute)).Round(time.Second)
+}
+
+// total はスナップショットの合計時間を返す
+func (s *snapshot) total() time.Duration {
+	return time.Duration(s.TotalMinutes * float64(time.Minute)).Round(time.Second)
+}
+
+// newSnapshot は集計結果からスナップショットを作成する
+func newSnapshot(r *Report, label, source string, args []string) *snapshot {
+	s := &snapshot{
+		Label:        label,
+		CreatedAt:    time.Now(),
+		Name:         r.Name,
+		Source:       source,
+		StartDate:    r.StartDate.Format("2006-01-02"),
+		EndDate:      r.EndDate.Format("2006-01-02"),
+		TotalMinutes: r.Total.Minutes(),
+		Args:         args,
+	}
+	for _, e := range r.Events {
+		key := e.Event.Id
+		if e.Manual || key == "" {
+			key = "manual:" + e.Start.In(r.Location).Format("2006-01-02") + ":" + e.Event.Summary
+		}
+		s.Events = append(s.Events, snapshotEvent{
+			Key:     key,
+			Summary: e.Event.Summary,
+			Start:   e.Start,
+			End:     e.End,
+			Minutes: e.Duration.Minutes(),
+			Manual:  e.Manual,
+		})
+	}
+	return s
+}
+
+// snapshotArgs は集計に使用したフラグを、後から同じ条件で集計し直せる引数に変換する
+// 「今週」のような相対的な期間は実行する日によって変わるため、解析した期間を -start と -end で保存する
+func snapshotArgs(fs *flag.FlagSet, cfg *Config, r *Report) []string {
+	query := flag.NewFlagSet("query", flag.ContinueOnError)
+	(&queryOptions{}).register(query, cfg)
+	args := []string{"-start=" + r.StartDate.Format("2006-01-02"), "-end=" + r.EndDate.Format("2006-01-02")}
+	fs.Visit(func(f *flag.Flag) {
+		if query.Lookup(f.Name) == nil || snapshotDateFlags[f.Name] {
+			return
+		}
+		args = append(args, "-"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// snapshotLabel はスナップショットの名前に使用できない文字を置き換える
+func snapshotLabel(label string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, label)
+}
+
+// snapshotPath はスナップショットの名前またはファイルのパスから、スナップショットのファイルのパスを返す
+func snapshotPath(dir, nameOrPath string) string {
+	if strings.ContainsAny(nameOrPath, `/\`) || strings.HasSuffix(nameOrPath, ".json") {
+		return nameOrPath
+	}
+	return filepath.Join(dir, nameOrPath+".json")
+}
+
+// saveSnapshot はスナップショットをファイルに保存する。同じ名前のスナップショットがある場合は、force が指定されない限りエラーを返す
+func saveSnapshot(path string, s *snapshot, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return errors.New(printer.Sprintf("スナップショット %s はすでに存在します（上書きする場合は -force を指定してください）", path))
+		}
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// loadSnapshot はファイルからスナップショットを読み込む
+func loadSnapshot(path string) (*snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// listSnapshots はディレクトリ内のスナップショットを作成日時の順に返す
+func listSnapshots(dir string) ([]*snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*snapshot
+	for _, path := range paths {
+		s, err := loadSnapshot(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// snapshotChange は時間が変わったイベントの変更前と変更後を表す
+type snapshotChange struct {
+	Old snapshotEvent
+	New snapshotEvent
+}
+
+// snapshotDiff は2つのスナップショットの間で追加・削除・時間が変わったイベントを表す
+type snapshotDiff struct {
+	Added   []snapshotEvent
+	Removed []snapshotEvent
+	Changed []snapshotChange
+}
+
+// empty は差分がないかどうかを判定する
+func (d *snapshotDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffSnapshots は変更前のスナップショットと変更後のスナップショットを比較する（イベントは開始日時の順に並べる）
+func diffSnapshots(old, cur *snapshot) *snapshotDiff {
+	d := &snapshotDiff{}
+	before := make(map[string]snapshotEvent, len(old.Events))
+	for _, e := range old.Events {
+		before[e.Key] = e
+	}
+	after := make(map[string]bool, len(cur.Events))
+	for _, e := range cur.Events {
+		after[e.Key] = true
+		prev, ok := before[e.Key]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, e)
+		case prev.duration() != e.duration():
+			d.Changed = append(d.Changed, snapshotChange{Old: prev, New: e})
+		}
+	}
+	for _, e := range old.Events {
+		if !after[e.Key] {
+			d.Removed = append(d.Removed, e)
+		}
+	}
+	byStart := func(events []snapshotEvent) {
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	}
+	byStart(d.Added)
+	byStart(d.Removed)
+	sort.SliceStable(d.Changed, func(i, j int) bool { return d.Changed[i].New.Start.Before(d.Changed[j].New.Start) })
+	return d
+}
+
+// sumSnapshotEvents はイベントの時間の合計を返す
+func sumSnapshotEvents(events []snapshotEvent) time.Duration {
+	var total time.Duration
+	for _, e := range events {
+		total += e.duration()
+	}
+	return total
+}
+
+// changedDelta は時間が変わったイベントの増減の合計を返す
+func changedDelta(changes []snapshotChange) time.Duration {
+	var delta time.Duration
+	for _, c := range changes {
+		delta += c.New.duration() - c.Old.duration()
+	}
+	return delta
+}
+
+// formatSnapshotEvent はイベントを「名前 (開始～終了)」の形式で表示用の文字列に変換する
+func formatSnapshotEvent(e snapshotEvent, location *time.Location) string {
+	if e.Manual {
+		return printer.Sprintf("[手動] %s (%s)", e.Summary, e.Start.In(location).Format("2006/01/02"))
+	}
+	return fmt.Sprintf("%s (%s～%s)", e.Summary, e.Start.In(location).Format("2006/01/02 15:04"), e.End.In(location).Format("15:04"))
+}
+
+// snapshotTitle はスナップショットを表す「'名前'（作成日時）」の文字列を返す
+func snapshotTitle(s *snapshot, location *time.Location) string {
+	return printer.Sprintf("'%s'（%s 作成）", s.Label, s.CreatedAt.In(location).Format("2006/01/02 15:04"))
+}
+
+// printSnapshotDiff は差分をテキスト形式で出力する
+func printSnapshotDiff(w io.Writer, old, cur *snapshot, d *snapshotDiff, location *time.Location) {
+	if cur.Label != "" {
+		fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("スナップショット %sと%sの比較:\n", snapshotTitle(old, location), snapshotTitle(cur, location))))
+	} else {
+		fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("スナップショット %sと現在の集計結果の比較:\n", snapshotTitle(old, location))))
+	}
+	printer.Fprintf(w, "イベント: '%s'\n", cur.Name)
+	printer.Fprintf(w, "検索期間: %s から %s\n", strings.ReplaceAll(cur.StartDate, "-", "/"), strings.ReplaceAll(cur.EndDate, "-", "/"))
+	printer.Fprintf(w, "合計時間: %s → %s (%s)\n", formatDuration(old.total()), formatDuration(cur.total()), formatSignedDuration(cur.total()-old.total()))
+	fmt.Fprintln(w)
+	if d.empty() {
+		printer.Fprintf(w, "スナップショットから変更はありません。\n")
+		return
+	}
+
+	if len(d.Added) > 0 {
+		fmt.Fprint(w, colorize(w, colorGreen, printer.Sprintf("追加されたイベント (%d件, %s):\n", len(d.Added), formatSignedDuration(sumSnapshotEvents(d.Added)))))
+		for _, e := range d.Added {
+			fmt.Fprintf(w, "+ %s [%s]\n", formatSnapshotEvent(e, location), formatDuration(e.duration()))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(d.Removed) > 0 {
+		fmt.Fprint(w, colorize(w, colorRed, printer.Sprintf("削除されたイベント (%d件, %s):\n", len(d.Removed), formatSignedDuration(-sumSnapshotEvents(d.Removed)))))
+		for _, e := range d.Removed {
+			fmt.Fprintf(w, "- %s [%s]\n", formatSnapshotEvent(e, location), formatDuration(e.duration()))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(d.Changed) > 0 {
+		fmt.Fprint(w, colorize(w, colorYellow, printer.Sprintf("時間が変わったイベント (%d件, %s):\n", len(d.Changed), formatSignedDuration(changedDelta(d.Changed)))))
+		for _, c := range d.Changed {
+			fmt.Fprintf(w, "~ %s [%s → %s]\n", formatSnapshotEvent(c.New, location), formatDuration(c.Old.duration()), formatDuration(c.New.duration()))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// printSnapshotDiffMarkdown は差分をMarkdown形式で出力する
+func printSnapshotDiffMarkdown(w io.Writer, old, cur *snapshot, d *snapshotDiff, location *time.Location) {
+	printer.Fprintf(w, "## イベント '%s' のスナップショットとの比較\n\n", escapeMarkdown(cur.Name))
+	printer.Fprintf(w, "- スナップショット: %s\n", escapeMarkdown(snapshotTitle(old, location)))
+	if cur.Label != "" {
+		printer.Fprintf(w, "- 比較するスナップショット: %s\n", escapeMarkdown(snapshotTitle(cur, location)))
+	}
+	printer.Fprintf(w, "- 検索期間: %s から %s\n", strings.ReplaceAll(cur.StartDate, "-", "/"), strings.ReplaceAll(cur.EndDate, "-", "/"))
+	printer.Fprintf(w, "- 合計時間: %s → **%s** (%s)\n\n", formatDuration(old.total()), formatDuration(cur.total()), formatSignedDuration(cur.total()-old.total()))
+	if d.empty() {
+		printer.Fprintf(w, "スナップショットから変更はありません。\n")
+		return
+	}
+	printer.Fprintf(w, "| 変更 | イベント | 変更前 | 変更後 | 増減 |\n")
+	printer.Fprintf(w, "|---|---|---:|---:|---:|\n")
+	for _, e := range d.Added {
+		printer.Fprintf(w, "| 追加 | %s | | %s | %s |\n", escapeMarkdown(formatSnapshotEvent(e, location)), formatDuration(e.duration()), formatSignedDuration(e.duration()))
+	}
+	for _, e := range d.Removed {
+		printer.Fprintf(w, "| 削除 | %s | %s | | %s |\n", escapeMarkdown(formatSnapshotEvent(e, location)), formatDuration(e.duration()), formatSignedDuration(-e.duration()))
+	}
+	for _, c := range d.Changed {
+		printer.Fprintf(w, "| 変更 | %s | %s | %s | %s |\n", escapeMarkdown(formatSnapshotEvent(c.New, location)), formatDuration(c.Old.duration()), formatDuration(c.New.duration()), formatSignedDuration(c.New.duration()-c.Old.duration()))
+	}
+	fmt.Fprintln(w)
+}
+
+// snapshotFlags は snapshot のフラグ
+type snapshotFlags struct {
+	opts  *queryOptions
+	label string
+	force bool
+	list  bool
+}
+
+// registerSnapshotFlags は snapshot のフラグを登録する（補完の候補にも使用する）
+func registerSnapshotFlags(fs *flag.FlagSet, cfg *Config) *snapshotFlags {
+	f := &snapshotFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.StringVar(&f.label, "label", "", "スナップショットの名前（デフォルトは作成日時）")
+	fs.BoolVar(&f.force, "force", false, "同じ名前のスナップショットがある場合は上書きする")
+	fs.BoolVar(&f.list, "list", false, "保存したスナップショットの一覧を表示")
+	return f
+}
+
+// runSnapshotCommand は集計結果をスナップショットとして保存するサブコマンドを実行する
+func runSnapshotCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	flags := registerSnapshotFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	if flags.list {
+		snapshots, err := listSnapshots(cfg.SnapshotDir)
+		if err != nil {
+			fatal("スナップショットの読み込みに失敗しました", "error", err)
+		}
+		if len(snapshots) == 0 {
+			printer.Printf("スナップショットがありません（%s）。\n", cfg.SnapshotDir)
+			return
+		}
+		for _, s := range snapshots {
+			printer.Printf("%s  %s  '%s' %s～%s  %s (%d件)\n", s.Label, s.CreatedAt.In(location).Format("2006/01/02 15:04"), s.Name,
+				strings.ReplaceAll(s.StartDate, "-", "/"), strings.ReplaceAll(s.EndDate, "-", "/"), formatDuration(s.total()), len(s.Events))
+		}
+		return
+	}
+	if opts.months != "" {
+		printer.Printf("エラー: snapshot では -months を指定できません。\n")
+		os.Exit(exitUsage)
+	}
+	opts.validate()
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	report := runQuery(ctx, opts.newSource(ctx, cfg), cfg, opts)
+
+	if flags.label == "" {
+		flags.label = time.Now().In(location).Format("20060102-150405")
+	}
+	flags.label = snapshotLabel(flags.label)
+	path := snapshotPath(cfg.SnapshotDir, flags.label)
+	s := newSnapshot(report, flags.label, opts.sourceName(), snapshotArgs(fs, cfg, report))
+	if err := saveSnapshot(path, s, flags.force); err != nil {
+		fatal("スナップショットの保存に失敗しました", "error", err)
+	}
+	printer.Printf("スナップショット '%s' を保存しました: %s（%s、%d件）\n", flags.label, path, formatDuration(report.Total), len(s.Events))
+}
+
+// diffFlags は diff のフラグ
+type diffFlags struct {
+	outputFormat string
+	timeout      time.Duration
+}
+
+// registerDiffFlags は diff のフラグを登録する（補完の候補にも使用する）
+func registerDiffFlags(fs *flag.FlagSet, cfg *Config) *diffFlags {
+	f := &diffFlags{}
+	fs.StringVar(&f.outputFormat, "output", "text", "出力形式（text, markdown）")
+	fs.DurationVar(&f.timeout, "timeout", 0, "処理全体のタイムアウト（例: 5m）。0の場合はタイムアウトしない")
+	return f
+}
+
+// runDiffCommand はスナップショットと現在の集計結果（または2つのスナップショット）を比較するサブコマンドを実行する
+func runDiffCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags := registerDiffFlags(fs, cfg)
+	parseFlags(fs, args)
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		printer.Printf("エラー: 比較するスナップショットの名前またはファイルのパスを指定してください。\n")
+		printer.Printf("使用方法: gcal-sum diff [-output=markdown] スナップショット [比較するスナップショット]\n")
+		os.Exit(exitUsage)
+	}
+	if !isValidOutputFormat(flags.outputFormat) {
+		printer.Printf("エラー: 出力形式 '%s' はサポートされていません（%s）。\n", flags.outputFormat, strings.Join(outputFormats, ", "))
+		os.Exit(exitUsage)
+	}
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	old, err := loadSnapshot(snapshotPath(cfg.SnapshotDir, fs.Arg(0)))
+	if err != nil {
+		fatal("スナップショットの読み込みに失敗しました", "error", err, "snapshot", fs.Arg(0))
+	}
+
+	var cur *snapshot
+	if fs.NArg() == 2 {
+		if cur, err = loadSnapshot(snapshotPath(cfg.SnapshotDir, fs.Arg(1))); err != nil {
+			fatal("スナップショットの読み込みに失敗しました", "error", err, "snapshot", fs.Arg(1))
+		}
+	} else {
+		// スナップショットを作成した時と同じ条件・期間で集計し直す（比較のための集計は履歴に保存しない）
+		qs := flag.NewFlagSet("diff", flag.ContinueOnError)
+		opts := &queryOptions{}
+		opts.register(qs, cfg)
+		if err := qs.Parse(old.Args); err != nil {
+			fatalCode(exitUsage, "スナップショットの集計条件の解析に失敗しました", "error", err)
+		}
+		opts.validate()
+		opts.noHistory = true
+		if flags.timeout > 0 {
+			opts.timeout = flags.timeout
+		}
+		ctx, cancel := newCommandContext(opts.timeout)
+		defer cancel()
+		report := runQuery(ctx, opts.newSource(ctx, cfg), cfg, opts)
+		cur = newSnapshot(report, "", opts.sourceName(), old.Args)
+	}
+
+	d := diffSnapshots(old, cur)
+	if flags.outputFormat == "markdown" {
+		printSnapshotDiffMarkdown(os.Stdout, old, cur, d, location)
+	} else {
+		printSnapshotDiff(os.Stdout, old, cur, d, location)
+	}
+}
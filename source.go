@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// eventQuery はイベントを取得する際の条件
+type eventQuery struct {
+	// タイトルなどに含まれる文字列での絞り込み（Calendar APIのqパラメータ）
+	Text string
+	// キャンセルされたイベントも取得する
+	ShowDeleted bool
+}
+
+// CalendarSource はイベントの取得元（Google Calendar、ICSファイル、フィクスチャなど）
+// 集計はこのインターフェースを通してイベントを取得するため、取得元に依存しない
+type CalendarSource interface {
+	// Events は期間と重なるイベントを開始日時順に取得する
+	Events(ctx context.Context, calendarID string, timeMin, timeMax time.Time, q eventQuery) ([]*calendar.Event, error)
+	// Calendars は利用可能なカレンダーの一覧を取得する
+	Calendars(ctx context.Context) ([]*calendar.CalendarListEntry, error)
+}
+
+// streamingSource はイベントを1ページずつ取得しながら渡せる取得元
+// 全件を保持せずに集計できるため、長い期間や大きなカレンダーでもメモリの使用量が増えない
+type streamingSource interface {
+	// EachEvent は期間と重なるイベントを開始日時順に1件ずつ f に渡す
+	EachEvent(ctx context.Context, calendarID string, timeMin, timeMax time.Time, q eventQuery, f func(*calendar.Event)) error
+}
+
+// eachEvent は取得元からイベントを1件ずつ f に渡す
+// 1ページずつ取得できない取得元の場合は、すべてのイベントを取得してから渡す
+func eachEvent(ctx context.Context, src CalendarSource, calendarID string, timeMin, timeMax time.Time, q eventQuery, f func(*calendar.Event)) error {
+	if ss, ok := src.(streamingSource); ok {
+		return ss.EachEvent(ctx, calendarID, timeMin, timeMax, q, f)
+	}
+	items, err := src.Events(ctx, calendarID, timeMin, timeMax, q)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		f(item)
+	}
+	return nil
+}
+
+// googleSource はGoogle Calendar APIからイベントを取得する
+type googleSource struct {
+	srv *calendar.Service
+}
+
+// Events はCalendar APIからイベントを取得する（全ページを取得）
+// 一時的なエラーの場合は最初のページから取得し直す
+func (s *googleSource) Events(ctx context.Context, calendarID string, timeMin, timeMax time.Time, q eventQuery) ([]*calendar.Event, error) {
+	var items []*calendar.Event
+	err := withRetry(ctx, func() error {
+		items = nil
+		call := s.srv.Events.List(calendarID).
+			TimeMin(timeMin.Format(time.RFC3339)).
+			TimeMax(timeMax.Format(time.RFC3339)).
+			SingleEvents(true).
+			ShowDeleted(q.ShowDeleted).
+			OrderBy("startTime").
+			Fields(eventListFields)
+		if q.Text != "" {
+			call = call.Q(q.Text)
+		}
+		return call.Pages(ctx, func(events *calendar.Events) error {
+			slog.Log(ctx, levelTrace, "イベントの一覧を1ページ取得しました", "calendar", calendarID, "count", len(events.Items))
+			progressFrom(ctx).addPage(len(events.Items))
+			items = append(items, events.Items...)
+			return nil
+		})
+	})
+	return items, err
+}
+
+// EachEvent はCalendar APIからイベントを1ページずつ取得し、1件ずつ f に渡す
+// 一時的なエラーの場合はそのページだけを取得し直すため、同じイベントを2回渡すことはない
+func (s *googleSource) EachEvent(ctx context.Context, calendarID string, timeMin, timeMax time.Time, q eventQuery, f func(*calendar.Event)) error {
+	pageToken := ""
+	for {
+		var events *calendar.Events
+		err := withRetry(ctx, func() error {
+			call := s.srv.Events.List(calendarID).
+				TimeMin(timeMin.Format(time.RFC3339)).
+				TimeMax(timeMax.Format(time.RFC3339)).
+				SingleEvents(true).
+				ShowDeleted(q.ShowDeleted).
+				OrderBy("startTime").
+				Fields(eventListFields).
+				PageToken(pageToken)
+			if q.Text != "" {
+				call = call.Q(q.Text)
+			}
+			var err error
+			events, err = call.Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		slog.Log(ctx, levelTrace, "イベントの一覧を1ページ取得しました", "calendar", calendarID, "count", len(events.Items))
+		progressFrom(ctx).addPage(len(events.Items))
+		for _, item := range events.Items {
+			f(item)
+		}
+		if events.NextPageToken == "" {
+			return nil
+		}
+		pageToken = events.NextPageToken
+	}
+}
+
+// Calendars はCalendar APIからカレンダーの一覧を取得する
+func (s *googleSource) Calendars(ctx context.Context) ([]*calendar.CalendarListEntry, error) {
+	var items []*calendar.CalendarListEntry
+	err := withRetry(ctx, func() error {
+		items = nil
+		return s.srv.CalendarList.List().Pages(ctx, func(list *calendar.CalendarList) error {
+			items = append(items, list.Items...)
+			return nil
+		})
+	})
+	return items, err
+}
+
+// icsSource はローカルのICSファイルからイベントを読み込む
+type icsSource struct {
+	path     string
+	location *time.Location
+}
+
+// Events はICSファイルから期間内のイベントを読み込む（ファイルは1つのカレンダーとして扱い、calendarIDは使用しない）
+func (s *icsSource) Events(ctx context.Context, calendarID string, timeMin, timeMax time.Time, q eventQuery) ([]*calendar.Event, error) {
+	items, err := loadICSEvents(s.path, s.location, timeMin, timeMax)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", printer.Sprintf("ICSファイル %s", s.path), err)
+	}
+	return items, nil
+}
+
+// Calendars はICSファイルを唯一のカレンダーとして返す
+func (s *icsSource) Calendars(ctx context.Context) ([]*calendar.CalendarListEntry, error) {
+	return []*calendar.CalendarListEntry{{Id: s.path, Summary: s.path, Primary: true}}, nil
+}
+
+// mockSource はフィクスチャのJSONファイルからイベントを読み込む
+type mockSource struct {
+	path     string
+	location *time.Location
+}
+
+// Events はフィクスチャから期間内のイベントを読み込む（calendarIDは使用しない）
+func (s *mockSource) Events(ctx context.Context, calendarID string, timeMin, timeMax time.Time, q eventQuery) ([]*calendar.Event, error) {
+	items, err := loadMockEvents(s.path, s.location, timeMin, timeMax, q.ShowDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", printer.Sprintf("フィクスチャ %s", s.path), err)
+	}
+	return items, nil
+}
+
+// Calendars はフィクスチャを唯一のカレンダーとして返す
+func (s *mockSource) Calendars(ctx context.Context) ([]*calendar.CalendarListEntry, error) {
+	return []*calendar.CalendarListEntry{{Id: s.path, Summary: s.path, Primary: true}}, nil
+}
+
+// isLocalSource は取得元がローカルのファイルかどうかを判定する
+// ローカルのファイルは期間の分割、キャッシュ、祝日カレンダー、ローカルストアを使用しない
+func isLocalSource(src CalendarSource) bool {
+	switch src.(type) {
+	case *icsSource, *mockSource:
+		return true
+	}
+	return false
+}
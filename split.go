@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// Split はイベントの説明の記述に従って、プロジェクトごとに分割したイベントの時間を表す
+type Split struct {
+	Project  string
+	Duration time.Duration
+}
+
+// 説明の1行に書かれた分割の区切り文字（「clientA: 30m / clientB: 60m」や「clientA: 30m, clientB: 60m」）
+var splitSeparators = []string{"/", ",", "、", "，"}
+
+// parseSplitMarkers はイベントの説明から「clientA: 30m / clientB: 60m」形式の行を探し、名前と時間の組を返す
+// 行のすべての項目が「名前: 時間」の形式になっている行だけを分割の指定とみなし、最初に見つかった行を使用する
+func parseSplitMarkers(description string) []Split {
+	// Google Calendarの説明はHTMLの改行を含むことがある
+	description = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n").Replace(description)
+	for _, line := range strings.Split(description, "\n") {
+		items := []string{line}
+		for _, sep := range splitSeparators {
+			var next []string
+			for _, item := range items {
+				next = append(next, strings.Split(item, sep)...)
+			}
+			items = next
+		}
+		var splits []Split
+		for _, item := range items {
+			name, value, ok := strings.Cut(strings.ReplaceAll(item, "：", ":"), ":")
+			if !ok {
+				splits = nil
+				break
+			}
+			name = strings.TrimSpace(name)
+			d, err := time.ParseDuration(strings.TrimSpace(value))
+			if name == "" || err != nil || d <= 0 {
+				splits = nil
+				break
+			}
+			splits = append(splits, Split{Project: name, Duration: d})
+		}
+		if len(splits) > 0 {
+			return splits
+		}
+	}
+	return nil
+}
+
+// splitDuration はイベントの集計する時間を、説明に書かれた時間の比率で分割する
+// 控除や期間の境界での切り詰めで集計する時間が説明の合計と異なる場合も、分割した時間の合計は集計する時間と一致させる
+// 名前はプロジェクトの対応付けに一致する場合はそのプロジェクト、一致しない場合は名前をそのままプロジェクトとする
+func splitDuration(markers []Split, duration time.Duration, rules []ProjectRule) []Split {
+	var written time.Duration
+	for _, m := range markers {
+		written += m.Duration
+	}
+	splits := make([]Split, len(markers))
+	var assigned time.Duration
+	for i, m := range markers {
+		project := projectFor(rules, m.Project)
+		if project == "" {
+			project = m.Project
+		}
+		d := time.Duration(float64(duration) * float64(m.Duration) / float64(written))
+		// 端数は最後の項目に含める
+		if i == len(markers)-1 {
+			d = duration - assigned
+		}
+		splits[i] = Split{Project: project, Duration: d}
+		assigned += d
+	}
+	return splits
+}
+
+// formatSplits はプロジェクトごとに分割した時間を表示用の文字列に変換する
+func formatSplits(splits []Split) string {
+	parts := make([]string, len(splits))
+	for i, s := range splits {
+		parts[i] = s.Project + " " + formatDuration(s.Duration)
+	}
+	return strings.Join(parts, ", ")
+}
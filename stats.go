@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats は一致したイベントの時間に関する統計値を表す
+type Stats struct {
+	Count      int
+	Average    time.Duration
+	Median     time.Duration
+	Shortest   time.Duration
+	Longest    time.Duration
+	ActiveDays int
+	PerDay     time.Duration
+}
+
+// computeStats はイベントの件数、平均・中央値・最短・最長の時間、実施日あたりの平均時間を求める
+func computeStats(events []MatchedEvent, location *time.Location) Stats {
+	var acc statsAccumulator
+	for _, e := range events {
+		acc.add(e, location)
+	}
+	return acc.result()
+}
+
+// statsAccumulator はイベントを1件ずつ受け取って統計値を求める
+// 中央値を求めるためにイベントごとの時間だけは保持するが、イベント自体は保持しない
+type statsAccumulator struct {
+	durations []time.Duration
+	days      map[string]bool
+	total     time.Duration
+}
+
+// add はイベントを統計値の計算に加える
+func (a *statsAccumulator) add(e MatchedEvent, location *time.Location) {
+	if a.days == nil {
+		a.days = make(map[string]bool)
+	}
+	a.durations = append(a.durations, e.Duration)
+	a.total += e.Duration
+	a.days[e.localStart(location).Format("2006-01-02")] = true
+}
+
+// result はそれまでに加えたイベントの統計値を返す
+func (a *statsAccumulator) result() Stats {
+	s := Stats{Count: len(a.durations)}
+	if len(a.durations) == 0 {
+		return s
+	}
+	durations := a.durations
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	n := len(durations)
+	s.Average = a.total / time.Duration(n)
+	if n%2 == 1 {
+		s.Median = durations[n/2]
+	} else {
+		s.Median = (durations[n/2-1] + durations[n/2]) / 2
+	}
+	s.Shortest = durations[0]
+	s.Longest = durations[n-1]
+	s.ActiveDays = len(a.days)
+	s.PerDay = a.total / time.Duration(s.ActiveDays)
+	return s
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// statsEvent は開始日時と長さから統計値の計算に使用するイベントを作成する
+func statsEvent(start time.Time, d time.Duration) MatchedEvent {
+	return MatchedEvent{Start: start, End: start.Add(d), Duration: d}
+}
+
+func TestComputeStats(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, 4, d, hour, 0, 0, 0, tokyo)
+	}
+
+	tests := []struct {
+		name   string
+		events []MatchedEvent
+		want   Stats
+	}{
+		{
+			name:   "イベントがない",
+			events: nil,
+			want:   Stats{},
+		},
+		{
+			name: "件数が奇数の場合の中央値",
+			events: []MatchedEvent{
+				statsEvent(day(1, 10), 3*time.Hour),
+				statsEvent(day(1, 14), time.Hour),
+				statsEvent(day(2, 10), 2*time.Hour),
+			},
+			want: Stats{
+				Count:      3,
+				Average:    2 * time.Hour,
+				Median:     2 * time.Hour,
+				Shortest:   time.Hour,
+				Longest:    3 * time.Hour,
+				ActiveDays: 2,
+				PerDay:     3 * time.Hour,
+			},
+		},
+		{
+			name: "件数が偶数の場合の中央値は中央の2件の平均",
+			events: []MatchedEvent{
+				statsEvent(day(1, 10), 4*time.Hour),
+				statsEvent(day(2, 10), 30*time.Minute),
+				statsEvent(day(3, 10), time.Hour),
+				statsEvent(day(4, 10), 2*time.Hour),
+			},
+			want: Stats{
+				Count:      4,
+				Average:    1*time.Hour + 52*time.Minute + 30*time.Second,
+				Median:     90 * time.Minute,
+				Shortest:   30 * time.Minute,
+				Longest:    4 * time.Hour,
+				ActiveDays: 4,
+				PerDay:     1*time.Hour + 52*time.Minute + 30*time.Second,
+			},
+		},
+		{
+			name: "実施日数は表示のタイムゾーンの日付で数える",
+			events: []MatchedEvent{
+				statsEvent(time.Date(2024, 4, 1, 15, 30, 0, 0, time.UTC), time.Hour), // 東京では4月2日 0:30
+				statsEvent(day(2, 10), time.Hour),
+				statsEvent(day(2, 23), time.Hour),
+			},
+			want: Stats{
+				Count:      3,
+				Average:    time.Hour,
+				Median:     time.Hour,
+				Shortest:   time.Hour,
+				Longest:    time.Hour,
+				ActiveDays: 1,
+				PerDay:     3 * time.Hour,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeStats(tt.events, tokyo); got != tt.want {
+				t.Errorf("computeStats = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeStatsCalendarLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// -calendar-timezone の場合は、取得元のカレンダーのタイムゾーンの日付で実施日を数える
+	a := statsEvent(time.Date(2024, 4, 1, 22, 0, 0, 0, newYork), time.Hour) // 東京では4月2日
+	b := statsEvent(time.Date(2024, 4, 2, 9, 0, 0, 0, tokyo), time.Hour)
+	if got := computeStats([]MatchedEvent{a, b}, tokyo).ActiveDays; got != 1 {
+		t.Errorf("表示のタイムゾーンの実施日数 = %d; want 1", got)
+	}
+	a.Location = newYork
+	if got := computeStats([]MatchedEvent{a, b}, tokyo).ActiveDays; got != 2 {
+		t.Errorf("カレンダーのタイムゾーンの実施日数 = %d; want 2", got)
+	}
+}
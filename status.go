@@ -0,0 +1,26 @@
+package main
+
+import "google.golang.org/api/calendar/v3"
+
+// isCancelled はイベントがキャンセルされているかを判定する
+func isCancelled(e *calendar.Event) bool {
+	return e.Status == "cancelled"
+}
+
+// declinedBySelf は自分が出席を辞退したイベントかどうかを判定する
+func declinedBySelf(e *calendar.Event) bool {
+	for _, a := range e.Attendees {
+		if a.Self {
+			return a.ResponseStatus == "declined"
+		}
+	}
+	return false
+}
+
+// isExcludedStatus はキャンセルされたイベントや辞退したイベントを集計から除外するかどうかを判定する
+func (o *queryOptions) isExcludedStatus(e *calendar.Event) bool {
+	if isCancelled(e) && !o.includeCancelled {
+		return true
+	}
+	return declinedBySelf(e) && !o.includeDeclined
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// eventStore はカレンダーごとに同期したイベントと同期トークンを保持するローカルストア
+type eventStore struct {
+	CalendarID string                     `json:"calendar_id"`
+	SyncToken  string                     `json:"sync_token"`
+	SyncedAt   time.Time                  `json:"synced_at"`
+	Events     map[string]*calendar.Event `json:"events"`
+
+	path string
+}
+
+// openEventStore はカレンダーのローカルストアを開く。存在しない場合は空のストアを返す
+func openEventStore(dir, calendarID string) (*eventStore, error) {
+	sum := sha256.Sum256([]byte(calendarID))
+	store := &eventStore{
+		CalendarID: calendarID,
+		Events:     make(map[string]*calendar.Event),
+		path:       filepath.Join(dir, hex.EncodeToString(sum[:])+".json"),
+	}
+
+	f, err := os.Open(store.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(store); err != nil {
+		return nil, err
+	}
+	if store.Events == nil {
+		store.Events = make(map[string]*calendar.Event)
+	}
+	return store, nil
+}
+
+// save はローカルストアをファイルに保存する
+// 書き込みの途中で中断しても前回のローカルストアが壊れないように、一時ファイルに書き込んでから置き換える
+func (s *eventStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, b, 0600)
+}
+
+// sync は同期トークンを使用して前回からの差分を取得し、ローカルストアに反映する
+// 同期トークンが無効になっている場合は全件を取得し直す
+func (s *eventStore) sync(ctx context.Context, srv *calendar.Service) (int, error) {
+	changed, nextToken, err := s.fetchChanges(ctx, srv, s.SyncToken)
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusGone {
+		slog.Info("同期トークンが無効になったため、全件を取得し直します", "calendar", s.CalendarID)
+		s.Events = make(map[string]*calendar.Event)
+		changed, nextToken, err = s.fetchChanges(ctx, srv, "")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range changed {
+		if item.Status == "cancelled" {
+			delete(s.Events, item.Id)
+			continue
+		}
+		s.Events[item.Id] = item
+	}
+	s.SyncToken = nextToken
+	s.SyncedAt = time.Now()
+	return len(changed), nil
+}
+
+// fetchChanges は同期トークン以降に変更されたイベントと次回の同期トークンを取得する
+func (s *eventStore) fetchChanges(ctx context.Context, srv *calendar.Service, syncToken string) ([]*calendar.Event, string, error) {
+	var items []*calendar.Event
+	var nextToken string
+	call := srv.Events.List(s.CalendarID).SingleEvents(true).Fields(eventListFields)
+	if syncToken != "" {
+		call = call.SyncToken(syncToken)
+	}
+	err := withRetry(ctx, func() error {
+		items = nil
+		return call.Pages(ctx, func(events *calendar.Events) error {
+			items = append(items, events.Items...)
+			progressFrom(ctx).addPage(len(events.Items))
+			if events.NextSyncToken != "" {
+				nextToken = events.NextSyncToken
+			}
+			return nil
+		})
+	})
+	return items, nextToken, err
+}
+
+// eventsInRange はローカルストアから期間と重なるイベントを開始日時順に返す
+func (s *eventStore) eventsInRange(location *time.Location, rangeStart, rangeEnd time.Time) []*calendar.Event {
+	var items []*calendar.Event
+	for _, item := range s.Events {
+		start, end, ok := eventTimes(item, location)
+		if !ok || !start.Before(rangeEnd) || !end.After(rangeStart) {
+			continue
+		}
+		items = append(items, item)
+	}
+	sortEventsByStart(items)
+	return items
+}
+
+// eventTimes はイベントの開始日時と終了日時を返す。終日イベントは指定されたタイムゾーンの0時として扱う
+func eventTimes(e *calendar.Event, location *time.Location) (time.Time, time.Time, bool) {
+	start, end, _, err := resolveEventTimes(e, location)
+	return start, end, err == nil
+}
+
+// syncStore はカレンダーのローカルストアを開き、最新の状態に同期して保存する
+func syncStore(ctx context.Context, srv *calendar.Service, storeDir, calendarID string) (*eventStore, error) {
+	store, err := openEventStore(storeDir, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", printer.Sprintf("ローカルストアの読み込みに失敗しました"), err)
+	}
+	changed, err := store.sync(ctx, srv)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", printer.Sprintf("イベントの同期に失敗しました"), err)
+	}
+	if err := store.save(); err != nil {
+		return nil, fmt.Errorf("%s: %w", printer.Sprintf("ローカルストアの保存に失敗しました"), err)
+	}
+	slog.Info("カレンダーを同期しました", "calendar", calendarID, "changed", changed, "stored", len(store.Events))
+	return store, nil
+}
+
+// syncCalendar はカレンダーのローカルストアを最新の状態に同期する。失敗した場合は終了する
+func syncCalendar(ctx context.Context, srv *calendar.Service, storeDir, calendarID string) *eventStore {
+	store, err := syncStore(ctx, srv, storeDir, calendarID)
+	if err != nil {
+		fatalAPI("ローカルストアの同期に失敗しました", contextError(ctx, err))
+	}
+	return store
+}
+
+// syncFlags は sync のフラグ
+type syncFlags struct {
+	calendarID string
+	timeout    time.Duration
+}
+
+// registerSyncFlags は sync のフラグを登録する（補完の候補にも使用する）
+func registerSyncFlags(fs *flag.FlagSet, cfg *Config) *syncFlags {
+	f := &syncFlags{}
+	fs.StringVar(&f.calendarID, "calendar", cfg.Calendar, "同期するカレンダーID")
+	fs.DurationVar(&f.timeout, "timeout", 0, "処理全体のタイムアウト（例: 5m）。0の場合はタイムアウトしない")
+	return f
+}
+
+// runSyncCommand はローカルストアを同期するサブコマンドを実行する
+func runSyncCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	flags := registerSyncFlags(fs, cfg)
+	parseFlags(fs, args)
+
+	ctx, cancel := newCommandContext(flags.timeout)
+	defer cancel()
+	srv := newCalendarService(ctx, cfg.CredentialsPath, cfg.TokenPath)
+	syncCalendar(ctx, srv, cfg.StoreDir, flags.calendarID)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestEventStoreSave(t *testing.T) {
+	dir := t.TempDir()
+	store, err := openEventStore(dir, "primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SyncToken = "token1"
+	store.Events["a"] = &calendar.Event{Id: "a", Summary: "定例"}
+	if err := store.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 保存し直しても、一時ファイルが残らずに置き換わること
+	store.SyncToken = "token2"
+	delete(store.Events, "a")
+	if err := store.save(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || filepath.Join(dir, entries[0].Name()) != store.path {
+		t.Errorf("ストアのディレクトリ = %v; want ストアのファイルだけ", entries)
+	}
+	info, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("パーミッション = %o; want 600", perm)
+	}
+
+	reopened, err := openEventStore(dir, "primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.SyncToken != "token2" || len(reopened.Events) != 0 {
+		t.Errorf("読み込んだストア = %+v; want token2 でイベントなし", reopened)
+	}
+}
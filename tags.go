@@ -0,0 +1,42 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// タグ（#clientA や #請求対象 など）に一致する正規表現
+var tagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_\-]+)`)
+
+// タグのないイベントのグループ名
+const noTagLabel = "(タグなし)"
+
+// extractTags はイベントのタイトルと説明からタグを抽出する（大文字小文字を区別せず重複を除く）
+func extractTags(e *calendar.Event) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, text := range []string{e.Summary, e.Description} {
+		for _, m := range tagPattern.FindAllStringSubmatch(text, -1) {
+			key := strings.ToLower(m[1])
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			tags = append(tags, m[1])
+		}
+	}
+	return tags
+}
+
+// hasTag はイベントに指定されたタグが付いているかを判定する
+func hasTag(e *calendar.Event, tag string) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	for _, t := range extractTags(e) {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// TargetProgress は目標時間に対する進捗を表す
+type TargetProgress struct {
+	Target    time.Duration
+	Percent   float64
+	Remaining time.Duration
+	// 今日までの1日あたりのペースから見込んだ期間終了時点の合計時間（期間開始前は0）
+	Projected time.Duration
+}
+
+// targetFor は -target または設定ファイルの targets からイベント名に対応する目標時間を求める
+func targetFor(flagValue string, targets map[string]string, name string) (time.Duration, error) {
+	value := flagValue
+	if value == "" {
+		for n, v := range targets {
+			if strings.EqualFold(n, name) {
+				value = v
+				break
+			}
+		}
+	}
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New(printer.Sprintf("目標時間 '%s' が不正です（例: 140h, 90m, 1h30m）: %v", value, err))
+	}
+	return d, nil
+}
+
+// computeTarget は集計結果と目標時間から進捗と期間終了時点の見込みを計算する
+func computeTarget(r *Report, target time.Duration, now time.Time) *TargetProgress {
+	p := &TargetProgress{
+		Target:    target,
+		Remaining: max(target-r.Total, 0),
+	}
+	if target > 0 {
+		p.Percent = float64(r.Total) / float64(target) * 100
+	}
+
+	// 期間の日数と、今日までの経過日数から1日あたりのペースを求める
+	totalDays := daysBetween(r.StartDate, r.EndDate) + 1
+	today := now.In(r.Location)
+	switch {
+	case today.Before(r.StartDate):
+		p.Projected = 0
+	case !today.Before(r.EndDate.AddDate(0, 0, 1)):
+		p.Projected = r.Total
+	default:
+		// カレンダーには今後の予定も含まれるため、ペースは今日までに始まったイベントから求める
+		toDate := r.StreamedToDate
+		for _, e := range r.Events {
+			if e.Start.Before(now) {
+				toDate += e.Duration
+			}
+		}
+		elapsed := daysBetween(r.StartDate, today) + 1
+		p.Projected = time.Duration(float64(toDate) / float64(elapsed) * float64(totalDays))
+	}
+	return p
+}
+
+// daysBetween は2つの日付の間の日数を返す
+func daysBetween(from, to time.Time) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// targetSummary は目標時間に対する進捗を表示用の文字列に変換する
+func targetSummary(p *TargetProgress) string {
+	s := printer.Sprintf("%s に対して %.1f%%（残り %s）", formatDuration(p.Target), p.Percent, formatDuration(p.Remaining))
+	if p.Projected > 0 {
+		s += printer.Sprintf("、期間終了時点の見込み %s", formatDuration(p.Projected))
+	}
+	return s
+}
+
+// targetColor は目標時間に対する進捗を表示する色を返す（色を付けない場合は空文字）
+func targetColor(p *TargetProgress) string {
+	switch {
+	case p.Target <= 0:
+		return ""
+	case p.Percent > 100:
+		return colorRed
+	case p.Projected > p.Target:
+		return colorYellow
+	case p.Percent == 100:
+		return colorGreen
+	}
+	return ""
+}
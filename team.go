@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// team で同時に集計する利用者の数のデフォルト値
+const defaultTeamParallel = 4
+
+// TeamConfig は team でドメイン全体の委任を使用して集計する際の設定
+type TeamConfig struct {
+	// ドメイン全体の委任を許可したサービスアカウントの鍵ファイル（JSON）のパス
+	ServiceAccount string `json:"service_account,omitempty"`
+
+	// 集計する利用者のメールアドレス
+	Users []string `json:"users,omitempty"`
+}
+
+// teamResult は1人の利用者の集計結果を表す（集計に失敗した場合は Err を設定する）
+type teamResult struct {
+	User   string
+	Report *Report
+	Err    error
+}
+
+// newDelegatedSource はサービスアカウントのドメイン全体の委任により、指定した利用者としてカレンダーを読み取る取得元を生成する
+func newDelegatedSource(ctx context.Context, keyPath, user string) (CalendarSource, error) {
+	b, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := google.JWTConfigFromJSON(b, calendar.CalendarReadonlyScope)
+	if err != nil {
+		return nil, err
+	}
+	conf.Subject = user
+	srv, err := calendar.NewService(ctx, option.WithHTTPClient(withRateLimit(conf.Client(ctx))))
+	if err != nil {
+		return nil, err
+	}
+	return &googleSource{srv: srv}, nil
+}
+
+// parseUsers はカンマ区切りのメールアドレスの一覧を解析する（重複は除く）
+func parseUsers(s string) []string {
+	var users []string
+	seen := make(map[string]bool)
+	for _, u := range strings.Split(s, ",") {
+		u = strings.TrimSpace(u)
+		if u == "" || seen[strings.ToLower(u)] {
+			continue
+		}
+		seen[strings.ToLower(u)] = true
+		users = append(users, u)
+	}
+	return users
+}
+
+// runTeamQueries は利用者ごとに、その利用者のメインのカレンダーに対して同じ条件の集計を並行に実行する
+// 1人の集計に失敗しても残りの利用者の集計は続け、失敗した利用者の結果にエラーを設定する
+func runTeamQueries(ctx context.Context, cfg *Config, o *queryOptions, users []string, keyPath string, parallel int) []teamResult {
+	results := make([]teamResult, len(users))
+	defer catchFatal()()
+
+	sem := make(chan struct{}, max(parallel, 1))
+	var wg sync.WaitGroup
+	for i, user := range users {
+		results[i].User = user
+		wg.Add(1)
+		go func(r *teamResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			defer func() {
+				if v := recover(); v != nil {
+					fe, ok := v.(*fatalError)
+					if !ok {
+						panic(v)
+					}
+					r.Err = fe
+				}
+			}()
+
+			// 利用者ごとのメインのカレンダーのIDはメールアドレスと同じため、キャッシュやチェックポイントも利用者ごとに分かれる
+			// 勤務時間帯は祝日を利用者ごとに設定するため、複製して共有しないようにする
+			uo := *o
+			uo.schedule = o.schedule.clone()
+			var src CalendarSource
+			if o.icsPath != "" || o.mockPath != "" {
+				src = o.newSource(ctx, cfg)
+			} else {
+				var err error
+				if src, err = newDelegatedSource(ctx, keyPath, r.User); err != nil {
+					slog.Error("サービスアカウントによる認証の設定に失敗しました", "user", r.User, "error", err)
+					r.Err = err
+					return
+				}
+				uo.calendarID = r.User
+			}
+			r.Report = runQuery(ctx, src, cfg, &uo)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// teamTotal はチーム全体の合計時間と、集計に成功した人数を返す
+func teamTotal(results []teamResult) (time.Duration, int) {
+	var total time.Duration
+	n := 0
+	for _, r := range results {
+		if r.Err == nil {
+			total += r.Report.Total
+			n++
+		}
+	}
+	return total, n
+}
+
+// printTeamComparison は利用者ごとの合計時間の比較表を出力形式に従って出力する
+func printTeamComparison(w io.Writer, results []teamResult, format string) {
+	if format == "markdown" {
+		printTeamMarkdown(w, results)
+		return
+	}
+	printTeamText(w, results)
+}
+
+// teamReport は比較表の見出しに使用する、集計に成功した最初の利用者の集計結果を返す
+func teamReport(results []teamResult) *Report {
+	for _, r := range results {
+		if r.Err == nil {
+			return r.Report
+		}
+	}
+	return nil
+}
+
+// printTeamText は利用者ごとの合計時間の比較表をテキスト形式で出力する
+// 集計に失敗した利用者のエラーはログに出力されるため、表には「エラー」とだけ表示する
+func printTeamText(w io.Writer, results []teamResult) {
+	first := teamReport(results)
+	if first != nil {
+		printer.Fprintf(w, "検索期間: %s から %s\n", first.StartDate.Format("2006/01/02"), first.EndDate.Format("2006/01/02"))
+		fmt.Fprint(w, colorize(w, colorBold, printer.Sprintf("イベント '%s' の利用者別の合計時間:\n", first.Name)))
+	}
+
+	// 列を揃えて表示するため、先に各行のセルを作ってから列の幅を求める
+	total, n := teamTotal(results)
+	var maxTotal time.Duration
+	count := 0
+	for _, r := range results {
+		if r.Err == nil {
+			maxTotal = max(maxTotal, r.Report.Total)
+			count += r.Report.count()
+		}
+	}
+	rows := [][]string{{printer.Sprintf("利用者"), printer.Sprintf("合計時間"), printer.Sprintf("件数"), printer.Sprintf("実施日数"), printer.Sprintf("割合")}}
+	for _, r := range results {
+		if r.Err != nil {
+			rows = append(rows, []string{r.User, printer.Sprintf("エラー"), "", "", ""})
+			continue
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(r.Report.Total) / float64(total) * 100
+		}
+		rows = append(rows, []string{r.User, formatDuration(r.Report.Total), printer.Sprintf("%d件", r.Report.count()), printer.Sprintf("%d日", r.Report.Stats.ActiveDays), fmt.Sprintf("%.1f%%", share)})
+	}
+	average := time.Duration(0)
+	if n > 0 {
+		average = total / time.Duration(n)
+	}
+	rows = append(rows,
+		[]string{printer.Sprintf("合計"), formatDuration(total), printer.Sprintf("%d件", count), "", ""},
+		[]string{printer.Sprintf("1人あたり"), formatDuration(average), "", "", ""})
+	widths := columnWidths(rows)
+	for i, row := range rows {
+		line := formatRow(row, widths)
+		switch {
+		case i == 0:
+			line = colorize(w, colorBold, strings.TrimRight(line, " "))
+		case i <= len(results) && results[i-1].Err == nil:
+			// 最も多い利用者を基準にした棒で比較する
+			share := 0.0
+			if maxTotal > 0 {
+				share = float64(results[i-1].Report.Total) / float64(maxTotal) * 100
+			}
+			if bar := shareBar(share); bar != "" {
+				line += "  " + colorize(w, colorCyan, bar)
+			} else {
+				line = strings.TrimRight(line, " ")
+			}
+		default:
+			line = strings.TrimRight(line, " ")
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+// printTeamMarkdown は利用者ごとの合計時間の比較表をMarkdown形式で出力する
+func printTeamMarkdown(w io.Writer, results []teamResult) {
+	if first := teamReport(results); first != nil {
+		printer.Fprintf(w, "## イベント '%s' の利用者別の集計\n\n", escapeMarkdown(first.Name))
+		printer.Fprintf(w, "- 検索期間: %s から %s\n\n", first.StartDate.Format("2006/01/02"), first.EndDate.Format("2006/01/02"))
+	}
+	total, n := teamTotal(results)
+	printer.Fprintf(w, "| 利用者 | 合計時間 | 件数 | 実施日数 | 割合 |\n")
+	printer.Fprintf(w, "|---|---:|---:|---:|---|\n")
+	count := 0
+	for _, r := range results {
+		if r.Err != nil {
+			printer.Fprintf(w, "| %s | エラー | | | |\n", escapeMarkdown(r.User))
+			continue
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(r.Report.Total) / float64(total) * 100
+		}
+		count += r.Report.count()
+		fmt.Fprintf(w, "| %s | %s | %d | %d | %.1f%% %s |\n", escapeMarkdown(r.User), formatDuration(r.Report.Total), r.Report.count(), r.Report.Stats.ActiveDays, share, shareBar(share))
+	}
+	average := time.Duration(0)
+	if n > 0 {
+		average = total / time.Duration(n)
+	}
+	printer.Fprintf(w, "| **合計** | **%s** | **%d** | | |\n", formatDuration(total), count)
+	printer.Fprintf(w, "| 1人あたり | %s | | | |\n\n", formatDuration(average))
+	for _, r := range results {
+		if r.Err != nil {
+			printer.Fprintf(w, "- %s の集計に失敗しました: %s\n", escapeMarkdown(r.User), escapeMarkdown(r.Err.Error()))
+		}
+	}
+}
+
+// teamFlags は team のフラグ
+type teamFlags struct {
+	opts         *queryOptions
+	usersFlag    string
+	keyPath      string
+	parallel     int
+	outputFormat string
+}
+
+// registerTeamFlags は team のフラグを登録する（補完の候補にも使用する）
+func registerTeamFlags(fs *flag.FlagSet, cfg *Config) *teamFlags {
+	f := &teamFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.StringVar(&f.usersFlag, "users", strings.Join(cfg.Team.Users, ","), "集計する利用者のメールアドレス、カンマ区切りで複数指定（デフォルトは設定ファイルの team.users）")
+	fs.StringVar(&f.keyPath, "service-account", cfg.Team.ServiceAccount, "ドメイン全体の委任を許可したサービスアカウントの鍵ファイル（デフォルトは設定ファイルの team.service_account）")
+	fs.IntVar(&f.parallel, "parallel", defaultTeamParallel, "同時に集計する利用者の数")
+	fs.StringVar(&f.outputFormat, "output", "text", "出力形式（text, markdown）")
+	return f
+}
+
+// runTeamCommand はドメイン全体の委任を使用して、複数の利用者のカレンダーで同じ集計を行い、利用者ごとに比較するサブコマンドを実行する
+func runTeamCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("team", flag.ExitOnError)
+	flags := registerTeamFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	if !isValidOutputFormat(flags.outputFormat) {
+		printer.Printf("エラー: 出力形式 '%s' はサポートされていません（%s）。\n", flags.outputFormat, strings.Join(outputFormats, ", "))
+		os.Exit(exitUsage)
+	}
+	users := parseUsers(flags.usersFlag)
+	if len(users) == 0 {
+		printer.Printf("エラー: -users または設定ファイルの team.users で集計する利用者を指定してください。\n")
+		os.Exit(exitUsage)
+	}
+	if flags.keyPath == "" && opts.icsPath == "" && opts.mockPath == "" {
+		printer.Printf("エラー: -service-account または設定ファイルの team.service_account でサービスアカウントの鍵ファイルを指定してください。\n")
+		os.Exit(exitUsage)
+	}
+	if opts.months != "" {
+		printer.Printf("エラー: team では -months を指定できません。\n")
+		os.Exit(exitUsage)
+	}
+	opts.validate()
+	if opts.icsPath != "" || opts.mockPath != "" {
+		slog.Warn("ICSファイルやフィクスチャを使用する場合は、すべての利用者で同じイベントを集計します")
+	}
+
+	// 利用者ごとの集計結果は比較表にまとめるため、個別の履歴は保存しない
+	// 並行に取得すると進捗の表示が混ざるため、進捗は表示しない
+	opts.noHistory = true
+	progressEnabled = false
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	results := runTeamQueries(ctx, cfg, opts, users, flags.keyPath, flags.parallel)
+	printTeamComparison(os.Stdout, results, flags.outputFormat)
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		slog.Error("一部の利用者の集計に失敗しました", "failed", failed, "total", len(results))
+		var fe *fatalError
+		for _, r := range results {
+			if errors.As(r.Err, &fe) {
+				exit(fe.code)
+			}
+		}
+		exit(exitAuth)
+	}
+	if total, _ := teamTotal(results); total == 0 {
+		exit(exitNoMatch)
+	}
+}
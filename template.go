@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateDuration はテンプレートから参照する時間
+// {{.Total}} は -duration-format の表示形式、{{.Total.Hours}} は小数の時間数、{{.Total.Minutes}} は分数で出力される
+type templateDuration time.Duration
+
+// Hours は時間数を小数で返す
+func (d templateDuration) Hours() float64 {
+	return time.Duration(d).Hours()
+}
+
+// Minutes は分数を返す
+func (d templateDuration) Minutes() int {
+	return int(time.Duration(d).Minutes())
+}
+
+// String は -duration-format の表示形式に従った文字列を返す
+func (d templateDuration) String() string {
+	return formatDuration(time.Duration(d))
+}
+
+// templateGroup はテンプレートから参照するグループごとの合計時間
+type templateGroup struct {
+	Key      string
+	Count    int
+	Duration templateDuration
+}
+
+// templateEvent はテンプレートから参照するイベント
+type templateEvent struct {
+	ID       string
+	Summary  string
+	Start    time.Time
+	End      time.Time
+	Duration templateDuration
+
+	// 調整ファイルによる手動調整かどうか
+	Manual bool
+}
+
+// templateReport は -format のテンプレートに渡す集計結果
+// 内部の Report の構造を変更してもテンプレートが壊れないように、参照できる項目をここで定める
+type templateReport struct {
+	Name       string
+	Start      string
+	End        string
+	Total      templateDuration
+	Count      int
+	ActiveDays int
+	Average    templateDuration
+	Median     templateDuration
+	Shortest   templateDuration
+	Longest    templateDuration
+	GroupBy    string
+	Groups     []templateGroup
+	Events     []templateEvent
+}
+
+// newTemplateReport は集計結果をテンプレートに渡す形式に変換する
+func newTemplateReport(r *Report) templateReport {
+	v := templateReport{
+		Name:       r.Name,
+		Start:      r.StartDate.Format("2006-01-02"),
+		End:        r.EndDate.Format("2006-01-02"),
+		Total:      templateDuration(r.Total),
+		Count:      r.count(),
+		ActiveDays: r.Stats.ActiveDays,
+		Average:    templateDuration(r.Stats.Average),
+		Median:     templateDuration(r.Stats.Median),
+		Shortest:   templateDuration(r.Stats.Shortest),
+		Longest:    templateDuration(r.Stats.Longest),
+		GroupBy:    r.GroupBy,
+	}
+	for _, g := range r.Groups {
+		v.Groups = append(v.Groups, templateGroup{Key: g.Key, Count: g.Count, Duration: templateDuration(g.Duration)})
+	}
+	for _, e := range r.Events {
+		v.Events = append(v.Events, templateEvent{
+			ID:       e.Event.Id,
+			Summary:  e.Event.Summary,
+			Start:    e.Start.In(r.Location),
+			End:      e.End.In(r.Location),
+			Duration: templateDuration(e.Duration),
+			Manual:   e.Manual,
+		})
+	}
+	return v
+}
+
+// parseFormatTemplate は -format で指定されたテンプレートを解析する
+// 存在しない項目の参照はイベントを取得する前に検出できるように、空の集計結果で一度実行しておく
+func parseFormatTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, templateReport{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// printTemplate はテンプレートに従って集計結果を出力する
+// テンプレートの末尾に改行がない場合は改行を補う
+func printTemplate(w io.Writer, tmpl *template.Template, r *Report) error {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, newTemplateReport(r)); err != nil {
+		return err
+	}
+	s := b.String()
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// timesheetDay は作業報告書の1日分の行
+type timesheetDay struct {
+	Date     time.Time
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Names    []string
+}
+
+// timesheetDays は一致したイベントを日ごとにまとめる
+// 開始・終了はその日の最初のイベントの開始と最後のイベントの終了、内容はイベント名を重複なく並べる
+func timesheetDays(r *Report) []timesheetDay {
+	var days []timesheetDay
+	for _, e := range r.Events {
+		start, end := e.Start.In(r.Location), e.End.In(r.Location)
+		date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, r.Location)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, timesheetDay{Date: date, Start: start, End: end})
+		}
+		d := &days[len(days)-1]
+		if end.After(d.End) {
+			d.End = end
+		}
+		d.Duration += e.Duration
+		if !containsString(d.Names, e.Event.Summary) {
+			d.Names = append(d.Names, e.Event.Summary)
+		}
+	}
+	return days
+}
+
+// timesheetOptions は作業報告書に記載する項目
+type timesheetOptions struct {
+	title   string
+	client  string
+	company string
+	worker  string
+}
+
+// 作業報告書の表の列（左端の位置と見出し）
+var timesheetColumns = []struct {
+	x     float64
+	label string
+}{
+	{40, "日付"},
+	{110, "曜日"},
+	{140, "開始"},
+	{185, "終了"},
+	{230, "時間"},
+	{300, "作業内容"},
+}
+
+// 作業報告書のレイアウト（ポイント）
+const (
+	timesheetMargin    = 40.0
+	timesheetRowHeight = 18.0
+	timesheetFontSize  = 9.5
+	// 最後のページで合計と署名欄を描画するのに必要な高さ
+	timesheetFooterHeight = 130.0
+)
+
+// buildTimesheet は集計結果から作業報告書のPDF文書を生成する
+func buildTimesheet(r *Report, o timesheetOptions) *pdfDocument {
+	doc := &pdfDocument{}
+	right := pdfPageWidth - timesheetMargin
+
+	page := doc.addPage()
+	y := pdfPageHeight - 52
+	page.text((pdfPageWidth-pdfTextWidth(o.title, 18))/2, y, 18, o.title)
+
+	y -= 36
+	if o.client != "" {
+		page.text(timesheetMargin, y, 12, printer.Sprintf("%s 御中", o.client))
+		page.line(timesheetMargin, y-4, timesheetMargin+220, y-4, 0.8)
+	}
+	if o.company != "" {
+		page.textRight(right, y, 10.5, o.company)
+		y -= 16
+	}
+	if o.worker != "" {
+		page.textRight(right, y, 10.5, printer.Sprintf("作業者: %s", o.worker))
+	}
+
+	y = pdfPageHeight - 140
+	page.text(timesheetMargin, y, 10.5, printer.Sprintf("作業期間: %s ～ %s", r.StartDate.Format("2006/01/02"), r.EndDate.Format("2006/01/02")))
+	page.textRight(right, y, 10.5, printer.Sprintf("合計作業時間: %s", formatDuration(r.Total)))
+	y -= 16
+	page.text(timesheetMargin, y, 10.5, printer.Sprintf("対象: %s", r.Name))
+	y -= 14
+
+	// 表の見出しを描画する。ページが変わった場合も各ページの先頭に描画する
+	header := func() {
+		page.fillRect(timesheetMargin, y-timesheetRowHeight, right-timesheetMargin, timesheetRowHeight, 0.88)
+		for _, c := range timesheetColumns {
+			page.text(c.x+4, y-13, timesheetFontSize, printer.Sprintf(c.label))
+		}
+		page.line(timesheetMargin, y, right, y, 0.8)
+		y -= timesheetRowHeight
+		page.line(timesheetMargin, y, right, y, 0.8)
+	}
+	header()
+
+	days := timesheetDays(r)
+	for i, d := range days {
+		// 最後の行の後には合計と署名欄を描画するため、収まらない場合は次のページに送る
+		bottom := timesheetMargin + 20
+		if i == len(days)-1 {
+			bottom += timesheetFooterHeight
+		}
+		if y-timesheetRowHeight < bottom {
+			page = doc.addPage()
+			y = pdfPageHeight - timesheetMargin
+			header()
+		}
+
+		base := y - 13
+		page.text(timesheetColumns[0].x+4, base, timesheetFontSize, d.Date.Format("2006/01/02"))
+		page.text(timesheetColumns[1].x+4, base, timesheetFontSize, weekdayLabel(weekdayIndex(d.Date)))
+		page.text(timesheetColumns[2].x+4, base, timesheetFontSize, d.Start.Format("15:04"))
+		page.text(timesheetColumns[3].x+4, base, timesheetFontSize, d.End.Format("15:04"))
+		page.textRight(timesheetColumns[5].x-8, base, timesheetFontSize, formatDuration(d.Duration))
+		names := pdfTruncate(strings.Join(d.Names, "、"), timesheetFontSize, right-timesheetColumns[5].x-8)
+		page.text(timesheetColumns[5].x+4, base, timesheetFontSize, names)
+		y -= timesheetRowHeight
+		page.line(timesheetMargin, y, right, y, 0.3)
+	}
+	if len(days) == 0 && y-timesheetRowHeight < timesheetMargin+20+timesheetFooterHeight {
+		page = doc.addPage()
+		y = pdfPageHeight - timesheetMargin
+	}
+
+	// 合計
+	page.line(timesheetMargin, y, right, y, 0.8)
+	page.text(timesheetColumns[0].x+4, y-13, timesheetFontSize, printer.Sprintf("合計（実施日数 %d日）", len(days)))
+	page.textRight(timesheetColumns[5].x-8, y-13, timesheetFontSize, formatDuration(r.Total))
+	y -= timesheetRowHeight
+	page.line(timesheetMargin, y, right, y, 0.8)
+
+	// 署名欄
+	y -= 60
+	for i, label := range []string{"作業者署名", "承認者署名"} {
+		x := timesheetMargin + float64(i)*(right-timesheetMargin)/2
+		page.text(x, y, 10.5, printer.Sprintf(label))
+		page.line(x, y-24, x+220, y-24, 0.8)
+		page.text(x, y-40, 9, printer.Sprintf("日付:"))
+		page.line(x+30, y-42, x+140, y-42, 0.5)
+	}
+
+	// ページ番号
+	for i, p := range doc.pages {
+		s := fmt.Sprintf("%d / %d", i+1, len(doc.pages))
+		p.text((pdfPageWidth-pdfTextWidth(s, 8))/2, 20, 8, s)
+	}
+	return doc
+}
+
+// exportPDFFlags は export pdf のフラグ
+type exportPDFFlags struct {
+	opts    *queryOptions
+	outPath string
+	ts      timesheetOptions
+}
+
+// registerExportPDFFlags は export pdf のフラグを登録する（補完の候補にも使用する）
+func registerExportPDFFlags(fs *flag.FlagSet, cfg *Config) *exportPDFFlags {
+	f := &exportPDFFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.StringVar(&f.outPath, "o", "timesheet.pdf", "出力先のファイル")
+	fs.StringVar(&f.ts.title, "title", "", "表題（デフォルトは「作業報告書」）")
+	fs.StringVar(&f.ts.client, "client", "", "提出先（クライアント）の名前")
+	fs.StringVar(&f.ts.company, "company", "", "作業者の会社名・屋号")
+	fs.StringVar(&f.ts.worker, "worker", "", "作業者の名前")
+	return f
+}
+
+// runExportPDF は一致したイベントから、日ごとの作業時間と署名欄を含む作業報告書をPDFで出力する
+func runExportPDF(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("export pdf", flag.ExitOnError)
+	flags := registerExportPDFFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+	ts := flags.ts
+	opts.validate()
+	if ts.title == "" {
+		ts.title = printer.Sprintf("作業報告書")
+	}
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	report := runQuery(ctx, src, cfg, opts)
+
+	f, err := os.Create(flags.outPath)
+	if err != nil {
+		fatal("出力ファイルの作成に失敗しました", "error", err)
+	}
+	err = buildTimesheet(report, ts).write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fatal("PDFの出力に失敗しました", "error", err)
+	}
+	printer.Printf("作業報告書を %s に保存しました\n", flags.outPath)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// timeEntryOptions はタイムトラッカー向けのCSVに出力する項目のオプション
+type timeEntryOptions struct {
+	client    string
+	project   string
+	task      string
+	email     string
+	firstName string
+	lastName  string
+	billable  bool
+}
+
+// entryProject はイベントのプロジェクト名を返す（-project-name の指定、プロジェクトの対応付け、イベント名の順に使用する）
+func (t *timeEntryOptions) entryProject(e MatchedEvent) string {
+	if t.project != "" {
+		return t.project
+	}
+	if e.Project != "" {
+		return e.Project
+	}
+	return e.Event.Summary
+}
+
+// formatClock は時間を HH:MM:SS 形式の文字列に変換する
+func formatClock(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// writeTogglCSV は一致したイベントをToggl TrackのCSVインポート形式で出力する
+func writeTogglCSV(w io.Writer, r *Report, t *timeEntryOptions) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Email", "Start date", "Start time", "Duration", "Project", "Task", "Client", "Description", "Billable", "Tags"})
+	billable := "No"
+	if t.billable {
+		billable = "Yes"
+	}
+	for _, e := range r.Events {
+		start := e.Start.In(r.Location)
+		cw.Write([]string{
+			t.email,
+			start.Format("2006-01-02"),
+			start.Format("15:04:05"),
+			formatClock(e.Duration),
+			t.entryProject(e),
+			t.task,
+			t.client,
+			e.Event.Summary,
+			billable,
+			strings.Join(extractTags(e.Event), ","),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeHarvestCSV は一致したイベントをHarvestのタイムエントリーのCSVインポート形式で出力する
+func writeHarvestCSV(w io.Writer, r *Report, t *timeEntryOptions) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Date", "Client", "Project", "Task", "Notes", "Hours", "First name", "Last name"})
+	for _, e := range r.Events {
+		cw.Write([]string{
+			e.Start.In(r.Location).Format("2006-01-02"),
+			t.client,
+			t.entryProject(e),
+			t.task,
+			e.Event.Summary,
+			fmt.Sprintf("%.2f", e.Duration.Hours()),
+			t.firstName,
+			t.lastName,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportTimeEntriesFlags は export toggl / export harvest のフラグ
+type exportTimeEntriesFlags struct {
+	opts    *queryOptions
+	t       *timeEntryOptions
+	outPath string
+}
+
+// registerExportTimeEntriesFlags は export toggl / export harvest のフラグを登録する（補完の候補にも使用する）
+func registerExportTimeEntriesFlags(fs *flag.FlagSet, cfg *Config, target string) *exportTimeEntriesFlags {
+	f := &exportTimeEntriesFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	f.t = &timeEntryOptions{}
+	fs.StringVar(&f.outPath, "o", "", "出力先のファイル（未指定の場合は標準出力）")
+	fs.StringVar(&f.t.client, "client", "", "タイムエントリーのクライアント名")
+	fs.StringVar(&f.t.project, "project-name", "", "タイムエントリーのプロジェクト名（未指定の場合はプロジェクトの対応付け、またはイベント名）")
+	fs.StringVar(&f.t.task, "task", "", "タイムエントリーのタスク名")
+	switch target {
+	case "toggl":
+		fs.StringVar(&f.t.email, "email", "", "Toggl Trackのユーザーのメールアドレス")
+		fs.BoolVar(&f.t.billable, "billable", false, "タイムエントリーを請求対象にする")
+	case "harvest":
+		fs.StringVar(&f.t.firstName, "first-name", "", "Harvestのユーザーの名")
+		fs.StringVar(&f.t.lastName, "last-name", "", "Harvestのユーザーの姓")
+	}
+	return f
+}
+
+// runExportTimeEntries は一致したイベントをタイムトラッカー（Toggl Track、Harvest）のCSVインポート形式で出力する
+func runExportTimeEntries(cfg *Config, target string, args []string) {
+	fs := flag.NewFlagSet("export "+target, flag.ExitOnError)
+	flags := registerExportTimeEntriesFlags(fs, cfg, target)
+	parseFlags(fs, args)
+	opts := flags.opts
+	t := flags.t
+	if target == "toggl" && t.email == "" {
+		printer.Printf("エラー: Toggl Trackのユーザーのメールアドレスを指定してください。\n")
+		printer.Printf("使用方法: gcal-sum export toggl -email=you@example.com -month=YYYY-MM -name=イベント名 [-o=toggl.csv]\n")
+		os.Exit(exitUsage)
+	}
+	opts.validate()
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	report := runQuery(ctx, src, cfg, opts)
+
+	var w io.Writer = os.Stdout
+	if flags.outPath != "" {
+		f, err := os.Create(flags.outPath)
+		if err != nil {
+			fatal("出力ファイルの作成に失敗しました", "error", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	write := writeTogglCSV
+	if target == "harvest" {
+		write = writeHarvestCSV
+	}
+	if err := write(w, report, t); err != nil {
+		fatal("CSVの出力に失敗しました", "error", err)
+	}
+	if flags.outPath != "" {
+		printer.Printf("%d件のタイムエントリーを %s に保存しました\n", len(report.Events), flags.outPath)
+	}
+	if len(report.Events) == 0 {
+		exit(exitNoMatch)
+	}
+}
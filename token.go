@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/term"
+)
+
+// トークンファイルのロックを確認する間隔
+const tokenLockPollInterval = 200 * time.Millisecond
+
+// lockTokenFile はトークンファイルのロックを取得し、ロックを解放する関数を返す
+// 定期実行が重なった場合などに、複数のプロセスが同時にトークンを更新して書き込まないようにする
+// ロックはトークンファイルと同じ場所のロックファイルに対するOSのファイルロックで表す
+// 異常終了した場合もOSがロックを解放するため、古いロックファイルを削除する必要はない（ロックファイルは削除せずに残す）
+func lockTokenFile(ctx context.Context, tokenPath string) func() {
+	lockPath := tokenPath + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		fatal("トークンファイルのロックに失敗しました", "error", err, "path", lockPath)
+	}
+	waiting := false
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			fatal("トークンファイルのロックに失敗しました", "error", err, "path", lockPath)
+		}
+		if locked {
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					unlockFile(f)
+					f.Close()
+				})
+			}
+		}
+		if !waiting {
+			slog.Info("別のプロセスがトークンを更新しているため、完了を待ちます", "path", lockPath)
+			waiting = true
+		}
+		select {
+		case <-time.After(tokenLockPollInterval):
+		case <-ctx.Done():
+			f.Close()
+			fatalCode(exitCodeFor(context.Cause(ctx)), "トークンファイルのロックを取得できませんでした", "reason", context.Cause(ctx), "path", lockPath)
+		}
+	}
+}
+
+// writeFileAtomic は一時ファイルに書き込んでから名前を変更することで、ファイルを置き換える
+// 書き込み中に異常終了したり、別のプロセスが同時に読み込んだりしても、途中までしか書き込まれていないファイルが見えないようにする
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// isInvalidGrant はトークンの更新に失敗した原因が、リフレッシュトークンの失効（invalid_grant）かどうかを判定する
+// アクセスの取り消し、パスワードの変更、テスト中のOAuth同意画面での期限切れなどで発生する
+func isInvalidGrant(err error) bool {
+	var re *oauth2.RetrieveError
+	return errors.As(err, &re) && re.ErrorCode == "invalid_grant"
+}
+
+// reauthenticate は失効したトークンファイルを削除し、ブラウザでの認証をやり直して新しいトークンを保存する
+// ブラウザでの認証を待てない環境（cronなど、標準エラー出力が端末でない場合）では、再認証の方法を示すエラーを返す
+// トークンファイルのロックを取得した状態で呼び出す
+func reauthenticate(ctx context.Context, config *oauth2.Config, tokenPath string, cause error) (*oauth2.Token, error) {
+	slog.Warn("リフレッシュトークンが失効しているため、トークンファイルを削除して再認証を行います", "path", tokenPath, "error", cause)
+	if err := os.Remove(tokenPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("トークンファイルの削除に失敗しました", "error", err, "path", tokenPath)
+	}
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil, fmt.Errorf("%w%s", cause, printer.Sprintf("（端末から gcal-sum を実行して再認証してください）"))
+	}
+	tok := getTokenFromWeb(ctx, config)
+	saveToken(tokenPath, tok)
+	return tok, nil
+}
+
+// reauthTokenSource は実行中にリフレッシュトークンが失効した場合に、再認証してトークンを取得し直すトークンソース
+type reauthTokenSource struct {
+	ctx       context.Context
+	config    *oauth2.Config
+	tokenPath string
+
+	mu  sync.Mutex
+	src oauth2.TokenSource
+	err error
+}
+
+// newReauthTokenSource は tok から始まり、失効した場合は再認証するトークンソースを生成する
+func newReauthTokenSource(ctx context.Context, config *oauth2.Config, tokenPath string, tok *oauth2.Token) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(tok, &reauthTokenSource{
+		ctx:       ctx,
+		config:    config,
+		tokenPath: tokenPath,
+		src:       config.TokenSource(ctx, tok),
+	})
+}
+
+func (s *reauthTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	tok, err := s.src.Token()
+	if err == nil || !isInvalidGrant(err) {
+		return tok, err
+	}
+
+	unlock := lockTokenFile(s.ctx, s.tokenPath)
+	defer unlock()
+	// 別のプロセスが先に再認証した場合は、そのトークンを使用する
+	if saved, readErr := tokenFromFile(s.tokenPath); readErr == nil && saved.Valid() {
+		s.src = s.config.TokenSource(s.ctx, saved)
+		return saved, nil
+	}
+	if tok, err = reauthenticate(s.ctx, s.config, s.tokenPath, err); err != nil {
+		s.err = err
+		return nil, err
+	}
+	s.src = s.config.TokenSource(s.ctx, tok)
+	return tok, nil
+}
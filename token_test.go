@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockTokenFile(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	unlock := lockTokenFile(context.Background(), tokenPath)
+
+	// ロックを保持している間は、別の取得はロックの解放を待つ
+	acquired := make(chan struct{})
+	go func() {
+		defer lockTokenFile(context.Background(), tokenPath)()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("ロックを保持している間にロックを取得できました")
+	case <-time.After(3 * tokenLockPollInterval):
+	}
+
+	unlock()
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(10 * tokenLockPollInterval):
+		t.Fatal("ロックの解放後もロックを取得できませんでした")
+	}
+}
+
+func TestLockTokenFileCancel(t *testing.T) {
+	defer catchFatal()()
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	defer lockTokenFile(context.Background(), tokenPath)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenLockPollInterval)
+	defer cancel()
+	defer func() {
+		if _, ok := recover().(*fatalError); !ok {
+			t.Error("ロックを待つ間にキャンセルしても fatal になりませんでした")
+		}
+	}()
+	lockTokenFile(ctx, tokenPath)
+	t.Error("ロックを保持している間にロックを取得できました")
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// トークンファイルを暗号化する鍵を指定する環境変数（32バイトの鍵をBase64で表した値）
+const tokenKeyEnv = "GCAL_SUM_TOKEN_KEY"
+
+// OSのキーリングに鍵を保存する際のサービス名とアカウント名
+const (
+	tokenKeyringService = "gcal-sum"
+	tokenKeyringAccount = "token-key"
+)
+
+// 暗号化したトークンファイルの暗号方式
+const tokenEncryptionAlgorithm = "aes-256-gcm"
+
+// トークンファイルの暗号化に使用する鍵の取得元（env: 環境変数, keyring: OSのキーリング）
+var tokenEncryptionModes = []string{"env", "keyring"}
+
+// encryptedToken は暗号化したトークンファイルの形式
+type encryptedToken struct {
+	Encryption string `json:"encryption"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// tokenKeyError はトークンファイルの暗号化の鍵に関するエラー
+// 認証をやり直しても解決しないため、トークンファイルがない場合とは区別して扱う
+type tokenKeyError struct {
+	err error
+}
+
+func (e *tokenKeyError) Error() string { return e.err.Error() }
+func (e *tokenKeyError) Unwrap() error { return e.err }
+
+// tokenKey はトークンファイルの暗号化に使用する鍵を返す（暗号化しない場合はnil）
+// キーリングの呼び出しは時間がかかるため、最初に必要になった時に1回だけ取得する
+var tokenKey = func() ([]byte, error) { return nil, nil }
+
+// setTokenEncryption は設定に従って、トークンファイルの暗号化に使用する鍵の取得方法を設定する
+// token_encryption を指定しない場合も、環境変数 GCAL_SUM_TOKEN_KEY があれば暗号化する
+func setTokenEncryption(cfg *Config) error {
+	mode := cfg.TokenEncryption
+	if mode == "" && os.Getenv(tokenKeyEnv) != "" {
+		mode = "env"
+	}
+	switch mode {
+	case "":
+		return nil
+	case "env":
+		tokenKey = sync.OnceValues(func() ([]byte, error) {
+			v := os.Getenv(tokenKeyEnv)
+			if v == "" {
+				return nil, errors.New(printer.Sprintf("環境変数 %s にトークンファイルを暗号化する鍵を指定してください（gcal-sum auth key で生成できます）", tokenKeyEnv))
+			}
+			return parseTokenKey(v)
+		})
+	case "keyring":
+		tokenKey = sync.OnceValues(func() ([]byte, error) {
+			v, err := keyringTokenKey()
+			if err != nil {
+				return nil, err
+			}
+			return parseTokenKey(v)
+		})
+	default:
+		return errors.New(printer.Sprintf("token_encryption '%s' はサポートされていません（%s）", mode, strings.Join(tokenEncryptionModes, ", ")))
+	}
+	return nil
+}
+
+// parseTokenKey はBase64で表した鍵を解析する（AES-256で使用するため32バイトであることを確認する）
+func parseTokenKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New(printer.Sprintf("トークンファイルを暗号化する鍵は、32バイトの値をBase64で表したものを指定してください（gcal-sum auth key で生成できます）"))
+	}
+	return key, nil
+}
+
+// newTokenKey はトークンファイルを暗号化する鍵を生成し、Base64で表した値を返す
+func newTokenKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// keyringTokenKey はOSのキーリングから鍵を取得する
+// macOSはキーチェーン（security）、Linuxなどは Secret Service（secret-tool）を使用する
+func keyringTokenKey() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", tokenKeyringService, "-a", tokenKeyringAccount, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", tokenKeyringService, "account", tokenKeyringAccount)
+	default:
+		return "", errors.New(printer.Sprintf("この環境ではOSのキーリングを使用できません。環境変数 %s で鍵を指定してください", tokenKeyEnv))
+	}
+	out, err := cmd.Output()
+	if err == nil && len(bytes.TrimSpace(out)) == 0 {
+		err = errors.New(printer.Sprintf("鍵が保存されていません"))
+	}
+	if err != nil {
+		return "", errors.New(printer.Sprintf("OSのキーリングから鍵を取得できません（gcal-sum auth key -keyring で鍵を生成して保存してください）: %v", err))
+	}
+	return string(out), nil
+}
+
+// storeKeyringTokenKey は鍵をOSのキーリングに保存する（同じ名前の鍵がある場合は置き換える）
+// 鍵がコマンドライン引数で他のプロセスから見えないように、鍵は標準入力から渡す
+func storeKeyringTokenKey(key string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// -w を最後の引数にして値を省略すると、security は確認のため2回入力を求める
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", tokenKeyringService, "-a", tokenKeyringAccount, "-w")
+		cmd.Stdin = strings.NewReader(key + "\n" + key + "\n")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("secret-tool", "store", "--label=gcal-sum token key", "service", tokenKeyringService, "account", tokenKeyringAccount)
+		cmd.Stdin = strings.NewReader(key)
+	default:
+		return errors.New(printer.Sprintf("この環境ではOSのキーリングを使用できません。環境変数 %s で鍵を指定してください", tokenKeyEnv))
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// newTokenCipher は鍵からAES-GCMの暗号化を生成する
+func newTokenCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encodeTokenFile はトークンファイルに書き込む内容を返す（暗号化する場合は暗号化した内容を返す）
+func encodeTokenFile(plain []byte) ([]byte, error) {
+	key, err := tokenKey()
+	if err != nil || key == nil {
+		return plain, err
+	}
+	aead, err := newTokenCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(encryptedToken{
+		Encryption: tokenEncryptionAlgorithm,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plain, []byte(tokenEncryptionAlgorithm)),
+	})
+}
+
+// readTokenFile はトークンファイルを読み込み、暗号化されている場合は復号した内容を返す
+// 暗号化を有効にする前に保存した平文のトークンファイルは、読み込んだ時点で暗号化して保存し直す
+func readTokenFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var enc encryptedToken
+	if json.Unmarshal(b, &enc) == nil && enc.Encryption != "" {
+		plain, err := decryptToken(&enc)
+		if err != nil {
+			return nil, &tokenKeyError{err}
+		}
+		return plain, nil
+	}
+
+	key, err := tokenKey()
+	if err != nil {
+		return nil, &tokenKeyError{err}
+	}
+	if key != nil {
+		if data, err := encodeTokenFile(b); err == nil && writeFileAtomic(path, data, 0600) == nil {
+			slog.Info("平文のトークンファイルを暗号化しました", "path", path)
+		} else {
+			slog.Warn("トークンファイルの暗号化に失敗しました", "path", path)
+		}
+	}
+	return b, nil
+}
+
+// decryptToken は暗号化したトークンファイルの内容を復号する
+func decryptToken(enc *encryptedToken) ([]byte, error) {
+	if enc.Encryption != tokenEncryptionAlgorithm {
+		return nil, errors.New(printer.Sprintf("トークンファイルの暗号方式 '%s' はサポートされていません", enc.Encryption))
+	}
+	key, err := tokenKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.New(printer.Sprintf("トークンファイルは暗号化されています。復号する鍵を環境変数 %s または設定ファイルの token_encryption で指定してください", tokenKeyEnv))
+	}
+	aead, err := newTokenCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, enc.Nonce, enc.Ciphertext, []byte(tokenEncryptionAlgorithm))
+	if err != nil {
+		return nil, errors.New(printer.Sprintf("トークンファイルを復号できません（暗号化した時と鍵が異なる可能性があります）"))
+	}
+	return plain, nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+	"google.golang.org/api/calendar/v3"
+)
+
+// TUIで扱うキー入力
+const (
+	keyRune = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyTab
+	keyBackspace
+	keyEscape
+	keyQuit
+)
+
+// tuiKey は1回分のキー入力を表す
+type tuiKey struct {
+	kind int
+	r    rune
+}
+
+// tuiCalendar はカレンダーの選択欄に表示するカレンダー
+type tuiCalendar struct {
+	ID      string
+	Summary string
+}
+
+// tuiState はTUIの表示状態を表す
+type tuiState struct {
+	calendars []tuiCalendar
+	selected  int
+	month     time.Time
+	filter    string
+	scroll    int
+
+	// カレンダーの選択欄を表示している間のカーソル位置（表示していない場合は-1）
+	picking int
+
+	location *time.Location
+	items    []*calendar.Event
+	loading  bool
+}
+
+// readKeys は標準入力からキー入力を読み取り、チャネルに送る
+func readKeys(ch chan<- tuiKey) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(ch)
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			ch <- k
+		}
+	}
+}
+
+// parseKeys は端末から読み取ったバイト列をキー入力に変換する
+func parseKeys(b []byte) []tuiKey {
+	var keys []tuiKey
+	for len(b) > 0 {
+		// 矢印キーはエスケープシーケンス（ESC [ A など）で送られてくる
+		if b[0] == 0x1b {
+			if len(b) >= 3 && b[1] == '[' {
+				switch b[2] {
+				case 'A':
+					keys = append(keys, tuiKey{kind: keyUp})
+				case 'B':
+					keys = append(keys, tuiKey{kind: keyDown})
+				case 'C':
+					keys = append(keys, tuiKey{kind: keyRight})
+				case 'D':
+					keys = append(keys, tuiKey{kind: keyLeft})
+				}
+				b = b[3:]
+				continue
+			}
+			keys = append(keys, tuiKey{kind: keyEscape})
+			b = b[1:]
+			continue
+		}
+		switch b[0] {
+		case 0x03, 0x04:
+			keys = append(keys, tuiKey{kind: keyQuit})
+		case '\r', '\n':
+			keys = append(keys, tuiKey{kind: keyEnter})
+		case '\t':
+			keys = append(keys, tuiKey{kind: keyTab})
+		case 0x7f, 0x08:
+			keys = append(keys, tuiKey{kind: keyBackspace})
+		}
+		if b[0] < 0x20 || b[0] == 0x7f {
+			b = b[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		keys = append(keys, tuiKey{kind: keyRune, r: r})
+		b = b[size:]
+	}
+	return keys
+}
+
+// handleKey はキー入力に応じて表示状態を更新する
+// 月やカレンダーが変わりイベントを取得し直す必要がある場合は reload に true、終了する場合は quit に true を返す
+func (s *tuiState) handleKey(k tuiKey) (reload, quit bool) {
+	// カレンダーの選択欄を表示している間は、上下で選択してEnterで確定する
+	if s.picking >= 0 {
+		switch k.kind {
+		case keyUp:
+			s.picking = max(s.picking-1, 0)
+		case keyDown:
+			s.picking = min(s.picking+1, len(s.calendars)-1)
+		case keyEnter:
+			reload = s.picking != s.selected
+			s.selected, s.picking, s.scroll = s.picking, -1, 0
+		case keyEscape, keyTab:
+			s.picking = -1
+		case keyQuit:
+			quit = true
+		}
+		return reload, quit
+	}
+
+	switch k.kind {
+	case keyRune:
+		s.filter += string(k.r)
+		s.scroll = 0
+	case keyBackspace:
+		if r := []rune(s.filter); len(r) > 0 {
+			s.filter = string(r[:len(r)-1])
+			s.scroll = 0
+		}
+	case keyEscape:
+		// 絞り込みの入力中は入力を消し、入力がない場合は終了する
+		if s.filter == "" {
+			quit = true
+		}
+		s.filter, s.scroll = "", 0
+	case keyLeft:
+		s.month, s.scroll, reload = s.month.AddDate(0, -1, 0), 0, true
+	case keyRight:
+		s.month, s.scroll, reload = s.month.AddDate(0, 1, 0), 0, true
+	case keyUp:
+		s.scroll = max(s.scroll-1, 0)
+	case keyDown:
+		s.scroll++
+	case keyTab:
+		if len(s.calendars) > 1 {
+			s.picking = s.selected
+		}
+	case keyQuit:
+		quit = true
+	}
+	return reload, quit
+}
+
+// report は現在の絞り込み条件で、表示中の月のイベントを集計する
+func (s *tuiState) report(o *queryOptions) *Report {
+	o.name = s.filter
+	endDate := s.month.AddDate(0, 1, -1)
+	return buildReport(s.items, o, s.month, endDate, s.location)
+}
+
+// render は現在の状態を端末に描画する
+func (s *tuiState) render(o *queryOptions) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = defaultTerminalWidth, 24
+	}
+
+	var b bytes.Buffer
+	cal := s.calendars[s.selected]
+	printer.Fprintf(&b, "カレンダー: %s   月: %s\n", cal.Summary, s.month.Format("2006/01"))
+	printer.Fprintf(&b, "絞り込み: %s_\n", s.filter)
+	printer.Fprintf(&b, "←→: 月の移動  ↑↓: スクロール  Tab: カレンダーの選択  文字入力: イベント名で絞り込み  Esc: 入力の消去・終了\n\n")
+
+	switch {
+	case s.picking >= 0:
+		printer.Fprintf(&b, "カレンダーを選択してください（Enter: 決定、Esc: 取り消し）:\n")
+		for i, c := range s.calendars {
+			cursor := "  "
+			if i == s.picking {
+				cursor = "> "
+			}
+			fmt.Fprintf(&b, "%s%s (%s)\n", cursor, c.Summary, c.ID)
+		}
+	case s.loading:
+		printer.Fprintf(&b, "イベントを取得しています…\n")
+	default:
+		r := s.report(o)
+		printer.Fprintf(&b, "合計時間: %s（%d件、実施日数 %d日）\n\n", formatDuration(r.Total), len(r.Events), r.Stats.ActiveDays)
+		if len(r.Events) == 0 {
+			printer.Fprintf(&b, "一致するイベントが見つかりませんでした。\n")
+			break
+		}
+		printChart(&b, groupEvents(r.Events, "day", s.location), groupByLabel("day"), width)
+	}
+
+	// 日別の内訳が画面に収まらない場合は、ヘッダーを残してスクロールする
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	const headerLines = 6
+	if len(lines) > height && len(lines) > headerLines {
+		body := lines[headerLines:]
+		s.scroll = min(s.scroll, max(len(body)-(height-headerLines), 0))
+		end := min(s.scroll+height-headerLines, len(body))
+		lines = append(lines[:headerLines:headerLines], body[s.scroll:end]...)
+	}
+
+	// 端末はrawモードのため、改行ごとに行頭へ戻す
+	fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+}
+
+// tuiCalendars はカレンダーの選択欄に表示するカレンダーの一覧を返す
+// 取得元のカレンダー一覧を取得し、指定されたカレンダーを先頭に並べる
+func tuiCalendars(ctx context.Context, src CalendarSource, o *queryOptions) []tuiCalendar {
+	var cals []tuiCalendar
+	seen := make(map[string]bool)
+	for _, id := range o.calendarIDs() {
+		cals = append(cals, tuiCalendar{ID: id, Summary: id})
+		seen[id] = true
+	}
+	list, err := src.Calendars(ctx)
+	if err != nil {
+		fatalAPI("カレンダー一覧の取得に失敗しました", contextError(ctx, err))
+	}
+	for _, item := range list {
+		for i := range cals {
+			if cals[i].ID == item.Id || (cals[i].ID == "primary" && item.Primary) {
+				cals[i].Summary = item.Summary
+			}
+		}
+		if !seen[item.Id] && !item.Primary {
+			cals = append(cals, tuiCalendar{ID: item.Id, Summary: item.Summary})
+		}
+	}
+	return cals
+}
+
+// registerTUIFlags は tui のフラグを登録する（補完の候補にも使用する）
+func registerTUIFlags(fs *flag.FlagSet, cfg *Config) *queryOptions {
+	opts := &queryOptions{}
+	opts.register(fs, cfg)
+	return opts
+}
+
+// runTUICommand は月やカレンダーを切り替えながら対話的に集計するTUIを実行する
+func runTUICommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	opts := registerTUIFlags(fs, cfg)
+	parseFlags(fs, args)
+	// 取得の進み具合は画面の描画と重なるため表示しない
+	progressEnabled = false
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		printer.Printf("エラー: tui は端末から実行してください。\n")
+		os.Exit(exitUsage)
+	}
+	if err := setDurationFormat(opts.durationFormat); err != nil {
+		printer.Printf("エラー: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	// 入力中のイベント名は部分一致で絞り込む
+	opts.match = "contains"
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	now := time.Now().In(location)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	if opts.month != "" {
+		if month, _, err = getMonthDates(opts.month, location); err != nil {
+			fatalCode(exitUsage, "月指定の解析に失敗しました", "error", err)
+		}
+	}
+
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	src := opts.newSource(ctx, cfg)
+	state := &tuiState{
+		calendars: tuiCalendars(ctx, src, opts),
+		month:     month,
+		picking:   -1,
+		location:  location,
+	}
+
+	// 画面を切り替えてrawモードにする。エラーで終了する場合も端末の状態を元に戻す
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		fatal("端末の設定に失敗しました", "error", err)
+	}
+	restore := func() {
+		fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+		term.Restore(int(os.Stdin.Fd()), oldState)
+	}
+	defer onFatal(restore)()
+	defer restore()
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+
+	// 描画中の画面が崩れないように、TUIの実行中はエラー以外のログを出力しない
+	slog.SetDefault(slog.New(newConsoleHandler(os.Stderr, slog.LevelError)))
+
+	// 一度取得した月のイベントは、カレンダーごとに保持して再利用する
+	fetched := make(map[string][]*calendar.Event)
+	load := func() {
+		key := state.calendars[state.selected].ID + "\n" + state.month.Format("2006-01")
+		items, ok := fetched[key]
+		if !ok {
+			state.loading = true
+			state.render(opts)
+			opts.calendarID = state.calendars[state.selected].ID
+			items = opts.fetchEvents(ctx, src, cfg, location, state.month, state.month.AddDate(0, 1, 0))
+			fetched[key] = items
+		}
+		state.items, state.loading = items, false
+	}
+	load()
+	state.render(opts)
+
+	keys := make(chan tuiKey)
+	go readKeys(keys)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case k, ok := <-keys:
+			if !ok {
+				return
+			}
+			reload, quit := state.handleKey(k)
+			if quit {
+				return
+			}
+			if reload {
+				load()
+			}
+			state.render(opts)
+		}
+	}
+}
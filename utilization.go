@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// 日本の祝日カレンダーのID
+const japaneseHolidayCalendar = "ja.japanese#holiday@group.v.calendar.google.com"
+
+// デフォルトの1日あたりの稼働時間
+const defaultHoursPerDay = 8 * time.Hour
+
+// Utilization は稼働可能時間に対する集計時間の割合を表す
+type Utilization struct {
+	WorkingDays int
+	Holidays    int
+	HoursPerDay time.Duration
+	Available   time.Duration
+	Percent     float64
+}
+
+// defaultWorkdays は勤務日の指定がない場合の勤務日（月曜日～金曜日）
+var defaultWorkdays = [7]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true}
+
+// fetchHolidays は祝日カレンダーから期間内の終日イベントの日付（YYYY-MM-DD）を取得する
+func fetchHolidays(ctx context.Context, src CalendarSource, calendarID string, startDate, searchEndDate time.Time) (map[string]bool, error) {
+	items, err := src.Events(ctx, calendarID, startDate, searchEndDate, eventQuery{})
+	if err != nil {
+		return nil, err
+	}
+	holidays := make(map[string]bool)
+	for _, item := range items {
+		if item.Start == nil || item.Start.Date == "" {
+			continue
+		}
+		// 複数日にわたる終日イベントはすべての日を祝日とする
+		start, err := time.Parse("2006-01-02", item.Start.Date)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse("2006-01-02", item.End.Date)
+		if err != nil || !end.After(start) {
+			end = start.AddDate(0, 0, 1)
+		}
+		for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+			holidays[d.Format("2006-01-02")] = true
+		}
+	}
+	return holidays, nil
+}
+
+// countWorkingDays は期間内の勤務日の数と、勤務日に重なった祝日の数を返す
+func countWorkingDays(startDate, endDate time.Time, workdays [7]bool, holidays map[string]bool) (int, int) {
+	working, holidayCount := 0, 0
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		if !workdays[d.Weekday()] {
+			continue
+		}
+		if holidays[d.Format("2006-01-02")] {
+			holidayCount++
+			continue
+		}
+		working++
+	}
+	return working, holidayCount
+}
+
+// computeUtilization は期間内の稼働可能時間に対する集計時間の割合を求める
+func computeUtilization(r *Report, workdays [7]bool, holidays map[string]bool, hoursPerDay time.Duration) *Utilization {
+	u := &Utilization{HoursPerDay: hoursPerDay}
+	u.WorkingDays, u.Holidays = countWorkingDays(r.StartDate, r.EndDate, workdays, holidays)
+	u.Available = time.Duration(u.WorkingDays) * hoursPerDay
+	if u.Available > 0 {
+		u.Percent = float64(r.Total) / float64(u.Available) * 100
+	}
+	return u
+}
+
+// utilizationSummary は稼働率を表示用の文字列に変換する
+func utilizationSummary(u *Utilization) string {
+	s := printer.Sprintf("%.1f%%（稼働可能時間 %s = %d日 × %s", u.Percent, formatDuration(u.Available), u.WorkingDays, formatDuration(u.HoursPerDay))
+	if u.Holidays > 0 {
+		s += printer.Sprintf("、祝日 %d日を除く", u.Holidays)
+	}
+	return s + printer.Sprintf("）")
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// watch のデフォルトの実行間隔
+const defaultWatchInterval = 24 * time.Hour
+
+// WatchJob は watch で定期的に実行する集計と、その結果の配信先
+type WatchJob struct {
+	// ログに表示する集計の名前
+	Name string `json:"name"`
+
+	// 集計の条件（serve のクエリパラメータと同じ名前で指定する）
+	Query map[string]string `json:"query"`
+
+	// 集計する期間（day: 今日, week: 今週, month: 今月）。省略した場合は month
+	Period string `json:"period,omitempty"`
+
+	// 集計結果の配信先
+	SlackWebhook string   `json:"slack_webhook,omitempty"`
+	Webhook      string   `json:"webhook,omitempty"`
+	Email        []string `json:"email,omitempty"`
+	HTML         string   `json:"html,omitempty"`
+}
+
+// validateWatchJobs は設定ファイルの集計を検証する
+func validateWatchJobs(jobs []WatchJob) error {
+	if len(jobs) == 0 {
+		return errors.New(printer.Sprintf("設定ファイルに watch が設定されていません"))
+	}
+	for _, j := range jobs {
+		if j.Name == "" {
+			return errors.New(printer.Sprintf("watch の name を指定してください"))
+		}
+		if j.Period != "" && !containsString(metricPeriods, j.Period) {
+			return errors.New(printer.Sprintf("watch '%s' の期間 '%s' はサポートされていません（%s）", j.Name, j.Period, strings.Join(metricPeriods, ", ")))
+		}
+	}
+	return nil
+}
+
+// nextRun は次に実行する時刻を求める。at（HH:MM形式）が指定されている場合は、次にその時刻になる日時を返す
+func nextRun(now time.Time, at string) (time.Time, error) {
+	if at == "" {
+		return now, nil
+	}
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return time.Time{}, errors.New(printer.Sprintf("実行時刻 '%s' はHH:MM形式で指定してください", at))
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if next.Before(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// runWatchJob は1件の集計を実行し、結果を配信する
+func (s *server) runWatchJob(ctx context.Context, job WatchJob, now time.Time) error {
+	params := url.Values{}
+	for k, v := range job.Query {
+		params.Set(k, v)
+	}
+	start, end := periodRange(job.Period, now)
+	params.Set("start", start.Format("2006-01-02"))
+	params.Set("end", end.Format("2006-01-02"))
+	params.Del("month")
+	params.Del("period")
+	params.Del("week")
+
+	opts, err := s.options(params)
+	if err != nil {
+		return err
+	}
+	report, err := s.query(ctx, opts)
+	if err != nil {
+		return err
+	}
+	slog.Info("集計しました", "job", job.Name, "total", formatDuration(report.Total), "count", report.count())
+
+	// いずれかの配信に失敗した場合も、残りの配信先には送信する
+	var errs []string
+	if job.SlackWebhook != "" {
+		if err := notifySlack(ctx, job.SlackWebhook, slackSummary(report)); err != nil {
+			errs = append(errs, "slack: "+err.Error())
+		}
+	}
+	if job.Webhook != "" {
+		if err := deliverWebhook(ctx, job.Webhook, report); err != nil {
+			errs = append(errs, "webhook: "+err.Error())
+		}
+	}
+	if len(job.Email) > 0 {
+		if err := deliverEmail(ctx, s.cfg.Delivery.Email, job.Email, report); err != nil {
+			errs = append(errs, "email: "+err.Error())
+		}
+	}
+	if job.HTML != "" {
+		if err := writeHTMLFile(job.HTML, report); err != nil {
+			errs = append(errs, "html: "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// writeHTMLFile は集計結果をHTMLレポートのファイルに保存する
+func writeHTMLFile(path string, r *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := printHTML(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// watchFlags は watch のフラグ
+type watchFlags struct {
+	opts  *queryOptions
+	every time.Duration
+	at    string
+	once  bool
+}
+
+// registerWatchFlags は watch のフラグを登録する（補完の候補にも使用する）
+func registerWatchFlags(fs *flag.FlagSet, cfg *Config) *watchFlags {
+	f := &watchFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.DurationVar(&f.every, "every", defaultWatchInterval, "集計を実行する間隔")
+	fs.StringVar(&f.at, "at", "", "最初に集計を実行する時刻（HH:MM形式）。未指定の場合は起動してすぐに実行")
+	fs.BoolVar(&f.once, "once", false, "集計を1回だけ実行して終了")
+	return f
+}
+
+// runWatchCommand は設定ファイルの集計を一定の間隔で実行し、結果を配信し続けるサブコマンドを実行する
+func runWatchCommand(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	flags := registerWatchFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+	// 集計は繰り返し実行されるため、取得の進み具合は表示しない
+	progressEnabled = false
+
+	if err := validateWatchJobs(cfg.Watch); err != nil {
+		fatalCode(exitUsage, "設定ファイルの watch が不正です", "error", err)
+	}
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("タイムゾーンの読み込みに失敗しました", "error", err)
+	}
+	next, err := nextRun(time.Now().In(location), flags.at)
+	if err != nil {
+		fatalCode(exitUsage, "-at の指定が不正です", "error", err)
+	}
+
+	ctx, cancel := newCommandContext(0)
+	defer cancel()
+	base := baseQueryArgs(fs, cfg)
+	for {
+		if wait := time.Until(next); wait > 0 {
+			slog.Info("次の集計まで待機します", "next", next.Format("2006/01/02 15:04"))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// 実行のたびにサービスを作り直し、有効期限が近いトークンは更新して保存する
+		s := &server{cfg: cfg, src: opts.newSource(ctx, cfg), base: base}
+		release := catchFatal()
+		failed := 0
+		for _, job := range cfg.Watch {
+			if err := s.runWatchJob(ctx, job, time.Now().In(location)); err != nil {
+				slog.Error("集計または配信に失敗しました", "job", job.Name, "error", err)
+				failed++
+			}
+		}
+		release()
+
+		if flags.once {
+			if failed > 0 {
+				exit(exitError)
+			}
+			return
+		}
+		next = next.Add(flags.every)
+		for !next.After(time.Now()) {
+			next = next.Add(flags.every)
+		}
+	}
+}
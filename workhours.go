@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+// 曜日の略称（-workdays で使用）
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// workSchedule は集計対象とする勤務時間帯と曜日を表す
+type workSchedule struct {
+	start time.Duration // 0時からの開始時刻
+	end   time.Duration // 0時からの終了時刻（開始時刻以下の場合は翌日にまたがる）
+	days  [7]bool       // time.Weekday ごとの勤務日
+
+	// 勤務日から除外する祝日（YYYY-MM-DD）
+	holidays map[string]bool
+}
+
+// parseClock は「HH:MM」形式の時刻を0時からの経過時間に変換する
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, errors.New(printer.Sprintf("時刻 '%s' はHH:MM形式で指定してください", s))
+	}
+	if h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, errors.New(printer.Sprintf("時刻 '%s' が範囲外です", s))
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// parseWorkdays は「mon-fri」や「mon,wed,fri」形式の曜日指定を解析する
+func parseWorkdays(s string) ([7]bool, error) {
+	var days [7]bool
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		start, ok := weekdayNames[from]
+		if !ok {
+			return days, errors.New(printer.Sprintf("曜日 '%s' が不正です（sun, mon, tue, wed, thu, fri, sat）", from))
+		}
+		if !isRange {
+			days[start] = true
+			continue
+		}
+		end, ok := weekdayNames[to]
+		if !ok {
+			return days, errors.New(printer.Sprintf("曜日 '%s' が不正です（sun, mon, tue, wed, thu, fri, sat）", to))
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// newWorkSchedule は -work-hours と -workdays の指定から勤務時間帯を作成する
+// どちらも指定されていない場合はnilを返す
+func newWorkSchedule(hours, workdays string) (*workSchedule, error) {
+	if hours == "" && workdays == "" {
+		return nil, nil
+	}
+	ws := &workSchedule{end: 24 * time.Hour}
+	for i := range ws.days {
+		ws.days[i] = true
+	}
+
+	if hours != "" {
+		from, to, ok := strings.Cut(hours, "-")
+		if !ok {
+			return nil, errors.New(printer.Sprintf("勤務時間 '%s' はHH:MM-HH:MM形式で指定してください", hours))
+		}
+		var err error
+		if ws.start, err = parseClock(from); err != nil {
+			return nil, err
+		}
+		if ws.end, err = parseClock(to); err != nil {
+			return nil, err
+		}
+	}
+	if workdays != "" {
+		days, err := parseWorkdays(workdays)
+		if err != nil {
+			return nil, err
+		}
+		ws.days = days
+	}
+	return ws, nil
+}
+
+// clone は勤務時間帯の複製を返す（並行に集計する場合に、それぞれの集計で祝日を別に設定できるようにする）
+func (ws *workSchedule) clone() *workSchedule {
+	if ws == nil {
+		return nil
+	}
+	c := *ws
+	c.holidays = maps.Clone(ws.holidays)
+	return &c
+}
+
+// overlap はイベントの時間のうち、勤務時間帯に含まれる部分の長さを返す
+func (ws *workSchedule) overlap(start, end time.Time, location *time.Location) time.Duration {
+	start = start.In(location)
+	end = end.In(location)
+
+	var total time.Duration
+	// 前日から翌日にまたがる勤務時間帯も考慮して、開始日の前日から順に調べる
+	day := time.Date(start.Year(), start.Month(), start.Day()-1, 0, 0, 0, 0, location)
+	for !day.After(end) {
+		if ws.isWorkday(day) {
+			windowStart, windowEnd := ws.window(day)
+			s := later(start, windowStart)
+			e := earlier(end, windowEnd)
+			if s.Before(e) {
+				total += e.Sub(s)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// window は指定された日の勤務時間帯の開始日時と終了日時を返す（日をまたぐ勤務時間帯にも対応する）
+func (ws *workSchedule) window(day time.Time) (time.Time, time.Time) {
+	start := clockOn(day, ws.start)
+	end := clockOn(day, ws.end)
+	if ws.end <= ws.start {
+		end = clockOn(day.AddDate(0, 0, 1), ws.end)
+	}
+	return start, end
+}
+
+// clockOn は0時からの時刻（parseClock の値）を、指定された日のその時刻の日時に変換する
+// 夏時間が切り替わる日は0時からの経過時間と時計の時刻がずれるため、経過時間を足さずに時計の時刻で求める（例: 9:00 は切り替えの前後に関わらず 9:00）
+// 夏時間の開始で時計が進められて存在しない時刻（例: America/New_York の 2:00〜2:59）は、切り替え後の最初の時刻（3:00）にする
+func clockOn(day time.Time, clock time.Duration) time.Time {
+	hour, minute := int(clock/time.Hour), int(clock%time.Hour/time.Minute)
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+
+	// 時計の時刻どおりになったかを、タイムゾーンの影響を受けないUTCの時刻どうしで比べる（24:00 は翌日の0:00になる）
+	want := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if got.Equal(want) {
+		return t
+	}
+	// 存在しない時刻は切り替えの前後どちらかの時刻に正規化されるため、切り替えの日時に合わせる
+	zoneStart, zoneEnd := t.ZoneBounds()
+	if got.Before(want) {
+		return zoneEnd
+	}
+	return zoneStart
+}
+
+// isWorkday は指定された日が勤務日（祝日を除く）かどうかを判定する
+func (ws *workSchedule) isWorkday(day time.Time) bool {
+	return ws.days[day.Weekday()] && !ws.holidays[day.Format("2006-01-02")]
+}
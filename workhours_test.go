@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockOn(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	springForward := time.Date(2024, 3, 10, 0, 0, 0, 0, newYork)
+	fallBack := time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)
+
+	tests := []struct {
+		name  string
+		day   time.Time
+		clock time.Duration
+		want  time.Time
+	}{
+		{"夏時間の開始日の時計の時刻", springForward, 9 * time.Hour, time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC)},
+		{"夏時間の開始日の切り替え前", springForward, time.Hour + 30*time.Minute, time.Date(2024, 3, 10, 6, 30, 0, 0, time.UTC)},
+		{"存在しない2:00は3:00にする", springForward, 2 * time.Hour, time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)},
+		{"存在しない2:30も3:00にする", springForward, 2*time.Hour + 30*time.Minute, time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)},
+		{"夏時間の開始日の3:00", springForward, 3 * time.Hour, time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)},
+		{"夏時間の開始日の24:00", springForward, 24 * time.Hour, time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC)},
+		{"夏時間の終了日の時計の時刻", fallBack, 9 * time.Hour, time.Date(2024, 11, 3, 14, 0, 0, 0, time.UTC)},
+		{"夏時間の終了日の切り替え後", fallBack, 3 * time.Hour, time.Date(2024, 11, 3, 8, 0, 0, 0, time.UTC)},
+		{"夏時間の終了日の0:00", fallBack, 0, time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clockOn(tt.day, tt.clock); !got.Equal(tt.want) {
+				t.Errorf("clockOn(%s, %v) = %v; want %v", tt.day.Format("2006-01-02"), tt.clock, got, tt.want.In(newYork))
+			}
+		})
+	}
+}
+
+func TestWorkScheduleOverlapAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		hours      string
+		start, end time.Time
+		want       time.Duration
+	}{
+		{
+			name:  "夏時間の開始日の勤務時間は時計の時刻で数える",
+			hours: "08:00-12:00",
+			start: time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			end:   time.Date(2024, 3, 11, 0, 0, 0, 0, newYork),
+			want:  4 * time.Hour,
+		},
+		{
+			name:  "夏時間の終了日の勤務時間は時計の時刻で数える",
+			hours: "08:00-12:00",
+			start: time.Date(2024, 11, 3, 0, 0, 0, 0, newYork),
+			end:   time.Date(2024, 11, 4, 0, 0, 0, 0, newYork),
+			want:  4 * time.Hour,
+		},
+		{
+			name:  "終了時刻が夏時間の開始で存在しない場合は切り替え後の最初の時刻まで",
+			hours: "22:00-02:00",
+			start: time.Date(2024, 3, 9, 22, 0, 0, 0, newYork),
+			end:   time.Date(2024, 3, 10, 4, 0, 0, 0, newYork),
+			want:  4 * time.Hour,
+		},
+		{
+			name:  "夏時間の終了で時計が戻る日をまたぐ勤務時間",
+			hours: "22:00-02:00",
+			start: time.Date(2024, 11, 2, 22, 0, 0, 0, newYork),
+			end:   time.Date(2024, 11, 3, 4, 0, 0, 0, newYork),
+			want:  5 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := newWorkSchedule(tt.hours, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ws.overlap(tt.start, tt.end, newYork); got != tt.want {
+				t.Errorf("overlap = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// 集計結果のイベントを識別する拡張プロパティのキー
+const summaryEventProperty = "gcalSumKey"
+
+// summaryEventKey は集計条件と期間から、集計結果のイベントを識別する文字列を生成する
+// 同じ条件・期間で実行し直した場合は、新しく作成せずに既存のイベントを更新する
+func summaryEventKey(r *Report) string {
+	sum := sha256.Sum256([]byte(r.Name + "\n" + r.StartDate.Format("2006-01-02") + "\n" + r.EndDate.Format("2006-01-02")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// summaryPeriodLabel は集計期間の表示名を返す。1か月分の場合は「2023/01」のように表す
+func summaryPeriodLabel(r *Report) string {
+	start, end := r.StartDate, r.EndDate
+	if start.Day() == 1 && end.Equal(start.AddDate(0, 1, -1)) {
+		return start.Format("2006/01")
+	}
+	return start.Format("2006/01/02") + "～" + end.Format("2006/01/02")
+}
+
+// summaryEvent は集計結果を、期間の初日の終日イベントに変換する
+func summaryEvent(r *Report, title string) *calendar.Event {
+	if title == "" {
+		title = fmt.Sprintf("%s: %s — %s", summaryPeriodLabel(r), r.Name, formatDuration(r.Total))
+	}
+
+	var b strings.Builder
+	printer.Fprintf(&b, "検索期間: %s から %s\n", r.StartDate.Format("2006/01/02"), r.EndDate.Format("2006/01/02"))
+	printer.Fprintf(&b, "合計時間: %s（%d件）\n", formatDuration(r.Total), r.count())
+	if len(r.Groups) > 0 {
+		fmt.Fprintln(&b)
+		printer.Fprintf(&b, "%s別の合計時間:\n", groupByLabel(r.GroupBy))
+		for _, g := range r.Groups {
+			printer.Fprintf(&b, "- %s: %s (%d件)\n", g.Key, formatDuration(g.Duration), g.Count)
+		}
+	}
+	fmt.Fprintln(&b)
+	printer.Fprintf(&b, "このイベントは gcal-sum が作成しました。\n")
+
+	return &calendar.Event{
+		Summary:     title,
+		Description: b.String(),
+		Start:       &calendar.EventDateTime{Date: r.StartDate.Format("2006-01-02")},
+		End:         &calendar.EventDateTime{Date: r.StartDate.AddDate(0, 0, 1).Format("2006-01-02")},
+		// 空き時間の計算に影響しないように「予定なし」にする
+		Transparency: "transparent",
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{summaryEventProperty: summaryEventKey(r)},
+		},
+	}
+}
+
+// exportCalendarFlags は export calendar のフラグ
+type exportCalendarFlags struct {
+	opts   *queryOptions
+	target string
+	title  string
+}
+
+// registerExportCalendarFlags は export calendar のフラグを登録する（補完の候補にも使用する）
+func registerExportCalendarFlags(fs *flag.FlagSet, cfg *Config) *exportCalendarFlags {
+	f := &exportCalendarFlags{}
+	f.opts = &queryOptions{}
+	f.opts.register(fs, cfg)
+	fs.StringVar(&f.target, "to-calendar", "", "集計結果のイベントを作成するカレンダーID")
+	fs.StringVar(&f.title, "title", "", "作成するイベントのタイトル（デフォルトは「2023/01: イベント名 — 合計時間」）")
+	return f
+}
+
+// runExportCalendar は集計結果を、指定されたカレンダーに終日イベントとして書き込む
+func runExportCalendar(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("export calendar", flag.ExitOnError)
+	flags := registerExportCalendarFlags(fs, cfg)
+	parseFlags(fs, args)
+	opts := flags.opts
+
+	if flags.target == "" {
+		printer.Printf("エラー: 集計結果のイベントを作成するカレンダーIDを指定してください。\n")
+		printer.Printf("使用方法: gcal-sum export calendar -to-calendar=カレンダーID -month=YYYY-MM -name=イベント名 [-title=タイトル]\n")
+		os.Exit(exitUsage)
+	}
+	opts.validate()
+
+	// カレンダーへのイベントの書き込みの権限がまだない場合は、ここで追加の許可を求める
+	ctx, cancel := newCommandContext(opts.timeout)
+	defer cancel()
+	client := newHTTPClient(ctx, cfg.CredentialsPath, cfg.TokenPath,
+		calendar.CalendarReadonlyScope, calendar.CalendarEventsScope)
+	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		fatal("Calendar APIの初期化に失敗しました", "error", err)
+	}
+
+	report := runQuery(ctx, &googleSource{srv: srv}, cfg, opts)
+	event := summaryEvent(report, flags.title)
+
+	// 同じ条件・期間で作成済みのイベントがある場合は更新する
+	var existing *calendar.Events
+	err = withRetry(ctx, func() (err error) {
+		existing, err = srv.Events.List(flags.target).
+			PrivateExtendedProperty(summaryEventProperty + "=" + summaryEventKey(report)).
+			ShowDeleted(false).
+			Context(ctx).
+			Do()
+		return err
+	})
+	if err != nil {
+		fatalAPI("作成済みの集計結果のイベントの検索に失敗しました", contextError(ctx, err))
+	}
+
+	if len(existing.Items) > 0 {
+		err = withRetry(ctx, func() error {
+			_, err := srv.Events.Update(flags.target, existing.Items[0].Id, event).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			fatalAPI("集計結果のイベントの更新に失敗しました", contextError(ctx, err))
+		}
+		printer.Printf("集計結果のイベント「%s」を更新しました\n", event.Summary)
+		return
+	}
+
+	err = withRetry(ctx, func() error {
+		_, err := srv.Events.Insert(flags.target, event).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		fatalAPI("集計結果のイベントの作成に失敗しました", contextError(ctx, err))
+	}
+	printer.Printf("集計結果のイベント「%s」を作成しました\n", event.Summary)
+}